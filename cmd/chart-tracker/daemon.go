@@ -0,0 +1,344 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cncf/hub/internal/hub"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// Daemon defaults
+	defaultTrackingInterval = 1 * time.Hour
+	defaultMaxJitter        = 5 * time.Minute
+	defaultCheckInterval    = 1 * time.Minute
+	defaultRequestsInterval = 10 * time.Second
+)
+
+// repositoryStatus represents the tracking status of a repository when the
+// tracker runs in daemon mode.
+type repositoryStatus struct {
+	Name                string        `json:"name"`
+	Interval            time.Duration `json:"-"`
+	IntervalStr         string        `json:"interval"`
+	Tracking            bool          `json:"tracking"`
+	LastTrackingStartTs int64         `json:"last_tracking_start_ts,omitempty"`
+	LastTrackingEndTs   int64         `json:"last_tracking_end_ts,omitempty"`
+	NextTrackingTs      int64         `json:"next_tracking_ts"`
+
+	// pendingRequests holds the ids of the tracking requests received while
+	// the repository was being tracked
+	pendingRequests []string
+}
+
+// daemon is in charge of tracking the repositories continuously. Each of the
+// repositories is tracked on its own interval, using the dispatcher and the
+// workers pool shared by all of them. The daemon keeps the tracking status of
+// each repository, which is exposed over http. Tracking requests submitted by
+// the repositories owners or received through their webhooks are also claimed
+// periodically and processed immediately.
+type daemon struct {
+	ctx              context.Context
+	hubAPI           *hub.Hub
+	d                *dispatcher
+	defaultInterval  time.Duration
+	intervals        map[string]time.Duration // K: repository name
+	maxJitter        time.Duration
+	checkInterval    time.Duration
+	requestsInterval time.Duration
+
+	mu     sync.RWMutex
+	status map[string]*repositoryStatus // K: repository name
+}
+
+// newDaemon creates a new daemon instance.
+func newDaemon(ctx context.Context, cfg *viper.Viper, hubAPI *hub.Hub, d *dispatcher) *daemon {
+	dmn := &daemon{
+		ctx:              ctx,
+		hubAPI:           hubAPI,
+		d:                d,
+		defaultInterval:  defaultTrackingInterval,
+		intervals:        make(map[string]time.Duration),
+		maxJitter:        defaultMaxJitter,
+		checkInterval:    defaultCheckInterval,
+		requestsInterval: defaultRequestsInterval,
+		status:           make(map[string]*repositoryStatus),
+	}
+	if cfg.IsSet("tracker.daemon.interval") {
+		dmn.defaultInterval = getPositiveDuration(cfg, "tracker.daemon.interval", defaultTrackingInterval)
+	}
+	if cfg.IsSet("tracker.daemon.maxJitter") {
+		dmn.maxJitter = cfg.GetDuration("tracker.daemon.maxJitter")
+	}
+	if cfg.IsSet("tracker.daemon.checkInterval") {
+		dmn.checkInterval = getPositiveDuration(cfg, "tracker.daemon.checkInterval", defaultCheckInterval)
+	}
+	if cfg.IsSet("tracker.daemon.requestsInterval") {
+		dmn.requestsInterval = getPositiveDuration(cfg, "tracker.daemon.requestsInterval", defaultRequestsInterval)
+	}
+	for name, interval := range cfg.GetStringMapString("tracker.daemon.intervals") {
+		v, err := time.ParseDuration(interval)
+		if err != nil {
+			log.Warn().Err(err).Str("repo", name).Msg("Invalid repository interval, using default")
+			continue
+		}
+		if v <= 0 {
+			log.Warn().Str("repo", name).Msg("Repository interval must be positive, using default")
+			continue
+		}
+		dmn.intervals[name] = v
+	}
+	return dmn
+}
+
+// getPositiveDuration returns the duration set in the configuration key
+// provided, falling back to the default value given when it is not positive.
+func getPositiveDuration(cfg *viper.Viper, key string, defaultValue time.Duration) time.Duration {
+	v := cfg.GetDuration(key)
+	if v <= 0 {
+		log.Warn().Str("key", key).Dur("default", defaultValue).Msg("Interval must be positive, using default")
+		return defaultValue
+	}
+	return v
+}
+
+// run starts the daemon. Due repositories and tracking requests are checked
+// periodically until the context is done. Once that happens, the daemon waits
+// for the repositories being tracked and closes the dispatcher queue, which
+// stops the workers.
+func (dmn *daemon) run(wg *sync.WaitGroup) {
+	defer wg.Done()
+	defer close(dmn.d.Queue)
+
+	var wgRepos sync.WaitGroup
+	limiter := rate.NewLimiter(25, 25)
+	track := func(r *hub.ChartRepository, requestsIDs []string) {
+		if err := limiter.Wait(dmn.ctx); err != nil {
+			return
+		}
+		wgRepos.Add(1)
+		go func() {
+			defer wgRepos.Done()
+			dmn.trackRepository(r, requestsIDs)
+		}()
+	}
+	ticker := time.NewTicker(dmn.checkInterval)
+	defer ticker.Stop()
+	requestsTicker := time.NewTicker(dmn.requestsInterval)
+	defer requestsTicker.Stop()
+	for _, r := range dmn.getDueRepositories() {
+		track(r, nil)
+	}
+	for {
+		select {
+		case <-ticker.C:
+			for _, r := range dmn.getDueRepositories() {
+				track(r, nil)
+			}
+		case <-requestsTicker.C:
+			requested, chartVersionsRequests := dmn.claimTrackingRequests()
+			for r, requestsIDs := range requested {
+				track(r, requestsIDs)
+			}
+			for _, tr := range chartVersionsRequests {
+				if err := limiter.Wait(dmn.ctx); err != nil {
+					break
+				}
+				wgRepos.Add(1)
+				go func(tr *hub.TrackingRequest) {
+					defer wgRepos.Done()
+					dmn.trackChartVersion(tr)
+				}(tr)
+			}
+		case <-dmn.ctx.Done():
+			wgRepos.Wait()
+			return
+		}
+	}
+}
+
+// getDueRepositories returns the repositories that are due to be tracked,
+// updating the status of all repositories available.
+func (dmn *daemon) getDueRepositories() []*hub.ChartRepository {
+	repos, err := dmn.hubAPI.GetChartRepositories(dmn.ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Error getting repositories")
+		return nil
+	}
+
+	dmn.mu.Lock()
+	defer dmn.mu.Unlock()
+	var due []*hub.ChartRepository
+	now := time.Now()
+	current := make(map[string]*repositoryStatus, len(repos))
+	for _, r := range repos {
+		s, ok := dmn.status[r.Name]
+		if !ok {
+			s = &repositoryStatus{Name: r.Name}
+		}
+		current[r.Name] = s
+		interval := dmn.getInterval(r.Name)
+		if s.NextTrackingTs == 0 || interval != s.Interval {
+			// Schedule next tracking based on the last tracking ts, adding
+			// some jitter to spread the load over time
+			s.Interval = interval
+			s.IntervalStr = interval.String()
+			next := time.Unix(r.LastTrackingTs, 0).Add(interval)
+			if next.Before(now) {
+				next = now
+			}
+			s.NextTrackingTs = next.Add(dmn.getJitter()).Unix()
+		}
+		if !s.Tracking && s.NextTrackingTs <= now.Unix() {
+			s.Tracking = true
+			due = append(due, r)
+		}
+	}
+	dmn.status = current
+	return due
+}
+
+// claimTrackingRequests claims the pending tracking requests, returning the
+// repositories that must be tracked now along with the ids of the requests
+// they will complete. Requests for repositories already being tracked are
+// queued, so that they are processed once the current tracking finishes.
+// Requests for specific chart versions are returned separately, as they can
+// be processed right away.
+func (dmn *daemon) claimTrackingRequests() (map[*hub.ChartRepository][]string, []*hub.TrackingRequest) {
+	requests, err := dmn.hubAPI.ClaimTrackingRequests(dmn.ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Error claiming tracking requests")
+		return nil, nil
+	}
+
+	dmn.mu.Lock()
+	defer dmn.mu.Unlock()
+	requested := make(map[*hub.ChartRepository][]string)
+	repos := make(map[string]*hub.ChartRepository)
+	var chartVersionsRequests []*hub.TrackingRequest
+	for _, tr := range requests {
+		if tr.ChartName != "" {
+			chartVersionsRequests = append(chartVersionsRequests, tr)
+			continue
+		}
+		r := tr.ChartRepository
+		s, ok := dmn.status[r.Name]
+		if !ok {
+			s = &repositoryStatus{
+				Name:        r.Name,
+				Interval:    dmn.getInterval(r.Name),
+				IntervalStr: dmn.getInterval(r.Name).String(),
+			}
+			dmn.status[r.Name] = s
+		}
+		if rr, ok := repos[r.Name]; ok {
+			requested[rr] = append(requested[rr], tr.TrackingRequestID)
+			continue
+		}
+		if s.Tracking {
+			s.pendingRequests = append(s.pendingRequests, tr.TrackingRequestID)
+			continue
+		}
+		log.Info().Str("repo", r.Name).Str("request", tr.TrackingRequestID).Msg("Tracking requested")
+		s.Tracking = true
+		repos[r.Name] = r
+		requested[r] = []string{tr.TrackingRequestID}
+	}
+	return requested, chartVersionsRequests
+}
+
+// trackRepository tracks the repository provided using the dispatcher,
+// updating its status accordingly. The tracking requests provided are
+// completed once the tracking finishes. If new requests for the repository
+// were received in the meantime, it is tracked again to complete them.
+func (dmn *daemon) trackRepository(r *hub.ChartRepository, requestsIDs []string) {
+	for {
+		dmn.updateStatus(r.Name, func(s *repositoryStatus) {
+			s.LastTrackingStartTs = time.Now().Unix()
+		})
+		log.Info().Str("repo", r.Name).Msg("Tracking repository")
+		err := dmn.d.trackRepository(r)
+		dmn.d.completeRequests(requestsIDs, err)
+
+		requestsIDs = nil
+		dmn.updateStatus(r.Name, func(s *repositoryStatus) {
+			now := time.Now()
+			s.LastTrackingEndTs = now.Unix()
+			if len(s.pendingRequests) > 0 && dmn.ctx.Err() == nil {
+				requestsIDs = s.pendingRequests
+				s.pendingRequests = nil
+				return
+			}
+			s.Tracking = false
+			s.NextTrackingTs = now.Add(s.Interval).Add(dmn.getJitter()).Unix()
+		})
+		if len(requestsIDs) == 0 {
+			return
+		}
+	}
+}
+
+// trackChartVersion processes the chart version requested in the tracking
+// request provided, completing the request once done.
+func (dmn *daemon) trackChartVersion(tr *hub.TrackingRequest) {
+	log.Info().
+		Str("repo", tr.ChartRepository.Name).
+		Str("chart", tr.ChartName).
+		Str("version", tr.ChartVersion).
+		Msg("Tracking chart version")
+	err := dmn.d.trackChartVersion(tr.ChartRepository, tr.ChartName, tr.ChartVersion)
+	if err != nil {
+		log.Error().Err(err).Str("repo", tr.ChartRepository.Name).Msg("Error tracking chart version")
+	}
+	dmn.d.completeRequests([]string{tr.TrackingRequestID}, err)
+}
+
+// updateStatus applies the function provided to the status of the repository
+// provided, when available.
+func (dmn *daemon) updateStatus(name string, fn func(s *repositoryStatus)) {
+	dmn.mu.Lock()
+	defer dmn.mu.Unlock()
+	if s, ok := dmn.status[name]; ok {
+		fn(s)
+	}
+}
+
+// getInterval returns the tracking interval of the repository provided.
+func (dmn *daemon) getInterval(name string) time.Duration {
+	if interval, ok := dmn.intervals[name]; ok {
+		return interval
+	}
+	return dmn.defaultInterval
+}
+
+// getJitter returns a random duration between zero and the max jitter.
+func (dmn *daemon) getJitter() time.Duration {
+	if dmn.maxJitter <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(dmn.maxJitter))) // #nosec
+}
+
+// statusHandler is an http handler that returns the tracking status of all
+// repositories as a json array.
+func (dmn *daemon) statusHandler(w http.ResponseWriter, r *http.Request) {
+	dmn.mu.RLock()
+	status := make([]repositoryStatus, 0, len(dmn.status))
+	for _, s := range dmn.status {
+		status = append(status, *s)
+	}
+	dmn.mu.RUnlock()
+	sort.Slice(status, func(i, j int) bool {
+		return status[i].Name < status[j].Name
+	})
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(status)
+}
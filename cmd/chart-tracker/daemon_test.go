@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cncf/hub/internal/hub"
+	"github.com/cncf/hub/internal/tests"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDaemon(t *testing.T) {
+	t.Run("valid intervals", func(t *testing.T) {
+		cfg := viper.New()
+		cfg.Set("tracker.daemon.interval", "30m")
+		cfg.Set("tracker.daemon.checkInterval", "30s")
+		cfg.Set("tracker.daemon.requestsInterval", "5s")
+		cfg.Set("tracker.daemon.intervals", map[string]string{"repo1": "10m"})
+		dmn := newDaemon(context.Background(), cfg, hub.New(&tests.DBMock{}, nil), nil)
+
+		assert.Equal(t, 30*time.Minute, dmn.defaultInterval)
+		assert.Equal(t, 30*time.Second, dmn.checkInterval)
+		assert.Equal(t, 5*time.Second, dmn.requestsInterval)
+		assert.Equal(t, map[string]time.Duration{"repo1": 10 * time.Minute}, dmn.intervals)
+	})
+
+	t.Run("invalid intervals fall back to defaults", func(t *testing.T) {
+		cfg := viper.New()
+		cfg.Set("tracker.daemon.interval", "0s")
+		cfg.Set("tracker.daemon.checkInterval", "0s")
+		cfg.Set("tracker.daemon.requestsInterval", "-5s")
+		cfg.Set("tracker.daemon.intervals", map[string]string{
+			"repo1": "0s",
+			"repo2": "-10m",
+			"repo3": "invalid",
+		})
+		dmn := newDaemon(context.Background(), cfg, hub.New(&tests.DBMock{}, nil), nil)
+
+		assert.Equal(t, defaultTrackingInterval, dmn.defaultInterval)
+		assert.Equal(t, defaultCheckInterval, dmn.checkInterval)
+		assert.Equal(t, defaultRequestsInterval, dmn.requestsInterval)
+		assert.Empty(t, dmn.intervals)
+	})
+}
+
+func TestDaemonGetDueRepositories(t *testing.T) {
+	now := time.Now().Unix()
+	db := &tests.DBMock{}
+	db.On("QueryRow", "select get_chart_repositories()").Return([]byte(fmt.Sprintf(`
+	[{
+		"chart_repository_id": "00000000-0000-0000-0000-000000000001",
+		"name": "repo1",
+		"url": "https://repo1.com",
+		"last_tracking_ts": null
+	}, {
+		"chart_repository_id": "00000000-0000-0000-0000-000000000002",
+		"name": "repo2",
+		"url": "https://repo2.com",
+		"last_tracking_ts": %d
+	}, {
+		"chart_repository_id": "00000000-0000-0000-0000-000000000003",
+		"name": "repo3",
+		"url": "https://repo3.com",
+		"last_tracking_ts": %d
+	}]
+	`, now-60, now-60)), nil)
+	cfg := viper.New()
+	cfg.Set("tracker.daemon.interval", "1h")
+	cfg.Set("tracker.daemon.maxJitter", "0s")
+	cfg.Set("tracker.daemon.intervals", map[string]string{"repo2": "30s"})
+	dmn := newDaemon(context.Background(), cfg, hub.New(db, nil), nil)
+
+	// Never tracked repositories and those whose interval has elapsed are due
+	due := dmn.getDueRepositories()
+	require.Len(t, due, 2)
+	assert.ElementsMatch(t, []string{"repo1", "repo2"}, []string{due[0].Name, due[1].Name})
+	assert.True(t, dmn.status["repo1"].Tracking)
+	assert.Equal(t, 30*time.Second, dmn.status["repo2"].Interval)
+	assert.False(t, dmn.status["repo3"].Tracking)
+	assert.Equal(t, now-60+3600, dmn.status["repo3"].NextTrackingTs)
+
+	// Repositories being tracked are not due again
+	due = dmn.getDueRepositories()
+	assert.Len(t, due, 0)
+
+	// Status is exposed over http
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/status", nil)
+	dmn.statusHandler(w, r)
+	resp := w.Result()
+	defer resp.Body.Close()
+	var status []*repositoryStatus
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&status))
+	require.Len(t, status, 3)
+	assert.Equal(t, "repo1", status[0].Name)
+	assert.Equal(t, "1h0m0s", status[0].IntervalStr)
+	assert.True(t, status[0].Tracking)
+	db.AssertExpectations(t)
+}
+
+func TestDaemonClaimTrackingRequests(t *testing.T) {
+	db := &tests.DBMock{}
+	db.On("QueryRow", "select claim_tracking_requests()").Return([]byte(`
+	[{
+		"tracking_request_id": "00000000-0000-0000-0000-000000000011",
+		"chart_repository": {"chart_repository_id": "00000000-0000-0000-0000-000000000001", "name": "repo1"}
+	}, {
+		"tracking_request_id": "00000000-0000-0000-0000-000000000012",
+		"chart_repository": {"chart_repository_id": "00000000-0000-0000-0000-000000000001", "name": "repo1"}
+	}, {
+		"tracking_request_id": "00000000-0000-0000-0000-000000000021",
+		"chart_repository": {"chart_repository_id": "00000000-0000-0000-0000-000000000002", "name": "repo2"}
+	}, {
+		"tracking_request_id": "00000000-0000-0000-0000-000000000022",
+		"chart_repository": {"chart_repository_id": "00000000-0000-0000-0000-000000000002", "name": "repo2"},
+		"chart_name": "chart1",
+		"chart_version": "1.0.0"
+	}]
+	`), nil)
+	dmn := newDaemon(context.Background(), viper.New(), hub.New(db, nil), nil)
+	dmn.status["repo2"] = &repositoryStatus{Name: "repo2", Tracking: true}
+
+	// Requests for repositories being tracked are queued, but chart versions
+	// requests are returned to be processed right away
+	requested, chartVersionsRequests := dmn.claimTrackingRequests()
+	require.Len(t, requested, 1)
+	for r, requestsIDs := range requested {
+		assert.Equal(t, "repo1", r.Name)
+		assert.Equal(t, []string{
+			"00000000-0000-0000-0000-000000000011",
+			"00000000-0000-0000-0000-000000000012",
+		}, requestsIDs)
+	}
+	assert.True(t, dmn.status["repo1"].Tracking)
+	assert.Equal(t, defaultTrackingInterval, dmn.status["repo1"].Interval)
+	assert.Equal(t, []string{"00000000-0000-0000-0000-000000000021"}, dmn.status["repo2"].pendingRequests)
+	require.Len(t, chartVersionsRequests, 1)
+	assert.Equal(t, "00000000-0000-0000-0000-000000000022", chartVersionsRequests[0].TrackingRequestID)
+	db.AssertExpectations(t)
+}
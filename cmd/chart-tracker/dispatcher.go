@@ -5,22 +5,42 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/cncf/hub/internal/hub"
 	"github.com/rs/zerolog/log"
 	"golang.org/x/time/rate"
-	"helm.sh/helm/v3/pkg/cli"
-	"helm.sh/helm/v3/pkg/getter"
 	"helm.sh/helm/v3/pkg/repo"
 )
 
+// maxVerificationFailures represents the number of consecutive tracking runs in
+// which the metadata file of a verified repository can fail to be fetched
+// before it loses its verified publisher flag.
+const maxVerificationFailures = 3
+
 // job represents a job for processing a given chart version in the provided
 // repository. Jobs are created by the dispatcher that will eventually be
 // handled by a worker.
 type job struct {
 	repo         *hub.ChartRepository
+	source       source
 	chartVersion *repo.ChartVersion
 	downloadLogo bool
+
+	// run collects the results of the jobs generated while tracking the
+	// repository, when available
+	run *trackingRun
+
+	// ec collects the errors found while handling the job, when provided.
+	// Otherwise the worker's errors collector is used.
+	ec *errorsCollector
+
+	// err holds the error that prevented the chart version from being
+	// registered, if any, once the job has been handled
+	err *hub.TrackingError
+
+	// wg is used to signal when the job has been handled
+	wg *sync.WaitGroup
 }
 
 // dispatcher is in charge of generating jobs and dispatching them among the
@@ -43,6 +63,11 @@ func newDispatcher(ctx context.Context, ec *errorsCollector, hubAPI *hub.Hub) *d
 }
 
 // run instructs the dispatcher to start processing the repositories provided.
+// The pending tracking requests are claimed as well, so that they are
+// processed even when the tracker does not run in daemon mode. Repositories
+// with pending requests are tracked along with the ones provided, and the
+// requests for specific chart versions are processed once all repositories
+// have been tracked.
 func (d *dispatcher) run(wg *sync.WaitGroup, reposNames []string) {
 	defer wg.Done()
 	defer close(d.Queue)
@@ -57,19 +82,61 @@ func (d *dispatcher) run(wg *sync.WaitGroup, reposNames []string) {
 		return
 	}
 
+	// Claim pending tracking requests
+	requests, err := d.hubAPI.ClaimTrackingRequests(d.ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Error claiming tracking requests")
+	}
+	requestsIDs := make(map[string][]string) // K: repository name
+	var chartVersionsRequests []*hub.TrackingRequest
+	for _, tr := range requests {
+		if tr.ChartName != "" {
+			chartVersionsRequests = append(chartVersionsRequests, tr)
+			continue
+		}
+		r := tr.ChartRepository
+		if _, ok := requestsIDs[r.Name]; !ok && !containsRepository(repos, r.Name) {
+			repos = append(repos, r)
+		}
+		requestsIDs[r.Name] = append(requestsIDs[r.Name], tr.TrackingRequestID)
+	}
+
 	// Track repositories charts
 	var wgRepos sync.WaitGroup
 	limiter := rate.NewLimiter(25, 25)
 	for _, r := range repos {
 		if err := limiter.Wait(d.ctx); err != nil {
 			log.Error().Err(err).Msg("Error waiting for limiter")
-			return
+			break
 		}
 		wgRepos.Add(1)
-		go d.trackRepositoryCharts(&wgRepos, r)
+		go func(r *hub.ChartRepository) {
+			defer wgRepos.Done()
+			err := d.trackRepository(r)
+			d.completeRequests(requestsIDs[r.Name], err)
+		}(r)
 	}
-
 	wgRepos.Wait()
+
+	// Process chart versions tracking requests
+	for _, tr := range chartVersionsRequests {
+		err := d.trackChartVersion(tr.ChartRepository, tr.ChartName, tr.ChartVersion)
+		if err != nil {
+			log.Error().Err(err).Str("repo", tr.ChartRepository.Name).Msg("Error tracking chart version")
+		}
+		d.completeRequests([]string{tr.TrackingRequestID}, err)
+	}
+}
+
+// containsRepository checks if the repository with the name provided is in
+// the given list of repositories.
+func containsRepository(repos []*hub.ChartRepository, name string) bool {
+	for _, r := range repos {
+		if r.Name == name {
+			return true
+		}
+	}
+	return false
 }
 
 // getRepositories returns the details of the repositories provided. If no
@@ -96,72 +163,275 @@ func (d *dispatcher) getRepositories(names []string) ([]*hub.ChartRepository, er
 	return repos, nil
 }
 
-// trackRepositoryCharts generates jobs for each of the chart versions found in
-// the given repository, provided that that version has not been already
-// processed and its digest has not changed.
-func (d *dispatcher) trackRepositoryCharts(wg *sync.WaitGroup, r *hub.ChartRepository) {
-	defer wg.Done()
+// trackRepository tracks the charts available in the repository provided,
+// returning once all the jobs generated for it have been handled by the
+// workers. The errors collected while tracking the repository are flushed
+// before returning. An error is returned when the repository could not be
+// tracked at all.
+func (d *dispatcher) trackRepository(r *hub.ChartRepository) error {
+	var wgJobs sync.WaitGroup
+	run := newTrackingRun(r)
+	err := d.trackRepositoryCharts(&wgJobs, r, run)
+	if err := d.waitJobs(&wgJobs); err != nil {
+		return err
+	}
+	errs := d.ec.flushRepository(r)
+	if err := d.hubAPI.RegisterTrackingRun(d.ctx, run.finish(errs)); err != nil {
+		log.Error().Err(err).Str("repo", r.Name).Msg("Error registering tracking run")
+	}
+	return err
+}
 
-	log.Info().Str("repo", r.Name).Msg("Loading chart repository index file")
-	indexFile, err := loadIndexFile(r)
+// trackChartVersion processes the chart version provided from the given
+// repository, regardless of its digest, returning once the job generated for
+// it has been handled. An error is returned when the chart version could not
+// be registered. Errors collected while processing it are not kept, so they
+// don't end up mixed with the ones found when tracking the whole repository.
+func (d *dispatcher) trackChartVersion(r *hub.ChartRepository, name, version string) error {
+	src, err := newSource(d.ctx, r)
 	if err != nil {
-		msg := "Error loading repository index file"
-		d.ec.append(r.ChartRepositoryID, fmt.Errorf("%s: %w", msg, err))
-		log.Error().Err(err).Str("repo", r.Name).Msg(msg)
+		return fmt.Errorf("error setting up repository source: %w", err)
+	}
+	charts, err := src.getChartVersions()
+	if err != nil {
+		return fmt.Errorf("error loading repository chart versions: %w", err)
+	}
+	for i, chartVersion := range charts[name] {
+		if chartVersion.Metadata.Version != version {
+			continue
+		}
+		var wgJobs sync.WaitGroup
+		wgJobs.Add(1)
+		j := &job{
+			repo:         r,
+			source:       src,
+			chartVersion: chartVersion,
+			downloadLogo: i == 0,
+			ec:           newErrorsCollector(d.ctx, d.hubAPI),
+			wg:           &wgJobs,
+		}
+		select {
+		case d.Queue <- j:
+		case <-d.ctx.Done():
+			return d.ctx.Err()
+		}
+		if err := d.waitJobs(&wgJobs); err != nil {
+			return err
+		}
+		if j.err != nil {
+			return fmt.Errorf("error processing chart version: %s", j.err.Message)
+		}
+		return nil
+	}
+	return fmt.Errorf("chart version not found in repository: %s@%s", name, version)
+}
+
+// completeRequests marks the tracking requests provided as completed or
+// failed, depending on the tracking error provided.
+func (d *dispatcher) completeRequests(requestsIDs []string, trackingErr error) {
+	if len(requestsIDs) == 0 {
 		return
 	}
+	status := hub.TrackingRequestCompleted
+	if trackingErr != nil {
+		status = hub.TrackingRequestFailed
+	}
+
+	// Requests are completed even when the tracker is shutting down, so that
+	// they don't remain in the processing status until they are reclaimed
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, id := range requestsIDs {
+		if err := d.hubAPI.CompleteTrackingRequest(ctx, id, status); err != nil {
+			log.Error().Err(err).Str("request", id).Msg("Error completing tracking request")
+		}
+	}
+}
+
+// waitJobs waits for the jobs tracked by the wait group provided to be handled
+// unless the context is done, as workers may not be processing jobs anymore
+// in that case.
+func (d *dispatcher) waitJobs(wgJobs *sync.WaitGroup) error {
+	jobsHandled := make(chan struct{})
+	go func() {
+		wgJobs.Wait()
+		close(jobsHandled)
+	}()
+	select {
+	case <-jobsHandled:
+		return nil
+	case <-d.ctx.Done():
+		return d.ctx.Err()
+	}
+}
+
+// trackRepositoryCharts generates jobs for each of the chart versions found in
+// the given repository, provided that that version has not been already
+// processed and its digest has not changed. Registered versions no longer
+// available in the repository are unregistered. The wait group provided is
+// used to track the jobs generated, whose results are collected in the run.
+func (d *dispatcher) trackRepositoryCharts(
+	wgJobs *sync.WaitGroup,
+	r *hub.ChartRepository,
+	run *trackingRun,
+) error {
+	log.Info().Str("repo", r.Name).Msg("Loading chart repository versions")
+	src, err := newSource(d.ctx, r)
+	if err != nil {
+		e := newTrackingError(hub.TrackingErrorIndexLoad, "", "", err)
+		e.URL = r.URL
+		d.ec.append(r.ChartRepositoryID, e)
+		log.Error().Err(err).Str("repo", r.Name).Msg("Error setting up repository source")
+		return err
+	}
 	log.Info().Str("repo", r.Name).Msg("Loading registered packages digest")
 	packagesDigest, err := d.hubAPI.GetChartRepositoryPackagesDigest(d.ctx, r.ChartRepositoryID)
 	if err != nil {
 		log.Error().Err(err).Str("repo", r.Name).Msg("Error getting repository packages digest")
-		return
+		return err
+	}
+	charts, err := src.getChartVersions()
+	if err != nil {
+		e := newTrackingError(hub.TrackingErrorIndexLoad, "", "", err)
+		if e.URL == "" {
+			e.URL = r.URL
+		}
+		d.ec.append(r.ChartRepositoryID, e)
+		log.Error().Err(err).Str("repo", r.Name).Msg("Error loading repository chart versions")
+		return err
+	}
+	var chartErrors []*hub.TrackingError
+	if ces, ok := src.(chartErrorsSource); ok {
+		chartErrors = ces.getChartErrors()
+		for _, e := range chartErrors {
+			d.ec.append(r.ChartRepositoryID, e)
+		}
 	}
-	for _, chartVersions := range indexFile.Entries {
+	d.checkVerifiedPublisher(r, src)
+	for _, chartVersions := range charts {
 		for i, chartVersion := range chartVersions {
 			var downloadLogo bool
 			if i == 0 {
 				downloadLogo = true
 			}
 			key := fmt.Sprintf("%s@%s", chartVersion.Metadata.Name, chartVersion.Metadata.Version)
-			if chartVersion.Digest != packagesDigest[key] {
-				d.Queue <- &job{
-					repo:         r,
-					chartVersion: chartVersion,
-					downloadLogo: downloadLogo,
-				}
+			if chartVersion.Digest == packagesDigest[key] {
+				continue
+			}
+			wgJobs.Add(1)
+			j := &job{
+				repo:         r,
+				source:       src,
+				chartVersion: chartVersion,
+				downloadLogo: downloadLogo,
+				run:          run,
+				wg:           wgJobs,
 			}
 			select {
+			case d.Queue <- j:
 			case <-d.ctx.Done():
-				return
-			default:
+				wgJobs.Done()
+				return d.ctx.Err()
 			}
 		}
 	}
 
+	d.unregisterRemovedVersions(r, charts, packagesDigest, chartErrors)
+
 	err = d.hubAPI.SetChartRepositoryLastTrackingTs(d.ctx, r.ChartRepositoryID)
 	if err != nil {
 		log.Error().Err(err).Str("repo", r.Name).Msg("Error setting repository last tracking ts")
 	}
+	return nil
 }
 
-// loadIndexFile downloads and parses the index file of the provided repository.
-func loadIndexFile(r *hub.ChartRepository) (*repo.IndexFile, error) {
-	repoConfig := &repo.Entry{
-		Name: r.Name,
-		URL:  r.URL,
+// checkVerifiedPublisher checks if the metadata file of the given repository
+// contains its verification token, updating the repository verified publisher
+// flag when its status changes. Repositories whose source does not support
+// metadata files cannot be verified. Failures fetching the metadata file of a
+// verified repository are registered, and the repository loses its verified
+// publisher flag after maxVerificationFailures consecutive failures.
+func (d *dispatcher) checkVerifiedPublisher(r *hub.ChartRepository, src source) {
+	var verified bool
+	if ms, ok := src.(metadataSource); ok && r.VerificationToken != "" {
+		md, err := ms.getRepositoryMetadata()
+		if err != nil {
+			log.Warn().Err(err).Str("repo", r.Name).Msg("Error getting repository metadata")
+			if !r.VerifiedPublisher {
+				return
+			}
+			err := d.hubAPI.RegisterChartRepositoryVerificationFailure(
+				d.ctx,
+				r.ChartRepositoryID,
+				maxVerificationFailures,
+			)
+			if err != nil {
+				log.Error().Err(err).Str("repo", r.Name).Msg("Error registering repository verification failure")
+			}
+			return
+		}
+		verified = md != nil && md.VerificationToken == r.VerificationToken
 	}
-	getters := getter.All(&cli.EnvSettings{})
-	chartRepository, err := repo.NewChartRepository(repoConfig, getters)
-	if err != nil {
-		return nil, err
+	if verified == r.VerifiedPublisher && r.VerificationFailures == 0 {
+		return
 	}
-	path, err := chartRepository.DownloadIndexFile()
-	if err != nil {
-		return nil, err
+	if err := d.hubAPI.SetChartRepositoryVerifiedPublisher(d.ctx, r.ChartRepositoryID, verified); err != nil {
+		log.Error().Err(err).Str("repo", r.Name).Msg("Error setting repository verified publisher")
 	}
-	indexFile, err := repo.LoadIndexFile(path)
-	if err != nil {
-		return nil, err
+}
+
+// unregisterRemovedVersions unregisters the chart versions registered for the
+// given repository that are no longer available in it. As a safety measure,
+// nothing is unregistered when the repository does not provide any charts.
+// The versions of the charts the source could not load are not unregistered
+// either, as they may still be available. When the chart a load error belongs
+// to is unknown, unregistration is skipped entirely.
+func (d *dispatcher) unregisterRemovedVersions(
+	r *hub.ChartRepository,
+	charts map[string][]*repo.ChartVersion,
+	packagesDigest map[string]string,
+	chartErrors []*hub.TrackingError,
+) {
+	if len(charts) == 0 {
+		if len(packagesDigest) > 0 {
+			log.Warn().Str("repo", r.Name).Msg("No charts found in repository, skipping unregistration")
+		}
+		return
+	}
+	failedCharts := make(map[string]struct{})
+	for _, e := range chartErrors {
+		if e.ChartName == "" {
+			log.Warn().Str("repo", r.Name).Msg("Errors loading repository charts, skipping unregistration")
+			return
+		}
+		failedCharts[e.ChartName] = struct{}{}
+	}
+	available := make(map[string]struct{})
+	for _, chartVersions := range charts {
+		for _, chartVersion := range chartVersions {
+			key := fmt.Sprintf("%s@%s", chartVersion.Metadata.Name, chartVersion.Metadata.Version)
+			available[key] = struct{}{}
+		}
+	}
+	for key := range packagesDigest {
+		if _, ok := available[key]; ok {
+			continue
+		}
+		sep := strings.LastIndex(key, "@")
+		if _, ok := failedCharts[key[:sep]]; ok {
+			continue
+		}
+		p := &hub.Package{
+			Kind:            hub.Chart,
+			Name:            key[:sep],
+			Version:         key[sep+1:],
+			ChartRepository: r,
+		}
+		log.Debug().Str("repo", r.Name).Str("chart", p.Name).Str("version", p.Version).Msg("Unregistering chart version")
+		if err := d.hubAPI.UnregisterPackage(d.ctx, p); err != nil {
+			e := newTrackingError(hub.TrackingErrorRegister, p.Name, p.Version, fmt.Errorf("error unregistering: %w", err))
+			d.ec.append(r.ChartRepositoryID, e)
+			log.Error().Err(err).Str("repo", r.Name).Str("chart", key).Msg("Error unregistering chart version")
+		}
 	}
-	return indexFile, nil
 }
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/cncf/hub/internal/hub"
+	"github.com/cncf/hub/internal/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+func TestDispatcherUnregisterRemovedVersions(t *testing.T) {
+	dbQuery := "select unregister_package($1::jsonb)"
+	r := &hub.ChartRepository{
+		ChartRepositoryID: "00000000-0000-0000-0000-000000000001",
+		Name:              "repo1",
+	}
+	packagesDigest := map[string]string{
+		"chart1@1.0.0": "digest1",
+		"chart1@0.9.0": "digest2",
+		"chart2@1.0.0": "digest3",
+	}
+
+	t.Run("versions no longer available are unregistered", func(t *testing.T) {
+		db := &tests.DBMock{}
+		var unregistered []string
+		db.On("Exec", dbQuery, mock.Anything).Run(func(args mock.Arguments) {
+			var p *hub.Package
+			_ = json.Unmarshal(args.Get(1).([]byte), &p)
+			unregistered = append(unregistered, p.Name+"@"+p.Version)
+		}).Return(nil)
+		d := newDispatcher(context.Background(), newErrorsCollector(context.Background(), nil), hub.New(db, nil))
+
+		charts := map[string][]*repo.ChartVersion{
+			"chart1": {
+				{Metadata: &chart.Metadata{Name: "chart1", Version: "1.0.0"}},
+			},
+		}
+		d.unregisterRemovedVersions(r, charts, packagesDigest, nil)
+		assert.ElementsMatch(t, []string{"chart1@0.9.0", "chart2@1.0.0"}, unregistered)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("nothing is unregistered when no charts are available", func(t *testing.T) {
+		db := &tests.DBMock{}
+		d := newDispatcher(context.Background(), newErrorsCollector(context.Background(), nil), hub.New(db, nil))
+
+		d.unregisterRemovedVersions(r, nil, packagesDigest, nil)
+		db.AssertNotCalled(t, "Exec", dbQuery, mock.Anything)
+	})
+
+	t.Run("versions of charts with load errors are not unregistered", func(t *testing.T) {
+		db := &tests.DBMock{}
+		var unregistered []string
+		db.On("Exec", dbQuery, mock.Anything).Run(func(args mock.Arguments) {
+			var p *hub.Package
+			_ = json.Unmarshal(args.Get(1).([]byte), &p)
+			unregistered = append(unregistered, p.Name+"@"+p.Version)
+		}).Return(nil)
+		d := newDispatcher(context.Background(), newErrorsCollector(context.Background(), nil), hub.New(db, nil))
+
+		charts := map[string][]*repo.ChartVersion{
+			"chart1": {
+				{Metadata: &chart.Metadata{Name: "chart1", Version: "1.0.0"}},
+			},
+		}
+		chartErrors := []*hub.TrackingError{
+			newTrackingError(hub.TrackingErrorChartDownload, "chart2", "1.0.0", errors.New("fake error")),
+		}
+		d.unregisterRemovedVersions(r, charts, packagesDigest, chartErrors)
+		assert.ElementsMatch(t, []string{"chart1@0.9.0"}, unregistered)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("nothing is unregistered when a chart could not be loaded", func(t *testing.T) {
+		db := &tests.DBMock{}
+		d := newDispatcher(context.Background(), newErrorsCollector(context.Background(), nil), hub.New(db, nil))
+
+		charts := map[string][]*repo.ChartVersion{
+			"chart1": {
+				{Metadata: &chart.Metadata{Name: "chart1", Version: "1.0.0"}},
+			},
+		}
+		chartErrors := []*hub.TrackingError{
+			newTrackingError(hub.TrackingErrorChartParse, "", "", errors.New("fake parse error")),
+		}
+		d.unregisterRemovedVersions(r, charts, packagesDigest, chartErrors)
+		db.AssertNotCalled(t, "Exec", dbQuery, mock.Anything)
+	})
+}
+
+func TestDispatcherCheckVerifiedPublisher(t *testing.T) {
+	dbQuery := `
+	update chart_repository set verified_publisher = $2, verification_failures = 0
+	where chart_repository_id = $1`
+	dbFailureQuery := `
+	update chart_repository set
+		verification_failures = verification_failures + 1,
+		verified_publisher = verified_publisher and verification_failures + 1 < $2
+	where chart_repository_id = $1`
+	repoID := "00000000-0000-0000-0000-000000000001"
+
+	testCases := []struct {
+		description          string
+		verificationToken    string
+		verifiedPublisher    bool
+		verificationFailures int
+		src                  source
+		expectedVerified     interface{}
+		expectedFailure      bool
+	}{
+		{
+			"token found in metadata file",
+			"token",
+			false,
+			0,
+			&metadataSourceMock{md: &repositoryMetadata{VerificationToken: "token"}},
+			true,
+			false,
+		},
+		{
+			"token no longer found in metadata file",
+			"token",
+			true,
+			0,
+			&metadataSourceMock{md: &repositoryMetadata{VerificationToken: "other"}},
+			false,
+			false,
+		},
+		{
+			"metadata file no longer available",
+			"token",
+			true,
+			0,
+			&metadataSourceMock{},
+			false,
+			false,
+		},
+		{
+			"repository without verification token",
+			"",
+			true,
+			0,
+			&metadataSourceMock{md: &repositoryMetadata{VerificationToken: "token"}},
+			false,
+			false,
+		},
+		{
+			"source does not support metadata files",
+			"token",
+			true,
+			0,
+			&ociSource{},
+			false,
+			false,
+		},
+		{
+			"verification status unchanged",
+			"token",
+			true,
+			0,
+			&metadataSourceMock{md: &repositoryMetadata{VerificationToken: "token"}},
+			nil,
+			false,
+		},
+		{
+			"verification status unchanged after previous failures",
+			"token",
+			true,
+			2,
+			&metadataSourceMock{md: &repositoryMetadata{VerificationToken: "token"}},
+			true,
+			false,
+		},
+		{
+			"error getting metadata file of verified repository",
+			"token",
+			true,
+			0,
+			&metadataSourceMock{err: errors.New("fake metadata error")},
+			nil,
+			true,
+		},
+		{
+			"error getting metadata file of not verified repository",
+			"token",
+			false,
+			0,
+			&metadataSourceMock{err: errors.New("fake metadata error")},
+			nil,
+			false,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			db := &tests.DBMock{}
+			if tc.expectedVerified != nil {
+				db.On("Exec", dbQuery, repoID, tc.expectedVerified).Return(nil)
+			}
+			if tc.expectedFailure {
+				db.On("Exec", dbFailureQuery, repoID, maxVerificationFailures).Return(nil)
+			}
+			d := newDispatcher(context.Background(), newErrorsCollector(context.Background(), nil), hub.New(db, nil))
+
+			r := &hub.ChartRepository{
+				ChartRepositoryID:    repoID,
+				Name:                 "repo1",
+				VerificationToken:    tc.verificationToken,
+				VerifiedPublisher:    tc.verifiedPublisher,
+				VerificationFailures: tc.verificationFailures,
+			}
+			d.checkVerifiedPublisher(r, tc.src)
+			db.AssertExpectations(t)
+		})
+	}
+}
+
+// metadataSourceMock is a source that provides the repository metadata given.
+type metadataSourceMock struct {
+	source
+	md  *repositoryMetadata
+	err error
+}
+
+// getRepositoryMetadata implements the metadataSource interface.
+func (s *metadataSourceMock) getRepositoryMetadata() (*repositoryMetadata, error) {
+	return s.md, s.err
+}
+
+func TestDispatcherCompleteRequests(t *testing.T) {
+	dbQuery := `
+	update tracking_request set status = $2, completed_at = current_timestamp
+	where tracking_request_id = $1`
+
+	t.Run("requests are completed when tracking succeeds", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, "request1", hub.TrackingRequestCompleted).Return(nil)
+		db.On("Exec", dbQuery, "request2", hub.TrackingRequestCompleted).Return(nil)
+		d := newDispatcher(context.Background(), newErrorsCollector(context.Background(), nil), hub.New(db, nil))
+
+		d.completeRequests([]string{"request1", "request2"}, nil)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("requests fail when tracking fails", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, "request1", hub.TrackingRequestFailed).Return(nil)
+		d := newDispatcher(context.Background(), newErrorsCollector(context.Background(), nil), hub.New(db, nil))
+
+		d.completeRequests([]string{"request1"}, errors.New("tracking error"))
+		db.AssertExpectations(t)
+	})
+}
+
+func TestDispatcherTrackChartVersion(t *testing.T) {
+	indexFile := `
+apiVersion: v1
+entries:
+  chart1:
+    - name: chart1
+      version: 1.0.0
+      digest: digest1
+      urls:
+        - chart1-1.0.0.tgz
+`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/index.yaml" {
+			_, _ = w.Write([]byte(indexFile))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+	r := &hub.ChartRepository{
+		ChartRepositoryID: "00000000-0000-0000-0000-000000000001",
+		Name:              "repo1",
+		URL:               server.URL,
+	}
+
+	t.Run("chart version not found", func(t *testing.T) {
+		ec := newErrorsCollector(context.Background(), nil)
+		d := newDispatcher(context.Background(), ec, hub.New(&tests.DBMock{}, nil))
+
+		err := d.trackChartVersion(r, "chart1", "2.0.0")
+		assert.Error(t, err)
+	})
+
+	t.Run("error processing chart version", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		ec := newErrorsCollector(ctx, nil)
+		d := newDispatcher(ctx, ec, hub.New(&tests.DBMock{}, nil))
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go newWorker(ctx, 0, ec, nil, nil).run(&wg, d.Queue)
+
+		err := d.trackChartVersion(r, "chart1", "1.0.0")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unexpected status code received: 404")
+		assert.Empty(t, ec.errors)
+		cancel()
+		wg.Wait()
+	})
+}
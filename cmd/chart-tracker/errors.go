@@ -2,7 +2,8 @@ package main
 
 import (
 	"context"
-	"strings"
+	"errors"
+	"fmt"
 	"sync"
 
 	"github.com/cncf/hub/internal/hub"
@@ -15,15 +16,61 @@ const (
 	maxErrorsPerChartRepository = 100
 )
 
+// httpError represents an unexpected response received from a remote server.
+type httpError struct {
+	url        string
+	statusCode int
+}
+
+// Error implements the error interface.
+func (e *httpError) Error() string {
+	return fmt.Sprintf("%s: unexpected status code received: %d", e.url, e.statusCode)
+}
+
+// chartParseError represents an error parsing a chart once it's been
+// downloaded.
+type chartParseError struct {
+	err error
+}
+
+// Error implements the error interface.
+func (e *chartParseError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *chartParseError) Unwrap() error {
+	return e.err
+}
+
+// newTrackingError creates a new tracking error of the kind provided for the
+// given chart version. When the error was caused by an unexpected http
+// response, the url and status code received are included.
+func newTrackingError(kind hub.TrackingErrorKind, name, version string, err error) *hub.TrackingError {
+	e := &hub.TrackingError{
+		Kind:         kind,
+		ChartName:    name,
+		ChartVersion: version,
+		Message:      err.Error(),
+	}
+	var httpErr *httpError
+	if errors.As(err, &httpErr) {
+		e.URL = httpErr.url
+		e.HTTPStatus = httpErr.statusCode
+	}
+	return e
+}
+
 // errorsCollector is in charge of collecting errors that happen while chart
-// repositories are being processed. Once all the processing is done, the
-// collected errors can be flushed, which will store them in the database.
+// repositories are being processed. Once the processing of a repository is
+// done, its collected errors can be flushed, which will store them in the
+// database.
 type errorsCollector struct {
 	ctx    context.Context
 	hubAPI *hub.Hub
 
 	mu     sync.Mutex
-	errors map[string][]error // K: chart repository id
+	errors map[string]*hub.TrackingErrors // K: chart repository id
 }
 
 // newErrorsCollector creates a new errorsCollector instance.
@@ -31,32 +78,80 @@ func newErrorsCollector(ctx context.Context, hubAPI *hub.Hub) *errorsCollector {
 	return &errorsCollector{
 		ctx:    ctx,
 		hubAPI: hubAPI,
-		errors: make(map[string][]error),
+		errors: make(map[string]*hub.TrackingErrors),
 	}
 }
 
 // appends adds the error provided to the chart repository's list of errors.
-func (c *errorsCollector) append(chartRepositoryID string, err error) {
+// Once the maximum number of errors has been reached, new errors are counted
+// as suppressed.
+func (c *errorsCollector) append(chartRepositoryID string, e *hub.TrackingError) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if len(c.errors[chartRepositoryID]) < maxErrorsPerChartRepository {
-		c.errors[chartRepositoryID] = append(c.errors[chartRepositoryID], err)
+	errs, ok := c.errors[chartRepositoryID]
+	if !ok {
+		errs = &hub.TrackingErrors{}
+		c.errors[chartRepositoryID] = errs
+	}
+	if len(errs.Errors) < maxErrorsPerChartRepository {
+		errs.Errors = append(errs.Errors, e)
+	} else {
+		errs.Suppressed++
 	}
 }
 
-// flush aggregates all errors collected per chart repository as a single text
-// and stores it in the database.
-func (c *errorsCollector) flush() {
-	for chartRepositoryID, errors := range c.errors {
-		var errStr strings.Builder
-		for _, err := range errors {
-			errStr.WriteString(err.Error())
-			errStr.WriteString("\n")
-		}
-		err := c.hubAPI.SetChartRepositoryLastTrackingErrors(c.ctx, chartRepositoryID, errStr.String())
+// flushRepository stores the errors collected for the chart repository
+// provided in the database, clearing the previous ones when there are none.
+// The repository owners are notified about the errors that were not present
+// in the previous tracking. Once flushed, the errors are removed from the
+// collector and returned.
+func (c *errorsCollector) flushRepository(r *hub.ChartRepository) *hub.TrackingErrors {
+	c.mu.Lock()
+	errs := c.errors[r.ChartRepositoryID]
+	delete(c.errors, r.ChartRepositoryID)
+	c.mu.Unlock()
+
+	var prevErrs *hub.TrackingErrors
+	var prevErrsAvailable bool
+	if errs != nil {
+		var err error
+		prevErrs, err = c.hubAPI.GetChartRepositoryLastTrackingErrors(c.ctx, r.ChartRepositoryID)
 		if err != nil {
-			log.Error().Err(err).Str("repoID", chartRepositoryID).Send()
+			log.Error().Err(err).Str("repo", r.Name).Msg("Error getting repository last tracking errors")
+		} else {
+			prevErrsAvailable = true
+		}
+	}
+	err := c.hubAPI.SetChartRepositoryLastTrackingErrors(c.ctx, r.ChartRepositoryID, errs)
+	if err != nil {
+		log.Error().Err(err).Str("repo", r.Name).Msg("Error setting repository last tracking errors")
+	}
+	if prevErrsAvailable {
+		if err := c.hubAPI.NotifyTrackingErrors(c.ctx, r, newTrackingErrors(prevErrs, errs)); err != nil {
+			log.Error().Err(err).Str("repo", r.Name).Msg("Error notifying repository tracking errors")
+		}
+	}
+	return errs
+}
+
+// newTrackingErrors returns the errors in errs that were not present in the
+// previous errors provided.
+func newTrackingErrors(prevErrs, errs *hub.TrackingErrors) []*hub.TrackingError {
+	if errs == nil {
+		return nil
+	}
+	prev := make(map[hub.TrackingError]struct{})
+	if prevErrs != nil {
+		for _, e := range prevErrs.Errors {
+			prev[*e] = struct{}{}
+		}
+	}
+	var newErrs []*hub.TrackingError
+	for _, e := range errs.Errors {
+		if _, ok := prev[*e]; !ok {
+			newErrs = append(newErrs, e)
 		}
 	}
+	return newErrs
 }
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cncf/hub/internal/email"
+	"github.com/cncf/hub/internal/hub"
+	"github.com/cncf/hub/internal/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTrackingError(t *testing.T) {
+	t.Run("generic error", func(t *testing.T) {
+		e := newTrackingError(hub.TrackingErrorRegister, "chart1", "1.0.0", errors.New("error1"))
+		assert.Equal(t, &hub.TrackingError{
+			Kind:         hub.TrackingErrorRegister,
+			ChartName:    "chart1",
+			ChartVersion: "1.0.0",
+			Message:      "error1",
+		}, e)
+	})
+
+	t.Run("http error", func(t *testing.T) {
+		err := fmt.Errorf("wrapped: %w", &httpError{url: "https://repo1.com/chart1.tgz", statusCode: 404})
+		e := newTrackingError(hub.TrackingErrorChartDownload, "chart1", "1.0.0", err)
+		assert.Equal(t, "https://repo1.com/chart1.tgz", e.URL)
+		assert.Equal(t, 404, e.HTTPStatus)
+	})
+}
+
+func TestErrorsCollector(t *testing.T) {
+	dbQuery := `
+	update chart_repository set last_tracking_errors = $2
+	where chart_repository_id = $1`
+	dbQueryLastErrors := `
+	select last_tracking_errors from chart_repository
+	where chart_repository_id = $1`
+	dbQueryRecipients := "select get_tracking_errors_notification_recipients($1::uuid)"
+	r := &hub.ChartRepository{ChartRepositoryID: "repoID", Name: "repo1"}
+
+	t.Run("errors over the limit are suppressed", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQueryLastErrors, "repoID").Return(nil, nil)
+		db.On("Exec", dbQuery, "repoID", mock.Anything).Return(nil)
+		ec := newErrorsCollector(context.Background(), hub.New(db, nil))
+
+		for i := 0; i < maxErrorsPerChartRepository+5; i++ {
+			ec.append("repoID", &hub.TrackingError{Kind: hub.TrackingErrorRegister, Message: "error"})
+		}
+		errs := ec.flushRepository(r)
+		require.NotNil(t, errs)
+		assert.Len(t, errs.Errors, maxErrorsPerChartRepository)
+		assert.Equal(t, 5, errs.Suppressed)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("previous errors are cleared when there are none", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, "repoID", []byte(nil)).Return(nil)
+		ec := newErrorsCollector(context.Background(), hub.New(db, nil))
+
+		errs := ec.flushRepository(r)
+		assert.Nil(t, errs)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("owners are notified about new errors", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQueryLastErrors, "repoID").Return([]byte(`
+		{
+			"errors": [{"kind": "register", "chart_name": "chart1", "message": "error1"}],
+			"suppressed": 0
+		}
+		`), nil)
+		db.On("Exec", dbQuery, "repoID", mock.Anything).Return(nil)
+		db.On("QueryRow", dbQueryRecipients, "repoID").Return([]byte(`["user1@email.com"]`), nil)
+		es := &tests.EmailSenderMock{}
+		es.On("SendEmail", mock.MatchedBy(func(data *email.Data) bool {
+			body := string(data.Body)
+			return strings.Contains(body, "register chart2: error2") &&
+				!strings.Contains(body, "register chart1: error1")
+		})).Return(nil)
+		ec := newErrorsCollector(context.Background(), hub.New(db, es))
+
+		ec.append("repoID", &hub.TrackingError{Kind: hub.TrackingErrorRegister, ChartName: "chart1", Message: "error1"})
+		ec.append("repoID", &hub.TrackingError{Kind: hub.TrackingErrorRegister, ChartName: "chart2", Message: "error2"})
+		errs := ec.flushRepository(r)
+		require.NotNil(t, errs)
+		assert.Len(t, errs.Errors, 2)
+		db.AssertExpectations(t)
+		es.AssertExpectations(t)
+	})
+
+	t.Run("owners are not notified when previous errors are not available", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQueryLastErrors, "repoID").Return(nil, errors.New("fake database failure"))
+		db.On("Exec", dbQuery, "repoID", mock.Anything).Return(nil)
+		es := &tests.EmailSenderMock{}
+		ec := newErrorsCollector(context.Background(), hub.New(db, es))
+
+		ec.append("repoID", &hub.TrackingError{Kind: hub.TrackingErrorRegister, Message: "error"})
+		ec.flushRepository(r)
+		db.AssertExpectations(t)
+		es.AssertExpectations(t)
+	})
+}
+
+func TestNewTrackingErrors(t *testing.T) {
+	e1 := &hub.TrackingError{Kind: hub.TrackingErrorRegister, ChartName: "chart1", Message: "error1"}
+	e2 := &hub.TrackingError{Kind: hub.TrackingErrorChartDownload, ChartName: "chart2", HTTPStatus: 404, Message: "error2"}
+	e2b := &hub.TrackingError{Kind: hub.TrackingErrorChartDownload, ChartName: "chart2", HTTPStatus: 500, Message: "error2"}
+
+	testCases := []struct {
+		description     string
+		prevErrs        *hub.TrackingErrors
+		errs            *hub.TrackingErrors
+		expectedNewErrs []*hub.TrackingError
+	}{
+		{
+			"no errors",
+			&hub.TrackingErrors{Errors: []*hub.TrackingError{e1}},
+			nil,
+			nil,
+		},
+		{
+			"no previous errors",
+			nil,
+			&hub.TrackingErrors{Errors: []*hub.TrackingError{e1, e2}},
+			[]*hub.TrackingError{e1, e2},
+		},
+		{
+			"same errors as in the previous tracking",
+			&hub.TrackingErrors{Errors: []*hub.TrackingError{e1, e2}},
+			&hub.TrackingErrors{Errors: []*hub.TrackingError{e2, e1}},
+			nil,
+		},
+		{
+			"some errors changed",
+			&hub.TrackingErrors{Errors: []*hub.TrackingError{e1, e2}},
+			&hub.TrackingErrors{Errors: []*hub.TrackingError{e1, e2b}},
+			[]*hub.TrackingError{e2b},
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			assert.Equal(t, tc.expectedNewErrs, newTrackingErrors(tc.prevErrs, tc.errs))
+		})
+	}
+}
@@ -2,11 +2,15 @@ package main
 
 import (
 	"context"
+	"math/rand"
+	"net/http"
 	"os"
 	"os/signal"
 	"sync"
 	"syscall"
+	"time"
 
+	"github.com/cncf/hub/internal/email"
 	"github.com/cncf/hub/internal/hub"
 	"github.com/cncf/hub/internal/util"
 	"github.com/rs/zerolog/log"
@@ -25,6 +29,10 @@ func main() {
 		log.Fatal().Err(err).Msg("Logger setup failed")
 	}
 
+	// Seed the random numbers generator used to spread the repositories
+	// tracking over time
+	rand.Seed(time.Now().UnixNano())
+
 	// Shutdown gracefully when SIGINT or SIGTERM signal is received
 	log.Info().Int("pid", os.Getpid()).Msg("Chart tracker started")
 	ctx, cancel := context.WithCancel(context.Background())
@@ -41,24 +49,65 @@ func main() {
 	if err != nil {
 		log.Fatal().Err(err).Msg("Database setup failed")
 	}
-	hubAPI := hub.New(db, nil)
+	var es hub.EmailSender
+	if s := email.NewSender(cfg); s != nil {
+		es = s
+	}
+	hubAPI := hub.New(db, es)
 	imageStore, err := util.SetupImageStore(cfg, db)
 	if err != nil {
 		log.Fatal().Err(err).Msg("ImageStore setup failed")
 	}
 
-	// Launch dispatcher and workers and wait for them to finish
+	// Launch dispatcher and workers and wait for them to finish. In daemon
+	// mode, repositories are tracked continuously until the tracker is asked
+	// to stop.
 	var wg sync.WaitGroup
 	ec := newErrorsCollector(ctx, hubAPI)
 	dispatcher := newDispatcher(ctx, ec, hubAPI)
 	wg.Add(1)
-	go dispatcher.run(&wg, cfg.GetStringSlice("tracker.repositoriesNames"))
+	if cfg.GetBool("tracker.daemon.enabled") {
+		daemon := newDaemon(ctx, cfg, hubAPI, dispatcher)
+		go daemon.run(&wg)
+		wg.Add(1)
+		go serveStatus(ctx, &wg, cfg.GetString("tracker.daemon.statusAddr"), daemon)
+	} else {
+		go dispatcher.run(&wg, cfg.GetStringSlice("tracker.repositoriesNames"))
+	}
 	for i := 0; i < cfg.GetInt("tracker.numWorkers"); i++ {
 		w := newWorker(ctx, i, ec, hubAPI, imageStore)
 		wg.Add(1)
 		go w.run(&wg, dispatcher.Queue)
 	}
 	wg.Wait()
-	ec.flush()
 	log.Info().Msg("Chart tracker finished")
 }
+
+// serveStatus starts an http server that exposes the daemon tracking status,
+// shutting it down when the context provided is done.
+func serveStatus(ctx context.Context, wg *sync.WaitGroup, addr string, daemon *daemon) {
+	defer wg.Done()
+	if addr == "" {
+		addr = ":8001"
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", daemon.statusHandler)
+	srv := &http.Server{
+		Addr:         addr,
+		Handler:      mux,
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Error().Err(err).Msg("Status server shutdown failed")
+		}
+	}()
+	log.Info().Str("addr", addr).Msg("Status server running")
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Error().Err(err).Msg("Status server failed")
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cncf/hub/internal/hub"
+)
+
+// trackingRun collects some stats about the chart versions processed while
+// tracking a repository, which are registered in the database once the
+// tracking finishes.
+type trackingRun struct {
+	repo      *hub.ChartRepository
+	startedAt time.Time
+
+	mu         sync.Mutex
+	processed  int
+	registered int
+	failed     int
+}
+
+// newTrackingRun creates a new trackingRun instance.
+func newTrackingRun(r *hub.ChartRepository) *trackingRun {
+	return &trackingRun{
+		repo:      r,
+		startedAt: time.Now(),
+	}
+}
+
+// recordJob records the result of handling a job generated for the run.
+func (tr *trackingRun) recordJob(registered bool) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	tr.processed++
+	if registered {
+		tr.registered++
+	} else {
+		tr.failed++
+	}
+}
+
+// finish builds a hub tracking run from the stats collected, including the
+// errors provided.
+func (tr *trackingRun) finish(errs *hub.TrackingErrors) *hub.TrackingRun {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	run := &hub.TrackingRun{
+		ChartRepositoryID:  tr.repo.ChartRepositoryID,
+		StartedAt:          tr.startedAt.Unix(),
+		FinishedAt:         time.Now().Unix(),
+		VersionsProcessed:  tr.processed,
+		VersionsRegistered: tr.registered,
+		VersionsFailed:     tr.failed,
+	}
+	if errs != nil {
+		run.Errors = errs.Errors
+		run.SuppressedErrors = errs.Suppressed
+	}
+	return run
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/cncf/hub/internal/hub"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrackingRun(t *testing.T) {
+	run := newTrackingRun(&hub.ChartRepository{ChartRepositoryID: "repoID"})
+	run.recordJob(true)
+	run.recordJob(true)
+	run.recordJob(false)
+
+	errs := &hub.TrackingErrors{
+		Errors: []*hub.TrackingError{
+			{Kind: hub.TrackingErrorRegister, Message: "error1"},
+		},
+		Suppressed: 1,
+	}
+	r := run.finish(errs)
+	assert.Equal(t, "repoID", r.ChartRepositoryID)
+	assert.Equal(t, run.startedAt.Unix(), r.StartedAt)
+	assert.GreaterOrEqual(t, r.FinishedAt, r.StartedAt)
+	assert.Equal(t, 3, r.VersionsProcessed)
+	assert.Equal(t, 2, r.VersionsRegistered)
+	assert.Equal(t, 1, r.VersionsFailed)
+	assert.Equal(t, errs.Errors, r.Errors)
+	assert.Equal(t, 1, r.SuppressedErrors)
+}
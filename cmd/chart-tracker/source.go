@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cncf/hub/internal/hub"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// repositoryMetadataFile represents the name of the metadata file chart
+	// repositories can provide. It is expected next to the index file in Helm
+	// repositories and at the root of git repositories.
+	repositoryMetadataFile = "hub-repo.yml"
+
+	// maxRepositoryMetadataSize represents the maximum size of the repository
+	// metadata file that will be read.
+	maxRepositoryMetadataSize = 64 * 1024
+)
+
+// source defines the methods a chart repository source must provide. The
+// dispatcher uses it to list the chart versions available in a repository,
+// and the workers to load the content of the versions that need to be
+// registered.
+type source interface {
+	// getChartVersions returns the chart versions available in the source,
+	// including their digest, indexed by chart name. Versions of each chart
+	// are expected to be sorted from newest to oldest.
+	getChartVersions() (map[string][]*repo.ChartVersion, error)
+
+	// loadChart loads the chart corresponding to the chart version provided.
+	loadChart(cv *repo.ChartVersion) (*chart.Chart, error)
+}
+
+// metadataSource defines the methods a source able to provide the repository
+// metadata file must implement. Not all sources support it.
+type metadataSource interface {
+	// getRepositoryMetadata returns the metadata provided by the repository,
+	// or nil when the repository does not provide a metadata file.
+	getRepositoryMetadata() (*repositoryMetadata, error)
+}
+
+// chartErrorsSource defines the methods a source that skips the charts it is
+// not able to load while getting the chart versions must implement.
+type chartErrorsSource interface {
+	// getChartErrors returns the errors found loading the charts skipped.
+	getChartErrors() []*hub.TrackingError
+}
+
+// repositoryMetadata represents the content of the repository metadata file.
+// The verification token is used to prove the ownership of the repository.
+type repositoryMetadata struct {
+	VerificationToken string `json:"verificationToken"`
+}
+
+// parseRepositoryMetadata parses the content of the repository metadata file
+// provided.
+func parseRepositoryMetadata(data []byte) (*repositoryMetadata, error) {
+	var md *repositoryMetadata
+	if err := yaml.Unmarshal(data, &md); err != nil {
+		return nil, fmt.Errorf("error parsing repository metadata file: %w", err)
+	}
+	return md, nil
+}
+
+// newSource returns the source that should be used to track the chart
+// repository provided.
+func newSource(ctx context.Context, r *hub.ChartRepository) (source, error) {
+	if r.Kind == hub.GitChartRepository {
+		return newGitSource(ctx, r), nil
+	}
+	if strings.HasPrefix(r.URL, ociScheme+"://") {
+		return newOCISource(r)
+	}
+	return newHelmIndexSource(r), nil
+}
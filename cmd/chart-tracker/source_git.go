@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/cncf/hub/internal/hub"
+	"github.com/cncf/hub/internal/util"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// gitSource is a source that gets the charts available in a git repository.
+// The repository is cloned and each directory containing a Chart.yaml file
+// that matches the repository path glob is loaded as a chart. Directories
+// inside a chart (like its unpacked subcharts) are not processed. Charts (and
+// the repository metadata file, if any) are kept in memory once loaded, so the
+// clone can be removed straight away. Charts that cannot be loaded are skipped
+// and the errors found are kept so that they can be reported.
+type gitSource struct {
+	ctx context.Context
+	r   *hub.ChartRepository
+
+	// K: chart version key (name@version)
+	charts map[string]*chart.Chart
+
+	chartErrors []*hub.TrackingError
+	metadata    []byte
+}
+
+// newGitSource creates a new gitSource instance.
+func newGitSource(ctx context.Context, r *hub.ChartRepository) *gitSource {
+	return &gitSource{
+		ctx:    ctx,
+		r:      r,
+		charts: make(map[string]*chart.Chart),
+	}
+}
+
+// getChartVersions implements the source interface.
+func (s *gitSource) getChartVersions() (map[string][]*repo.ChartVersion, error) {
+	tmpDir, err := ioutil.TempDir("", "chart-tracker")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+	if err := util.CloneGitRepository(s.ctx, s.r.URL, s.r.GitBranch, tmpDir); err != nil {
+		return nil, fmt.Errorf("error cloning git repository: %w", err)
+	}
+	s.metadata, err = readRepositoryMetadataFile(filepath.Join(tmpDir, repositoryMetadataFile))
+	if err != nil {
+		return nil, fmt.Errorf("error reading repository metadata file: %w", err)
+	}
+
+	indexFile := repo.NewIndexFile()
+	err = filepath.Walk(tmpDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if _, err := os.Stat(filepath.Join(p, "Chart.yaml")); err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if err := s.ctx.Err(); err != nil {
+			return err
+		}
+		relPath, _ := filepath.Rel(tmpDir, p)
+		if !s.matchesPathGlob(filepath.ToSlash(relPath)) {
+			return filepath.SkipDir
+		}
+		chart, err := loader.LoadDir(p)
+		if err != nil {
+			err = fmt.Errorf("error loading chart %s: %w", relPath, err)
+			s.chartErrors = append(s.chartErrors, newTrackingError(hub.TrackingErrorChartParse, "", "", err))
+			return filepath.SkipDir
+		}
+		digest, err := getDirDigest(p)
+		if err != nil {
+			return err
+		}
+		md := chart.Metadata
+		indexFile.Add(md, "", s.r.URL, digest)
+		s.charts[fmt.Sprintf("%s@%s", md.Name, md.Version)] = chart
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, err
+	}
+	indexFile.SortEntries()
+
+	chartVersions := make(map[string][]*repo.ChartVersion, len(indexFile.Entries))
+	for name, versions := range indexFile.Entries {
+		chartVersions[name] = versions
+	}
+	return chartVersions, nil
+}
+
+// loadChart implements the source interface.
+func (s *gitSource) loadChart(cv *repo.ChartVersion) (*chart.Chart, error) {
+	chart, ok := s.charts[fmt.Sprintf("%s@%s", cv.Metadata.Name, cv.Metadata.Version)]
+	if !ok {
+		return nil, fmt.Errorf("chart %s@%s not found", cv.Metadata.Name, cv.Metadata.Version)
+	}
+	return chart, nil
+}
+
+// getChartErrors implements the chartErrorsSource interface.
+func (s *gitSource) getChartErrors() []*hub.TrackingError {
+	return s.chartErrors
+}
+
+// getRepositoryMetadata implements the metadataSource interface. The metadata
+// file is read when the repository is cloned to get its chart versions.
+func (s *gitSource) getRepositoryMetadata() (*repositoryMetadata, error) {
+	if s.metadata == nil {
+		return nil, nil
+	}
+	return parseRepositoryMetadata(s.metadata)
+}
+
+// matchesPathGlob checks if the path provided, relative to the root of the git
+// repository, matches the repository path glob. When no glob is provided, all
+// paths match.
+func (s *gitSource) matchesPathGlob(p string) bool {
+	if s.r.GitPathGlob == "" {
+		return true
+	}
+	return matchPathGlob(strings.Trim(s.r.GitPathGlob, "/"), p)
+}
+
+// matchPathGlob checks if the slash separated path provided matches the glob
+// pattern given. Patterns segments are matched using path.Match, and the **
+// segment matches any number of path segments (including none).
+func matchPathGlob(pattern, p string) bool {
+	return matchPathSegments(strings.Split(pattern, "/"), strings.Split(p, "/"))
+}
+
+// matchPathSegments checks if the path segments provided match the pattern
+// segments given.
+func matchPathSegments(pattern, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(segments); i++ {
+				if matchPathSegments(pattern[1:], segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segments) == 0 {
+			return false
+		}
+		if matched, _ := path.Match(pattern[0], segments[0]); !matched {
+			return false
+		}
+		pattern, segments = pattern[1:], segments[1:]
+	}
+	return len(segments) == 0
+}
+
+// getDirDigest returns a digest computed from the paths and content of all the
+// files in the directory provided.
+func getDirDigest(dir string) (string, error) {
+	h := sha256.New()
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		relPath, _ := filepath.Rel(dir, p)
+		_, _ = io.WriteString(h, filepath.ToSlash(relPath))
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(h, f)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// readRepositoryMetadataFile reads up to maxRepositoryMetadataSize bytes of the
+// repository metadata file located at the path provided. Nil is returned when
+// the file does not exist.
+func readRepositoryMetadataFile(p string) ([]byte, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	return ioutil.ReadAll(io.LimitReader(f, maxRepositoryMetadataSize))
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/cncf/hub/internal/hub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitSource(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	// Setup git repository with some charts
+	repoDir, err := ioutil.TempDir("", "chart-tracker-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(repoDir)
+	writeChart(t, filepath.Join(repoDir, "charts", "chart1"), "chart1", "1.0.0")
+	writeChart(t, filepath.Join(repoDir, "charts", "chart1", "charts", "subchart1"), "subchart1", "1.0.0")
+	writeChart(t, filepath.Join(repoDir, "charts", "chart2"), "chart2", "0.1.0")
+	writeChart(t, filepath.Join(repoDir, "other", "chart3"), "chart3", "2.0.0")
+	require.NoError(t, os.MkdirAll(filepath.Join(repoDir, "charts", "broken"), 0755))
+	brokenChartYaml := filepath.Join(repoDir, "charts", "broken", "Chart.yaml")
+	require.NoError(t, ioutil.WriteFile(brokenChartYaml, []byte("name: ["), 0644))
+	metadataFile := filepath.Join(repoDir, repositoryMetadataFile)
+	require.NoError(t, ioutil.WriteFile(metadataFile, []byte("verificationToken: token\n"), 0644))
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@email.com", "commit", "-q", "-m", "charts"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+	}
+
+	s := newGitSource(context.Background(), &hub.ChartRepository{
+		URL:         repoDir,
+		Kind:        hub.GitChartRepository,
+		GitPathGlob: "charts/**",
+	})
+
+	// Only charts matching the path glob are returned, skipping subcharts
+	charts, err := s.getChartVersions()
+	require.NoError(t, err)
+	require.Len(t, charts, 2)
+	require.Len(t, charts["chart1"], 1)
+	require.Len(t, charts["chart2"], 1)
+	cv := charts["chart1"][0]
+	assert.Equal(t, "1.0.0", cv.Version)
+	assert.NotEmpty(t, cv.Digest)
+
+	// Charts that cannot be loaded are reported as errors
+	chartErrors := s.getChartErrors()
+	require.Len(t, chartErrors, 1)
+	assert.Equal(t, hub.TrackingErrorChartParse, chartErrors[0].Kind)
+	assert.Contains(t, chartErrors[0].Message, "error loading chart charts/broken")
+
+	// Digests are stable between runs
+	charts2, err := newGitSource(context.Background(), s.r).getChartVersions()
+	require.NoError(t, err)
+	assert.Equal(t, cv.Digest, charts2["chart1"][0].Digest)
+
+	// Charts can be loaded once listed
+	chart, err := s.loadChart(cv)
+	require.NoError(t, err)
+	assert.Equal(t, "chart1", chart.Metadata.Name)
+	assert.Equal(t, "1.0.0", chart.Metadata.Version)
+
+	// Repository metadata file is read from the root of the repository
+	md, err := s.getRepositoryMetadata()
+	require.NoError(t, err)
+	require.NotNil(t, md)
+	assert.Equal(t, "token", md.VerificationToken)
+}
+
+func TestMatchPathGlob(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		p       string
+		matches bool
+	}{
+		{"charts/*", "charts/chart1", true},
+		{"charts/*", "charts/group/chart1", false},
+		{"charts/**", "charts/chart1", true},
+		{"charts/**", "charts/group/chart1", true},
+		{"charts/**", "other/chart1", false},
+		{"**/chart1", "chart1", true},
+		{"**/chart1", "charts/group/chart1", true},
+		{"charts/**/stable-*", "charts/group/stable-chart1", true},
+		{"charts/**/stable-*", "charts/group/chart1", false},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.pattern+" "+tc.p, func(t *testing.T) {
+			assert.Equal(t, tc.matches, matchPathGlob(tc.pattern, tc.p))
+		})
+	}
+}
+
+func TestReadRepositoryMetadataFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chart-tracker-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	t.Run("file does not exist", func(t *testing.T) {
+		data, err := readRepositoryMetadataFile(filepath.Join(dir, "missing.yml"))
+		require.NoError(t, err)
+		assert.Nil(t, data)
+	})
+
+	t.Run("file larger than the maximum size allowed", func(t *testing.T) {
+		p := filepath.Join(dir, repositoryMetadataFile)
+		content := make([]byte, maxRepositoryMetadataSize+1)
+		require.NoError(t, ioutil.WriteFile(p, content, 0644))
+		data, err := readRepositoryMetadataFile(p)
+		require.NoError(t, err)
+		assert.Len(t, data, maxRepositoryMetadataSize)
+	})
+}
+
+// writeChart writes a minimal chart to the directory provided.
+func writeChart(t *testing.T, dir, name, version string) {
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	chartYaml := fmt.Sprintf("apiVersion: v2\nname: %s\nversion: %s\n", name, version)
+	err := ioutil.WriteFile(filepath.Join(dir, "Chart.yaml"), []byte(chartYaml), 0644)
+	require.NoError(t, err)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/cncf/hub/internal/hub"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// helmIndexSource is a source that gets the chart versions available from the
+// index file of a Helm chart repository, downloading the charts archives over
+// http when they need to be loaded.
+type helmIndexSource struct {
+	r          *hub.ChartRepository
+	httpClient *http.Client
+}
+
+// newHelmIndexSource creates a new helmIndexSource instance.
+func newHelmIndexSource(r *hub.ChartRepository) *helmIndexSource {
+	return &helmIndexSource{
+		r: r,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// getChartVersions implements the source interface.
+func (s *helmIndexSource) getChartVersions() (map[string][]*repo.ChartVersion, error) {
+	indexFile, err := s.loadIndexFile()
+	if err != nil {
+		return nil, err
+	}
+	chartVersions := make(map[string][]*repo.ChartVersion, len(indexFile.Entries))
+	for name, versions := range indexFile.Entries {
+		chartVersions[name] = versions
+	}
+	return chartVersions, nil
+}
+
+// loadChart implements the source interface.
+func (s *helmIndexSource) loadChart(cv *repo.ChartVersion) (*chart.Chart, error) {
+	if len(cv.URLs) == 0 {
+		return nil, fmt.Errorf("no urls available for %s@%s", cv.Metadata.Name, cv.Metadata.Version)
+	}
+
+	// Prepare chart archive url
+	u := cv.URLs[0]
+	if _, err := url.ParseRequestURI(u); err != nil {
+		tmp, err := url.Parse(s.r.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chart url: %s", u)
+		}
+		tmp.Path = path.Join(tmp.Path, u)
+		u = tmp.String()
+	}
+
+	// Load chart from remote archive
+	resp, err := s.httpClient.Get(u)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", u, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &httpError{url: u, statusCode: resp.StatusCode}
+	}
+	chart, err := loader.LoadArchive(resp.Body)
+	if err != nil {
+		return nil, &chartParseError{fmt.Errorf("%s: %w", u, err)}
+	}
+	return chart, nil
+}
+
+// getRepositoryMetadata implements the metadataSource interface.
+func (s *helmIndexSource) getRepositoryMetadata() (*repositoryMetadata, error) {
+	tmp, err := url.Parse(s.r.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid repository url: %s", s.r.URL)
+	}
+	tmp.Path = path.Join(tmp.Path, repositoryMetadataFile)
+	u := tmp.String()
+
+	resp, err := s.httpClient.Get(u)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", u, err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, &httpError{url: u, statusCode: resp.StatusCode}
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxRepositoryMetadataSize))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", u, err)
+	}
+	return parseRepositoryMetadata(data)
+}
+
+// loadIndexFile downloads and parses the index file of the repository.
+func (s *helmIndexSource) loadIndexFile() (*repo.IndexFile, error) {
+	repoConfig := &repo.Entry{
+		Name: s.r.Name,
+		URL:  s.r.URL,
+	}
+	getters := getter.All(&cli.EnvSettings{})
+	chartRepository, err := repo.NewChartRepository(repoConfig, getters)
+	if err != nil {
+		return nil, err
+	}
+	path, err := chartRepository.DownloadIndexFile()
+	if err != nil {
+		return nil, err
+	}
+	indexFile, err := repo.LoadIndexFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return indexFile, nil
+}
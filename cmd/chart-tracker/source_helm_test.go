@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cncf/hub/internal/hub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHelmIndexSourceGetRepositoryMetadata(t *testing.T) {
+	t.Run("metadata file available", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/charts/"+repositoryMetadataFile, r.URL.Path)
+			_, _ = w.Write([]byte("verificationToken: token\n"))
+		}))
+		defer server.Close()
+
+		s := newHelmIndexSource(&hub.ChartRepository{URL: server.URL + "/charts/"})
+		md, err := s.getRepositoryMetadata()
+		require.NoError(t, err)
+		require.NotNil(t, md)
+		assert.Equal(t, "token", md.VerificationToken)
+	})
+
+	t.Run("metadata file not found", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		defer server.Close()
+
+		s := newHelmIndexSource(&hub.ChartRepository{URL: server.URL})
+		md, err := s.getRepositoryMetadata()
+		require.NoError(t, err)
+		assert.Nil(t, md)
+	})
+
+	t.Run("unexpected status code", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		s := newHelmIndexSource(&hub.ChartRepository{URL: server.URL})
+		_, err := s.getRepositoryMetadata()
+		var httpErr *httpError
+		require.True(t, errors.As(err, &httpErr))
+		assert.Equal(t, http.StatusInternalServerError, httpErr.statusCode)
+	})
+
+	t.Run("invalid metadata file", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("verificationToken: [\n"))
+		}))
+		defer server.Close()
+
+		s := newHelmIndexSource(&hub.ChartRepository{URL: server.URL})
+		_, err := s.getRepositoryMetadata()
+		assert.Error(t, err)
+	})
+}
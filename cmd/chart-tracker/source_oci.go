@@ -0,0 +1,308 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/cncf/hub/internal/hub"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+const (
+	// ociScheme represents the scheme used by the urls of chart repositories
+	// backed by an OCI registry.
+	ociScheme = "oci"
+
+	// Media types used by OCI registries when storing Helm charts
+	ociManifestMediaType            = "application/vnd.oci.image.manifest.v1+json"
+	helmChartContentMediaType       = "application/vnd.cncf.helm.chart.content.v1.tar+gzip"
+	helmChartContentLegacyMediaType = "application/tar+gzip"
+)
+
+// authParamRE is a regular expression used to extract the parameters from a
+// WWW-Authenticate bearer challenge.
+var authParamRE = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// ociSource is a source that gets the chart versions available from an OCI
+// registry using the OCI distribution API. The chart repository url must point
+// to the location of a chart in the registry (i.e. oci://registry/path/chart),
+// and each of the tags of that chart that are a valid semver version will be
+// considered a chart version. The digest of each version is the one of its
+// manifest, so tags pushed again with a different content are detected.
+type ociSource struct {
+	r           *hub.ChartRepository
+	baseURL     *url.URL
+	repository  string
+	chartName   string
+	httpClient  *http.Client
+	chartErrors []*hub.TrackingError
+
+	mu    sync.Mutex
+	token string
+}
+
+// newOCISource creates a new ociSource instance.
+func newOCISource(r *hub.ChartRepository) (*ociSource, error) {
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		return nil, err
+	}
+	repository := strings.Trim(u.Path, "/")
+	if u.Scheme != ociScheme || u.Host == "" || repository == "" {
+		return nil, fmt.Errorf("invalid oci chart repository url: %s", r.URL)
+	}
+	return &ociSource{
+		r: r,
+		baseURL: &url.URL{
+			Scheme: "https",
+			Host:   u.Host,
+		},
+		repository: repository,
+		chartName:  repository[strings.LastIndex(repository, "/")+1:],
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}, nil
+}
+
+// getChartVersions implements the source interface.
+func (s *ociSource) getChartVersions() (map[string][]*repo.ChartVersion, error) {
+	s.chartErrors = nil
+	tags, err := s.getTags()
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]*repo.ChartVersion, 0, len(tags))
+	for _, tag := range tags {
+		// Helm replaces the + character in versions with an underscore as it
+		// is not allowed in OCI tags
+		version := strings.ReplaceAll(tag, "_", "+")
+		if _, err := semver.NewVersion(version); err != nil {
+			continue
+		}
+		digest, err := s.getManifestDigest(tag)
+		if err != nil {
+			e := newTrackingError(hub.TrackingErrorChartDownload, s.chartName, version, err)
+			s.chartErrors = append(s.chartErrors, e)
+			continue
+		}
+		versions = append(versions, &repo.ChartVersion{
+			Metadata: &chart.Metadata{
+				Name:    s.chartName,
+				Version: version,
+			},
+			URLs:   []string{fmt.Sprintf("%s:%s", s.r.URL, tag)},
+			Digest: digest,
+		})
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		vi, _ := semver.NewVersion(versions[i].Version)
+		vj, _ := semver.NewVersion(versions[j].Version)
+		return vi.GreaterThan(vj)
+	})
+	return map[string][]*repo.ChartVersion{s.chartName: versions}, nil
+}
+
+// getChartErrors implements the chartErrorsSource interface.
+func (s *ociSource) getChartErrors() []*hub.TrackingError {
+	return s.chartErrors
+}
+
+// loadChart implements the source interface.
+func (s *ociSource) loadChart(cv *repo.ChartVersion) (*chart.Chart, error) {
+	// Get chart version manifest
+	resp, err := s.do(http.MethodGet, fmt.Sprintf("/v2/%s/manifests/%s", s.repository, cv.Digest))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var manifest struct {
+		Layers []struct {
+			MediaType string `json:"mediaType"`
+			Digest    string `json:"digest"`
+		} `json:"layers"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("error decoding manifest: %w", err)
+	}
+
+	// Load chart from the manifest layer containing the chart archive
+	for _, layer := range manifest.Layers {
+		if layer.MediaType != helmChartContentMediaType && layer.MediaType != helmChartContentLegacyMediaType {
+			continue
+		}
+		resp, err := s.do(http.MethodGet, fmt.Sprintf("/v2/%s/blobs/%s", s.repository, layer.Digest))
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		chart, err := loader.LoadArchive(resp.Body)
+		if err != nil {
+			return nil, &chartParseError{err}
+		}
+		return chart, nil
+	}
+	return nil, errors.New("chart content layer not found in manifest")
+}
+
+// getTags returns all the tags available in the repository.
+func (s *ociSource) getTags() ([]string, error) {
+	var tags []string
+	next := fmt.Sprintf("/v2/%s/tags/list", s.repository)
+	for next != "" {
+		resp, err := s.do(http.MethodGet, next)
+		if err != nil {
+			return nil, err
+		}
+		var tagsList struct {
+			Tags []string `json:"tags"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&tagsList)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error decoding tags list: %w", err)
+		}
+		tags = append(tags, tagsList.Tags...)
+		next = getNextLink(resp.Header.Get("Link"))
+	}
+	return tags, nil
+}
+
+// getManifestDigest returns the digest of the manifest of the tag provided.
+func (s *ociSource) getManifestDigest(tag string) (string, error) {
+	resp, err := s.do(http.MethodHead, fmt.Sprintf("/v2/%s/manifests/%s", s.repository, tag))
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return "", fmt.Errorf("digest not available for tag %s", tag)
+	}
+	return digest, nil
+}
+
+// do sends a request to the registry using the method and path provided. The
+// path may also be an absolute url, like the ones found in Link headers. When
+// the registry requires a bearer token, an anonymous one will be requested to
+// the authorization service and the request will be retried.
+func (s *ociSource) do(method, path string) (*http.Response, error) {
+	ref, err := url.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	u := s.baseURL.ResolveReference(ref).String()
+	resp, err := s.doRequest(method, u)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		resp.Body.Close()
+		if err := s.requestToken(resp.Header.Get("WWW-Authenticate")); err != nil {
+			return nil, fmt.Errorf("%s: error requesting token: %w", u, err)
+		}
+		resp, err = s.doRequest(method, u)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, &httpError{url: u, statusCode: resp.StatusCode}
+	}
+	return resp, nil
+}
+
+// doRequest sends a request to the url provided, including the token when one
+// is available and the url belongs to the registry.
+func (s *ociSource) doRequest(method, u string) (*http.Response, error) {
+	req, err := http.NewRequest(method, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", ociManifestMediaType)
+	s.mu.Lock()
+	if s.token != "" && req.URL.Host == s.baseURL.Host {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	s.mu.Unlock()
+	return s.httpClient.Do(req)
+}
+
+// requestToken requests an anonymous token to the authorization service
+// described in the challenge provided.
+func (s *ociSource) requestToken(challenge string) error {
+	if !strings.HasPrefix(challenge, "Bearer ") {
+		return errors.New("unsupported authentication challenge")
+	}
+	params := make(map[string]string)
+	for _, m := range authParamRE.FindAllStringSubmatch(challenge, -1) {
+		params[m[1]] = m[2]
+	}
+	if params["realm"] == "" {
+		return errors.New("realm not found in authentication challenge")
+	}
+	u, err := url.Parse(params["realm"])
+	if err != nil {
+		return err
+	}
+	q := u.Query()
+	for _, k := range []string{"service", "scope"} {
+		if params[k] != "" {
+			q.Set(k, params[k])
+		}
+	}
+	u.RawQuery = q.Encode()
+	resp, err := s.httpClient.Get(u.String())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code received: %d", resp.StatusCode)
+	}
+	var tokenResp struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = tokenResp.Token
+	if s.token == "" {
+		s.token = tokenResp.AccessToken
+	}
+	return nil
+}
+
+// getNextLink extracts the url of the next page of results from the Link
+// header provided, returning an empty string when there is none. The url
+// returned may be relative to the registry or absolute.
+func getNextLink(link string) string {
+	for _, entry := range strings.Split(link, ",") {
+		parts := strings.Split(entry, ";")
+		target := strings.TrimSpace(parts[0])
+		if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+		for _, param := range parts[1:] {
+			if strings.ReplaceAll(strings.TrimSpace(param), " ", "") == `rel="next"` {
+				return target[1 : len(target)-1]
+			}
+		}
+	}
+	return ""
+}
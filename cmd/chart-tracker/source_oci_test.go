@@ -0,0 +1,201 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cncf/hub/internal/hub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOCISource(t *testing.T) {
+	chartData := buildChartArchive(t, "chart1", "1.0.0+build1")
+	registry := newRegistryStandIn(t, "org/chart1", map[string][]byte{
+		"1.0.0_build1": chartData,
+		"0.9.0":        buildChartArchive(t, "chart1", "0.9.0"),
+		"latest":       chartData,
+	})
+	defer registry.Close()
+
+	s, err := newOCISource(&hub.ChartRepository{
+		URL: strings.Replace(registry.URL, "https", "oci", 1) + "/org/chart1",
+	})
+	require.NoError(t, err)
+	s.httpClient = registry.Client()
+
+	t.Run("get chart versions", func(t *testing.T) {
+		charts, err := s.getChartVersions()
+		require.NoError(t, err)
+		require.Len(t, charts, 1)
+		versions := charts["chart1"]
+		require.Len(t, versions, 2)
+		assert.Equal(t, "chart1", versions[0].Name)
+		assert.Equal(t, "1.0.0+build1", versions[0].Version)
+		assert.Equal(t, "sha256:manifest-1.0.0_build1", versions[0].Digest)
+		assert.Equal(t, "0.9.0", versions[1].Version)
+		assert.Equal(t, "sha256:manifest-0.9.0", versions[1].Digest)
+	})
+
+	t.Run("load chart", func(t *testing.T) {
+		charts, err := s.getChartVersions()
+		require.NoError(t, err)
+		chart, err := s.loadChart(charts["chart1"][0])
+		require.NoError(t, err)
+		assert.Equal(t, "chart1", chart.Metadata.Name)
+		assert.Equal(t, "1.0.0+build1", chart.Metadata.Version)
+	})
+
+	t.Run("tags pushed again are detected", func(t *testing.T) {
+		handler := registry.Config.Handler
+		registry.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodHead && strings.HasSuffix(r.URL.Path, "/manifests/0.9.0") {
+				w.Header().Set("Docker-Content-Digest", "sha256:manifest-0.9.0-pushed-again")
+				return
+			}
+			handler.ServeHTTP(w, r)
+		})
+		defer func() { registry.Config.Handler = handler }()
+
+		charts, err := s.getChartVersions()
+		require.NoError(t, err)
+		versions := charts["chart1"]
+		require.Len(t, versions, 2)
+		assert.Equal(t, "sha256:manifest-1.0.0_build1", versions[0].Digest)
+		assert.Equal(t, "sha256:manifest-0.9.0-pushed-again", versions[1].Digest)
+		assert.Empty(t, s.getChartErrors())
+	})
+
+	t.Run("tags failing are skipped and their errors recorded", func(t *testing.T) {
+		handler := registry.Config.Handler
+		registry.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodHead && strings.HasSuffix(r.URL.Path, "/manifests/0.9.0") {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			handler.ServeHTTP(w, r)
+		})
+		defer func() { registry.Config.Handler = handler }()
+
+		charts, err := s.getChartVersions()
+		require.NoError(t, err)
+		versions := charts["chart1"]
+		require.Len(t, versions, 1)
+		assert.Equal(t, "1.0.0+build1", versions[0].Version)
+		chartErrors := s.getChartErrors()
+		require.Len(t, chartErrors, 1)
+		assert.Equal(t, hub.TrackingErrorChartDownload, chartErrors[0].Kind)
+		assert.Equal(t, "chart1", chartErrors[0].ChartName)
+		assert.Equal(t, "0.9.0", chartErrors[0].ChartVersion)
+		assert.Equal(t, http.StatusInternalServerError, chartErrors[0].HTTPStatus)
+	})
+}
+
+func TestGetNextLink(t *testing.T) {
+	testCases := []struct {
+		link     string
+		expected string
+	}{
+		{"", ""},
+		{`</v2/org/chart1/tags/list?n=10&last=b>; rel="next"`, "/v2/org/chart1/tags/list?n=10&last=b"},
+		{`<https://registry.io/v2/org/chart1/tags/list?last=b>; rel="next"`, "https://registry.io/v2/org/chart1/tags/list?last=b"},
+		{`</v2/_catalog?last=a>; rel="prev", </v2/_catalog?last=c>; rel="next"`, "/v2/_catalog?last=c"},
+		{`</v2/org/chart1/tags/list?last=b>; rel="prev"`, ""},
+		{`/v2/org/chart1/tags/list; rel="next"`, ""},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.link, func(t *testing.T) {
+			assert.Equal(t, tc.expected, getNextLink(tc.link))
+		})
+	}
+}
+
+// newRegistryStandIn returns a test server that implements the subset of the
+// OCI distribution API used by the oci source. Requests must be authenticated
+// using a bearer token provided by the server itself.
+func newRegistryStandIn(t *testing.T, repository string, tags map[string][]byte) *httptest.Server {
+	const token = "token1"
+	var s *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "repository:"+repository+":pull", r.URL.Query().Get("scope"))
+		_ = json.NewEncoder(w).Encode(map[string]string{"token": token})
+	})
+	mux.HandleFunc("/v2/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(
+				`Bearer realm="%s/token",service="registry",scope="repository:%s:pull"`,
+				s.URL, repository,
+			))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		prefix := fmt.Sprintf("/v2/%s/", repository)
+		if !strings.HasPrefix(r.URL.Path, prefix) {
+			http.NotFound(w, r)
+			return
+		}
+		parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, prefix), "/", 2)
+		switch {
+		case parts[0] == "tags" && len(parts) == 2 && parts[1] == "list":
+			tagsList := struct {
+				Tags []string `json:"tags"`
+			}{}
+			for tag := range tags {
+				tagsList.Tags = append(tagsList.Tags, tag)
+			}
+			_ = json.NewEncoder(w).Encode(tagsList)
+		case parts[0] == "manifests" && len(parts) == 2:
+			ref := strings.TrimPrefix(parts[1], "sha256:manifest-")
+			if _, ok := tags[ref]; !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("Docker-Content-Digest", "sha256:manifest-"+ref)
+			if r.Method == http.MethodHead {
+				return
+			}
+			_, _ = fmt.Fprintf(w, `{"layers": [{"mediaType": "%s", "digest": "sha256:blob-%s"}]}`,
+				helmChartContentMediaType, ref)
+		case parts[0] == "blobs" && len(parts) == 2:
+			data, ok := tags[strings.TrimPrefix(parts[1], "sha256:blob-")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write(data)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	s = httptest.NewTLSServer(mux)
+	return s
+}
+
+// buildChartArchive builds a minimal chart archive for the chart name and
+// version provided.
+func buildChartArchive(t *testing.T, name, version string) []byte {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	chartYaml := fmt.Sprintf("apiVersion: v2\nname: %s\nversion: %s\n", name, version)
+	err := tw.WriteHeader(&tar.Header{
+		Name: name + "/Chart.yaml",
+		Mode: 0644,
+		Size: int64(len(chartYaml)),
+	})
+	require.NoError(t, err)
+	_, err = tw.Write([]byte(chartYaml))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+	return buf.Bytes()
+}
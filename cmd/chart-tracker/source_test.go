@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cncf/hub/internal/hub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSource(t *testing.T) {
+	t.Run("helm index source", func(t *testing.T) {
+		s, err := newSource(context.Background(), &hub.ChartRepository{URL: "https://repo1.com"})
+		require.NoError(t, err)
+		assert.IsType(t, &helmIndexSource{}, s)
+	})
+
+	t.Run("oci source", func(t *testing.T) {
+		s, err := newSource(context.Background(), &hub.ChartRepository{URL: "oci://registry.io/org/chart1"})
+		require.NoError(t, err)
+		require.IsType(t, &ociSource{}, s)
+		assert.Equal(t, "https://registry.io", s.(*ociSource).baseURL.String())
+		assert.Equal(t, "org/chart1", s.(*ociSource).repository)
+		assert.Equal(t, "chart1", s.(*ociSource).chartName)
+	})
+
+	t.Run("oci source with port", func(t *testing.T) {
+		s, err := newSource(context.Background(), &hub.ChartRepository{URL: "oci://registry.io:5000/chart1"})
+		require.NoError(t, err)
+		require.IsType(t, &ociSource{}, s)
+		assert.Equal(t, "https://registry.io:5000", s.(*ociSource).baseURL.String())
+		assert.Equal(t, "chart1", s.(*ociSource).repository)
+	})
+
+	t.Run("git source", func(t *testing.T) {
+		s, err := newSource(context.Background(), &hub.ChartRepository{
+			URL:  "https://github.com/org1/charts",
+			Kind: hub.GitChartRepository,
+		})
+		require.NoError(t, err)
+		assert.IsType(t, &gitSource{}, s)
+	})
+
+	t.Run("invalid oci url", func(t *testing.T) {
+		_, err := newSource(context.Background(), &hub.ChartRepository{URL: "oci://registry.io"})
+		assert.Error(t, err)
+	})
+}
@@ -7,8 +7,6 @@ import (
 	"image"
 	"io/ioutil"
 	"net/http"
-	"net/url"
-	"path"
 	"runtime/debug"
 	"sync"
 	"time"
@@ -18,7 +16,6 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"helm.sh/helm/v3/pkg/chart"
-	"helm.sh/helm/v3/pkg/chart/loader"
 )
 
 // worker is in charge of handling jobs generated by the dispatcher.
@@ -63,25 +60,37 @@ func (w *worker) run(wg *sync.WaitGroup, queue chan *job) {
 				Str("chart", md.Name).
 				Str("version", md.Version).
 				Msg("Handling job")
-			if err := w.handleJob(j); err != nil {
+			trackingErr := w.handleJob(j)
+			if trackingErr != nil {
+				w.errorsCollector(j).append(j.repo.ChartRepositoryID, trackingErr)
 				w.logger.Error().
-					Err(err).
+					Err(trackingErr).
 					Str("repo", j.repo.Name).
 					Str("chart", md.Name).
 					Str("version", md.Version).
 					Msg("Error handling job")
 			}
+			if j.run != nil {
+				j.run.recordJob(trackingErr == nil)
+			}
+			j.err = trackingErr
+			j.wg.Done()
 		case <-w.ctx.Done():
 			return
 		}
 	}
 }
 
-// handleJob handles the provided job. This involves downloading the chart
-// archive, extracting its contents and register the corresponding package.
-func (w *worker) handleJob(j *job) error {
+// handleJob handles the provided job. This involves loading the chart from the
+// job's source, extracting its contents and register the corresponding package.
+// When the chart version cannot be registered, the error that prevented it is
+// returned.
+func (w *worker) handleJob(j *job) (trackingErr *hub.TrackingError) {
 	defer func() {
 		if r := recover(); r != nil {
+			md := j.chartVersion.Metadata
+			err := fmt.Errorf("panic handling chart: %v", r)
+			trackingErr = newTrackingError(hub.TrackingErrorChartParse, md.Name, md.Version, err)
 			w.logger.Error().
 				Str("repo", j.repo.Name).
 				Str("chart", j.chartVersion.Metadata.Name).
@@ -92,30 +101,16 @@ func (w *worker) handleJob(j *job) error {
 		}
 	}()
 
-	// Prepare chart archive url
-	u := j.chartVersion.URLs[0]
-	if _, err := url.ParseRequestURI(u); err != nil {
-		tmp, err := url.Parse(j.repo.URL)
-		if err != nil {
-			w.ec.append(j.repo.ChartRepositoryID, fmt.Errorf("invalid chart url: %s", u))
-			w.logger.Error().Str("url", u).Msg("invalid url")
-			return err
-		}
-		tmp.Path = path.Join(tmp.Path, u)
-		u = tmp.String()
-	}
-
-	// Load chart from remote archive
-	chart, err := w.loadChart(u)
+	// Load chart from source
+	chart, err := j.source.loadChart(j.chartVersion)
 	if err != nil {
-		w.ec.append(j.repo.ChartRepositoryID, fmt.Errorf("error loading chart %s: %w", u, err))
-		w.logger.Warn().
-			Str("repo", j.repo.Name).
-			Str("chart", j.chartVersion.Metadata.Name).
-			Str("version", j.chartVersion.Metadata.Version).
-			Str("url", u).
-			Msg("Chart load failed")
-		return nil
+		kind := hub.TrackingErrorChartDownload
+		var parseErr *chartParseError
+		if errors.As(err, &parseErr) {
+			kind = hub.TrackingErrorChartParse
+		}
+		md := j.chartVersion.Metadata
+		return newTrackingError(kind, md.Name, md.Version, err)
 	}
 	md := chart.Metadata
 
@@ -126,12 +121,20 @@ func (w *worker) handleJob(j *job) error {
 			logoURL = md.Icon
 			data, err := w.downloadImage(md.Icon)
 			if err != nil {
-				w.ec.append(j.repo.ChartRepositoryID, fmt.Errorf("error dowloading logo %s: %w", md.Icon, err))
+				e := newTrackingError(hub.TrackingErrorLogoDownload, md.Name, md.Version, err)
+				e.URL = md.Icon
+				w.errorsCollector(j).append(j.repo.ChartRepositoryID, e)
 				w.logger.Debug().Err(err).Str("url", md.Icon).Msg("Image download failed")
 			} else {
 				logoImageID, err = w.imageStore.SaveImage(w.ctx, data)
-				if err != nil && !errors.Is(err, image.ErrFormat) {
-					w.logger.Warn().Err(err).Str("url", md.Icon).Msg("Save image failed")
+				if err != nil {
+					if errors.Is(err, image.ErrFormat) {
+						e := newTrackingError(hub.TrackingErrorLogoDecode, md.Name, md.Version, err)
+						e.URL = md.Icon
+						w.errorsCollector(j).append(j.repo.ChartRepositoryID, e)
+					} else {
+						w.logger.Warn().Err(err).Str("url", md.Icon).Msg("Save image failed")
+					}
 				}
 			}
 		}
@@ -169,24 +172,19 @@ func (w *worker) handleJob(j *job) error {
 	}
 
 	// Register package
-	return w.hubAPI.RegisterPackage(w.ctx, p)
+	if err := w.hubAPI.RegisterPackage(w.ctx, p); err != nil {
+		return newTrackingError(hub.TrackingErrorRegister, md.Name, md.Version, err)
+	}
+	return nil
 }
 
-// loadChart loads a chart from a remote archive located at the url provided.
-func (w *worker) loadChart(u string) (*chart.Chart, error) {
-	resp, err := w.httpClient.Get(u)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode == http.StatusOK {
-		chart, err := loader.LoadArchive(resp.Body)
-		if err != nil {
-			return nil, err
-		}
-		return chart, nil
+// errorsCollector returns the errors collector that should be used to collect
+// the errors found while handling the job provided.
+func (w *worker) errorsCollector(j *job) *errorsCollector {
+	if j.ec != nil {
+		return j.ec
 	}
-	return nil, fmt.Errorf("unexpected status code received: %d", resp.StatusCode)
+	return w.ec
 }
 
 // downloadImage downloads the image located at the url provided.
@@ -199,7 +197,7 @@ func (w *worker) downloadImage(u string) ([]byte, error) {
 	if resp.StatusCode == http.StatusOK {
 		return ioutil.ReadAll(resp.Body)
 	}
-	return nil, fmt.Errorf("unexpected status code received: %d", resp.StatusCode)
+	return nil, &httpError{url: u, statusCode: resp.StatusCode}
 }
 
 // getFile returns the file requested from the provided chart.
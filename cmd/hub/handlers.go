@@ -2,10 +2,15 @@ package main
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
 	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/url"
@@ -22,44 +27,134 @@ import (
 	"github.com/gorilla/securecookie"
 	svg "github.com/h2non/go-is-svg"
 	"github.com/ironstar-io/chizerolog"
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
+	"golang.org/x/time/rate"
 )
 
 const (
+	// Base url used to build the links sent to users
+	defaultBaseURL = "https://hub.cncf.io"
+
 	// Cache
 	staticCacheMaxAge     = 365 * 24 * time.Hour
 	defaultAPICacheMaxAge = 15 * time.Minute
 
 	// Session
-	sessionCookieName = "sid"
-	sessionDuration   = 30 * 24 * time.Hour
+	sessionCookieName            = "sid"
+	sessionDuration              = 30 * 24 * time.Hour
+	sessionCookieRefreshInterval = 24 * time.Hour
+
+	// Api keys
+	apiKeyHeader = "X-API-Key"
+	bearerPrefix = "Bearer "
+
+	// Database errors
+	insufficientPrivilegeErrCode     = "42501"
+	uniqueViolationErrCode           = "23505"
+	foreignKeyViolationErrCode       = "23503"
+	checkViolationErrCode            = "23514"
+	invalidTextRepresentationErrCode = "22P02"
+
+	// Webhooks
+	webhookSignatureHeader = "X-Hub-Signature"
+	webhookSignaturePrefix = "sha256="
+	webhookMaxPayloadSize  = 64 * 1024
+
+	// Tracking runs
+	defaultTrackingRunsLimit = 20
+	maxTrackingRunsLimit     = 100
+
+	// Webhook deliveries
+	defaultWebhookDeliveriesLimit = 20
+	maxWebhookDeliveriesLimit     = 100
+
+	// Audit events
+	defaultAuditEventsLimit = 20
+	maxAuditEventsLimit     = 100
+
+	// Email verification resend rate limit (per ip address)
+	resendVerificationInterval = 1 * time.Minute
+	resendVerificationBurst    = 3
+
+	// Login rate limits (per ip address and per ip address and email)
+	loginInterval      = 6 * time.Second
+	loginBurst         = 10
+	loginEmailInterval = 1 * time.Minute
+	loginEmailBurst    = 10
+
+	// Password reset requests rate limits (per ip address and per email)
+	passwordResetInterval      = 1 * time.Minute
+	passwordResetBurst         = 3
+	passwordResetEmailInterval = 15 * time.Minute
+	passwordResetEmailBurst    = 3
 )
 
 // handlers groups all the http handlers defined for the hub, including the
 // router in charge of sending requests to the right handler.
 type handlers struct {
 	cfg        *viper.Viper
+	baseURL    string
 	hubAPI     *hub.Hub
 	imageStore *pg.ImageStore
 	router     http.Handler
 	sc         *securecookie.SecureCookie
+	scFresh    *securecookie.SecureCookie
 
 	mu          sync.RWMutex
 	imagesCache map[string][]byte
+
+	trustedProxies            []*net.IPNet
+	resendVerificationLimiter *ipRateLimiter
+	loginLimiter              *ipRateLimiter
+	loginEmailLimiter         *ipRateLimiter
+	passwordResetLimiter      *ipRateLimiter
+	passwordResetEmailLimiter *ipRateLimiter
+	oauthProviders            map[string]*oauthProvider
 }
 
 // setupHandlers creates a new handlers instance.
 func setupHandlers(cfg *viper.Viper, hubAPI *hub.Hub, imageStore *pg.ImageStore) *handlers {
 	sc := securecookie.New([]byte(cfg.GetString("server.cookie.hashKey")), nil)
 	sc.MaxAge(int(sessionDuration.Seconds()))
+	scFresh := securecookie.New([]byte(cfg.GetString("server.cookie.hashKey")), nil)
+	scFresh.MaxAge(int(sessionCookieRefreshInterval.Seconds()))
 	h := &handlers{
 		cfg:         cfg,
+		baseURL:     defaultBaseURL,
 		hubAPI:      hubAPI,
 		imageStore:  imageStore,
 		imagesCache: make(map[string][]byte),
 		sc:          sc,
+		scFresh:     scFresh,
+
+		trustedProxies: parseTrustedProxies(cfg.GetStringSlice("server.trustedProxies")),
+		resendVerificationLimiter: newIPRateLimiter(
+			rate.Every(resendVerificationInterval),
+			resendVerificationBurst,
+		),
+		loginLimiter: newIPRateLimiter(
+			rate.Every(loginInterval),
+			loginBurst,
+		),
+		loginEmailLimiter: newIPRateLimiter(
+			rate.Every(loginEmailInterval),
+			loginEmailBurst,
+		),
+		passwordResetLimiter: newIPRateLimiter(
+			rate.Every(passwordResetInterval),
+			passwordResetBurst,
+		),
+		passwordResetEmailLimiter: newIPRateLimiter(
+			rate.Every(passwordResetEmailInterval),
+			passwordResetEmailBurst,
+		),
+		oauthProviders: setupOAuthProviders(cfg),
+	}
+	if cfg.IsSet("server.baseURL") {
+		h.baseURL = strings.TrimSuffix(cfg.GetString("server.baseURL"), "/")
 	}
 	h.setupRouter()
 	return h
@@ -71,9 +166,10 @@ func (h *handlers) setupRouter() {
 	r := chi.NewRouter()
 
 	// Setup middleware and special handlers
-	r.Use(middleware.RealIP)
+	r.Use(h.realIP)
 	r.Use(chizerolog.LoggerMiddleware(&log.Logger))
 	r.Use(middleware.Recoverer)
+	r.Use(injectRequestInfo)
 	if h.cfg.GetBool("server.basicAuth.enabled") {
 		r.Use(h.basicAuth)
 	}
@@ -87,29 +183,100 @@ func (h *handlers) setupRouter() {
 			r.Get("/search", h.searchPackages)
 			r.Get("/chart/{repoName}/{packageName}", h.getPackage(hub.Chart))
 			r.Get("/chart/{repoName}/{packageName}/{version}", h.getPackage(hub.Chart))
+			r.Get("/operator/{providerName}/{packageName}", h.getPackage(hub.Operator))
+			r.Get("/operator/{providerName}/{packageName}/{version}", h.getPackage(hub.Operator))
 		})
 
 		r.Route("/user", func(r chi.Router) {
 			r.Post("/", h.registerUser)
+			r.With(h.requireLogin).Delete("/", h.deleteUser)
 			r.Post("/verifyEmail", h.verifyEmail)
-			r.Post("/login", h.login)
+			r.With(h.resendVerificationLimiter.handler).Post("/resendVerification", h.resendVerification)
+			r.With(h.passwordResetLimiter.handler).Post("/requestPasswordReset", h.requestPasswordReset)
+			r.Post("/resetPassword", h.resetPassword)
+			r.With(h.loginLimiter.handler).Post("/login", h.login)
 			r.Get("/logout", h.logout)
 			r.With(h.requireLogin).Get("/alias", h.getUserAlias)
+			r.With(h.requireLogin).Get("/profile", h.getUserProfile)
+			r.With(h.requireLogin).Put("/profile", h.updateUserProfile)
+			r.With(h.requireLogin).Put("/password", h.updateUserPassword)
+			r.With(h.requireLogin).Put("/email", h.updateUserEmail)
+			r.With(h.requireLogin).Get("/notifications", h.getUserNotificationsSettings)
+			r.With(h.requireLogin).Put("/notifications", h.updateUserNotificationsSettings)
+			r.With(h.requireLogin).Get("/subscriptions", h.getUserSubscriptions)
+			r.With(h.requireLogin).Post("/subscriptions", h.addSubscription)
+			r.With(h.requireLogin).Delete("/subscriptions", h.deleteSubscription)
+			r.With(h.requireLogin).Get("/sessions", h.getUserSessions)
+			r.With(h.requireLogin).Delete("/sessions", h.deleteUserSessions)
+			r.With(h.requireLogin).Delete("/sessions/{sessionID}", h.deleteUserSession)
+			r.With(h.requireLogin).Get("/apiKeys", h.getUserAPIKeys)
+			r.With(h.requireLogin).Post("/apiKeys", h.addAPIKey)
+			r.With(h.requireLogin).Delete("/apiKeys/{apiKeyID}", h.deleteAPIKey)
+			r.With(h.requireLogin).Get("/invitations", h.getUserOrganizationInvitations)
+			r.With(h.requireLogin).Post("/invitations/accept", h.acceptOrganizationInvitation)
+			r.With(h.requireLogin).Post("/invitations/decline", h.declineOrganizationInvitation)
 		})
 
+		r.Post("/webhook/chart/{repoName}", h.chartRepositoryWebhook)
 		r.Route("/admin", func(r chi.Router) {
-			r.Use(h.requireLogin)
+			r.With(h.requireLogin).Get("/audit", h.getAuditEvents)
 			r.Route("/chart", func(r chi.Router) {
-				r.Get("/", h.getChartRepositories)
-				r.Post("/", h.addChartRepository)
-				r.Put("/{repoName}", h.updateChartRepository)
-				r.Delete("/{repoName}", h.deleteChartRepository)
+				// Chart repositories and tracking (api keys allowed)
+				r.Group(func(r chi.Router) {
+					r.Use(h.requireLoginOrAPIKey)
+					r.Get("/", h.getChartRepositories)
+					r.Post("/", h.addChartRepository)
+					r.Put("/{repoName}", h.updateChartRepository)
+					r.Delete("/{repoName}", h.deleteChartRepository)
+					r.Post("/{repoName}/track", h.addTrackingRequest)
+					r.Get("/{repoName}/track/{trackingRequestID}", h.getTrackingRequest)
+					r.Get("/{repoName}/tracking", h.getTrackingRuns)
+					r.Get("/{repoName}/errors", h.getChartRepositoryTrackingErrors)
+				})
+
+				// Chart repositories settings (session required)
+				r.Group(func(r chi.Router) {
+					r.Use(h.requireLogin)
+					r.Post("/{repoName}/transfer", h.transferChartRepository)
+					r.Post("/{repoName}/transfer/accept", h.acceptChartRepositoryTransfer)
+					r.Post("/{repoName}/transfer/cancel", h.cancelChartRepositoryTransfer)
+					r.Post("/{repoName}/transfer/decline", h.declineChartRepositoryTransfer)
+					r.Post("/{repoName}/webhookSecret", h.rotateChartRepositoryWebhookSecret)
+					r.Delete("/{repoName}/webhookSecret", h.deleteChartRepositoryWebhookSecret)
+					r.Post("/{repoName}/verificationToken", h.generateChartRepositoryVerificationToken)
+					r.Get("/{repoName}/webhooks", h.getChartRepositoryWebhooks)
+					r.Post("/{repoName}/webhooks", h.addWebhook)
+					r.Delete("/{repoName}/webhooks/{webhookID}", h.deleteWebhook)
+					r.Get("/{repoName}/webhooks/{webhookID}/deliveries", h.getWebhookDeliveries)
+				})
+			})
+			r.Route("/org", func(r chi.Router) {
+				r.With(h.requireLogin).Get("/", h.getUserOrganizations)
+				r.With(h.requireLogin).Post("/", h.addOrganization)
+				r.Route("/{orgName}", func(r chi.Router) {
+					r.With(h.requireLogin).Put("/", h.updateOrganization)
+					r.With(h.requireLogin).Delete("/", h.deleteOrganization)
+					r.With(h.requireLogin).Get("/members", h.getOrganizationMembers)
+					r.With(h.requireLogin).Post("/member/{userAlias}", h.addOrganizationMember)
+					r.With(h.requireLogin).Put("/member/{userAlias}", h.updateOrganizationMemberRole)
+					r.With(h.requireLogin).Delete("/member/{userAlias}", h.deleteOrganizationMember)
+					r.With(h.requireLogin).Post("/invitation", h.addOrganizationInvitation)
+					r.With(h.requireLoginOrAPIKey).Get("/chart", h.getOrganizationChartRepositories)
+					r.With(h.requireLoginOrAPIKey).Post("/chart", h.addChartRepository)
+					r.With(h.requireLogin).Get("/transfers", h.getOrganizationChartRepositoryTransfers)
+				})
 			})
 		})
 
 		r.Head("/checkAvailability/{resourceKind}", h.checkAvailability)
 	})
 
+	// OAuth
+	r.Route("/oauth", func(r chi.Router) {
+		r.Get("/{provider}", h.oauthRedirect)
+		r.Get("/{provider}/callback", h.oauthCallback)
+	})
+
 	// Images
 	r.Get("/image/{image}", h.image)
 
@@ -237,10 +404,11 @@ func buildSearchPackageInput(qs url.Values) (*hub.SearchPackageInput, error) {
 func (h *handlers) getPackage(kind hub.PackageKind) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		input := &hub.GetPackageInput{
-			Kind:                kind,
-			ChartRepositoryName: chi.URLParam(r, "repoName"),
-			PackageName:         chi.URLParam(r, "packageName"),
-			Version:             chi.URLParam(r, "version"),
+			Kind:                 kind,
+			ChartRepositoryName:  chi.URLParam(r, "repoName"),
+			OperatorProviderName: chi.URLParam(r, "providerName"),
+			PackageName:          chi.URLParam(r, "packageName"),
+			Version:              chi.URLParam(r, "version"),
 		}
 		jsonData, err := h.hubAPI.GetPackageJSON(r.Context(), input)
 		if err != nil {
@@ -270,12 +438,7 @@ func (h *handlers) registerUser(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	scheme := "http"
-	if r.TLS != nil {
-		scheme = "https"
-	}
-	baseURL := fmt.Sprintf("%s://%s", scheme, r.Host)
-	err = h.hubAPI.RegisterUser(r.Context(), user, baseURL)
+	err = h.hubAPI.RegisterUser(r.Context(), user, h.baseURL)
 	if err != nil {
 		log.Error().Err(err).Msg("registerUser failed")
 		http.Error(w, "", http.StatusInternalServerError)
@@ -318,6 +481,76 @@ func (h *handlers) verifyEmail(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// resendVerification is an http handler used to request a new email
+// verification code to be sent to the email address provided.
+func (h *handlers) resendVerification(w http.ResponseWriter, r *http.Request) {
+	email := r.FormValue("email")
+	if email == "" {
+		errMsg := "email not provided"
+		log.Error().Msg(errMsg)
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	if err := h.hubAPI.ResendEmailVerificationCode(r.Context(), email, h.baseURL); err != nil {
+		log.Error().Err(err).Msg("resendVerification failed")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+}
+
+// requestPasswordReset is an http handler used to request a password reset
+// code to be sent to the email address provided.
+func (h *handlers) requestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	email := r.FormValue("email")
+	if email == "" {
+		errMsg := "email not provided"
+		log.Error().Msg(errMsg)
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	if !h.passwordResetEmailLimiter.allow(strings.ToLower(email)) {
+		http.Error(w, "", http.StatusTooManyRequests)
+		return
+	}
+	if err := h.hubAPI.RequestPasswordReset(r.Context(), email, h.baseURL); err != nil {
+		log.Error().Err(err).Msg("requestPasswordReset failed")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+}
+
+// resetPassword is an http handler used to update a user's password using a
+// password reset code.
+func (h *handlers) resetPassword(w http.ResponseWriter, r *http.Request) {
+	code := r.FormValue("code")
+	if code == "" {
+		errMsg := "password reset code not provided"
+		log.Error().Msg(errMsg)
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	password := r.FormValue("password")
+	if password == "" {
+		errMsg := "password not provided"
+		log.Error().Msg(errMsg)
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	updated, err := h.hubAPI.ResetPassword(r.Context(), code, password)
+	if err != nil {
+		log.Error().Err(err).Msg("resetPassword failed")
+		if isInvalidTextRepresentationError(err) {
+			http.Error(w, "invalid password reset code", http.StatusBadRequest)
+		} else {
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+	if !updated {
+		w.WriteHeader(http.StatusGone)
+	}
+}
+
 // login is an http handler used to log a user in.
 func (h *handlers) login(w http.ResponseWriter, r *http.Request) {
 	// Extract credentials from request
@@ -330,7 +563,13 @@ func (h *handlers) login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if the credentials provided are valid
+	// Check if the credentials provided are valid. Attempts are limited per
+	// ip address and email, so that the account of a given user cannot be
+	// locked out by other clients.
+	if !h.loginEmailLimiter.allow(getIP(r) + "|" + strings.ToLower(email)) {
+		http.Error(w, "", http.StatusTooManyRequests)
+		return
+	}
 	checkCredentialsOutput, err := h.hubAPI.CheckCredentials(r.Context(), email, password)
 	if err != nil {
 		log.Error().Err(err).Msg("checkCredentials failed")
@@ -341,27 +580,44 @@ func (h *handlers) login(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
+	if !checkCredentialsOutput.EmailVerified {
+		http.Error(w, "email not verified", http.StatusForbidden)
+		return
+	}
 
+	// Register user session and set session cookie
+	if err := h.registerSession(w, r, checkCredentialsOutput.UserID); err != nil {
+		log.Error().Err(err).Msg("registerSession failed")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+}
+
+// registerSession registers a new session for the user provided and sets the
+// corresponding session cookie in the response.
+func (h *handlers) registerSession(w http.ResponseWriter, r *http.Request, userID string) error {
 	// Register user session
-	ip, _, _ := net.SplitHostPort(r.RemoteAddr)
 	session := &hub.Session{
-		UserID:    checkCredentialsOutput.UserID,
-		IP:        ip,
+		UserID:    userID,
+		IP:        getIP(r),
 		UserAgent: r.UserAgent(),
 	}
 	sessionID, err := h.hubAPI.RegisterSession(r.Context(), session)
 	if err != nil {
-		log.Error().Err(err).Msg("registerSession failed")
-		http.Error(w, "", http.StatusInternalServerError)
-		return
+		return err
 	}
 
-	// Generate and set session cookie
+	return h.setSessionCookie(w, sessionID)
+}
+
+// setSessionCookie generates a session cookie for the session provided and
+// sets it in the response. Session cookies are re-issued periodically while
+// the session is being used, so that they only expire once the session has
+// not been used for the session duration.
+func (h *handlers) setSessionCookie(w http.ResponseWriter, sessionID []byte) error {
 	encodedSessionID, err := h.sc.Encode(sessionCookieName, sessionID)
 	if err != nil {
-		log.Error().Err(err).Msg("sessionID encoding failed")
-		http.Error(w, "", http.StatusInternalServerError)
-		return
+		return fmt.Errorf("sessionID encoding failed: %w", err)
 	}
 	cookie := &http.Cookie{
 		Name:     sessionCookieName,
@@ -374,25 +630,79 @@ func (h *handlers) login(w http.ResponseWriter, r *http.Request) {
 		cookie.Secure = true
 	}
 	http.SetCookie(w, cookie)
+	return nil
 }
 
 // logout is an http handler used to log a user out.
 func (h *handlers) logout(w http.ResponseWriter, r *http.Request) {
 	// Delete user session
+	if sessionID := h.getSessionID(r); sessionID != nil {
+		if err := h.hubAPI.DeleteSession(r.Context(), sessionID); err != nil {
+			log.Error().Err(err).Msg("deleteSession failed")
+		}
+	}
+
+	// Request browser to delete session cookie
+	cookie := &http.Cookie{
+		Name:    sessionCookieName,
+		Expires: time.Now().Add(-24 * time.Hour),
+	}
+	http.SetCookie(w, cookie)
+}
+
+// getSessionID returns the session id stored in the session cookie of the
+// request provided, or nil if a valid session cookie was not provided.
+func (h *handlers) getSessionID(r *http.Request) []byte {
 	cookie, err := r.Cookie(sessionCookieName)
-	if err == nil {
-		var sessionID []byte
-		err = h.sc.Decode(sessionCookieName, cookie.Value, &sessionID)
-		if err == nil {
-			err = h.hubAPI.DeleteSession(r.Context(), sessionID)
-			if err != nil {
-				log.Error().Err(err).Msg("deleteSession failed")
-			}
+	if err != nil {
+		return nil
+	}
+	var sessionID []byte
+	if err := h.sc.Decode(sessionCookieName, cookie.Value, &sessionID); err != nil {
+		return nil
+	}
+	return sessionID
+}
+
+// getUserSessions is an http handler that returns the sessions of the user
+// doing the request. The session used to do the request is flagged.
+func (h *handlers) getUserSessions(w http.ResponseWriter, r *http.Request) {
+	jsonData, err := h.hubAPI.GetUserSessionsJSON(r.Context(), h.getSessionID(r))
+	if err != nil {
+		log.Error().Err(err).Msg("getUserSessions failed")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	renderJSON(w, jsonData, 0)
+}
+
+// deleteUserSession is an http handler that deletes the provided session of
+// the user doing the request.
+func (h *handlers) deleteUserSession(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "sessionID")
+	if err := h.hubAPI.DeleteUserSession(r.Context(), sessionID); err != nil {
+		log.Error().Err(err).Str("sessionID", sessionID).Msg("deleteUserSession failed")
+		if isInvalidTextRepresentationError(err) {
+			http.Error(w, "invalid session id", http.StatusBadRequest)
+		} else {
+			http.Error(w, "", http.StatusInternalServerError)
 		}
+		return
+	}
+}
+
+// deleteUserSessions is an http handler that deletes all the sessions of the
+// user doing the request, logging the user out everywhere. The user's api keys
+// are revoked as well.
+func (h *handlers) deleteUserSessions(w http.ResponseWriter, r *http.Request) {
+	if err := h.hubAPI.DeleteUserSessions(r.Context()); err != nil {
+		log.Error().Err(err).Msg("deleteUserSessions failed")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
 	}
 
 	// Request browser to delete session cookie
-	cookie = &http.Cookie{
+	cookie := &http.Cookie{
 		Name:    sessionCookieName,
 		Expires: time.Now().Add(-24 * time.Hour),
 	}
@@ -411,147 +721,1403 @@ func (h *handlers) getUserAlias(w http.ResponseWriter, r *http.Request) {
 	renderJSON(w, jsonData, 0)
 }
 
-// getChartRepositories is an http handler that returns the chart repositories
-// owned by the user doing the request.
-func (h *handlers) getChartRepositories(w http.ResponseWriter, r *http.Request) {
-	jsonData, err := h.hubAPI.GetChartRepositoriesByUserJSON(r.Context())
+// getUserProfile is an http handler used to get the logged in user profile.
+func (h *handlers) getUserProfile(w http.ResponseWriter, r *http.Request) {
+	jsonData, err := h.hubAPI.GetUserProfileJSON(r.Context())
 	if err != nil {
-		log.Error().Err(err).Msg("getChartRepositories failed")
+		log.Error().Err(err).Msg("getUserProfile failed")
 		http.Error(w, "", http.StatusInternalServerError)
 		return
 	}
-	renderJSON(w, jsonData, defaultAPICacheMaxAge)
+	renderJSON(w, jsonData, 0)
 }
 
-// addChartRepository is an http handler that adds the provided chart
-// repository to the database.
-func (h *handlers) addChartRepository(w http.ResponseWriter, r *http.Request) {
-	repo := &hub.ChartRepository{}
-	if err := json.NewDecoder(r.Body).Decode(&repo); err != nil {
-		log.Error().Err(err).Msg("invalid chart repository")
-		http.Error(w, "chart repository provided is not valid", http.StatusBadRequest)
+// updateUserProfile is an http handler used to update the logged in user
+// profile. The alias must be available, as when registering a new user.
+func (h *handlers) updateUserProfile(w http.ResponseWriter, r *http.Request) {
+	user := &hub.User{}
+	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
+		log.Error().Err(err).Msg("invalid user profile")
+		http.Error(w, "user profile provided is not valid", http.StatusBadRequest)
 		return
 	}
-	if repo.Name == "" || repo.URL == "" {
-		http.Error(w, "chart repository name and url must be provided", http.StatusBadRequest)
+	if user.Alias == "" {
+		http.Error(w, "alias not provided", http.StatusBadRequest)
 		return
 	}
-	if err := h.hubAPI.AddChartRepository(r.Context(), repo); err != nil {
-		log.Error().Err(err).Msg("addChartRepository failed")
-		http.Error(w, "", http.StatusInternalServerError)
+	if err := h.hubAPI.UpdateUserProfile(r.Context(), user); err != nil {
+		if isUniqueViolationError(err) {
+			http.Error(w, "alias not available", http.StatusConflict)
+		} else {
+			log.Error().Err(err).Msg("updateUserProfile failed")
+			http.Error(w, "", http.StatusInternalServerError)
+		}
 		return
 	}
 }
 
-// updateChartRepository is an http handler that updates the provided chart
-// repository in the database.
-func (h *handlers) updateChartRepository(w http.ResponseWriter, r *http.Request) {
-	repo := &hub.ChartRepository{}
-	if err := json.NewDecoder(r.Body).Decode(&repo); err != nil {
-		log.Error().Err(err).Msg("invalid chart repository")
-		http.Error(w, "chart repository provided is not valid", http.StatusBadRequest)
+// updateUserPassword is an http handler used to update the logged in user
+// password. The current password must be provided as well, unless the user
+// doesn't have one yet. The user is logged out from all sessions but the one
+// used to do the request.
+func (h *handlers) updateUserPassword(w http.ResponseWriter, r *http.Request) {
+	oldPassword := r.FormValue("old")
+	newPassword := r.FormValue("new")
+	if newPassword == "" {
+		errMsg := "new password not provided"
+		log.Error().Msg(errMsg)
+		http.Error(w, errMsg, http.StatusBadRequest)
 		return
 	}
-	repo.Name = chi.URLParam(r, "repoName")
-	if err := h.hubAPI.UpdateChartRepository(r.Context(), repo); err != nil {
-		log.Error().Err(err).Msg("updateChartRepository failed")
+	updated, err := h.hubAPI.UpdateUserPassword(r.Context(), oldPassword, newPassword, h.getSessionID(r))
+	if err != nil {
+		log.Error().Err(err).Msg("updateUserPassword failed")
 		http.Error(w, "", http.StatusInternalServerError)
 		return
 	}
+	if !updated {
+		http.Error(w, "old password is not valid", http.StatusBadRequest)
+	}
 }
 
-// deleteChartRepository is an http handler that deletes the provided chart
-// repository from the database.
-func (h *handlers) deleteChartRepository(w http.ResponseWriter, r *http.Request) {
-	repo := &hub.ChartRepository{
-		Name: chi.URLParam(r, "repoName"),
+// updateUserEmail is an http handler used to request the logged in user email
+// to be changed. The new email must be verified before it replaces the
+// current one.
+func (h *handlers) updateUserEmail(w http.ResponseWriter, r *http.Request) {
+	email := r.FormValue("email")
+	if email == "" {
+		errMsg := "email not provided"
+		log.Error().Msg(errMsg)
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
 	}
-	if err := h.hubAPI.DeleteChartRepository(r.Context(), repo); err != nil {
-		log.Error().Err(err).Msg("deleteChartRepository failed")
-		http.Error(w, "", http.StatusInternalServerError)
+	if err := h.hubAPI.UpdateUserEmail(r.Context(), email, h.baseURL); err != nil {
+		if isUniqueViolationError(err) {
+			http.Error(w, "email not available", http.StatusConflict)
+		} else {
+			log.Error().Err(err).Msg("updateUserEmail failed")
+			http.Error(w, "", http.StatusInternalServerError)
+		}
 		return
 	}
 }
 
-// requireLogin is a middleware that verifies if a user is logged in.
-func (h *handlers) requireLogin(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Extract and validate cookie from request
-		cookie, err := r.Cookie(sessionCookieName)
-		if err != nil {
-			http.Error(w, "", http.StatusUnauthorized)
-			return
-		}
-		var sessionID []byte
-		if err = h.sc.Decode(sessionCookieName, cookie.Value, &sessionID); err != nil {
-			log.Error().Err(err).Msg("sessionID decoding failed")
-			http.Error(w, "", http.StatusUnauthorized)
-			return
-		}
-
-		// Check the session provided is valid
-		checkSessionOutput, err := h.hubAPI.CheckSession(r.Context(), sessionID, sessionDuration)
-		if err != nil {
-			log.Error().Err(err).Msg("checkSession failed")
+// deleteUser is an http handler used to delete the logged in user account.
+// Accounts owning chart repositories cannot be deleted.
+func (h *handlers) deleteUser(w http.ResponseWriter, r *http.Request) {
+	if err := h.hubAPI.DeleteUser(r.Context()); err != nil {
+		if isForeignKeyViolationError(err) {
+			http.Error(w, "chart repositories owned must be deleted first", http.StatusConflict)
+		} else {
+			log.Error().Err(err).Msg("deleteUser failed")
 			http.Error(w, "", http.StatusInternalServerError)
-			return
-		}
-		if !checkSessionOutput.Valid {
-			w.WriteHeader(http.StatusUnauthorized)
-			return
 		}
+		return
+	}
 
-		// Inject userID in context and call next handler
-		ctx := context.WithValue(r.Context(), hub.UserIDKey, checkSessionOutput.UserID)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+	// Request browser to delete session cookie
+	cookie := &http.Cookie{
+		Name:    sessionCookieName,
+		Expires: time.Now().Add(-24 * time.Hour),
+	}
+	http.SetCookie(w, cookie)
 }
 
-// image in an http handler that serves images stored in the database.
-func (h *handlers) image(w http.ResponseWriter, r *http.Request) {
-	// Extract image id and version
-	image := chi.URLParam(r, "image")
-	parts := strings.Split(image, "@")
-	var imageID, version string
-	if len(parts) == 2 {
-		imageID = parts[0]
-		version = parts[1]
-	} else {
-		imageID = image
+// getUserNotificationsSettings is an http handler used to get the logged in
+// user notifications settings.
+func (h *handlers) getUserNotificationsSettings(w http.ResponseWriter, r *http.Request) {
+	jsonData, err := h.hubAPI.GetUserNotificationsSettingsJSON(r.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("getUserNotificationsSettings failed")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
 	}
+	renderJSON(w, jsonData, 0)
+}
 
-	// Check if image version data is cached
-	h.mu.RLock()
-	data, ok := h.imagesCache[image]
-	h.mu.RUnlock()
-	if !ok {
-		// Get image data from database
-		var err error
-		data, err = h.imageStore.GetImage(r.Context(), imageID, version)
-		if err != nil {
-			if errors.Is(err, pgx.ErrNoRows) {
-				http.NotFound(w, r)
-			} else {
-				log.Error().Err(err).Str("imageID", imageID).Send()
-				http.Error(w, "", http.StatusInternalServerError)
-			}
-			return
-		}
+// updateUserNotificationsSettings is an http handler used to update the logged
+// in user notifications settings.
+func (h *handlers) updateUserNotificationsSettings(w http.ResponseWriter, r *http.Request) {
+	s := &hub.NotificationsSettings{}
+	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+		log.Error().Err(err).Msg("invalid notifications settings")
+		http.Error(w, "notifications settings provided are not valid", http.StatusBadRequest)
+		return
+	}
+	if err := h.hubAPI.UpdateUserNotificationsSettings(r.Context(), s); err != nil {
+		log.Error().Err(err).Msg("updateUserNotificationsSettings failed")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+}
 
-		// Save image data in cache
-		h.mu.Lock()
-		h.imagesCache[image] = data
-		h.mu.Unlock()
+// getUserSubscriptions is an http handler that returns the packages the user
+// doing the request is subscribed to.
+func (h *handlers) getUserSubscriptions(w http.ResponseWriter, r *http.Request) {
+	jsonData, err := h.hubAPI.GetUserSubscriptionsJSON(r.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("getUserSubscriptions failed")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
 	}
+	renderJSON(w, jsonData, 0)
+}
 
-	// Set headers and write image data to response writer
-	w.Header().Set("Cache-Control", "public, max-age=31536000")
-	if svg.Is(data) {
-		w.Header().Set("Content-Type", "image/svg+xml")
-	} else {
-		w.Header().Set("Content-Type", http.DetectContentType(data))
+// addSubscription is an http handler that adds the provided subscription to
+// the database.
+func (h *handlers) addSubscription(w http.ResponseWriter, r *http.Request) {
+	s, err := decodeSubscription(r.Body)
+	if err != nil {
+		log.Error().Err(err).Msg("invalid subscription")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.hubAPI.AddSubscription(r.Context(), s); err != nil {
+		switch {
+		case isInvalidTextRepresentationError(err):
+			http.Error(w, "invalid package id", http.StatusBadRequest)
+		case isForeignKeyViolationError(err):
+			http.NotFound(w, r)
+		default:
+			log.Error().Err(err).Msg("addSubscription failed")
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
 	}
-	_, _ = w.Write(data)
+}
+
+// deleteSubscription is an http handler that deletes the provided
+// subscription from the database.
+func (h *handlers) deleteSubscription(w http.ResponseWriter, r *http.Request) {
+	s, err := decodeSubscription(r.Body)
+	if err != nil {
+		log.Error().Err(err).Msg("invalid subscription")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.hubAPI.DeleteSubscription(r.Context(), s); err != nil {
+		if isInvalidTextRepresentationError(err) {
+			http.Error(w, "invalid package id", http.StatusBadRequest)
+		} else {
+			log.Error().Err(err).Msg("deleteSubscription failed")
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+}
+
+// decodeSubscription decodes and validates the subscription provided in the
+// reader given.
+func decodeSubscription(r io.Reader) (*hub.Subscription, error) {
+	s := &hub.Subscription{}
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, errors.New("subscription provided is not valid")
+	}
+	if s.PackageID == "" {
+		return nil, errors.New("package id not provided")
+	}
+	if s.EventKind != hub.NewRelease {
+		return nil, errors.New("event kind not supported")
+	}
+	return s, nil
+}
+
+// getUserAPIKeys is an http handler that returns the api keys of the user
+// doing the request.
+func (h *handlers) getUserAPIKeys(w http.ResponseWriter, r *http.Request) {
+	jsonData, err := h.hubAPI.GetUserAPIKeysJSON(r.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("getUserAPIKeys failed")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	renderJSON(w, jsonData, 0)
+}
+
+// addAPIKey is an http handler that adds a new api key for the user doing the
+// request. The key generated is returned, as it cannot be retrieved later.
+func (h *handlers) addAPIKey(w http.ResponseWriter, r *http.Request) {
+	ak := &hub.APIKey{}
+	if err := json.NewDecoder(r.Body).Decode(&ak); err != nil {
+		log.Error().Err(err).Msg("invalid api key")
+		http.Error(w, "api key provided is not valid", http.StatusBadRequest)
+		return
+	}
+	if ak.Name == "" {
+		http.Error(w, "name not provided", http.StatusBadRequest)
+		return
+	}
+	output, err := h.hubAPI.AddAPIKey(r.Context(), ak.Name)
+	if err != nil {
+		if isUniqueViolationError(err) {
+			http.Error(w, "api key name already in use", http.StatusConflict)
+		} else {
+			log.Error().Err(err).Msg("addAPIKey failed")
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+	jsonData, _ := json.Marshal(output)
+	renderJSON(w, jsonData, 0)
+}
+
+// deleteAPIKey is an http handler that deletes the provided api key of the
+// user doing the request.
+func (h *handlers) deleteAPIKey(w http.ResponseWriter, r *http.Request) {
+	apiKeyID := chi.URLParam(r, "apiKeyID")
+	if err := h.hubAPI.DeleteAPIKey(r.Context(), apiKeyID); err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			http.NotFound(w, r)
+		case isInvalidTextRepresentationError(err):
+			http.Error(w, "invalid api key id", http.StatusBadRequest)
+		default:
+			log.Error().Err(err).Str("apiKeyID", apiKeyID).Msg("deleteAPIKey failed")
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+}
+
+// getChartRepositories is an http handler that returns the chart repositories
+// owned by the user doing the request.
+func (h *handlers) getChartRepositories(w http.ResponseWriter, r *http.Request) {
+	jsonData, err := h.hubAPI.GetChartRepositoriesByUserJSON(r.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("getChartRepositories failed")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	renderJSON(w, jsonData, 0)
+}
+
+// getOrganizationChartRepositories is an http handler that returns the chart
+// repositories owned by the organization provided.
+func (h *handlers) getOrganizationChartRepositories(w http.ResponseWriter, r *http.Request) {
+	orgName := chi.URLParam(r, "orgName")
+	jsonData, err := h.hubAPI.GetOrganizationChartRepositoriesJSON(r.Context(), orgName)
+	if err != nil {
+		log.Error().Err(err).Str("orgName", orgName).Msg("getOrganizationChartRepositories failed")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	renderJSON(w, jsonData, 0)
+}
+
+// addChartRepository is an http handler that adds the provided chart
+// repository to the database. When the request is done in the context of an
+// organization, the repository will belong to it instead of to the user.
+func (h *handlers) addChartRepository(w http.ResponseWriter, r *http.Request) {
+	repo := &hub.ChartRepository{}
+	if err := json.NewDecoder(r.Body).Decode(&repo); err != nil {
+		log.Error().Err(err).Msg("invalid chart repository")
+		http.Error(w, "chart repository provided is not valid", http.StatusBadRequest)
+		return
+	}
+	if repo.Name == "" || repo.URL == "" {
+		http.Error(w, "chart repository name and url must be provided", http.StatusBadRequest)
+		return
+	}
+	if err := validateChartRepository(repo); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	orgName := chi.URLParam(r, "orgName")
+	if err := h.hubAPI.AddChartRepository(r.Context(), orgName, repo); err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			http.NotFound(w, r)
+		case isInsufficientPrivilegeError(err):
+			http.Error(w, "", http.StatusForbidden)
+		default:
+			log.Error().Err(err).Str("orgName", orgName).Msg("addChartRepository failed")
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+}
+
+// updateChartRepository is an http handler that updates the provided chart
+// repository in the database.
+func (h *handlers) updateChartRepository(w http.ResponseWriter, r *http.Request) {
+	// The kind is initialized to an invalid value to detect updates that do
+	// not provide it, which keep the kind of the stored repository
+	repo := &hub.ChartRepository{Kind: -1}
+	if err := json.NewDecoder(r.Body).Decode(&repo); err != nil {
+		log.Error().Err(err).Msg("invalid chart repository")
+		http.Error(w, "chart repository provided is not valid", http.StatusBadRequest)
+		return
+	}
+	repo.Name = chi.URLParam(r, "repoName")
+	if repo.Kind == -1 {
+		storedRepo, err := h.hubAPI.GetChartRepositoryByName(r.Context(), repo.Name)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.NotFound(w, r)
+			} else {
+				log.Error().Err(err).Msg("getChartRepositoryByName failed")
+				http.Error(w, "", http.StatusInternalServerError)
+			}
+			return
+		}
+		repo.Kind = storedRepo.Kind
+	}
+	if err := validateChartRepository(repo); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.hubAPI.UpdateChartRepository(r.Context(), repo); err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			http.NotFound(w, r)
+		case isInsufficientPrivilegeError(err):
+			http.Error(w, "", http.StatusForbidden)
+		default:
+			log.Error().Err(err).Msg("updateChartRepository failed")
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+}
+
+// validateChartRepository validates the kind specific fields of the chart
+// repository provided.
+func validateChartRepository(repo *hub.ChartRepository) error {
+	switch repo.Kind {
+	case hub.HelmChartRepository:
+		if repo.GitBranch != "" || repo.GitPathGlob != "" {
+			return errors.New("git fields can only be provided for git chart repositories")
+		}
+	case hub.GitChartRepository:
+		if _, err := path.Match(repo.GitPathGlob, ""); err != nil {
+			return fmt.Errorf("invalid git path glob: %s", repo.GitPathGlob)
+		}
+	default:
+		return errors.New("invalid chart repository kind")
+	}
+	return nil
+}
+
+// deleteChartRepository is an http handler that deletes the provided chart
+// repository from the database.
+func (h *handlers) deleteChartRepository(w http.ResponseWriter, r *http.Request) {
+	repo := &hub.ChartRepository{
+		Name: chi.URLParam(r, "repoName"),
+	}
+	if err := h.hubAPI.DeleteChartRepository(r.Context(), repo); err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			http.NotFound(w, r)
+		case isInsufficientPrivilegeError(err):
+			http.Error(w, "", http.StatusForbidden)
+		default:
+			log.Error().Err(err).Msg("deleteChartRepository failed")
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+}
+
+// transferChartRepository is an http handler that requests the transfer of the
+// provided chart repository to the organization given. The transfer must be
+// accepted by an admin of the destination organization.
+func (h *handlers) transferChartRepository(w http.ResponseWriter, r *http.Request) {
+	orgName := r.FormValue("orgName")
+	if orgName == "" {
+		errMsg := "organization name not provided"
+		log.Error().Msg(errMsg)
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	repoName := chi.URLParam(r, "repoName")
+	if err := h.hubAPI.TransferChartRepository(r.Context(), repoName, orgName); err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			http.NotFound(w, r)
+		case isInsufficientPrivilegeError(err):
+			http.Error(w, "", http.StatusForbidden)
+		case isCheckViolationError(err):
+			http.Error(w, "chart repository already belongs to the organization", http.StatusConflict)
+		default:
+			log.Error().Err(err).Str("repoName", repoName).Msg("transferChartRepository failed")
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+}
+
+// acceptChartRepositoryTransfer is an http handler that accepts the pending
+// transfer of the provided chart repository to an organization.
+func (h *handlers) acceptChartRepositoryTransfer(w http.ResponseWriter, r *http.Request) {
+	repoName := chi.URLParam(r, "repoName")
+	if err := h.hubAPI.AcceptChartRepositoryTransfer(r.Context(), repoName); err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			http.NotFound(w, r)
+		case isInsufficientPrivilegeError(err):
+			http.Error(w, "", http.StatusForbidden)
+		default:
+			log.Error().Err(err).Str("repoName", repoName).Msg("acceptChartRepositoryTransfer failed")
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+}
+
+// cancelChartRepositoryTransfer is an http handler that cancels the pending
+// transfer of the provided chart repository to an organization.
+func (h *handlers) cancelChartRepositoryTransfer(w http.ResponseWriter, r *http.Request) {
+	repoName := chi.URLParam(r, "repoName")
+	if err := h.hubAPI.CancelChartRepositoryTransfer(r.Context(), repoName); err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			http.NotFound(w, r)
+		case isInsufficientPrivilegeError(err):
+			http.Error(w, "", http.StatusForbidden)
+		default:
+			log.Error().Err(err).Str("repoName", repoName).Msg("cancelChartRepositoryTransfer failed")
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+}
+
+// declineChartRepositoryTransfer is an http handler that declines the pending
+// transfer of the provided chart repository to an organization.
+func (h *handlers) declineChartRepositoryTransfer(w http.ResponseWriter, r *http.Request) {
+	repoName := chi.URLParam(r, "repoName")
+	if err := h.hubAPI.DeclineChartRepositoryTransfer(r.Context(), repoName); err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			http.NotFound(w, r)
+		case isInsufficientPrivilegeError(err):
+			http.Error(w, "", http.StatusForbidden)
+		default:
+			log.Error().Err(err).Str("repoName", repoName).Msg("declineChartRepositoryTransfer failed")
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+}
+
+// getOrganizationChartRepositoryTransfers is an http handler that returns the
+// pending transfers of chart repositories to the provided organization.
+func (h *handlers) getOrganizationChartRepositoryTransfers(w http.ResponseWriter, r *http.Request) {
+	orgName := chi.URLParam(r, "orgName")
+	jsonData, err := h.hubAPI.GetOrganizationChartRepositoryTransfersJSON(r.Context(), orgName)
+	if err != nil {
+		log.Error().Err(err).Str("orgName", orgName).Msg("getOrganizationChartRepositoryTransfers failed")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	renderJSON(w, jsonData, 0)
+}
+
+// getUserOrganizations is an http handler that returns the organizations the
+// user doing the request belongs to.
+func (h *handlers) getUserOrganizations(w http.ResponseWriter, r *http.Request) {
+	jsonData, err := h.hubAPI.GetUserOrganizationsJSON(r.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("getUserOrganizations failed")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	renderJSON(w, jsonData, 0)
+}
+
+// addOrganization is an http handler that adds the provided organization to
+// the database.
+func (h *handlers) addOrganization(w http.ResponseWriter, r *http.Request) {
+	org := &hub.Organization{}
+	if err := json.NewDecoder(r.Body).Decode(&org); err != nil {
+		log.Error().Err(err).Msg("invalid organization")
+		http.Error(w, "organization provided is not valid", http.StatusBadRequest)
+		return
+	}
+	if org.Name == "" {
+		http.Error(w, "organization name must be provided", http.StatusBadRequest)
+		return
+	}
+	if err := h.hubAPI.AddOrganization(r.Context(), org); err != nil {
+		log.Error().Err(err).Msg("addOrganization failed")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+}
+
+// updateOrganization is an http handler that updates the provided
+// organization in the database.
+func (h *handlers) updateOrganization(w http.ResponseWriter, r *http.Request) {
+	org := &hub.Organization{}
+	if err := json.NewDecoder(r.Body).Decode(&org); err != nil {
+		log.Error().Err(err).Msg("invalid organization")
+		http.Error(w, "organization provided is not valid", http.StatusBadRequest)
+		return
+	}
+	org.Name = chi.URLParam(r, "orgName")
+	if err := h.hubAPI.UpdateOrganization(r.Context(), org); err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			http.NotFound(w, r)
+		case isInsufficientPrivilegeError(err):
+			http.Error(w, "", http.StatusForbidden)
+		default:
+			log.Error().Err(err).Msg("updateOrganization failed")
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+}
+
+// deleteOrganization is an http handler that deletes the provided
+// organization from the database.
+func (h *handlers) deleteOrganization(w http.ResponseWriter, r *http.Request) {
+	orgName := chi.URLParam(r, "orgName")
+	if err := h.hubAPI.DeleteOrganization(r.Context(), orgName); err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			http.NotFound(w, r)
+		case isInsufficientPrivilegeError(err):
+			http.Error(w, "", http.StatusForbidden)
+		default:
+			log.Error().Err(err).Str("orgName", orgName).Msg("deleteOrganization failed")
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+}
+
+// getOrganizationMembers is an http handler that returns the members of the
+// provided organization.
+func (h *handlers) getOrganizationMembers(w http.ResponseWriter, r *http.Request) {
+	orgName := chi.URLParam(r, "orgName")
+	jsonData, err := h.hubAPI.GetOrganizationMembersJSON(r.Context(), orgName)
+	if err != nil {
+		log.Error().Err(err).Str("orgName", orgName).Msg("getOrganizationMembers failed")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	renderJSON(w, jsonData, 0)
+}
+
+// addOrganizationMember is an http handler that adds a member to the provided
+// organization.
+func (h *handlers) addOrganizationMember(w http.ResponseWriter, r *http.Request) {
+	orgName := chi.URLParam(r, "orgName")
+	userAlias := chi.URLParam(r, "userAlias")
+	if err := h.hubAPI.AddOrganizationMember(r.Context(), orgName, userAlias); err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			http.NotFound(w, r)
+		case isInsufficientPrivilegeError(err):
+			http.Error(w, "", http.StatusForbidden)
+		default:
+			log.Error().Err(err).Str("orgName", orgName).Msg("addOrganizationMember failed")
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+}
+
+// deleteOrganizationMember is an http handler that removes a member from the
+// provided organization.
+func (h *handlers) deleteOrganizationMember(w http.ResponseWriter, r *http.Request) {
+	orgName := chi.URLParam(r, "orgName")
+	userAlias := chi.URLParam(r, "userAlias")
+	if err := h.hubAPI.DeleteOrganizationMember(r.Context(), orgName, userAlias); err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			http.NotFound(w, r)
+		case isInsufficientPrivilegeError(err):
+			http.Error(w, "", http.StatusForbidden)
+		case isCheckViolationError(err):
+			http.Error(w, "organization must have at least one owner", http.StatusConflict)
+		default:
+			log.Error().Err(err).Str("orgName", orgName).Msg("deleteOrganizationMember failed")
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+}
+
+// updateOrganizationMemberRole is an http handler that updates the role of a
+// member of the provided organization.
+func (h *handlers) updateOrganizationMemberRole(w http.ResponseWriter, r *http.Request) {
+	input := struct {
+		Role hub.OrganizationRole `json:"role"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		log.Error().Err(err).Msg("invalid organization member role")
+		http.Error(w, "organization member role provided is not valid", http.StatusBadRequest)
+		return
+	}
+	if !input.Role.IsValid() {
+		http.Error(w, "organization member role provided is not valid", http.StatusBadRequest)
+		return
+	}
+	orgName := chi.URLParam(r, "orgName")
+	userAlias := chi.URLParam(r, "userAlias")
+	if err := h.hubAPI.UpdateOrganizationMemberRole(r.Context(), orgName, userAlias, input.Role); err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			http.NotFound(w, r)
+		case isInsufficientPrivilegeError(err):
+			http.Error(w, "", http.StatusForbidden)
+		case isCheckViolationError(err):
+			http.Error(w, "organization must have at least one owner", http.StatusConflict)
+		default:
+			log.Error().Err(err).Str("orgName", orgName).Msg("updateOrganizationMemberRole failed")
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+}
+
+// addOrganizationInvitation is an http handler that invites the email
+// provided to join the organization. The invitation is accepted even when
+// the email does not belong to a registered user, so that the existence of an
+// account is not revealed.
+func (h *handlers) addOrganizationInvitation(w http.ResponseWriter, r *http.Request) {
+	email := r.FormValue("email")
+	if email == "" {
+		errMsg := "email not provided"
+		log.Error().Msg(errMsg)
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	orgName := chi.URLParam(r, "orgName")
+	if err := h.hubAPI.AddOrganizationInvitation(r.Context(), orgName, email, h.baseURL); err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			http.NotFound(w, r)
+		case isInsufficientPrivilegeError(err):
+			http.Error(w, "", http.StatusForbidden)
+		default:
+			log.Error().Err(err).Str("orgName", orgName).Msg("addOrganizationInvitation failed")
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// getUserOrganizationInvitations is an http handler that returns the pending
+// organization invitations of the user doing the request, including the
+// tokens needed to accept or decline them.
+func (h *handlers) getUserOrganizationInvitations(w http.ResponseWriter, r *http.Request) {
+	invitations, err := h.hubAPI.GetUserOrganizationInvitations(r.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("getUserOrganizationInvitations failed")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	jsonData, _ := json.Marshal(invitations)
+	renderJSON(w, jsonData, 0)
+}
+
+// acceptOrganizationInvitation is an http handler used to accept the
+// organization invitation identified by the token provided.
+func (h *handlers) acceptOrganizationInvitation(w http.ResponseWriter, r *http.Request) {
+	token := r.FormValue("token")
+	if token == "" {
+		errMsg := "invitation token not provided"
+		log.Error().Msg(errMsg)
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	accepted, err := h.hubAPI.AcceptOrganizationInvitation(r.Context(), token)
+	if err != nil {
+		if isInvalidTextRepresentationError(err) {
+			http.Error(w, "invalid invitation token", http.StatusBadRequest)
+			return
+		}
+		log.Error().Err(err).Msg("acceptOrganizationInvitation failed")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	if !accepted {
+		w.WriteHeader(http.StatusGone)
+	}
+}
+
+// declineOrganizationInvitation is an http handler used to decline the
+// organization invitation identified by the token provided.
+func (h *handlers) declineOrganizationInvitation(w http.ResponseWriter, r *http.Request) {
+	token := r.FormValue("token")
+	if token == "" {
+		errMsg := "invitation token not provided"
+		log.Error().Msg(errMsg)
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	declined, err := h.hubAPI.DeclineOrganizationInvitation(r.Context(), token)
+	if err != nil {
+		if isInvalidTextRepresentationError(err) {
+			http.Error(w, "invalid invitation token", http.StatusBadRequest)
+			return
+		}
+		log.Error().Err(err).Msg("declineOrganizationInvitation failed")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	if !declined {
+		w.WriteHeader(http.StatusGone)
+	}
+}
+
+// addTrackingRequest is an http handler that requests the provided chart
+// repository to be tracked as soon as possible. The tracking request is
+// returned so that its status can be polled.
+func (h *handlers) addTrackingRequest(w http.ResponseWriter, r *http.Request) {
+	repoName := chi.URLParam(r, "repoName")
+	jsonData, err := h.hubAPI.AddTrackingRequestJSON(r.Context(), repoName)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			http.NotFound(w, r)
+		case isInsufficientPrivilegeError(err):
+			http.Error(w, "", http.StatusForbidden)
+		default:
+			log.Error().Err(err).Str("repoName", repoName).Msg("addTrackingRequest failed")
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+	renderJSON(w, jsonData, 0)
+}
+
+// getTrackingRequest is an http handler that returns the tracking request
+// provided, allowing clients to poll its status.
+func (h *handlers) getTrackingRequest(w http.ResponseWriter, r *http.Request) {
+	repoName := chi.URLParam(r, "repoName")
+	trackingRequestID := chi.URLParam(r, "trackingRequestID")
+	jsonData, err := h.hubAPI.GetTrackingRequestJSON(r.Context(), repoName, trackingRequestID)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			http.NotFound(w, r)
+		case isInvalidTextRepresentationError(err):
+			http.Error(w, "invalid tracking request id", http.StatusBadRequest)
+		default:
+			log.Error().Err(err).Str("repoName", repoName).Msg("getTrackingRequest failed")
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+	renderJSON(w, jsonData, 0)
+}
+
+// getTrackingRuns is an http handler that returns the tracking runs history of
+// the provided chart repository, most recent first. Results can be paginated
+// using the limit and offset query parameters.
+func (h *handlers) getTrackingRuns(w http.ResponseWriter, r *http.Request) {
+	repoName := chi.URLParam(r, "repoName")
+	limit, offset, err := getPagination(r.URL.Query(), defaultTrackingRunsLimit, maxTrackingRunsLimit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	jsonData, err := h.hubAPI.GetTrackingRunsJSON(r.Context(), repoName, limit, offset)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			http.NotFound(w, r)
+		case isInsufficientPrivilegeError(err):
+			http.Error(w, "", http.StatusForbidden)
+		default:
+			log.Error().Err(err).Str("repoName", repoName).Msg("getTrackingRuns failed")
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+	renderJSON(w, jsonData, 0)
+}
+
+// getChartRepositoryTrackingErrors is an http handler that returns the errors
+// that happened during the last tracking of the provided chart repository.
+// Errors can be filtered by kind using the kind query parameter.
+func (h *handlers) getChartRepositoryTrackingErrors(w http.ResponseWriter, r *http.Request) {
+	repoName := chi.URLParam(r, "repoName")
+	kinds := r.URL.Query()["kind"]
+	for _, kind := range kinds {
+		switch hub.TrackingErrorKind(kind) {
+		case hub.TrackingErrorIndexLoad,
+			hub.TrackingErrorChartDownload,
+			hub.TrackingErrorChartParse,
+			hub.TrackingErrorLogoDownload,
+			hub.TrackingErrorLogoDecode,
+			hub.TrackingErrorRegister:
+		default:
+			http.Error(w, fmt.Sprintf("invalid kind: %s", kind), http.StatusBadRequest)
+			return
+		}
+	}
+	jsonData, err := h.hubAPI.GetChartRepositoryTrackingErrorsJSON(r.Context(), repoName, kinds)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			http.NotFound(w, r)
+		case isInsufficientPrivilegeError(err):
+			http.Error(w, "", http.StatusForbidden)
+		default:
+			log.Error().Err(err).Str("repoName", repoName).Msg("getChartRepositoryTrackingErrors failed")
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+	renderJSON(w, jsonData, 0)
+}
+
+// rotateChartRepositoryWebhookSecret is an http handler that generates a new
+// webhook secret for the provided chart repository, replacing the existing
+// one. The new secret is returned as a json object.
+func (h *handlers) rotateChartRepositoryWebhookSecret(w http.ResponseWriter, r *http.Request) {
+	repoName := chi.URLParam(r, "repoName")
+	secret, err := h.hubAPI.RotateChartRepositoryWebhookSecret(r.Context(), repoName)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			http.NotFound(w, r)
+		case isInsufficientPrivilegeError(err):
+			http.Error(w, "", http.StatusForbidden)
+		default:
+			log.Error().Err(err).Str("repoName", repoName).Msg("rotateChartRepositoryWebhookSecret failed")
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+	jsonData, _ := json.Marshal(map[string]string{"secret": secret})
+	renderJSON(w, jsonData, 0)
+}
+
+// deleteChartRepositoryWebhookSecret is an http handler that deletes the
+// webhook secret of the provided chart repository, disabling its webhook.
+func (h *handlers) deleteChartRepositoryWebhookSecret(w http.ResponseWriter, r *http.Request) {
+	repoName := chi.URLParam(r, "repoName")
+	if err := h.hubAPI.DeleteChartRepositoryWebhookSecret(r.Context(), repoName); err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			http.NotFound(w, r)
+		case isInsufficientPrivilegeError(err):
+			http.Error(w, "", http.StatusForbidden)
+		default:
+			log.Error().Err(err).Str("repoName", repoName).Msg("deleteChartRepositoryWebhookSecret failed")
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+}
+
+// generateChartRepositoryVerificationToken is an http handler that generates
+// a new verification token for the provided chart repository. The repository
+// owner must publish it in the repository metadata file to be flagged as a
+// verified publisher.
+func (h *handlers) generateChartRepositoryVerificationToken(w http.ResponseWriter, r *http.Request) {
+	repoName := chi.URLParam(r, "repoName")
+	token, err := h.hubAPI.GenerateChartRepositoryVerificationToken(r.Context(), repoName)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			http.NotFound(w, r)
+		case isInsufficientPrivilegeError(err):
+			http.Error(w, "", http.StatusForbidden)
+		default:
+			log.Error().Err(err).Str("repoName", repoName).Msg("generateChartRepositoryVerificationToken failed")
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+	jsonData, _ := json.Marshal(map[string]string{"token": token})
+	renderJSON(w, jsonData, 0)
+}
+
+// getChartRepositoryWebhooks is an http handler that returns the outgoing
+// webhooks of the provided chart repository.
+func (h *handlers) getChartRepositoryWebhooks(w http.ResponseWriter, r *http.Request) {
+	repoName := chi.URLParam(r, "repoName")
+	jsonData, err := h.hubAPI.GetChartRepositoryWebhooksJSON(r.Context(), repoName)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			http.NotFound(w, r)
+		case isInsufficientPrivilegeError(err):
+			http.Error(w, "", http.StatusForbidden)
+		default:
+			log.Error().Err(err).Str("repoName", repoName).Msg("getChartRepositoryWebhooks failed")
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+	renderJSON(w, jsonData, 0)
+}
+
+// addWebhook is an http handler that adds the provided outgoing webhook to the
+// chart repository given.
+func (h *handlers) addWebhook(w http.ResponseWriter, r *http.Request) {
+	repoName := chi.URLParam(r, "repoName")
+	wh := &hub.Webhook{}
+	if err := json.NewDecoder(r.Body).Decode(&wh); err != nil {
+		log.Error().Err(err).Msg("invalid webhook")
+		http.Error(w, "webhook provided is not valid", http.StatusBadRequest)
+		return
+	}
+	if err := validateWebhook(wh); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	webhookID, err := h.hubAPI.AddWebhook(r.Context(), repoName, wh)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			http.NotFound(w, r)
+		case isInsufficientPrivilegeError(err):
+			http.Error(w, "", http.StatusForbidden)
+		default:
+			log.Error().Err(err).Str("repoName", repoName).Msg("addWebhook failed")
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+	jsonData, _ := json.Marshal(map[string]string{"webhook_id": webhookID})
+	renderJSON(w, jsonData, 0)
+}
+
+// validateWebhook validates a webhook instance before we attempt to add it to
+// the database.
+func validateWebhook(wh *hub.Webhook) error {
+	u, err := url.Parse(wh.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("invalid url")
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errors.New("url host not allowed")
+	}
+	if ip := net.ParseIP(host); ip != nil && !isWebhookIPAllowed(ip) {
+		return errors.New("url host not allowed")
+	}
+	if wh.Secret == "" {
+		return errors.New("secret not provided")
+	}
+	if len(wh.EventKinds) == 0 {
+		return errors.New("event kinds not provided")
+	}
+	for _, kind := range wh.EventKinds {
+		if kind != hub.NewRelease && kind != hub.VersionRemoved {
+			return fmt.Errorf("invalid event kind: %d", kind)
+		}
+	}
+	return nil
+}
+
+// deleteWebhook is an http handler that deletes the provided outgoing webhook
+// from the chart repository given.
+func (h *handlers) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	repoName := chi.URLParam(r, "repoName")
+	webhookID := chi.URLParam(r, "webhookID")
+	if err := h.hubAPI.DeleteWebhook(r.Context(), repoName, webhookID); err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			http.NotFound(w, r)
+		case isInsufficientPrivilegeError(err):
+			http.Error(w, "", http.StatusForbidden)
+		case isInvalidTextRepresentationError(err):
+			http.Error(w, "invalid webhook id", http.StatusBadRequest)
+		default:
+			log.Error().Err(err).Str("repoName", repoName).Str("webhookID", webhookID).Msg("deleteWebhook failed")
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+}
+
+// getWebhookDeliveries is an http handler that returns the deliveries of the
+// provided outgoing webhook, most recent first. Results can be paginated using
+// the limit and offset query parameters.
+func (h *handlers) getWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	repoName := chi.URLParam(r, "repoName")
+	webhookID := chi.URLParam(r, "webhookID")
+	limit, offset, err := getPagination(r.URL.Query(), defaultWebhookDeliveriesLimit, maxWebhookDeliveriesLimit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	jsonData, err := h.hubAPI.GetWebhookDeliveriesJSON(r.Context(), repoName, webhookID, limit, offset)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			http.NotFound(w, r)
+		case isInsufficientPrivilegeError(err):
+			http.Error(w, "", http.StatusForbidden)
+		default:
+			log.Error().Err(err).Str("repoName", repoName).Str("webhookID", webhookID).Msg("getWebhookDeliveries failed")
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+	renderJSON(w, jsonData, 0)
+}
+
+// chartRepositoryWebhook is an http handler that receives notifications about
+// chart versions released in the provided chart repository, requesting them
+// to be processed as soon as possible. Notifications must be signed using the
+// repository webhook secret (HMAC-SHA256 of the payload).
+func (h *handlers) chartRepositoryWebhook(w http.ResponseWriter, r *http.Request) {
+	repoName := chi.URLParam(r, "repoName")
+	payload, err := ioutil.ReadAll(io.LimitReader(r.Body, webhookMaxPayloadSize))
+	if err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	// Check payload signature
+	secret, err := h.hubAPI.GetChartRepositoryWebhookSecret(r.Context(), repoName)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		log.Error().Err(err).Str("repoName", repoName).Msg("chartRepositoryWebhook failed")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	if secret == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if !isValidWebhookSignature(secret, payload, r.Header.Get(webhookSignatureHeader)) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	// Request chart version to be processed
+	var input struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(payload, &input); err != nil || input.Name == "" || input.Version == "" {
+		http.Error(w, "chart name and version must be provided", http.StatusBadRequest)
+		return
+	}
+	jsonData, err := h.hubAPI.AddChartVersionTrackingRequestJSON(r.Context(), repoName, input.Name, input.Version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			http.NotFound(w, r)
+		} else {
+			log.Error().Err(err).Str("repoName", repoName).Msg("chartRepositoryWebhook failed")
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+	renderJSON(w, jsonData, 0)
+}
+
+// isValidWebhookSignature checks if the signature provided matches the
+// HMAC-SHA256 of the payload using the given secret.
+func isValidWebhookSignature(secret string, payload []byte, signature string) bool {
+	if !strings.HasPrefix(signature, webhookSignaturePrefix) {
+		return false
+	}
+	sig, err := hex.DecodeString(strings.TrimPrefix(signature, webhookSignaturePrefix))
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(payload)
+	return hmac.Equal(sig, mac.Sum(nil))
+}
+
+// getPagination returns the limit and offset provided in the query string
+// given, using the default limit provided when none is set.
+func getPagination(qs url.Values, defaultLimit, maxLimit int) (limit, offset int, err error) {
+	limit = defaultLimit
+	if qs.Get("limit") != "" {
+		limit, err = strconv.Atoi(qs.Get("limit"))
+		if err != nil || limit <= 0 || limit > maxLimit {
+			return 0, 0, fmt.Errorf("invalid limit: %s", qs.Get("limit"))
+		}
+	}
+	if qs.Get("offset") != "" {
+		offset, err = strconv.Atoi(qs.Get("offset"))
+		if err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("invalid offset: %s", qs.Get("offset"))
+		}
+	}
+	return limit, offset, nil
+}
+
+// getAuditEvents is an http handler that returns the audit events visible to
+// the user doing the request, most recent first. Results can be paginated
+// using the limit and offset query parameters.
+func (h *handlers) getAuditEvents(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := getPagination(r.URL.Query(), defaultAuditEventsLimit, maxAuditEventsLimit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	jsonData, err := h.hubAPI.GetAuditEventsJSON(r.Context(), limit, offset)
+	if err != nil {
+		log.Error().Err(err).Msg("getAuditEvents failed")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	renderJSON(w, jsonData, 0)
+}
+
+// injectRequestInfo is a middleware that injects some information about the
+// request (ip address and user agent) in its context, so that it can be
+// recorded in the audit events registered while processing it.
+func injectRequestInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ri := &hub.RequestInfo{
+			IP:        getIP(r),
+			UserAgent: r.UserAgent(),
+		}
+		ctx := context.WithValue(r.Context(), hub.RequestInfoKey, ri)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// getIP returns the ip address the request provided comes from.
+func getIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return ip
+}
+
+// realIP is a middleware that sets the request remote address to the client
+// ip address reported in the X-Forwarded-For or X-Real-IP headers. Those
+// headers are only taken into account when the request comes from one of the
+// trusted proxies configured, as otherwise clients could spoof them.
+func (h *handlers) realIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.isTrustedProxy(net.ParseIP(getIP(r))) {
+			if ip := h.getForwardedIP(r); ip != "" {
+				r.RemoteAddr = ip
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// getForwardedIP returns the client ip address reported by the proxies in
+// front of the hub. The X-Forwarded-For header is processed from right to
+// left, skipping the trusted proxies, as the leftmost entries are provided by
+// the client and cannot be trusted.
+func (h *handlers) getForwardedIP(r *http.Request) string {
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		entries := strings.Split(xff, ",")
+		for i := len(entries) - 1; i >= 0; i-- {
+			ip := net.ParseIP(strings.TrimSpace(entries[i]))
+			if ip == nil {
+				return ""
+			}
+			if !h.isTrustedProxy(ip) {
+				return ip.String()
+			}
+		}
+		return ""
+	}
+	if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+		return ip.String()
+	}
+	return ""
+}
+
+// isTrustedProxy checks if the ip address provided belongs to one of the
+// trusted proxies configured.
+func (h *handlers) isTrustedProxy(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, n := range h.trustedProxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTrustedProxies parses the trusted proxies provided, which can be ip
+// addresses or networks in CIDR notation. Invalid entries are ignored.
+func parseTrustedProxies(proxies []string) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				log.Error().Str("proxy", proxy).Msg("invalid trusted proxy ignored")
+				continue
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(proxy)
+		if err != nil {
+			log.Error().Err(err).Str("proxy", proxy).Msg("invalid trusted proxy ignored")
+			continue
+		}
+		networks = append(networks, n)
+	}
+	return networks
+}
+
+// requireLoginOrAPIKey is a middleware that verifies if a user is logged in
+// or has provided a valid api key. Api keys are only accepted in the routes
+// used to manage chart repositories and their tracking.
+func (h *handlers) requireLoginOrAPIKey(next http.Handler) http.Handler {
+	requireLogin := h.requireLogin(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Fall back to the session cookie when no api key is provided
+		key := getAPIKey(r)
+		if key == "" {
+			requireLogin.ServeHTTP(w, r)
+			return
+		}
+
+		// Check the api key provided is valid
+		checkAPIKeyOutput, err := h.hubAPI.CheckAPIKey(r.Context(), key)
+		if err != nil {
+			log.Error().Err(err).Msg("checkAPIKey failed")
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+		if !checkAPIKeyOutput.Valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		// Inject userID in context and call next handler
+		ctx := context.WithValue(r.Context(), hub.UserIDKey, checkAPIKeyOutput.UserID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// requireLogin is a middleware that verifies if a user is logged in using the
+// session cookie.
+func (h *handlers) requireLogin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Extract and validate cookie from request
+		cookie, err := r.Cookie(sessionCookieName)
+		if err != nil {
+			http.Error(w, "", http.StatusUnauthorized)
+			return
+		}
+		var sessionID []byte
+		if err = h.sc.Decode(sessionCookieName, cookie.Value, &sessionID); err != nil {
+			log.Error().Err(err).Msg("sessionID decoding failed")
+			http.Error(w, "", http.StatusUnauthorized)
+			return
+		}
+
+		// Check the session provided is valid
+		checkSessionOutput, err := h.hubAPI.CheckSession(r.Context(), sessionID, sessionDuration)
+		if err != nil {
+			log.Error().Err(err).Msg("checkSession failed")
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+		if !checkSessionOutput.Valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		// Re-issue the session cookie when it was not issued recently
+		var freshSessionID []byte
+		if err := h.scFresh.Decode(sessionCookieName, cookie.Value, &freshSessionID); err != nil {
+			if err := h.setSessionCookie(w, sessionID); err != nil {
+				log.Error().Err(err).Msg("setSessionCookie failed")
+			}
+		}
+
+		// Inject userID in context and call next handler
+		ctx := context.WithValue(r.Context(), hub.UserIDKey, checkSessionOutput.UserID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// getSessionUserID returns the id of the user logged in using the session
+// cookie provided in the request, if any.
+func (h *handlers) getSessionUserID(r *http.Request) (string, error) {
+	cookie, err := r.Cookie(sessionCookieName)
+	if err != nil {
+		return "", nil
+	}
+	var sessionID []byte
+	if err = h.sc.Decode(sessionCookieName, cookie.Value, &sessionID); err != nil {
+		return "", nil
+	}
+	checkSessionOutput, err := h.hubAPI.CheckSession(r.Context(), sessionID, sessionDuration)
+	if err != nil {
+		return "", err
+	}
+	if !checkSessionOutput.Valid {
+		return "", nil
+	}
+	return checkSessionOutput.UserID, nil
+}
+
+// getAPIKey returns the api key provided in the request, if any. Api keys can
+// be provided using the Authorization header (Bearer scheme) or the X-API-Key
+// header.
+func getAPIKey(r *http.Request) string {
+	if key := r.Header.Get(apiKeyHeader); key != "" {
+		return key
+	}
+	authz := r.Header.Get("Authorization")
+	if len(authz) > len(bearerPrefix) && strings.EqualFold(authz[:len(bearerPrefix)], bearerPrefix) {
+		return strings.TrimSpace(authz[len(bearerPrefix):])
+	}
+	return ""
+}
+
+// image in an http handler that serves images stored in the database.
+func (h *handlers) image(w http.ResponseWriter, r *http.Request) {
+	// Extract image id and version
+	image := chi.URLParam(r, "image")
+	parts := strings.Split(image, "@")
+	var imageID, version string
+	if len(parts) == 2 {
+		imageID = parts[0]
+		version = parts[1]
+	} else {
+		imageID = image
+	}
+
+	// Check if image version data is cached
+	h.mu.RLock()
+	data, ok := h.imagesCache[image]
+	h.mu.RUnlock()
+	if !ok {
+		// Get image data from database
+		var err error
+		data, err = h.imageStore.GetImage(r.Context(), imageID, version)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				http.NotFound(w, r)
+			} else {
+				log.Error().Err(err).Str("imageID", imageID).Send()
+				http.Error(w, "", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		// Save image data in cache
+		h.mu.Lock()
+		h.imagesCache[image] = data
+		h.mu.Unlock()
+	}
+
+	// Set headers and write image data to response writer
+	w.Header().Set("Cache-Control", "public, max-age=31536000")
+	if svg.Is(data) {
+		w.Header().Set("Content-Type", "image/svg+xml")
+	} else {
+		w.Header().Set("Content-Type", http.DetectContentType(data))
+	}
+	_, _ = w.Write(data)
 }
 
 // checkAvailability is a middleware that checks the availability of a given
@@ -565,6 +2131,7 @@ func (h *handlers) checkAvailability(w http.ResponseWriter, r *http.Request) {
 		"userAlias",
 		"chartRepositoryName",
 		"chartRepositoryURL",
+		"organizationName",
 	}
 	isResourceKindValid := func(resourceKind string) bool {
 		for _, k := range validResourceKinds {
@@ -624,13 +2191,56 @@ func (h *handlers) basicAuth(next http.Handler) http.Handler {
 }
 
 // renderJSON is a helper to write the json data provided to the given http
-// response writer, setting the appropriate content type and cache
+// response writer, setting the appropriate content type and cache. Responses
+// with no cache max age, like the ones specific to the user doing the request,
+// must not be stored by caches.
 func renderJSON(w http.ResponseWriter, jsonData []byte, cacheMaxAge time.Duration) {
-	w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", int64(cacheMaxAge.Seconds())))
+	if cacheMaxAge == 0 {
+		w.Header().Set("Cache-Control", "no-store")
+	} else {
+		w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", int64(cacheMaxAge.Seconds())))
+	}
 	w.Header().Set("Content-Type", "application/json")
 	_, _ = w.Write(jsonData)
 }
 
+// isInsufficientPrivilegeError checks if the error provided was returned by
+// the database because the user does not have enough privileges to perform
+// the requested operation.
+func isInsufficientPrivilegeError(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == insufficientPrivilegeErrCode
+}
+
+// isUniqueViolationError checks if the error provided was returned by the
+// database because a unique constraint was violated.
+func isUniqueViolationError(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolationErrCode
+}
+
+// isForeignKeyViolationError checks if the error provided was returned by the
+// database because a foreign key constraint was violated.
+func isForeignKeyViolationError(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolationErrCode
+}
+
+// isCheckViolationError checks if the error provided was returned by the
+// database because a check constraint was violated.
+func isCheckViolationError(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == checkViolationErrCode
+}
+
+// isInvalidTextRepresentationError checks if the error provided was returned
+// by the database because some input value was not valid for its type, like
+// an invalid uuid.
+func isInvalidTextRepresentationError(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == invalidTextRepresentationErrCode
+}
+
 // fileServer sets up a http.FileServer handler to serve static files from a
 // a http.FileSystem.
 func fileServer(r chi.Router, path string, fs http.FileSystem) {
@@ -2,6 +2,10 @@ package main
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -12,10 +16,13 @@ import (
 	"testing"
 	"time"
 
+	"github.com/cncf/hub/internal/email"
 	"github.com/cncf/hub/internal/hub"
 	"github.com/cncf/hub/internal/img/pg"
 	"github.com/cncf/hub/internal/tests"
 	"github.com/go-chi/chi"
+	"github.com/gorilla/securecookie"
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/rs/zerolog"
 	"github.com/spf13/viper"
@@ -25,7 +32,11 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
-var errFakeDatabaseFailure = errors.New("fake database failure")
+var (
+	errFakeDatabaseFailure    = errors.New("fake database failure")
+	errFakeEmailSenderFailure = errors.New("fake email sender failure")
+	errNoDataFound            = &pgconn.PgError{Code: "P0002"}
+)
 
 func TestMain(m *testing.M) {
 	zerolog.SetGlobalLevel(zerolog.Disabled)
@@ -263,6 +274,23 @@ func TestGetPackage(t *testing.T) {
 		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
 		th.db.AssertExpectations(t)
 	})
+
+	t.Run("existing operator package", func(t *testing.T) {
+		th := setupTestHandlers()
+		expectedInput := `{"kind":1,"chart_repository_name":"","operator_provider_name":"provider1","package_name":"package1","version":"1.0.0"}`
+		th.db.On("QueryRow", dbQuery, []byte(expectedInput)).Return([]byte("packageDataJSON"), nil)
+		s := httptest.NewServer(th.h.router)
+		defer s.Close()
+
+		resp, err := http.Get(s.URL + "/api/v1/package/operator/provider1/package1/1.0.0")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		data, _ := ioutil.ReadAll(resp.Body)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, []byte("packageDataJSON"), data)
+		th.db.AssertExpectations(t)
+	})
 }
 
 func TestRegisterUser(t *testing.T) {
@@ -421,8 +449,278 @@ func TestVerifyEmail(t *testing.T) {
 	})
 }
 
+func TestResendVerification(t *testing.T) {
+	dbQuery := "select regenerate_email_verification_code($1::text)"
+
+	t.Run("no email provided", func(t *testing.T) {
+		th := setupTestHandlers()
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/", nil)
+		th.h.resendVerification(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("email provided", func(t *testing.T) {
+		testCases := []struct {
+			description        string
+			dbResponse         []interface{}
+			expectedStatusCode int
+		}{
+			{
+				"no code generated",
+				[]interface{}{nil, pgx.ErrNoRows},
+				http.StatusOK,
+			},
+			{
+				"email verification code sent",
+				[]interface{}{"emailVerificationCode", nil},
+				http.StatusOK,
+			},
+			{
+				"database error",
+				[]interface{}{nil, errFakeDatabaseFailure},
+				http.StatusInternalServerError,
+			},
+		}
+		for _, tc := range testCases {
+			tc := tc
+			t.Run(tc.description, func(t *testing.T) {
+				th := setupTestHandlers()
+				th.db.On("QueryRow", dbQuery, "user1@email.com").Return(tc.dbResponse...)
+				if tc.dbResponse[1] == nil {
+					th.es.On("SendEmail", mock.Anything).Return(nil)
+				}
+
+				w := httptest.NewRecorder()
+				r, _ := http.NewRequest("POST", "/", strings.NewReader("email=user1@email.com"))
+				r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				th.h.resendVerification(w, r)
+				resp := w.Result()
+				defer resp.Body.Close()
+
+				assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+				th.db.AssertExpectations(t)
+				th.es.AssertExpectations(t)
+			})
+		}
+	})
+
+	t.Run("requests are rate limited", func(t *testing.T) {
+		th := setupTestHandlers()
+		th.db.On("QueryRow", dbQuery, "user1@email.com").Return(nil, pgx.ErrNoRows)
+
+		for i := 0; i <= resendVerificationBurst; i++ {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("POST", "/api/v1/user/resendVerification", strings.NewReader("email=user1@email.com"))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			th.h.router.ServeHTTP(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			if i < resendVerificationBurst {
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+			} else {
+				assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+			}
+		}
+		th.db.AssertNumberOfCalls(t, "QueryRow", resendVerificationBurst)
+	})
+}
+
+func TestRequestPasswordReset(t *testing.T) {
+	dbQuery := "select request_password_reset_code($1::text)"
+
+	t.Run("no email provided", func(t *testing.T) {
+		th := setupTestHandlers()
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/", nil)
+		th.h.requestPasswordReset(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("email provided", func(t *testing.T) {
+		testCases := []struct {
+			description         string
+			dbResponse          []interface{}
+			emailSenderResponse error
+			expectedStatusCode  int
+		}{
+			{
+				"email not registered",
+				[]interface{}{nil, pgx.ErrNoRows},
+				nil,
+				http.StatusOK,
+			},
+			{
+				"password reset code sent",
+				[]interface{}{"passwordResetCode", nil},
+				nil,
+				http.StatusOK,
+			},
+			{
+				"error sending password reset code",
+				[]interface{}{"passwordResetCode", nil},
+				errFakeEmailSenderFailure,
+				http.StatusInternalServerError,
+			},
+			{
+				"database error",
+				[]interface{}{nil, errFakeDatabaseFailure},
+				nil,
+				http.StatusInternalServerError,
+			},
+		}
+		for _, tc := range testCases {
+			tc := tc
+			t.Run(tc.description, func(t *testing.T) {
+				th := setupTestHandlers()
+				th.db.On("QueryRow", dbQuery, "user1@email.com").Return(tc.dbResponse...)
+				if tc.dbResponse[1] == nil {
+					th.es.On("SendEmail", mock.MatchedBy(func(data *email.Data) bool {
+						link := "https://hub.test/resetPassword?code=passwordResetCode"
+						return strings.Contains(string(data.Body), link)
+					})).Return(tc.emailSenderResponse)
+				}
+
+				w := httptest.NewRecorder()
+				r, _ := http.NewRequest("POST", "/", strings.NewReader("email=user1@email.com"))
+				r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				r.Host = "attacker.test"
+				th.h.requestPasswordReset(w, r)
+				resp := w.Result()
+				defer resp.Body.Close()
+
+				assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+				th.db.AssertExpectations(t)
+				th.es.AssertExpectations(t)
+			})
+		}
+	})
+
+	t.Run("requests are rate limited per ip address", func(t *testing.T) {
+		th := setupTestHandlers()
+		th.db.On("QueryRow", dbQuery, mock.Anything).Return(nil, pgx.ErrNoRows)
+
+		for i := 0; i <= passwordResetBurst; i++ {
+			w := httptest.NewRecorder()
+			body := fmt.Sprintf("email=user%d@email.com", i)
+			r, _ := http.NewRequest("POST", "/api/v1/user/requestPasswordReset", strings.NewReader(body))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			th.h.router.ServeHTTP(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			if i < passwordResetBurst {
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+			} else {
+				assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+			}
+		}
+		th.db.AssertNumberOfCalls(t, "QueryRow", passwordResetBurst)
+	})
+
+	t.Run("requests are rate limited per email", func(t *testing.T) {
+		th := setupTestHandlers()
+		th.db.On("QueryRow", dbQuery, mock.Anything).Return(nil, pgx.ErrNoRows)
+
+		for i := 0; i <= passwordResetEmailBurst; i++ {
+			w := httptest.NewRecorder()
+			body := "email=user1@email.com"
+			if i%2 == 1 {
+				body = "email=USER1@email.com"
+			}
+			r, _ := http.NewRequest("POST", "/", strings.NewReader(body))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			r.RemoteAddr = fmt.Sprintf("192.0.2.%d:1234", i)
+			th.h.requestPasswordReset(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			if i < passwordResetEmailBurst {
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+			} else {
+				assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+			}
+		}
+		th.db.AssertNumberOfCalls(t, "QueryRow", passwordResetEmailBurst)
+	})
+}
+
+func TestResetPassword(t *testing.T) {
+	dbQuery := "select reset_password($1::uuid, $2::text)"
+
+	t.Run("invalid input", func(t *testing.T) {
+		for _, body := range []string{"", "code=1234", "password=password"} {
+			th := setupTestHandlers()
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("POST", "/", strings.NewReader(body))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			th.h.resetPassword(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+		}
+	})
+
+	t.Run("valid input", func(t *testing.T) {
+		testCases := []struct {
+			description        string
+			dbResponse         []interface{}
+			expectedStatusCode int
+		}{
+			{
+				"code not valid",
+				[]interface{}{false, nil},
+				http.StatusGone,
+			},
+			{
+				"code is not a valid uuid",
+				[]interface{}{false, &pgconn.PgError{Code: invalidTextRepresentationErrCode}},
+				http.StatusBadRequest,
+			},
+			{
+				"password updated",
+				[]interface{}{true, nil},
+				http.StatusOK,
+			},
+			{
+				"database error",
+				[]interface{}{false, errFakeDatabaseFailure},
+				http.StatusInternalServerError,
+			},
+		}
+		for _, tc := range testCases {
+			tc := tc
+			t.Run(tc.description, func(t *testing.T) {
+				th := setupTestHandlers()
+				th.db.On("QueryRow", dbQuery, "1234", mock.Anything).Return(tc.dbResponse...)
+
+				w := httptest.NewRecorder()
+				r, _ := http.NewRequest("POST", "/", strings.NewReader("code=1234&password=password"))
+				r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				th.h.resetPassword(w, r)
+				resp := w.Result()
+				defer resp.Body.Close()
+
+				assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+				th.db.AssertExpectations(t)
+			})
+		}
+	})
+}
+
 func TestLogin(t *testing.T) {
-	dbQuery1 := `select user_id, password from "user" where email = $1`
+	dbQuery1 := `select user_id, coalesce(password, ''), email_verified from "user" where email = $1`
 	dbQuery2 := `select register_session($1::jsonb)`
 
 	t.Run("credentials not provided", func(t *testing.T) {
@@ -455,7 +753,7 @@ func TestLogin(t *testing.T) {
 	t.Run("invalid credentials provided", func(t *testing.T) {
 		th := setupTestHandlers()
 		pw, _ := bcrypt.GenerateFromPassword([]byte("pass"), bcrypt.DefaultCost)
-		th.db.On("QueryRow", dbQuery1, mock.Anything).Return([]interface{}{"userID", string(pw)}, nil)
+		th.db.On("QueryRow", dbQuery1, mock.Anything).Return([]interface{}{"userID", string(pw), true}, nil)
 
 		w := httptest.NewRecorder()
 		r, _ := http.NewRequest("POST", "/", strings.NewReader("email=email&password=pass2"))
@@ -468,10 +766,27 @@ func TestLogin(t *testing.T) {
 		th.db.AssertExpectations(t)
 	})
 
+	t.Run("email not verified", func(t *testing.T) {
+		th := setupTestHandlers()
+		pw, _ := bcrypt.GenerateFromPassword([]byte("pass"), bcrypt.DefaultCost)
+		th.db.On("QueryRow", dbQuery1, mock.Anything).Return([]interface{}{"userID", string(pw), false}, nil)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/", strings.NewReader("email=email&password=pass"))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		th.h.login(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.Empty(t, resp.Cookies())
+		th.db.AssertExpectations(t)
+	})
+
 	t.Run("error registering session", func(t *testing.T) {
 		th := setupTestHandlers()
 		pw, _ := bcrypt.GenerateFromPassword([]byte("pass"), bcrypt.DefaultCost)
-		th.db.On("QueryRow", dbQuery1, mock.Anything).Return([]interface{}{"userID", string(pw)}, nil)
+		th.db.On("QueryRow", dbQuery1, mock.Anything).Return([]interface{}{"userID", string(pw), true}, nil)
 		th.db.On("QueryRow", dbQuery2, mock.Anything).Return(nil, errFakeDatabaseFailure)
 
 		w := httptest.NewRecorder()
@@ -488,7 +803,7 @@ func TestLogin(t *testing.T) {
 	t.Run("login succeeded", func(t *testing.T) {
 		th := setupTestHandlers()
 		pw, _ := bcrypt.GenerateFromPassword([]byte("pass"), bcrypt.DefaultCost)
-		th.db.On("QueryRow", dbQuery1, mock.Anything).Return([]interface{}{"userID", string(pw)}, nil)
+		th.db.On("QueryRow", dbQuery1, mock.Anything).Return([]interface{}{"userID", string(pw), true}, nil)
 		th.db.On("QueryRow", dbQuery2, mock.Anything).Return([]byte("sessionID"), nil)
 
 		w := httptest.NewRecorder()
@@ -511,10 +826,128 @@ func TestLogin(t *testing.T) {
 		assert.Equal(t, []byte("sessionID"), sessionID)
 		th.db.AssertExpectations(t)
 	})
+
+	t.Run("requests are rate limited per ip address", func(t *testing.T) {
+		th := setupTestHandlers()
+		th.db.On("QueryRow", dbQuery1, mock.Anything).Return(nil, pgx.ErrNoRows)
+
+		for i := 0; i <= loginBurst; i++ {
+			w := httptest.NewRecorder()
+			body := fmt.Sprintf("email=user%d@email.com&password=pass", i)
+			r, _ := http.NewRequest("POST", "/api/v1/user/login", strings.NewReader(body))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			th.h.router.ServeHTTP(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			if i < loginBurst {
+				assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+			} else {
+				assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+			}
+		}
+		th.db.AssertNumberOfCalls(t, "QueryRow", loginBurst)
+	})
+
+	t.Run("requests are rate limited per ip address and email", func(t *testing.T) {
+		th := setupTestHandlers()
+		th.db.On("QueryRow", dbQuery1, mock.Anything).Return(nil, pgx.ErrNoRows)
+
+		for i := 0; i <= loginEmailBurst; i++ {
+			w := httptest.NewRecorder()
+			email := "User1@email.com"
+			if i%2 == 1 {
+				email = "user1@email.com"
+			}
+			r, _ := http.NewRequest("POST", "/", strings.NewReader("email="+email+"&password=pass"))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			r.RemoteAddr = "192.0.2.1:1234"
+			th.h.login(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			if i < loginEmailBurst {
+				assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+			} else {
+				assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+			}
+		}
+
+		// Other clients can still log in using the same email
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/", strings.NewReader("email=user1@email.com&password=pass"))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.RemoteAddr = "192.0.2.2:1234"
+		th.h.login(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		th.db.AssertNumberOfCalls(t, "QueryRow", loginEmailBurst+1)
+	})
+}
+
+func TestRealIP(t *testing.T) {
+	testCases := []struct {
+		description string
+		remoteAddr  string
+		headers     map[string]string
+		expectedIP  string
+	}{
+		{
+			"headers are ignored when the request does not come from a trusted proxy",
+			"203.0.113.1:1234",
+			map[string]string{"X-Forwarded-For": "198.51.100.1", "X-Real-IP": "198.51.100.1"},
+			"203.0.113.1",
+		},
+		{
+			"client ip is taken from x-forwarded-for when request comes from a trusted proxy",
+			"10.0.0.1:1234",
+			map[string]string{"X-Forwarded-For": "198.51.100.1"},
+			"198.51.100.1",
+		},
+		{
+			"spoofed x-forwarded-for entries are skipped",
+			"10.0.0.1:1234",
+			map[string]string{"X-Forwarded-For": "192.0.2.1, 198.51.100.1, 10.0.0.2"},
+			"198.51.100.1",
+		},
+		{
+			"invalid x-forwarded-for entries are not used",
+			"10.0.0.1:1234",
+			map[string]string{"X-Forwarded-For": "198.51.100.1, invalid"},
+			"10.0.0.1",
+		},
+		{
+			"client ip is taken from x-real-ip when request comes from a trusted proxy",
+			"192.0.2.10:1234",
+			map[string]string{"X-Real-IP": "198.51.100.1"},
+			"198.51.100.1",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			th := setupTestHandlers()
+			th.h.trustedProxies = parseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.10", "invalid"})
+
+			r, _ := http.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tc.remoteAddr
+			for k, v := range tc.headers {
+				r.Header.Set(k, v)
+			}
+			var ip string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ip = getIP(r)
+			})
+			th.h.realIP(next).ServeHTTP(httptest.NewRecorder(), r)
+
+			assert.Equal(t, tc.expectedIP, ip)
+		})
+	}
 }
 
 func TestLogout(t *testing.T) {
-	dbQuery := "delete from session where session_id = $1"
+	dbQuery := "select delete_session($1::bytea, $2::jsonb)"
 
 	t.Run("invalid or no session cookie provided", func(t *testing.T) {
 		testCases := []struct {
@@ -574,7 +1007,7 @@ func TestLogout(t *testing.T) {
 			tc := tc
 			t.Run(tc.description, func(t *testing.T) {
 				th := setupTestHandlers()
-				th.db.On("Exec", dbQuery, mock.Anything).Return(tc.dbResponse)
+				th.db.On("Exec", dbQuery, []byte("sessionID"), []byte("{}")).Return(tc.dbResponse)
 
 				w := httptest.NewRecorder()
 				r, _ := http.NewRequest("GET", "/", nil)
@@ -598,17 +1031,22 @@ func TestLogout(t *testing.T) {
 	})
 }
 
-func TestGetUserAlias(t *testing.T) {
-	dbQuery := `select alias from "user" where user_id = $1`
+func TestGetUserSessions(t *testing.T) {
+	dbQuery := "select get_user_sessions($1::uuid, $2::bytea)"
 
 	t.Run("database query succeeded", func(t *testing.T) {
 		th := setupTestHandlers()
-		th.db.On("QueryRow", dbQuery, mock.Anything).Return("alias", nil)
+		th.db.On("QueryRow", dbQuery, "userID", []byte("sessionID")).Return([]byte("dataJSON"), nil)
 
 		w := httptest.NewRecorder()
 		r, _ := http.NewRequest("GET", "/", nil)
+		encodedSessionID, _ := th.h.sc.Encode(sessionCookieName, []byte("sessionID"))
+		r.AddCookie(&http.Cookie{
+			Name:  sessionCookieName,
+			Value: encodedSessionID,
+		})
 		r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
-		th.h.getUserAlias(w, r)
+		th.h.getUserSessions(w, r)
 		resp := w.Result()
 		defer resp.Body.Close()
 		h := resp.Header
@@ -617,13 +1055,118 @@ func TestGetUserAlias(t *testing.T) {
 		assert.Equal(t, http.StatusOK, resp.StatusCode)
 		assert.Equal(t, "application/json", h.Get("Content-Type"))
 		assert.Equal(t, buildCacheControlHeader(0), h.Get("Cache-Control"))
-		assert.Equal(t, []byte(`{"alias": "alias"}`), data)
+		assert.Equal(t, []byte("dataJSON"), data)
 		th.db.AssertExpectations(t)
 	})
 
 	t.Run("database error", func(t *testing.T) {
 		th := setupTestHandlers()
-		th.db.On("QueryRow", dbQuery, mock.Anything).Return("", errFakeDatabaseFailure)
+		th.db.On("QueryRow", dbQuery, "userID", mock.Anything).Return(nil, errFakeDatabaseFailure)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/", nil)
+		r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+		th.h.getUserSessions(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		th.db.AssertExpectations(t)
+	})
+}
+
+func TestDeleteUserSession(t *testing.T) {
+	dbQuery := "select delete_user_session($1::uuid, $2::uuid, $3::jsonb)"
+
+	testCases := []struct {
+		description        string
+		dbResponse         interface{}
+		expectedStatusCode int
+	}{
+		{
+			"success",
+			nil,
+			http.StatusOK,
+		},
+		{
+			"invalid session id",
+			&pgconn.PgError{Code: invalidTextRepresentationErrCode},
+			http.StatusBadRequest,
+		},
+		{
+			"database error",
+			errFakeDatabaseFailure,
+			http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			th := setupTestHandlers()
+			th.db.On("Exec", dbQuery, "userID", "sessionID", []byte("{}")).Return(tc.dbResponse)
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("DELETE", "/", nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("sessionID", "sessionID")
+			ctx := context.WithValue(r.Context(), chi.RouteCtxKey, rctx)
+			ctx = context.WithValue(ctx, hub.UserIDKey, "userID")
+			r = r.WithContext(ctx)
+			th.h.deleteUserSession(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+			th.db.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeleteUserSessions(t *testing.T) {
+	dbQuery := "select delete_user_sessions($1::uuid, $2::jsonb)"
+
+	t.Run("sessions deleted successfully", func(t *testing.T) {
+		th := setupTestHandlers()
+		th.db.On("Exec", dbQuery, "userID", []byte("{}")).Return(nil)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("DELETE", "/", nil)
+		r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+		th.h.deleteUserSessions(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Len(t, resp.Cookies(), 1)
+		cookie := resp.Cookies()[0]
+		assert.Equal(t, sessionCookieName, cookie.Name)
+		assert.True(t, cookie.Expires.Before(time.Now().Add(-24*time.Hour)))
+		th.db.AssertExpectations(t)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		th := setupTestHandlers()
+		th.db.On("Exec", dbQuery, "userID", []byte("{}")).Return(errFakeDatabaseFailure)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("DELETE", "/", nil)
+		r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+		th.h.deleteUserSessions(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		assert.Empty(t, resp.Cookies())
+		th.db.AssertExpectations(t)
+	})
+}
+
+func TestGetUserAlias(t *testing.T) {
+	dbQuery := `select alias from "user" where user_id = $1`
+
+	t.Run("database query succeeded", func(t *testing.T) {
+		th := setupTestHandlers()
+		th.db.On("QueryRow", dbQuery, mock.Anything).Return("alias", nil)
 
 		w := httptest.NewRecorder()
 		r, _ := http.NewRequest("GET", "/", nil)
@@ -631,23 +1174,2126 @@ func TestGetUserAlias(t *testing.T) {
 		th.h.getUserAlias(w, r)
 		resp := w.Result()
 		defer resp.Body.Close()
+		h := resp.Header
+		data, _ := ioutil.ReadAll(resp.Body)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/json", h.Get("Content-Type"))
+		assert.Equal(t, buildCacheControlHeader(0), h.Get("Cache-Control"))
+		assert.Equal(t, []byte(`{"alias": "alias"}`), data)
+		th.db.AssertExpectations(t)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		th := setupTestHandlers()
+		th.db.On("QueryRow", dbQuery, mock.Anything).Return("", errFakeDatabaseFailure)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/", nil)
+		r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+		th.h.getUserAlias(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		th.db.AssertExpectations(t)
+	})
+}
+
+func TestGetUserProfile(t *testing.T) {
+	dbQuery := `
+	select json_build_object(
+		'alias', alias,
+		'first_name', first_name,
+		'last_name', last_name,
+		'email', email
+	)
+	from "user" where user_id = $1`
+
+	t.Run("database query succeeded", func(t *testing.T) {
+		th := setupTestHandlers()
+		th.db.On("QueryRow", dbQuery, "userID").Return([]byte("profileJSON"), nil)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/", nil)
+		r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+		th.h.getUserProfile(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+		h := resp.Header
+		data, _ := ioutil.ReadAll(resp.Body)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/json", h.Get("Content-Type"))
+		assert.Equal(t, buildCacheControlHeader(0), h.Get("Cache-Control"))
+		assert.Equal(t, []byte("profileJSON"), data)
+		th.db.AssertExpectations(t)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		th := setupTestHandlers()
+		th.db.On("QueryRow", dbQuery, "userID").Return(nil, errFakeDatabaseFailure)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/", nil)
+		r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+		th.h.getUserProfile(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		th.db.AssertExpectations(t)
+	})
+}
+
+func TestUpdateUserProfile(t *testing.T) {
+	dbQuery := `
+	update "user" set
+		alias = $2,
+		first_name = nullif($3, ''),
+		last_name = nullif($4, '')
+	where user_id = $1`
+
+	t.Run("invalid profile provided", func(t *testing.T) {
+		for _, body := range []string{"", "-", `{"first_name": "first_name"}`} {
+			th := setupTestHandlers()
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("PUT", "/", strings.NewReader(body))
+			r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+			th.h.updateUserProfile(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+		}
+	})
+
+	testCases := []struct {
+		description        string
+		dbResponse         error
+		expectedStatusCode int
+	}{
+		{
+			"profile updated",
+			nil,
+			http.StatusOK,
+		},
+		{
+			"alias not available",
+			&pgconn.PgError{Code: uniqueViolationErrCode},
+			http.StatusConflict,
+		},
+		{
+			"database error",
+			errFakeDatabaseFailure,
+			http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			th := setupTestHandlers()
+			th.db.On("Exec", dbQuery, "userID", "alias", "first_name", "").Return(tc.dbResponse)
+
+			w := httptest.NewRecorder()
+			body := strings.NewReader(`{"alias": "alias", "first_name": "first_name"}`)
+			r, _ := http.NewRequest("PUT", "/", body)
+			r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+			th.h.updateUserProfile(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+			th.db.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateUserPassword(t *testing.T) {
+	dbQueryGet := `select coalesce(password, '') from "user" where user_id = $1`
+	dbQueryUpdate := "select update_user_password($1::uuid, $2::text, $3::bytea)"
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("old"), bcrypt.DefaultCost)
+
+	t.Run("new password not provided", func(t *testing.T) {
+		for _, body := range []string{"", "old=old"} {
+			th := setupTestHandlers()
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("PUT", "/", strings.NewReader(body))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+			th.h.updateUserPassword(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+		}
+	})
+
+	testCases := []struct {
+		description        string
+		currentPassword    string
+		oldPassword        string
+		dbUpdateResponse   error
+		expectedStatusCode int
+	}{
+		{
+			"password updated",
+			string(hashedPassword),
+			"old",
+			nil,
+			http.StatusOK,
+		},
+		{
+			"password set by user without password",
+			"",
+			"",
+			nil,
+			http.StatusOK,
+		},
+		{
+			"old password not provided",
+			string(hashedPassword),
+			"",
+			nil,
+			http.StatusBadRequest,
+		},
+		{
+			"invalid old password",
+			string(hashedPassword),
+			"invalid",
+			nil,
+			http.StatusBadRequest,
+		},
+		{
+			"database error",
+			string(hashedPassword),
+			"old",
+			errFakeDatabaseFailure,
+			http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			th := setupTestHandlers()
+			th.db.On("QueryRow", dbQueryGet, "userID").Return(tc.currentPassword, nil)
+			if tc.expectedStatusCode != http.StatusBadRequest {
+				th.db.On("Exec", dbQueryUpdate, "userID", mock.Anything, []byte("sessionID")).
+					Return(tc.dbUpdateResponse)
+			}
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("PUT", "/", strings.NewReader("old="+tc.oldPassword+"&new=new"))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			encodedSessionID, _ := th.h.sc.Encode(sessionCookieName, []byte("sessionID"))
+			r.AddCookie(&http.Cookie{Name: sessionCookieName, Value: encodedSessionID})
+			r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+			th.h.updateUserPassword(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+			th.db.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateUserEmail(t *testing.T) {
+	dbQueryGet := `select email from "user" where user_id = $1`
+	dbQuery := "select update_user_email($1::uuid, $2::text)"
+
+	t.Run("email not provided", func(t *testing.T) {
+		th := setupTestHandlers()
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("PUT", "/", nil)
+		r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+		th.h.updateUserEmail(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	testCases := []struct {
+		description        string
+		dbResponse         []interface{}
+		expectedStatusCode int
+	}{
+		{
+			"email verification code sent",
+			[]interface{}{"emailVerificationCode", nil},
+			http.StatusOK,
+		},
+		{
+			"email not available",
+			[]interface{}{nil, &pgconn.PgError{Code: uniqueViolationErrCode}},
+			http.StatusConflict,
+		},
+		{
+			"database error",
+			[]interface{}{nil, errFakeDatabaseFailure},
+			http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			th := setupTestHandlers()
+			th.db.On("QueryRow", dbQueryGet, "userID").Return("old@email.com", nil)
+			th.db.On("QueryRow", dbQuery, "userID", "new@email.com").Return(tc.dbResponse...)
+			if tc.expectedStatusCode == http.StatusOK {
+				th.es.On("SendEmail", mock.MatchedBy(func(data *email.Data) bool {
+					link := "https://hub.test/verifyEmail?code=emailVerificationCode"
+					return data.To == "new@email.com" && strings.Contains(string(data.Body), link)
+				})).Return(nil)
+				th.es.On("SendEmail", mock.MatchedBy(func(data *email.Data) bool {
+					return data.To == "old@email.com"
+				})).Return(nil)
+			}
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("PUT", "/", strings.NewReader("email=new@email.com"))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+			th.h.updateUserEmail(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+			th.db.AssertExpectations(t)
+			th.es.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	dbQuery := `delete from "user" where user_id = $1`
+
+	testCases := []struct {
+		description        string
+		dbResponse         error
+		expectedStatusCode int
+	}{
+		{
+			"user deleted",
+			nil,
+			http.StatusOK,
+		},
+		{
+			"user owns chart repositories",
+			&pgconn.PgError{Code: foreignKeyViolationErrCode},
+			http.StatusConflict,
+		},
+		{
+			"database error",
+			errFakeDatabaseFailure,
+			http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			th := setupTestHandlers()
+			th.db.On("Exec", dbQuery, "userID").Return(tc.dbResponse)
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("DELETE", "/", nil)
+			r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+			th.h.deleteUser(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+			if tc.expectedStatusCode == http.StatusOK {
+				cookie := resp.Cookies()[0]
+				assert.Equal(t, sessionCookieName, cookie.Name)
+				assert.True(t, cookie.Expires.Before(time.Now()))
+			}
+			th.db.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetUserNotificationsSettings(t *testing.T) {
+	dbQuery := `
+	select json_build_object('tracking_errors', tracking_errors_notifications)
+	from "user" where user_id = $1`
+
+	t.Run("database query succeeded", func(t *testing.T) {
+		th := setupTestHandlers()
+		th.db.On("QueryRow", dbQuery, "userID").Return([]byte(`{"tracking_errors": true}`), nil)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/", nil)
+		r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+		th.h.getUserNotificationsSettings(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+		h := resp.Header
+		data, _ := ioutil.ReadAll(resp.Body)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/json", h.Get("Content-Type"))
+		assert.Equal(t, buildCacheControlHeader(0), h.Get("Cache-Control"))
+		assert.Equal(t, []byte(`{"tracking_errors": true}`), data)
+		th.db.AssertExpectations(t)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		th := setupTestHandlers()
+		th.db.On("QueryRow", dbQuery, "userID").Return(nil, errFakeDatabaseFailure)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/", nil)
+		r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+		th.h.getUserNotificationsSettings(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		th.db.AssertExpectations(t)
+	})
+}
+
+func TestUpdateUserNotificationsSettings(t *testing.T) {
+	dbQuery := `
+	update "user" set tracking_errors_notifications = $2
+	where user_id = $1`
+
+	t.Run("invalid notifications settings provided", func(t *testing.T) {
+		th := setupTestHandlers()
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("PUT", "/", strings.NewReader("-"))
+		r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+		th.h.updateUserNotificationsSettings(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("valid notifications settings provided", func(t *testing.T) {
+		testCases := []struct {
+			description        string
+			dbResponse         interface{}
+			expectedStatusCode int
+		}{
+			{
+				"success",
+				nil,
+				http.StatusOK,
+			},
+			{
+				"database error",
+				errFakeDatabaseFailure,
+				http.StatusInternalServerError,
+			},
+		}
+		for _, tc := range testCases {
+			tc := tc
+			t.Run(tc.description, func(t *testing.T) {
+				th := setupTestHandlers()
+				th.db.On("Exec", dbQuery, "userID", false).Return(tc.dbResponse)
+
+				w := httptest.NewRecorder()
+				r, _ := http.NewRequest("PUT", "/", strings.NewReader(`{"tracking_errors": false}`))
+				r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+				th.h.updateUserNotificationsSettings(w, r)
+				resp := w.Result()
+				defer resp.Body.Close()
+
+				assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+				th.db.AssertExpectations(t)
+			})
+		}
+	})
+}
+
+func TestGetUserSubscriptions(t *testing.T) {
+	dbQuery := "select get_user_subscriptions($1::uuid)"
+
+	t.Run("database query succeeded", func(t *testing.T) {
+		th := setupTestHandlers()
+		th.db.On("QueryRow", dbQuery, "userID").Return([]byte("dataJSON"), nil)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/", nil)
+		r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+		th.h.getUserSubscriptions(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+		h := resp.Header
+		data, _ := ioutil.ReadAll(resp.Body)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/json", h.Get("Content-Type"))
+		assert.Equal(t, buildCacheControlHeader(0), h.Get("Cache-Control"))
+		assert.Equal(t, []byte("dataJSON"), data)
+		th.db.AssertExpectations(t)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		th := setupTestHandlers()
+		th.db.On("QueryRow", dbQuery, "userID").Return(nil, errFakeDatabaseFailure)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/", nil)
+		r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+		th.h.getUserSubscriptions(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		th.db.AssertExpectations(t)
+	})
+}
+
+func TestAddSubscription(t *testing.T) {
+	dbQuery := "select add_subscription($1::uuid, $2::jsonb)"
+
+	t.Run("invalid subscription provided", func(t *testing.T) {
+		testCases := []struct {
+			description      string
+			subscriptionJSON string
+		}{
+			{
+				"invalid json",
+				"-",
+			},
+			{
+				"missing package id",
+				`{"event_kind": 0}`,
+			},
+			{
+				"invalid event kind",
+				`{"package_id": "packageID", "event_kind": 9}`,
+			},
+		}
+		for _, tc := range testCases {
+			tc := tc
+			t.Run(tc.description, func(t *testing.T) {
+				th := setupTestHandlers()
+
+				w := httptest.NewRecorder()
+				r, _ := http.NewRequest("POST", "/", strings.NewReader(tc.subscriptionJSON))
+				r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+				th.h.addSubscription(w, r)
+				resp := w.Result()
+				defer resp.Body.Close()
+
+				assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			})
+		}
+	})
+
+	t.Run("valid subscription provided", func(t *testing.T) {
+		testCases := []struct {
+			description        string
+			dbResponse         interface{}
+			expectedStatusCode int
+		}{
+			{
+				"success",
+				nil,
+				http.StatusOK,
+			},
+			{
+				"database error",
+				errFakeDatabaseFailure,
+				http.StatusInternalServerError,
+			},
+			{
+				"invalid package id",
+				&pgconn.PgError{Code: invalidTextRepresentationErrCode},
+				http.StatusBadRequest,
+			},
+			{
+				"package not found",
+				&pgconn.PgError{Code: foreignKeyViolationErrCode},
+				http.StatusNotFound,
+			},
+		}
+		for _, tc := range testCases {
+			tc := tc
+			t.Run(tc.description, func(t *testing.T) {
+				th := setupTestHandlers()
+				th.db.On("Exec", dbQuery, "userID", []byte(`{"package_id":"packageID","event_kind":0}`)).Return(tc.dbResponse)
+
+				w := httptest.NewRecorder()
+				r, _ := http.NewRequest("POST", "/", strings.NewReader(`{"package_id": "packageID", "event_kind": 0}`))
+				r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+				th.h.addSubscription(w, r)
+				resp := w.Result()
+				defer resp.Body.Close()
+
+				assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+				th.db.AssertExpectations(t)
+			})
+		}
+	})
+}
+
+func TestDeleteSubscription(t *testing.T) {
+	dbQuery := "select delete_subscription($1::uuid, $2::jsonb)"
+
+	t.Run("invalid subscription provided", func(t *testing.T) {
+		th := setupTestHandlers()
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("DELETE", "/", strings.NewReader(`{"event_kind": 0}`))
+		r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+		th.h.deleteSubscription(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("valid subscription provided", func(t *testing.T) {
+		testCases := []struct {
+			description        string
+			dbResponse         interface{}
+			expectedStatusCode int
+		}{
+			{
+				"success",
+				nil,
+				http.StatusOK,
+			},
+			{
+				"database error",
+				errFakeDatabaseFailure,
+				http.StatusInternalServerError,
+			},
+			{
+				"invalid package id",
+				&pgconn.PgError{Code: invalidTextRepresentationErrCode},
+				http.StatusBadRequest,
+			},
+		}
+		for _, tc := range testCases {
+			tc := tc
+			t.Run(tc.description, func(t *testing.T) {
+				th := setupTestHandlers()
+				th.db.On("Exec", dbQuery, "userID", []byte(`{"package_id":"packageID","event_kind":0}`)).Return(tc.dbResponse)
+
+				w := httptest.NewRecorder()
+				r, _ := http.NewRequest("DELETE", "/", strings.NewReader(`{"package_id": "packageID", "event_kind": 0}`))
+				r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+				th.h.deleteSubscription(w, r)
+				resp := w.Result()
+				defer resp.Body.Close()
+
+				assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+				th.db.AssertExpectations(t)
+			})
+		}
+	})
+}
+
+func TestGetUserAPIKeys(t *testing.T) {
+	dbQuery := "select get_user_api_keys($1::uuid)"
+
+	t.Run("database query succeeded", func(t *testing.T) {
+		th := setupTestHandlers()
+		th.db.On("QueryRow", dbQuery, "userID").Return([]byte("dataJSON"), nil)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/", nil)
+		r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+		th.h.getUserAPIKeys(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+		h := resp.Header
+		data, _ := ioutil.ReadAll(resp.Body)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/json", h.Get("Content-Type"))
+		assert.Equal(t, buildCacheControlHeader(0), h.Get("Cache-Control"))
+		assert.Equal(t, []byte("dataJSON"), data)
+		th.db.AssertExpectations(t)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		th := setupTestHandlers()
+		th.db.On("QueryRow", dbQuery, "userID").Return(nil, errFakeDatabaseFailure)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/", nil)
+		r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+		th.h.getUserAPIKeys(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		th.db.AssertExpectations(t)
+	})
+}
+
+func TestAddAPIKey(t *testing.T) {
+	dbQuery := "select add_api_key($1::uuid, $2::jsonb, $3::jsonb)"
+
+	t.Run("invalid api key provided", func(t *testing.T) {
+		testCases := []struct {
+			description string
+			apiKeyJSON  string
+		}{
+			{
+				"invalid json",
+				"-",
+			},
+			{
+				"missing name",
+				`{"name": ""}`,
+			},
+		}
+		for _, tc := range testCases {
+			tc := tc
+			t.Run(tc.description, func(t *testing.T) {
+				th := setupTestHandlers()
+
+				w := httptest.NewRecorder()
+				r, _ := http.NewRequest("POST", "/", strings.NewReader(tc.apiKeyJSON))
+				r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+				th.h.addAPIKey(w, r)
+				resp := w.Result()
+				defer resp.Body.Close()
+
+				assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			})
+		}
+	})
+
+	t.Run("valid api key provided", func(t *testing.T) {
+		testCases := []struct {
+			description        string
+			dbResponse         interface{}
+			expectedStatusCode int
+		}{
+			{
+				"name already in use",
+				&pgconn.PgError{Code: uniqueViolationErrCode},
+				http.StatusConflict,
+			},
+			{
+				"database error",
+				errFakeDatabaseFailure,
+				http.StatusInternalServerError,
+			},
+		}
+		for _, tc := range testCases {
+			tc := tc
+			t.Run(tc.description, func(t *testing.T) {
+				th := setupTestHandlers()
+				th.db.On("QueryRow", dbQuery, "userID", mock.Anything, []byte("{}")).Return(nil, tc.dbResponse)
+
+				w := httptest.NewRecorder()
+				r, _ := http.NewRequest("POST", "/", strings.NewReader(`{"name": "key1"}`))
+				r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+				th.h.addAPIKey(w, r)
+				resp := w.Result()
+				defer resp.Body.Close()
+
+				assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+				th.db.AssertExpectations(t)
+			})
+		}
+	})
+
+	t.Run("api key added successfully", func(t *testing.T) {
+		th := setupTestHandlers()
+		th.db.On("QueryRow", dbQuery, "userID", mock.Anything, []byte("{}")).Return("apiKeyID", nil)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/", strings.NewReader(`{"name": "key1"}`))
+		r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+		th.h.addAPIKey(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+		data, _ := ioutil.ReadAll(resp.Body)
+		var output *hub.AddAPIKeyOutput
+		_ = json.Unmarshal(data, &output)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "apiKeyID", output.APIKeyID)
+		assert.NotEmpty(t, output.Key)
+		th.db.AssertExpectations(t)
+	})
+}
+
+func TestDeleteAPIKey(t *testing.T) {
+	dbQuery := "select delete_api_key($1::uuid, $2::uuid, $3::jsonb)"
+
+	testCases := []struct {
+		description        string
+		dbResponse         interface{}
+		expectedStatusCode int
+	}{
+		{
+			"success",
+			nil,
+			http.StatusOK,
+		},
+		{
+			"database error",
+			errFakeDatabaseFailure,
+			http.StatusInternalServerError,
+		},
+		{
+			"api key not found",
+			errNoDataFound,
+			http.StatusNotFound,
+		},
+		{
+			"invalid api key id",
+			&pgconn.PgError{Code: invalidTextRepresentationErrCode},
+			http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			th := setupTestHandlers()
+			th.db.On("Exec", dbQuery, "userID", "apiKeyID", []byte("{}")).Return(tc.dbResponse)
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("DELETE", "/", nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("apiKeyID", "apiKeyID")
+			ctx := context.WithValue(r.Context(), chi.RouteCtxKey, rctx)
+			ctx = context.WithValue(ctx, hub.UserIDKey, "userID")
+			r = r.WithContext(ctx)
+			th.h.deleteAPIKey(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+			th.db.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetChartRepositories(t *testing.T) {
+	dbQuery := "select get_chart_repositories_by_user($1)"
+
+	t.Run("valid request", func(t *testing.T) {
+		th := setupTestHandlers()
+		th.db.On("QueryRow", dbQuery, mock.Anything).Return([]byte("userChartRepositoriesJSON"), nil)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/", nil)
+		r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+		th.h.getChartRepositories(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+		h := resp.Header
+		data, _ := ioutil.ReadAll(resp.Body)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/json", h.Get("Content-Type"))
+		assert.Equal(t, buildCacheControlHeader(0), h.Get("Cache-Control"))
+		assert.Equal(t, []byte("userChartRepositoriesJSON"), data)
+		th.db.AssertExpectations(t)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		th := setupTestHandlers()
+		th.db.On("QueryRow", dbQuery, mock.Anything).Return(nil, errFakeDatabaseFailure)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/", nil)
+		r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+		th.h.getChartRepositories(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		th.db.AssertExpectations(t)
+	})
+}
+
+func TestAddChartRepository(t *testing.T) {
+	dbQuery := "select add_chart_repository($1::jsonb, $2::jsonb)"
+
+	t.Run("invalid chart repository provided", func(t *testing.T) {
+		testCases := []struct {
+			description string
+			repoJSON    string
+		}{
+			{
+				"no chart repository provided",
+				"",
+			},
+			{
+				"invalid json",
+				"-",
+			},
+			{
+				"missing name",
+				`{"url": "https://repo1.url"}`,
+			},
+			{
+				"missing url",
+				`{"name": "repo1"}`,
+			},
+			{
+				"invalid kind",
+				`{"name": "repo1", "url": "https://repo1.url", "kind": 5}`,
+			},
+			{
+				"git fields in helm repository",
+				`{"name": "repo1", "url": "https://repo1.url", "git_branch": "main"}`,
+			},
+			{
+				"invalid git path glob",
+				`{"name": "repo1", "url": "https://repo1.url", "kind": 1, "git_path_glob": "charts/["}`,
+			},
+		}
+		for _, tc := range testCases {
+			tc := tc
+			t.Run(tc.description, func(t *testing.T) {
+				th := setupTestHandlers()
+
+				w := httptest.NewRecorder()
+				r, _ := http.NewRequest("POST", "/", strings.NewReader(tc.repoJSON))
+				r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+				th.h.addChartRepository(w, r)
+				resp := w.Result()
+				defer resp.Body.Close()
+
+				assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			})
+		}
+	})
+
+	t.Run("valid chart repository provided", func(t *testing.T) {
+		repoJSON := `
+		{
+			"name": "repo1",
+			"display_name": "Repository 1",
+			"url": "https://repo1.url"
+		}
+		`
+		testCases := []struct {
+			description        string
+			dbResponse         interface{}
+			expectedStatusCode int
+		}{
+			{
+				"success",
+				nil,
+				http.StatusOK,
+			},
+			{
+				"database error",
+				errFakeDatabaseFailure,
+				http.StatusInternalServerError,
+			},
+			{
+				"user is not a member of the organization",
+				&pgconn.PgError{Code: insufficientPrivilegeErrCode},
+				http.StatusForbidden,
+			},
+		}
+		for _, tc := range testCases {
+			tc := tc
+			t.Run(tc.description, func(t *testing.T) {
+				th := setupTestHandlers()
+				th.db.On("Exec", dbQuery, mock.Anything, []byte("{}")).Return(tc.dbResponse)
+
+				w := httptest.NewRecorder()
+				r, _ := http.NewRequest("POST", "/", strings.NewReader(repoJSON))
+				r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+				th.h.addChartRepository(w, r)
+				resp := w.Result()
+				defer resp.Body.Close()
+
+				assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+				th.db.AssertExpectations(t)
+			})
+		}
+	})
+}
+
+func TestGetOrganizationChartRepositories(t *testing.T) {
+	dbQuery := "select get_chart_repositories_by_org($1::uuid, $2::text)"
+
+	t.Run("valid request", func(t *testing.T) {
+		th := setupTestHandlers()
+		th.db.On("QueryRow", dbQuery, "userID", "org1").Return([]byte("orgChartRepositoriesJSON"), nil)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/", nil)
+		r = r.WithContext(newOrgRequestContext(r.Context(), "org1"))
+		th.h.getOrganizationChartRepositories(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+		h := resp.Header
+		data, _ := ioutil.ReadAll(resp.Body)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/json", h.Get("Content-Type"))
+		assert.Equal(t, buildCacheControlHeader(0), h.Get("Cache-Control"))
+		assert.Equal(t, []byte("orgChartRepositoriesJSON"), data)
+		th.db.AssertExpectations(t)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		th := setupTestHandlers()
+		th.db.On("QueryRow", dbQuery, "userID", "org1").Return(nil, errFakeDatabaseFailure)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/", nil)
+		r = r.WithContext(newOrgRequestContext(r.Context(), "org1"))
+		th.h.getOrganizationChartRepositories(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		th.db.AssertExpectations(t)
+	})
+}
+
+func TestUpdateChartRepository(t *testing.T) {
+	dbQuery := "select update_chart_repository($1::jsonb, $2::jsonb)"
+
+	t.Run("invalid chart repository provided", func(t *testing.T) {
+		testCases := []struct {
+			description string
+			repoJSON    string
+		}{
+			{
+				"no chart repository provided",
+				"",
+			},
+			{
+				"invalid json",
+				"-",
+			},
+			{
+				"git fields provided for helm repository",
+				`{"url": "https://repo1.url", "kind": 0, "git_branch": "main"}`,
+			},
+		}
+		for _, tc := range testCases {
+			tc := tc
+			t.Run(tc.description, func(t *testing.T) {
+				th := setupTestHandlers()
+
+				w := httptest.NewRecorder()
+				r, _ := http.NewRequest("PUT", "/", strings.NewReader(tc.repoJSON))
+				r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+				th.h.updateChartRepository(w, r)
+				resp := w.Result()
+				defer resp.Body.Close()
+
+				assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			})
+		}
+	})
+
+	t.Run("kind not provided", func(t *testing.T) {
+		dbQueryRepo := "select get_chart_repository_by_name($1::text)"
+		repoJSON := `{"display_name": "Repository 1 updated", "url": "https://repo1.url/updated"}`
+
+		t.Run("stored repository kind is kept", func(t *testing.T) {
+			th := setupTestHandlers()
+			th.db.On("QueryRow", dbQueryRepo, "repo1").Return([]byte(`{"name": "repo1", "kind": 1}`), nil)
+			th.db.On("Exec", dbQuery, mock.MatchedBy(func(repoJSON []byte) bool {
+				var repo *hub.ChartRepository
+				_ = json.Unmarshal(repoJSON, &repo)
+				return repo != nil && repo.Kind == hub.GitChartRepository
+			}), []byte("{}")).Return(nil)
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("PUT", "/", strings.NewReader(repoJSON))
+			r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("repoName", "repo1")
+			r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+			th.h.updateChartRepository(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			th.db.AssertExpectations(t)
+		})
+
+		t.Run("chart repository not found", func(t *testing.T) {
+			th := setupTestHandlers()
+			th.db.On("QueryRow", dbQueryRepo, "repo1").Return(nil, pgx.ErrNoRows)
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("PUT", "/", strings.NewReader(repoJSON))
+			r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("repoName", "repo1")
+			r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+			th.h.updateChartRepository(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+			th.db.AssertExpectations(t)
+		})
+	})
+
+	t.Run("valid chart repository provided", func(t *testing.T) {
+		repoJSON := `
+		{
+			"display_name": "Repository 1 updated",
+			"url": "https://repo1.url/updated",
+			"kind": 0
+		}
+		`
+		testCases := []struct {
+			description        string
+			dbResponse         interface{}
+			expectedStatusCode int
+		}{
+			{
+				"success",
+				nil,
+				http.StatusOK,
+			},
+			{
+				"database error",
+				errFakeDatabaseFailure,
+				http.StatusInternalServerError,
+			},
+			{
+				"chart repository not found",
+				errNoDataFound,
+				http.StatusNotFound,
+			},
+			{
+				"insufficient privilege",
+				&pgconn.PgError{Code: insufficientPrivilegeErrCode},
+				http.StatusForbidden,
+			},
+		}
+		for _, tc := range testCases {
+			tc := tc
+			t.Run(tc.description, func(t *testing.T) {
+				th := setupTestHandlers()
+				th.db.On("Exec", dbQuery, mock.Anything, []byte("{}")).Return(tc.dbResponse)
+
+				w := httptest.NewRecorder()
+				r, _ := http.NewRequest("PUT", "/", strings.NewReader(repoJSON))
+				r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+				rctx := chi.NewRouteContext()
+				rctx.URLParams.Add("repoName", "repo1")
+				r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+				th.h.updateChartRepository(w, r)
+				resp := w.Result()
+				defer resp.Body.Close()
+
+				assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+				th.db.AssertExpectations(t)
+			})
+		}
+	})
+}
+
+func TestDeleteChartRepository(t *testing.T) {
+	dbQuery := "select delete_chart_repository($1::jsonb, $2::jsonb)"
+
+	testCases := []struct {
+		description        string
+		dbResponse         interface{}
+		expectedStatusCode int
+	}{
+		{
+			"valid request",
+			nil,
+			http.StatusOK,
+		},
+		{
+			"database error",
+			errFakeDatabaseFailure,
+			http.StatusInternalServerError,
+		},
+		{
+			"chart repository not found",
+			errNoDataFound,
+			http.StatusNotFound,
+		},
+		{
+			"insufficient privilege",
+			&pgconn.PgError{Code: insufficientPrivilegeErrCode},
+			http.StatusForbidden,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			th := setupTestHandlers()
+			th.db.On("Exec", dbQuery, mock.Anything, []byte("{}")).Return(tc.dbResponse)
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("DELETE", "/", nil)
+			r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("repoName", "repo1")
+			r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+			th.h.deleteChartRepository(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+			th.db.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTransferChartRepository(t *testing.T) {
+	dbQuery := "select transfer_chart_repository($1::uuid, $2::text, $3::text, $4::jsonb)"
+
+	t.Run("organization name not provided", func(t *testing.T) {
+		th := setupTestHandlers()
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/", nil)
+		r = r.WithContext(newRepoRequestContext(r.Context(), "repo1"))
+		th.h.transferChartRepository(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	testCases := []struct {
+		description        string
+		dbResponse         []interface{}
+		expectedStatusCode int
+	}{
+		{
+			"transfer requested",
+			[]interface{}{"transferID", nil},
+			http.StatusOK,
+		},
+		{
+			"repository or destination organization not found",
+			[]interface{}{nil, pgx.ErrNoRows},
+			http.StatusNotFound,
+		},
+		{
+			"repository already belongs to the organization",
+			[]interface{}{nil, &pgconn.PgError{Code: checkViolationErrCode}},
+			http.StatusConflict,
+		},
+		{
+			"database error",
+			[]interface{}{nil, errFakeDatabaseFailure},
+			http.StatusInternalServerError,
+		},
+		{
+			"user role does not allow transferring the repository",
+			[]interface{}{nil, &pgconn.PgError{Code: insufficientPrivilegeErrCode}},
+			http.StatusForbidden,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			th := setupTestHandlers()
+			th.db.On("QueryRow", dbQuery, "userID", "repo1", "org1", []byte("{}")).Return(tc.dbResponse...)
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("POST", "/", strings.NewReader("orgName=org1"))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			r = r.WithContext(newRepoRequestContext(r.Context(), "repo1"))
+			th.h.transferChartRepository(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+			th.db.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAcceptChartRepositoryTransfer(t *testing.T) {
+	testChartRepositoryTransferAction(t,
+		"select accept_chart_repository_transfer($1::uuid, $2::text, $3::jsonb)",
+		func(th *testHandlers) http.HandlerFunc { return th.h.acceptChartRepositoryTransfer },
+	)
+}
+
+func TestCancelChartRepositoryTransfer(t *testing.T) {
+	testChartRepositoryTransferAction(t,
+		"select cancel_chart_repository_transfer($1::uuid, $2::text, $3::jsonb)",
+		func(th *testHandlers) http.HandlerFunc { return th.h.cancelChartRepositoryTransfer },
+	)
+}
+
+func TestDeclineChartRepositoryTransfer(t *testing.T) {
+	testChartRepositoryTransferAction(t,
+		"select decline_chart_repository_transfer($1::uuid, $2::text, $3::jsonb)",
+		func(th *testHandlers) http.HandlerFunc { return th.h.declineChartRepositoryTransfer },
+	)
+}
+
+func testChartRepositoryTransferAction(
+	t *testing.T,
+	dbQuery string,
+	handler func(th *testHandlers) http.HandlerFunc,
+) {
+	testCases := []struct {
+		description        string
+		dbResponse         interface{}
+		expectedStatusCode int
+	}{
+		{
+			"transfer processed",
+			nil,
+			http.StatusOK,
+		},
+		{
+			"database error",
+			errFakeDatabaseFailure,
+			http.StatusInternalServerError,
+		},
+		{
+			"no pending transfer",
+			errNoDataFound,
+			http.StatusNotFound,
+		},
+		{
+			"user role does not allow processing the transfer",
+			&pgconn.PgError{Code: insufficientPrivilegeErrCode},
+			http.StatusForbidden,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			th := setupTestHandlers()
+			th.db.On("Exec", dbQuery, "userID", "repo1", []byte("{}")).Return(tc.dbResponse)
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("POST", "/", nil)
+			r = r.WithContext(newRepoRequestContext(r.Context(), "repo1"))
+			handler(th)(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+			th.db.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetOrganizationChartRepositoryTransfers(t *testing.T) {
+	dbQuery := "select get_organization_chart_repository_transfers($1::uuid, $2::text)"
+
+	t.Run("valid request", func(t *testing.T) {
+		th := setupTestHandlers()
+		th.db.On("QueryRow", dbQuery, "userID", "org1").Return([]byte("dataJSON"), nil)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/", nil)
+		r = r.WithContext(newOrgRequestContext(r.Context(), "org1"))
+		th.h.getOrganizationChartRepositoryTransfers(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+		h := resp.Header
+		data, _ := ioutil.ReadAll(resp.Body)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/json", h.Get("Content-Type"))
+		assert.Equal(t, buildCacheControlHeader(0), h.Get("Cache-Control"))
+		assert.Equal(t, []byte("dataJSON"), data)
+		th.db.AssertExpectations(t)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		th := setupTestHandlers()
+		th.db.On("QueryRow", dbQuery, "userID", "org1").Return(nil, errFakeDatabaseFailure)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/", nil)
+		r = r.WithContext(newOrgRequestContext(r.Context(), "org1"))
+		th.h.getOrganizationChartRepositoryTransfers(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		th.db.AssertExpectations(t)
+	})
+}
+
+func TestAddTrackingRequest(t *testing.T) {
+	dbQuery := "select add_tracking_request($1::uuid, $2::text)"
+
+	testCases := []struct {
+		description        string
+		dbResponse         []interface{}
+		expectedStatusCode int
+	}{
+		{
+			"tracking request added",
+			[]interface{}{[]byte("trackingRequestJSON"), nil},
+			http.StatusOK,
+		},
+		{
+			"repository not found",
+			[]interface{}{nil, pgx.ErrNoRows},
+			http.StatusNotFound,
+		},
+		{
+			"user role does not allow tracking the repository",
+			[]interface{}{nil, &pgconn.PgError{Code: insufficientPrivilegeErrCode}},
+			http.StatusForbidden,
+		},
+		{
+			"database error",
+			[]interface{}{nil, errFakeDatabaseFailure},
+			http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			th := setupTestHandlers()
+			th.db.On("QueryRow", dbQuery, "userID", "repo1").Return(tc.dbResponse...)
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("POST", "/", nil)
+			r = r.WithContext(newRepoRequestContext(r.Context(), "repo1"))
+			th.h.addTrackingRequest(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+			data, _ := ioutil.ReadAll(resp.Body)
+
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+			if tc.expectedStatusCode == http.StatusOK {
+				assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+				assert.Equal(t, []byte("trackingRequestJSON"), data)
+			}
+			th.db.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetTrackingRequest(t *testing.T) {
+	dbQuery := "select get_tracking_request($1::uuid, $2::text, $3::uuid)"
+
+	testCases := []struct {
+		description        string
+		dbResponse         []interface{}
+		expectedStatusCode int
+	}{
+		{
+			"tracking request found",
+			[]interface{}{[]byte("trackingRequestJSON"), nil},
+			http.StatusOK,
+		},
+		{
+			"tracking request not found",
+			[]interface{}{nil, pgx.ErrNoRows},
+			http.StatusNotFound,
+		},
+		{
+			"invalid tracking request id",
+			[]interface{}{nil, &pgconn.PgError{Code: invalidTextRepresentationErrCode}},
+			http.StatusBadRequest,
+		},
+		{
+			"database error",
+			[]interface{}{nil, errFakeDatabaseFailure},
+			http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			th := setupTestHandlers()
+			th.db.On("QueryRow", dbQuery, "userID", "repo1", "requestID").Return(tc.dbResponse...)
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("GET", "/", nil)
+			rctx := &chi.Context{
+				URLParams: chi.RouteParams{
+					Keys:   []string{"repoName", "trackingRequestID"},
+					Values: []string{"repo1", "requestID"},
+				},
+			}
+			ctx := context.WithValue(r.Context(), hub.UserIDKey, "userID")
+			r = r.WithContext(context.WithValue(ctx, chi.RouteCtxKey, rctx))
+			th.h.getTrackingRequest(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+			th.db.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetTrackingRuns(t *testing.T) {
+	dbQuery := "select get_tracking_runs($1::uuid, $2::text, $3::int, $4::int)"
+
+	t.Run("invalid query parameters", func(t *testing.T) {
+		for _, qs := range []string{"limit=a", "limit=0", "limit=1000", "offset=a", "offset=-1"} {
+			th := setupTestHandlers()
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("GET", "/?"+qs, nil)
+			r = r.WithContext(newRepoRequestContext(r.Context(), "repo1"))
+			th.h.getTrackingRuns(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, qs)
+		}
+	})
+
+	testCases := []struct {
+		description        string
+		dbResponse         []interface{}
+		expectedStatusCode int
+	}{
+		{
+			"tracking runs returned",
+			[]interface{}{[]byte("trackingRunsJSON"), nil},
+			http.StatusOK,
+		},
+		{
+			"repository not found",
+			[]interface{}{nil, pgx.ErrNoRows},
+			http.StatusNotFound,
+		},
+		{
+			"user does not own the repository",
+			[]interface{}{nil, &pgconn.PgError{Code: insufficientPrivilegeErrCode}},
+			http.StatusForbidden,
+		},
+		{
+			"database error",
+			[]interface{}{nil, errFakeDatabaseFailure},
+			http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			th := setupTestHandlers()
+			th.db.On("QueryRow", dbQuery, "userID", "repo1", 5, 10).Return(tc.dbResponse...)
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("GET", "/?limit=5&offset=10", nil)
+			r = r.WithContext(newRepoRequestContext(r.Context(), "repo1"))
+			th.h.getTrackingRuns(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+			data, _ := ioutil.ReadAll(resp.Body)
+
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+			if tc.expectedStatusCode == http.StatusOK {
+				assert.Equal(t, []byte("trackingRunsJSON"), data)
+			}
+			th.db.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetChartRepositoryTrackingErrors(t *testing.T) {
+	dbQuery := "select get_chart_repository_tracking_errors($1::uuid, $2::text, $3::text[])"
+
+	t.Run("invalid kind", func(t *testing.T) {
+		th := setupTestHandlers()
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/?kind=register&kind=invalid", nil)
+		r = r.WithContext(newRepoRequestContext(r.Context(), "repo1"))
+		th.h.getChartRepositoryTrackingErrors(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	testCases := []struct {
+		description        string
+		dbResponse         []interface{}
+		expectedStatusCode int
+	}{
+		{
+			"tracking errors returned",
+			[]interface{}{[]byte("trackingErrorsJSON"), nil},
+			http.StatusOK,
+		},
+		{
+			"repository not found",
+			[]interface{}{nil, pgx.ErrNoRows},
+			http.StatusNotFound,
+		},
+		{
+			"user does not own the repository",
+			[]interface{}{nil, &pgconn.PgError{Code: insufficientPrivilegeErrCode}},
+			http.StatusForbidden,
+		},
+		{
+			"database error",
+			[]interface{}{nil, errFakeDatabaseFailure},
+			http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			th := setupTestHandlers()
+			kinds := []string{"chart-download", "register"}
+			th.db.On("QueryRow", dbQuery, "userID", "repo1", kinds).Return(tc.dbResponse...)
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("GET", "/?kind=chart-download&kind=register", nil)
+			r = r.WithContext(newRepoRequestContext(r.Context(), "repo1"))
+			th.h.getChartRepositoryTrackingErrors(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+			data, _ := ioutil.ReadAll(resp.Body)
+
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+			if tc.expectedStatusCode == http.StatusOK {
+				assert.Equal(t, []byte("trackingErrorsJSON"), data)
+			}
+			th.db.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRotateChartRepositoryWebhookSecret(t *testing.T) {
+	dbQuery := "select rotate_chart_repository_webhook_secret($1::uuid, $2::text, $3::jsonb)"
+
+	testCases := []struct {
+		description        string
+		dbResponse         []interface{}
+		expectedStatusCode int
+	}{
+		{
+			"secret rotated",
+			[]interface{}{"secret", nil},
+			http.StatusOK,
+		},
+		{
+			"repository not found",
+			[]interface{}{nil, pgx.ErrNoRows},
+			http.StatusNotFound,
+		},
+		{
+			"user does not own the repository",
+			[]interface{}{nil, &pgconn.PgError{Code: insufficientPrivilegeErrCode}},
+			http.StatusForbidden,
+		},
+		{
+			"database error",
+			[]interface{}{nil, errFakeDatabaseFailure},
+			http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			th := setupTestHandlers()
+			th.db.On("QueryRow", dbQuery, "userID", "repo1", []byte("{}")).Return(tc.dbResponse...)
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("POST", "/", nil)
+			r = r.WithContext(newRepoRequestContext(r.Context(), "repo1"))
+			th.h.rotateChartRepositoryWebhookSecret(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+			data, _ := ioutil.ReadAll(resp.Body)
+
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+			if tc.expectedStatusCode == http.StatusOK {
+				assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+				assert.JSONEq(t, `{"secret": "secret"}`, string(data))
+			}
+			th.db.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeleteChartRepositoryWebhookSecret(t *testing.T) {
+	dbQuery := "select delete_chart_repository_webhook_secret($1::uuid, $2::text, $3::jsonb)"
+
+	t.Run("secret deleted", func(t *testing.T) {
+		th := setupTestHandlers()
+		th.db.On("Exec", dbQuery, "userID", "repo1", []byte("{}")).Return(nil)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("DELETE", "/", nil)
+		r = r.WithContext(newRepoRequestContext(r.Context(), "repo1"))
+		th.h.deleteChartRepositoryWebhookSecret(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		th.db.AssertExpectations(t)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		th := setupTestHandlers()
+		th.db.On("Exec", dbQuery, "userID", "repo1", []byte("{}")).Return(errFakeDatabaseFailure)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("DELETE", "/", nil)
+		r = r.WithContext(newRepoRequestContext(r.Context(), "repo1"))
+		th.h.deleteChartRepositoryWebhookSecret(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		th.db.AssertExpectations(t)
+	})
+}
+
+func TestGenerateChartRepositoryVerificationToken(t *testing.T) {
+	dbQuery := "select generate_chart_repository_verification_token($1::uuid, $2::text)"
+
+	testCases := []struct {
+		description        string
+		dbResponse         []interface{}
+		expectedStatusCode int
+	}{
+		{
+			"token generated",
+			[]interface{}{"token", nil},
+			http.StatusOK,
+		},
+		{
+			"repository not found",
+			[]interface{}{nil, pgx.ErrNoRows},
+			http.StatusNotFound,
+		},
+		{
+			"insufficient privilege",
+			[]interface{}{nil, &pgconn.PgError{Code: insufficientPrivilegeErrCode}},
+			http.StatusForbidden,
+		},
+		{
+			"database error",
+			[]interface{}{nil, errFakeDatabaseFailure},
+			http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			th := setupTestHandlers()
+			th.db.On("QueryRow", dbQuery, "userID", "repo1").Return(tc.dbResponse...)
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("POST", "/", nil)
+			r = r.WithContext(newRepoRequestContext(r.Context(), "repo1"))
+			th.h.generateChartRepositoryVerificationToken(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+			data, _ := ioutil.ReadAll(resp.Body)
+
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+			if tc.expectedStatusCode == http.StatusOK {
+				assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+				assert.JSONEq(t, `{"token": "token"}`, string(data))
+			}
+			th.db.AssertExpectations(t)
+		})
+	}
+}
+
+func TestChartRepositoryWebhook(t *testing.T) {
+	secretQuery := "select coalesce(webhook_secret, '') from chart_repository where name = $1"
+	addQuery := "select add_chart_version_tracking_request($1::text, $2::text, $3::text)"
+	validPayload := `{"name": "chart1", "version": "1.0.0"}`
+	sign := func(payload string) string {
+		mac := hmac.New(sha256.New, []byte("secret"))
+		_, _ = mac.Write([]byte(payload))
+		return webhookSignaturePrefix + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	testCases := []struct {
+		description        string
+		payload            string
+		signature          string
+		secretResponse     []interface{}
+		addResponse        []interface{}
+		expectedStatusCode int
+	}{
+		{
+			"repository not found",
+			validPayload,
+			sign(validPayload),
+			[]interface{}{nil, pgx.ErrNoRows},
+			nil,
+			http.StatusNotFound,
+		},
+		{
+			"repository has no webhook secret",
+			validPayload,
+			sign(validPayload),
+			[]interface{}{"", nil},
+			nil,
+			http.StatusNotFound,
+		},
+		{
+			"error getting webhook secret",
+			validPayload,
+			sign(validPayload),
+			[]interface{}{nil, errFakeDatabaseFailure},
+			nil,
+			http.StatusInternalServerError,
+		},
+		{
+			"signature not provided",
+			validPayload,
+			"",
+			[]interface{}{"secret", nil},
+			nil,
+			http.StatusUnauthorized,
+		},
+		{
+			"invalid signature",
+			validPayload,
+			sign(`{"name": "chart2", "version": "1.0.0"}`),
+			[]interface{}{"secret", nil},
+			nil,
+			http.StatusUnauthorized,
+		},
+		{
+			"chart version not provided",
+			`{"name": "chart1"}`,
+			sign(`{"name": "chart1"}`),
+			[]interface{}{"secret", nil},
+			nil,
+			http.StatusBadRequest,
+		},
+		{
+			"error adding tracking request",
+			validPayload,
+			sign(validPayload),
+			[]interface{}{"secret", nil},
+			[]interface{}{nil, errFakeDatabaseFailure},
+			http.StatusInternalServerError,
+		},
+		{
+			"tracking request added",
+			validPayload,
+			sign(validPayload),
+			[]interface{}{"secret", nil},
+			[]interface{}{[]byte("trackingRequestJSON"), nil},
+			http.StatusOK,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			th := setupTestHandlers()
+			th.db.On("QueryRow", secretQuery, "repo1").Return(tc.secretResponse...)
+			if tc.addResponse != nil {
+				th.db.On("QueryRow", addQuery, "repo1", "chart1", "1.0.0").Return(tc.addResponse...)
+			}
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("POST", "/", strings.NewReader(tc.payload))
+			r.Header.Set(webhookSignatureHeader, tc.signature)
+			rctx := &chi.Context{
+				URLParams: chi.RouteParams{
+					Keys:   []string{"repoName"},
+					Values: []string{"repo1"},
+				},
+			}
+			r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+			th.h.chartRepositoryWebhook(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+			data, _ := ioutil.ReadAll(resp.Body)
+
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+			if tc.expectedStatusCode == http.StatusOK {
+				assert.Equal(t, []byte("trackingRequestJSON"), data)
+			}
+			th.db.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetChartRepositoryWebhooks(t *testing.T) {
+	dbQuery := "select get_chart_repository_webhooks($1::uuid, $2::text)"
+
+	testCases := []struct {
+		description        string
+		dbResponse         []interface{}
+		expectedStatusCode int
+	}{
+		{
+			"webhooks returned",
+			[]interface{}{[]byte("webhooksJSON"), nil},
+			http.StatusOK,
+		},
+		{
+			"repository not found",
+			[]interface{}{nil, pgx.ErrNoRows},
+			http.StatusNotFound,
+		},
+		{
+			"user does not own the repository",
+			[]interface{}{nil, &pgconn.PgError{Code: insufficientPrivilegeErrCode}},
+			http.StatusForbidden,
+		},
+		{
+			"database error",
+			[]interface{}{nil, errFakeDatabaseFailure},
+			http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			th := setupTestHandlers()
+			th.db.On("QueryRow", dbQuery, "userID", "repo1").Return(tc.dbResponse...)
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("GET", "/", nil)
+			r = r.WithContext(newRepoRequestContext(r.Context(), "repo1"))
+			th.h.getChartRepositoryWebhooks(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+			data, _ := ioutil.ReadAll(resp.Body)
+
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+			if tc.expectedStatusCode == http.StatusOK {
+				assert.Equal(t, []byte("webhooksJSON"), data)
+			}
+			th.db.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAddWebhook(t *testing.T) {
+	dbQuery := "select add_webhook($1::uuid, $2::text, $3::jsonb, $4::jsonb)"
+	whJSON := `{"url": "https://url.test", "secret": "secret", "event_kinds": [0, 1]}`
+
+	t.Run("invalid webhook provided", func(t *testing.T) {
+		testCases := []struct {
+			description string
+			whJSON      string
+		}{
+			{"no webhook provided", ""},
+			{"invalid json", "-"},
+			{"invalid url", `{"url": "ftp://url.test", "secret": "secret", "event_kinds": [0]}`},
+			{"missing host", `{"url": "https://", "secret": "secret", "event_kinds": [0]}`},
+			{"localhost url", `{"url": "http://localhost:8000", "secret": "secret", "event_kinds": [0]}`},
+			{"loopback url", `{"url": "http://127.0.0.1", "secret": "secret", "event_kinds": [0]}`},
+			{"link-local url", `{"url": "http://169.254.169.254/latest", "secret": "secret", "event_kinds": [0]}`},
+			{"missing secret", `{"url": "https://url.test", "event_kinds": [0]}`},
+			{"missing event kinds", `{"url": "https://url.test", "secret": "secret"}`},
+			{"invalid event kind", `{"url": "https://url.test", "secret": "secret", "event_kinds": [9]}`},
+		}
+		for _, tc := range testCases {
+			tc := tc
+			t.Run(tc.description, func(t *testing.T) {
+				th := setupTestHandlers()
+
+				w := httptest.NewRecorder()
+				r, _ := http.NewRequest("POST", "/", strings.NewReader(tc.whJSON))
+				r = r.WithContext(newRepoRequestContext(r.Context(), "repo1"))
+				th.h.addWebhook(w, r)
+				resp := w.Result()
+				defer resp.Body.Close()
+
+				assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			})
+		}
+	})
+
+	testCases := []struct {
+		description        string
+		dbResponse         []interface{}
+		expectedStatusCode int
+	}{
+		{
+			"webhook added",
+			[]interface{}{"webhookID", nil},
+			http.StatusOK,
+		},
+		{
+			"repository not found",
+			[]interface{}{nil, pgx.ErrNoRows},
+			http.StatusNotFound,
+		},
+		{
+			"user does not own the repository",
+			[]interface{}{nil, &pgconn.PgError{Code: insufficientPrivilegeErrCode}},
+			http.StatusForbidden,
+		},
+		{
+			"database error",
+			[]interface{}{nil, errFakeDatabaseFailure},
+			http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			th := setupTestHandlers()
+			th.db.On("QueryRow", dbQuery, "userID", "repo1", mock.Anything, []byte("{}")).Return(tc.dbResponse...)
 
-		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
-		th.db.AssertExpectations(t)
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("POST", "/", strings.NewReader(whJSON))
+			r = r.WithContext(newRepoRequestContext(r.Context(), "repo1"))
+			th.h.addWebhook(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+			data, _ := ioutil.ReadAll(resp.Body)
+
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+			if tc.expectedStatusCode == http.StatusOK {
+				assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+				assert.JSONEq(t, `{"webhook_id": "webhookID"}`, string(data))
+			}
+			th.db.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeleteWebhook(t *testing.T) {
+	dbQuery := "select delete_webhook($1::uuid, $2::text, $3::uuid, $4::jsonb)"
+
+	testCases := []struct {
+		description        string
+		dbResponse         []interface{}
+		expectedStatusCode int
+	}{
+		{
+			"webhook deleted",
+			[]interface{}{"webhookID", nil},
+			http.StatusOK,
+		},
+		{
+			"webhook not found",
+			[]interface{}{nil, pgx.ErrNoRows},
+			http.StatusNotFound,
+		},
+		{
+			"invalid webhook id",
+			[]interface{}{nil, &pgconn.PgError{Code: invalidTextRepresentationErrCode}},
+			http.StatusBadRequest,
+		},
+		{
+			"user does not own the repository",
+			[]interface{}{nil, &pgconn.PgError{Code: insufficientPrivilegeErrCode}},
+			http.StatusForbidden,
+		},
+		{
+			"database error",
+			[]interface{}{nil, errFakeDatabaseFailure},
+			http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			th := setupTestHandlers()
+			th.db.On("QueryRow", dbQuery, "userID", "repo1", "webhookID", []byte("{}")).Return(tc.dbResponse...)
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("DELETE", "/", nil)
+			r = r.WithContext(newWebhookRequestContext(r.Context(), "repo1", "webhookID"))
+			th.h.deleteWebhook(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+			th.db.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetWebhookDeliveries(t *testing.T) {
+	dbQuery := "select get_webhook_deliveries($1::uuid, $2::text, $3::uuid, $4::int, $5::int)"
+
+	t.Run("invalid query parameters", func(t *testing.T) {
+		for _, qs := range []string{"limit=a", "limit=0", "limit=1000", "offset=a", "offset=-1"} {
+			th := setupTestHandlers()
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("GET", "/?"+qs, nil)
+			r = r.WithContext(newWebhookRequestContext(r.Context(), "repo1", "webhookID"))
+			th.h.getWebhookDeliveries(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, qs)
+		}
 	})
+
+	testCases := []struct {
+		description        string
+		dbResponse         []interface{}
+		expectedStatusCode int
+	}{
+		{
+			"webhook deliveries returned",
+			[]interface{}{[]byte("deliveriesJSON"), nil},
+			http.StatusOK,
+		},
+		{
+			"repository not found",
+			[]interface{}{nil, pgx.ErrNoRows},
+			http.StatusNotFound,
+		},
+		{
+			"user does not own the repository",
+			[]interface{}{nil, &pgconn.PgError{Code: insufficientPrivilegeErrCode}},
+			http.StatusForbidden,
+		},
+		{
+			"database error",
+			[]interface{}{nil, errFakeDatabaseFailure},
+			http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			th := setupTestHandlers()
+			th.db.On("QueryRow", dbQuery, "userID", "repo1", "webhookID", 5, 10).Return(tc.dbResponse...)
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("GET", "/?limit=5&offset=10", nil)
+			r = r.WithContext(newWebhookRequestContext(r.Context(), "repo1", "webhookID"))
+			th.h.getWebhookDeliveries(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+			data, _ := ioutil.ReadAll(resp.Body)
+
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+			if tc.expectedStatusCode == http.StatusOK {
+				assert.Equal(t, []byte("deliveriesJSON"), data)
+			}
+			th.db.AssertExpectations(t)
+		})
+	}
 }
 
-func TestGetChartRepositories(t *testing.T) {
-	dbQuery := "select get_chart_repositories_by_user($1)"
+func TestGetUserOrganizations(t *testing.T) {
+	dbQuery := "select get_user_organizations($1::uuid)"
 
 	t.Run("valid request", func(t *testing.T) {
 		th := setupTestHandlers()
-		th.db.On("QueryRow", dbQuery, mock.Anything).Return([]byte("userChartRepositoriesJSON"), nil)
+		th.db.On("QueryRow", dbQuery, "userID").Return([]byte("userOrganizationsJSON"), nil)
 
 		w := httptest.NewRecorder()
 		r, _ := http.NewRequest("GET", "/", nil)
 		r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
-		th.h.getChartRepositories(w, r)
+		th.h.getUserOrganizations(w, r)
 		resp := w.Result()
 		defer resp.Body.Close()
 		h := resp.Header
@@ -655,19 +3301,19 @@ func TestGetChartRepositories(t *testing.T) {
 
 		assert.Equal(t, http.StatusOK, resp.StatusCode)
 		assert.Equal(t, "application/json", h.Get("Content-Type"))
-		assert.Equal(t, buildCacheControlHeader(defaultAPICacheMaxAge), h.Get("Cache-Control"))
-		assert.Equal(t, []byte("userChartRepositoriesJSON"), data)
+		assert.Equal(t, buildCacheControlHeader(0), h.Get("Cache-Control"))
+		assert.Equal(t, []byte("userOrganizationsJSON"), data)
 		th.db.AssertExpectations(t)
 	})
 
 	t.Run("database error", func(t *testing.T) {
 		th := setupTestHandlers()
-		th.db.On("QueryRow", dbQuery, mock.Anything).Return(nil, errFakeDatabaseFailure)
+		th.db.On("QueryRow", dbQuery, "userID").Return(nil, errFakeDatabaseFailure)
 
 		w := httptest.NewRecorder()
 		r, _ := http.NewRequest("GET", "/", nil)
 		r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
-		th.h.getChartRepositories(w, r)
+		th.h.getUserOrganizations(w, r)
 		resp := w.Result()
 		defer resp.Body.Close()
 
@@ -676,16 +3322,16 @@ func TestGetChartRepositories(t *testing.T) {
 	})
 }
 
-func TestAddChartRepository(t *testing.T) {
-	dbQuery := "select add_chart_repository($1::jsonb)"
+func TestAddOrganization(t *testing.T) {
+	dbQuery := "select add_organization($1::uuid, $2::jsonb)"
 
-	t.Run("invalid chart repository provided", func(t *testing.T) {
+	t.Run("invalid organization provided", func(t *testing.T) {
 		testCases := []struct {
 			description string
-			repoJSON    string
+			orgJSON     string
 		}{
 			{
-				"no chart repository provided",
+				"no organization provided",
 				"",
 			},
 			{
@@ -694,11 +3340,7 @@ func TestAddChartRepository(t *testing.T) {
 			},
 			{
 				"missing name",
-				`{"url": "https://repo1.url"}`,
-			},
-			{
-				"missing url",
-				`{"name": "repo1"}`,
+				`{"display_name": "Organization 1"}`,
 			},
 		}
 		for _, tc := range testCases {
@@ -707,9 +3349,9 @@ func TestAddChartRepository(t *testing.T) {
 				th := setupTestHandlers()
 
 				w := httptest.NewRecorder()
-				r, _ := http.NewRequest("POST", "/", strings.NewReader(tc.repoJSON))
+				r, _ := http.NewRequest("POST", "/", strings.NewReader(tc.orgJSON))
 				r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
-				th.h.addChartRepository(w, r)
+				th.h.addOrganization(w, r)
 				resp := w.Result()
 				defer resp.Body.Close()
 
@@ -718,12 +3360,12 @@ func TestAddChartRepository(t *testing.T) {
 		}
 	})
 
-	t.Run("valid chart repository provided", func(t *testing.T) {
-		repoJSON := `
+	t.Run("valid organization provided", func(t *testing.T) {
+		orgJSON := `
 		{
-			"name": "repo1",
-			"display_name": "Repository 1",
-			"url": "https://repo1.url"
+			"name": "org1",
+			"display_name": "Organization 1",
+			"description": "description"
 		}
 		`
 		testCases := []struct {
@@ -746,12 +3388,12 @@ func TestAddChartRepository(t *testing.T) {
 			tc := tc
 			t.Run(tc.description, func(t *testing.T) {
 				th := setupTestHandlers()
-				th.db.On("Exec", dbQuery, mock.Anything).Return(tc.dbResponse)
+				th.db.On("Exec", dbQuery, "userID", mock.Anything).Return(tc.dbResponse)
 
 				w := httptest.NewRecorder()
-				r, _ := http.NewRequest("POST", "/", strings.NewReader(repoJSON))
+				r, _ := http.NewRequest("POST", "/", strings.NewReader(orgJSON))
 				r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
-				th.h.addChartRepository(w, r)
+				th.h.addOrganization(w, r)
 				resp := w.Result()
 				defer resp.Body.Close()
 
@@ -762,60 +3404,510 @@ func TestAddChartRepository(t *testing.T) {
 	})
 }
 
-func TestUpdateChartRepository(t *testing.T) {
-	dbQuery := "select update_chart_repository($1::jsonb)"
+func TestUpdateOrganization(t *testing.T) {
+	dbQuery := "select update_organization($1::uuid, $2::jsonb)"
 
-	t.Run("invalid chart repository provided", func(t *testing.T) {
+	t.Run("invalid organization provided", func(t *testing.T) {
+		th := setupTestHandlers()
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("PUT", "/", strings.NewReader("-"))
+		r = r.WithContext(newOrgRequestContext(r.Context(), "org1"))
+		th.h.updateOrganization(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("valid organization provided", func(t *testing.T) {
+		orgJSON := `{"display_name": "Organization 1 updated"}`
+		expectedOrgJSON := `{"organization_id":"","name":"org1","display_name":"Organization 1 updated","description":"","home_url":"","logo_url":""}`
 		testCases := []struct {
-			description string
-			repoJSON    string
+			description        string
+			dbResponse         interface{}
+			expectedStatusCode int
+		}{
+			{
+				"success",
+				nil,
+				http.StatusOK,
+			},
+			{
+				"database error",
+				errFakeDatabaseFailure,
+				http.StatusInternalServerError,
+			},
+		}
+		for _, tc := range testCases {
+			tc := tc
+			t.Run(tc.description, func(t *testing.T) {
+				th := setupTestHandlers()
+				th.db.On("Exec", dbQuery, "userID", []byte(expectedOrgJSON)).Return(tc.dbResponse)
+
+				w := httptest.NewRecorder()
+				r, _ := http.NewRequest("PUT", "/", strings.NewReader(orgJSON))
+				r = r.WithContext(newOrgRequestContext(r.Context(), "org1"))
+				th.h.updateOrganization(w, r)
+				resp := w.Result()
+				defer resp.Body.Close()
+
+				assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+				th.db.AssertExpectations(t)
+			})
+		}
+	})
+}
+
+func TestDeleteOrganization(t *testing.T) {
+	dbQuery := "select delete_organization($1::uuid, $2::text)"
+
+	t.Run("valid request", func(t *testing.T) {
+		th := setupTestHandlers()
+		th.db.On("Exec", dbQuery, "userID", "org1").Return(nil)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("DELETE", "/", nil)
+		r = r.WithContext(newOrgRequestContext(r.Context(), "org1"))
+		th.h.deleteOrganization(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		th.db.AssertExpectations(t)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		th := setupTestHandlers()
+		th.db.On("Exec", dbQuery, "userID", "org1").Return(errFakeDatabaseFailure)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("DELETE", "/", nil)
+		r = r.WithContext(newOrgRequestContext(r.Context(), "org1"))
+		th.h.deleteOrganization(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		th.db.AssertExpectations(t)
+	})
+}
+
+func TestGetOrganizationMembers(t *testing.T) {
+	dbQuery := "select get_organization_members($1::uuid, $2::text)"
+
+	t.Run("valid request", func(t *testing.T) {
+		th := setupTestHandlers()
+		th.db.On("QueryRow", dbQuery, "userID", "org1").Return([]byte("organizationMembersJSON"), nil)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/", nil)
+		r = r.WithContext(newOrgRequestContext(r.Context(), "org1"))
+		th.h.getOrganizationMembers(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+		h := resp.Header
+		data, _ := ioutil.ReadAll(resp.Body)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/json", h.Get("Content-Type"))
+		assert.Equal(t, buildCacheControlHeader(0), h.Get("Cache-Control"))
+		assert.Equal(t, []byte("organizationMembersJSON"), data)
+		th.db.AssertExpectations(t)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		th := setupTestHandlers()
+		th.db.On("QueryRow", dbQuery, "userID", "org1").Return(nil, errFakeDatabaseFailure)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/", nil)
+		r = r.WithContext(newOrgRequestContext(r.Context(), "org1"))
+		th.h.getOrganizationMembers(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		th.db.AssertExpectations(t)
+	})
+}
+
+func TestAddOrganizationMember(t *testing.T) {
+	dbQuery := "select add_organization_member($1::uuid, $2::text, $3::text, $4::jsonb)"
+
+	testCases := []struct {
+		description        string
+		dbResponse         interface{}
+		expectedStatusCode int
+	}{
+		{
+			"success",
+			nil,
+			http.StatusOK,
+		},
+		{
+			"database error",
+			errFakeDatabaseFailure,
+			http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			th := setupTestHandlers()
+			th.db.On("Exec", dbQuery, "userID", "org1", "user1", []byte("{}")).Return(tc.dbResponse)
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("POST", "/", nil)
+			rctx := &chi.Context{
+				URLParams: chi.RouteParams{
+					Keys:   []string{"orgName", "userAlias"},
+					Values: []string{"org1", "user1"},
+				},
+			}
+			ctx := context.WithValue(r.Context(), hub.UserIDKey, "userID")
+			r = r.WithContext(context.WithValue(ctx, chi.RouteCtxKey, rctx))
+			th.h.addOrganizationMember(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+			th.db.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeleteOrganizationMember(t *testing.T) {
+	dbQuery := "select delete_organization_member($1::uuid, $2::text, $3::text, $4::jsonb)"
+
+	testCases := []struct {
+		description        string
+		dbResponse         interface{}
+		expectedStatusCode int
+	}{
+		{
+			"success",
+			nil,
+			http.StatusOK,
+		},
+		{
+			"database error",
+			errFakeDatabaseFailure,
+			http.StatusInternalServerError,
+		},
+		{
+			"last owner cannot be removed",
+			&pgconn.PgError{Code: checkViolationErrCode},
+			http.StatusConflict,
+		},
+		{
+			"organization not found",
+			errNoDataFound,
+			http.StatusNotFound,
+		},
+		{
+			"user role does not allow managing members",
+			&pgconn.PgError{Code: insufficientPrivilegeErrCode},
+			http.StatusForbidden,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			th := setupTestHandlers()
+			th.db.On("Exec", dbQuery, "userID", "org1", "user1", []byte("{}")).Return(tc.dbResponse)
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("DELETE", "/", nil)
+			rctx := &chi.Context{
+				URLParams: chi.RouteParams{
+					Keys:   []string{"orgName", "userAlias"},
+					Values: []string{"org1", "user1"},
+				},
+			}
+			ctx := context.WithValue(r.Context(), hub.UserIDKey, "userID")
+			r = r.WithContext(context.WithValue(ctx, chi.RouteCtxKey, rctx))
+			th.h.deleteOrganizationMember(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+			th.db.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateOrganizationMemberRole(t *testing.T) {
+	dbQuery := "select update_organization_member_role($1::uuid, $2::text, $3::text, $4::text, $5::jsonb)"
+
+	newRequest := func(body string) *http.Request {
+		r, _ := http.NewRequest("PUT", "/", strings.NewReader(body))
+		rctx := &chi.Context{
+			URLParams: chi.RouteParams{
+				Keys:   []string{"orgName", "userAlias"},
+				Values: []string{"org1", "user1"},
+			},
+		}
+		ctx := context.WithValue(r.Context(), hub.UserIDKey, "userID")
+		return r.WithContext(context.WithValue(ctx, chi.RouteCtxKey, rctx))
+	}
+
+	t.Run("invalid role provided", func(t *testing.T) {
+		testCases := []string{
+			"",
+			"-",
+			`{"role": ""}`,
+			`{"role": "superuser"}`,
+		}
+		for _, body := range testCases {
+			body := body
+			t.Run(body, func(t *testing.T) {
+				th := setupTestHandlers()
+
+				w := httptest.NewRecorder()
+				th.h.updateOrganizationMemberRole(w, newRequest(body))
+				resp := w.Result()
+				defer resp.Body.Close()
+
+				assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			})
+		}
+	})
+
+	t.Run("valid role provided", func(t *testing.T) {
+		testCases := []struct {
+			description        string
+			dbResponse         interface{}
+			expectedStatusCode int
 		}{
 			{
-				"no chart repository provided",
-				"",
+				"success",
+				nil,
+				http.StatusOK,
+			},
+			{
+				"database error",
+				errFakeDatabaseFailure,
+				http.StatusInternalServerError,
+			},
+			{
+				"last owner cannot be demoted",
+				&pgconn.PgError{Code: checkViolationErrCode},
+				http.StatusConflict,
 			},
 			{
-				"invalid json",
-				"-",
+				"organization not found",
+				errNoDataFound,
+				http.StatusNotFound,
+			},
+			{
+				"user role does not allow managing members",
+				&pgconn.PgError{Code: insufficientPrivilegeErrCode},
+				http.StatusForbidden,
 			},
 		}
 		for _, tc := range testCases {
 			tc := tc
 			t.Run(tc.description, func(t *testing.T) {
 				th := setupTestHandlers()
+				th.db.On("Exec", dbQuery, "userID", "org1", "user1", "admin", []byte("{}")).Return(tc.dbResponse)
 
 				w := httptest.NewRecorder()
-				r, _ := http.NewRequest("PUT", "/", strings.NewReader(tc.repoJSON))
-				r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
-				th.h.updateChartRepository(w, r)
+				th.h.updateOrganizationMemberRole(w, newRequest(`{"role": "admin"}`))
 				resp := w.Result()
 				defer resp.Body.Close()
 
-				assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+				assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+				th.db.AssertExpectations(t)
 			})
 		}
 	})
+}
 
-	t.Run("valid chart repository provided", func(t *testing.T) {
-		repoJSON := `
+func TestAddOrganizationInvitation(t *testing.T) {
+	dbQuery := "select add_organization_invitation($1::uuid, $2::text, $3::text, $4::jsonb)"
+
+	t.Run("email not provided", func(t *testing.T) {
+		th := setupTestHandlers()
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/", nil)
+		r = r.WithContext(newOrgRequestContext(r.Context(), "org1"))
+		th.h.addOrganizationInvitation(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	testCases := []struct {
+		description         string
+		dbResponse          []interface{}
+		emailSenderResponse error
+		expectedStatusCode  int
+	}{
 		{
-			"display_name": "Repository 1 updated",
-			"url": "https://repo1.url/updated"
-		}
-		`
+			"invitation sent",
+			[]interface{}{"invitationToken", nil},
+			nil,
+			http.StatusAccepted,
+		},
+		{
+			"error sending invitation",
+			[]interface{}{"invitationToken", nil},
+			errFakeEmailSenderFailure,
+			http.StatusInternalServerError,
+		},
+		{
+			"email already belongs to a member",
+			[]interface{}{nil, pgx.ErrNoRows},
+			nil,
+			http.StatusAccepted,
+		},
+		{
+			"database error",
+			[]interface{}{nil, errFakeDatabaseFailure},
+			nil,
+			http.StatusInternalServerError,
+		},
+		{
+			"organization not found",
+			[]interface{}{nil, errNoDataFound},
+			nil,
+			http.StatusNotFound,
+		},
+		{
+			"user role does not allow managing members",
+			[]interface{}{nil, &pgconn.PgError{Code: insufficientPrivilegeErrCode}},
+			nil,
+			http.StatusForbidden,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			th := setupTestHandlers()
+			th.db.On("QueryRow", dbQuery, "userID", "org1", "user1@email.com", []byte("{}")).Return(tc.dbResponse...)
+			if tc.dbResponse[1] == nil {
+				th.es.On("SendEmail", mock.MatchedBy(func(data *email.Data) bool {
+					return data.To == "user1@email.com" &&
+						strings.Contains(string(data.Body), "https://hub.test/acceptInvitation?token=invitationToken")
+				})).Return(tc.emailSenderResponse)
+			}
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("POST", "/", strings.NewReader("email=user1@email.com"))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			r.Host = "attacker.test"
+			r = r.WithContext(newOrgRequestContext(r.Context(), "org1"))
+			th.h.addOrganizationInvitation(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+			th.db.AssertExpectations(t)
+			th.es.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetUserOrganizationInvitations(t *testing.T) {
+	dbQuery := "select get_user_organization_invitations($1::uuid)"
+
+	t.Run("invitations returned", func(t *testing.T) {
+		th := setupTestHandlers()
+		th.db.On("QueryRow", dbQuery, "userID").Return([]byte(`[{"organization_name": "org1", "token": "invitationToken"}]`), nil)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/", nil)
+		r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+		th.h.getUserOrganizationInvitations(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+		data, _ := ioutil.ReadAll(resp.Body)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+		var invitations []*hub.OrganizationInvitation
+		require.NoError(t, json.Unmarshal(data, &invitations))
+		assert.Equal(t, []*hub.OrganizationInvitation{
+			{OrganizationName: "org1", Token: "invitationToken"},
+		}, invitations)
+		th.db.AssertExpectations(t)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		th := setupTestHandlers()
+		th.db.On("QueryRow", dbQuery, "userID").Return(nil, errFakeDatabaseFailure)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/", nil)
+		r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+		th.h.getUserOrganizationInvitations(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		th.db.AssertExpectations(t)
+	})
+}
+
+func TestAcceptOrganizationInvitation(t *testing.T) {
+	testOrganizationInvitationResponse(t, "select accept_organization_invitation($1::uuid, $2::uuid, $3::jsonb)",
+		func(th *testHandlers) http.HandlerFunc { return th.h.acceptOrganizationInvitation })
+}
+
+func TestDeclineOrganizationInvitation(t *testing.T) {
+	testOrganizationInvitationResponse(t, "select decline_organization_invitation($1::uuid, $2::uuid, $3::jsonb)",
+		func(th *testHandlers) http.HandlerFunc { return th.h.declineOrganizationInvitation })
+}
+
+func testOrganizationInvitationResponse(
+	t *testing.T,
+	dbQuery string,
+	handler func(th *testHandlers) http.HandlerFunc,
+) {
+	newRequest := func(token string) *http.Request {
+		r, _ := http.NewRequest("POST", "/", strings.NewReader("token="+token))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+	}
+
+	t.Run("token not provided", func(t *testing.T) {
+		th := setupTestHandlers()
+
+		w := httptest.NewRecorder()
+		handler(th)(w, newRequest(""))
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("token provided", func(t *testing.T) {
 		testCases := []struct {
 			description        string
-			dbResponse         interface{}
+			dbResponse         []interface{}
 			expectedStatusCode int
 		}{
 			{
-				"success",
-				nil,
+				"invitation processed",
+				[]interface{}{true, nil},
 				http.StatusOK,
 			},
+			{
+				"invitation not found or expired",
+				[]interface{}{false, nil},
+				http.StatusGone,
+			},
+			{
+				"malformed token",
+				[]interface{}{false, &pgconn.PgError{Code: invalidTextRepresentationErrCode}},
+				http.StatusBadRequest,
+			},
 			{
 				"database error",
-				errFakeDatabaseFailure,
+				[]interface{}{false, errFakeDatabaseFailure},
 				http.StatusInternalServerError,
 			},
 		}
@@ -823,12 +3915,10 @@ func TestUpdateChartRepository(t *testing.T) {
 			tc := tc
 			t.Run(tc.description, func(t *testing.T) {
 				th := setupTestHandlers()
-				th.db.On("Exec", dbQuery, mock.Anything).Return(tc.dbResponse)
+				th.db.On("QueryRow", dbQuery, "userID", "invitationToken", []byte("{}")).Return(tc.dbResponse...)
 
 				w := httptest.NewRecorder()
-				r, _ := http.NewRequest("PUT", "/", strings.NewReader(repoJSON))
-				r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
-				th.h.updateChartRepository(w, r)
+				handler(th)(w, newRequest("invitationToken"))
 				resp := w.Result()
 				defer resp.Body.Close()
 
@@ -839,17 +3929,52 @@ func TestUpdateChartRepository(t *testing.T) {
 	})
 }
 
-func TestDeleteChartRepository(t *testing.T) {
-	dbQuery := "select delete_chart_repository($1::jsonb)"
+func TestGetAuditEvents(t *testing.T) {
+	dbQuery := "select get_audit_events($1::uuid, $2::int, $3::int)"
 
-	t.Run("valid request", func(t *testing.T) {
+	t.Run("invalid query parameters", func(t *testing.T) {
+		for _, qs := range []string{"limit=a", "limit=0", "limit=1000", "offset=a", "offset=-1"} {
+			th := setupTestHandlers()
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("GET", "/?"+qs, nil)
+			r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+			th.h.getAuditEvents(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, qs)
+		}
+	})
+
+	t.Run("audit events returned", func(t *testing.T) {
 		th := setupTestHandlers()
-		th.db.On("Exec", dbQuery, mock.Anything).Return(nil)
+		th.db.On("QueryRow", dbQuery, "userID", 5, 10).Return([]byte("auditEventsJSON"), nil)
 
 		w := httptest.NewRecorder()
-		r, _ := http.NewRequest("DELETE", "/", nil)
+		r, _ := http.NewRequest("GET", "/?limit=5&offset=10", nil)
+		r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
+		th.h.getAuditEvents(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+		h := resp.Header
+		data, _ := ioutil.ReadAll(resp.Body)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/json", h.Get("Content-Type"))
+		assert.Equal(t, buildCacheControlHeader(0), h.Get("Cache-Control"))
+		assert.Equal(t, []byte("auditEventsJSON"), data)
+		th.db.AssertExpectations(t)
+	})
+
+	t.Run("default pagination used", func(t *testing.T) {
+		th := setupTestHandlers()
+		th.db.On("QueryRow", dbQuery, "userID", defaultAuditEventsLimit, 0).Return([]byte("auditEventsJSON"), nil)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/", nil)
 		r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
-		th.h.deleteChartRepository(w, r)
+		th.h.getAuditEvents(w, r)
 		resp := w.Result()
 		defer resp.Body.Close()
 
@@ -859,12 +3984,12 @@ func TestDeleteChartRepository(t *testing.T) {
 
 	t.Run("database error", func(t *testing.T) {
 		th := setupTestHandlers()
-		th.db.On("Exec", dbQuery, mock.Anything).Return(errFakeDatabaseFailure)
+		th.db.On("QueryRow", dbQuery, "userID", 5, 10).Return(nil, errFakeDatabaseFailure)
 
 		w := httptest.NewRecorder()
-		r, _ := http.NewRequest("DELETE", "/", nil)
+		r, _ := http.NewRequest("GET", "/?limit=5&offset=10", nil)
 		r = r.WithContext(context.WithValue(r.Context(), hub.UserIDKey, "userID"))
-		th.h.deleteChartRepository(w, r)
+		th.h.getAuditEvents(w, r)
 		resp := w.Result()
 		defer resp.Body.Close()
 
@@ -873,10 +3998,30 @@ func TestDeleteChartRepository(t *testing.T) {
 	})
 }
 
+func TestInjectRequestInfo(t *testing.T) {
+	var ri *hub.RequestInfo
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ri, _ = r.Context().Value(hub.RequestInfoKey).(*hub.RequestInfo)
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "192.168.1.100:12345"
+	r.Header.Set("User-Agent", "Safari 13.0.5")
+	injectRequestInfo(next).ServeHTTP(w, r)
+
+	require.NotNil(t, ri)
+	assert.Equal(t, "192.168.1.100", ri.IP)
+	assert.Equal(t, "Safari 13.0.5", ri.UserAgent)
+}
+
 func TestRequireLogin(t *testing.T) {
 	dbQuery := `
-	select user_id, floor(extract(epoch from created_at))
-	from session where session_id = $1
+	select s.user_id, floor(extract(epoch from s.last_seen_at))
+	from session s
+	join "user" u using (user_id)
+	where s.session_id = $1
+	and u.email_verified = true
 	`
 
 	t.Run("session cookie not provided", func(t *testing.T) {
@@ -963,9 +4108,161 @@ func TestRequireLogin(t *testing.T) {
 		resp := w.Result()
 		defer resp.Body.Close()
 
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Empty(t, resp.Cookies())
+		th.db.AssertExpectations(t)
+	})
+
+	t.Run("session cookie not issued recently is re-issued", func(t *testing.T) {
+		th := setupTestHandlers()
+		th.db.On("QueryRow", dbQuery, mock.Anything).Return([]interface{}{
+			"userID",
+			time.Now().Unix(),
+		}, nil)
+		th.h.scFresh = securecookie.New([]byte(th.cfg.GetString("server.cookie.hashKey")), nil)
+		th.h.scFresh.MinAge(int(sessionCookieRefreshInterval.Seconds()))
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/", nil)
+		encodedSessionID, _ := th.h.sc.Encode(sessionCookieName, []byte("sessionID"))
+		r.AddCookie(&http.Cookie{
+			Name:  sessionCookieName,
+			Value: encodedSessionID,
+		})
+		th.h.requireLogin(http.HandlerFunc(th.h.serveIndex)).ServeHTTP(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Len(t, resp.Cookies(), 1)
+		cookie := resp.Cookies()[0]
+		assert.Equal(t, sessionCookieName, cookie.Name)
+		assert.True(t, cookie.Expires.After(time.Now().Add(sessionDuration-time.Hour)))
+		var sessionID []byte
+		err := th.h.sc.Decode(sessionCookieName, cookie.Value, &sessionID)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("sessionID"), sessionID)
+		th.db.AssertExpectations(t)
+	})
+
+	t.Run("api keys are not accepted", func(t *testing.T) {
+		th := setupTestHandlers()
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/", nil)
+		r.Header.Set(apiKeyHeader, "key")
+		th.h.requireLogin(http.HandlerFunc(th.h.serveIndex)).ServeHTTP(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		th.db.AssertNotCalled(t, "QueryRow", mock.Anything, mock.Anything)
+	})
+}
+
+func TestRequireLoginOrAPIKey(t *testing.T) {
+	dbQuerySession := `
+	select s.user_id, floor(extract(epoch from s.last_seen_at))
+	from session s
+	join "user" u using (user_id)
+	where s.session_id = $1
+	and u.email_verified = true
+	`
+	dbQuery := `
+	select k.user_id
+	from api_key k
+	join "user" u using (user_id)
+	where k.key_hash = $1
+	and u.email_verified = true
+	`
+
+	t.Run("session cookie used when no api key is provided", func(t *testing.T) {
+		th := setupTestHandlers()
+		th.db.On("QueryRow", dbQuerySession, mock.Anything).Return([]interface{}{
+			"userID",
+			time.Now().Unix(),
+		}, nil)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/", nil)
+		encodedSessionID, _ := th.h.sc.Encode(sessionCookieName, []byte("sessionID"))
+		r.AddCookie(&http.Cookie{
+			Name:  sessionCookieName,
+			Value: encodedSessionID,
+		})
+		th.h.requireLoginOrAPIKey(http.HandlerFunc(th.h.serveIndex)).ServeHTTP(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
 		assert.Equal(t, http.StatusOK, resp.StatusCode)
 		th.db.AssertExpectations(t)
 	})
+
+	t.Run("api key provided", func(t *testing.T) {
+		hash := sha256.Sum256([]byte("key"))
+		keyHash := hex.EncodeToString(hash[:])
+
+		testCases := []struct {
+			description        string
+			header             string
+			value              string
+			dbResponse         []interface{}
+			expectedStatusCode int
+		}{
+			{
+				"invalid api key provided (bearer)",
+				"Authorization",
+				"Bearer key",
+				[]interface{}{nil, pgx.ErrNoRows},
+				http.StatusUnauthorized,
+			},
+			{
+				"error checking api key",
+				"X-API-Key",
+				"key",
+				[]interface{}{nil, errFakeDatabaseFailure},
+				http.StatusInternalServerError,
+			},
+			{
+				"valid api key provided (bearer)",
+				"Authorization",
+				"bearer key",
+				[]interface{}{"userID", nil},
+				http.StatusOK,
+			},
+			{
+				"valid api key provided (x-api-key)",
+				"X-API-Key",
+				"key",
+				[]interface{}{"userID", nil},
+				http.StatusOK,
+			},
+		}
+		for _, tc := range testCases {
+			tc := tc
+			t.Run(tc.description, func(t *testing.T) {
+				th := setupTestHandlers()
+				th.db.On("QueryRow", dbQuery, keyHash).Return(tc.dbResponse...)
+
+				w := httptest.NewRecorder()
+				r, _ := http.NewRequest("GET", "/", nil)
+				r.Header.Set(tc.header, tc.value)
+				var userID interface{}
+				next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					userID = r.Context().Value(hub.UserIDKey)
+				})
+				th.h.requireLoginOrAPIKey(next).ServeHTTP(w, r)
+				resp := w.Result()
+				defer resp.Body.Close()
+
+				assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+				if tc.expectedStatusCode == http.StatusOK {
+					assert.Equal(t, "userID", userID)
+				}
+				th.db.AssertExpectations(t)
+			})
+		}
+	})
 }
 
 func TestImage(t *testing.T) {
@@ -1095,6 +4392,11 @@ func TestCheckAvailability(t *testing.T) {
 					`select chart_repository_id from chart_repository where url = $1`,
 					false,
 				},
+				{
+					"organizationName",
+					`select organization_id from organization where name = $1`,
+					true,
+				},
 			}
 			for _, tc := range testCases {
 				tc := tc
@@ -1188,6 +4490,7 @@ type testHandlers struct {
 func setupTestHandlers() *testHandlers {
 	cfg := viper.New()
 	cfg.Set("server.webBuildPath", "testdata")
+	cfg.Set("server.baseURL", "https://hub.test/")
 	db := &tests.DBMock{}
 	es := &tests.EmailSenderMock{}
 	hubAPI := hub.New(db, es)
@@ -1202,5 +4505,41 @@ func setupTestHandlers() *testHandlers {
 }
 
 func buildCacheControlHeader(cacheMaxAge time.Duration) string {
+	if cacheMaxAge == 0 {
+		return "no-store"
+	}
 	return fmt.Sprintf("max-age=%d", int64(cacheMaxAge.Seconds()))
 }
+
+func newOrgRequestContext(parent context.Context, orgName string) context.Context {
+	rctx := &chi.Context{
+		URLParams: chi.RouteParams{
+			Keys:   []string{"orgName"},
+			Values: []string{orgName},
+		},
+	}
+	ctx := context.WithValue(parent, hub.UserIDKey, "userID")
+	return context.WithValue(ctx, chi.RouteCtxKey, rctx)
+}
+
+func newRepoRequestContext(parent context.Context, repoName string) context.Context {
+	rctx := &chi.Context{
+		URLParams: chi.RouteParams{
+			Keys:   []string{"repoName"},
+			Values: []string{repoName},
+		},
+	}
+	ctx := context.WithValue(parent, hub.UserIDKey, "userID")
+	return context.WithValue(ctx, chi.RouteCtxKey, rctx)
+}
+
+func newWebhookRequestContext(parent context.Context, repoName, webhookID string) context.Context {
+	rctx := &chi.Context{
+		URLParams: chi.RouteParams{
+			Keys:   []string{"repoName", "webhookID"},
+			Values: []string{repoName, webhookID},
+		},
+	}
+	ctx := context.WithValue(parent, hub.UserIDKey, "userID")
+	return context.WithValue(ctx, chi.RouteCtxKey, rctx)
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -54,11 +55,25 @@ func main() {
 	}()
 	log.Info().Str("addr", addr).Int("pid", os.Getpid()).Msg("Hub server running!")
 
+	// Launch webhooks dispatcher and sessions purger, as well as the
+	// notifications dispatcher when an email sender is available
+	var wg sync.WaitGroup
+	dispatcherCtx, stopDispatcher := context.WithCancel(context.Background())
+	wg.Add(2)
+	go newWebhooksDispatcher(cfg, hubAPI).run(dispatcherCtx, &wg)
+	go newSessionsPurger(cfg, hubAPI).run(dispatcherCtx, &wg)
+	if es != nil {
+		wg.Add(1)
+		go newNotificationsDispatcher(cfg, hubAPI).run(dispatcherCtx, &wg)
+	}
+
 	// Shutdown server gracefully when SIGINT or SIGTERM signal is received
 	shutdown := make(chan os.Signal, 1)
 	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
 	<-shutdown
 	log.Info().Msg("Hub server shutting down..")
+	stopDispatcher()
+	wg.Wait()
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.GetDuration("server.shutdownTimeout"))
 	defer cancel()
 	if err := srv.Shutdown(ctx); err != nil {
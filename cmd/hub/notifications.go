@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cncf/hub/internal/hub"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+const (
+	// Notifications dispatcher defaults
+	defaultNotificationsInterval = 1 * time.Minute
+	notificationsBatchSize       = 100
+	maxNotificationAttempts      = 5
+)
+
+// notificationsDispatcher is in charge of delivering the notifications about
+// packages events to the users subscribed to them. Periodically, it generates
+// the notifications for the events registered and sends the pending ones in
+// batches, grouping them in a single email per user. Notifications that could
+// not be sent are retried with some backoff until the maximum number of
+// attempts is reached.
+type notificationsDispatcher struct {
+	hubAPI   *hub.Hub
+	interval time.Duration
+	baseURL  string
+}
+
+// newNotificationsDispatcher creates a new notificationsDispatcher instance.
+func newNotificationsDispatcher(cfg *viper.Viper, hubAPI *hub.Hub) *notificationsDispatcher {
+	d := &notificationsDispatcher{
+		hubAPI:   hubAPI,
+		interval: defaultNotificationsInterval,
+		baseURL:  defaultBaseURL,
+	}
+	if cfg.IsSet("notifications.interval") {
+		d.interval = cfg.GetDuration("notifications.interval")
+	}
+	if cfg.IsSet("server.baseURL") {
+		d.baseURL = strings.TrimSuffix(cfg.GetString("server.baseURL"), "/")
+	}
+	return d
+}
+
+// run starts the dispatcher. Notifications are dispatched periodically until
+// the context provided is done.
+func (d *notificationsDispatcher) run(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := d.dispatch(ctx); err != nil {
+				log.Error().Err(err).Msg("Error dispatching notifications")
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// dispatch generates the notifications for the events registered and sends
+// the pending ones. Notifications are marked as sent once delivered, so that
+// they are not sent again.
+func (d *notificationsDispatcher) dispatch(ctx context.Context) error {
+	if err := d.hubAPI.GenerateNotifications(ctx); err != nil {
+		return err
+	}
+	for {
+		notifications, err := d.hubAPI.ClaimNotifications(ctx, notificationsBatchSize, maxNotificationAttempts)
+		if err != nil {
+			return err
+		}
+		for _, email := range groupNotificationsByEmail(notifications) {
+			d.send(ctx, email.address, email.notifications)
+		}
+		if len(notifications) < notificationsBatchSize {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// send sends the notifications provided to the email address given, recording
+// the result of the delivery.
+func (d *notificationsDispatcher) send(ctx context.Context, address string, notifications []*hub.Notification) {
+	ids := make([]string, 0, len(notifications))
+	for _, n := range notifications {
+		ids = append(ids, n.NotificationID)
+	}
+	sendErr := d.hubAPI.SendNotificationsEmail(address, notifications, d.baseURL)
+	if sendErr != nil {
+		log.Error().Err(sendErr).Strs("notifications", ids).Msg("Error sending notifications email")
+	}
+	if err := d.hubAPI.CompleteNotifications(ctx, ids, sendErr); err != nil {
+		log.Error().Err(err).Strs("notifications", ids).Msg("Error completing notifications")
+	}
+}
+
+// notificationsEmail represents the notifications to be sent to a given email
+// address.
+type notificationsEmail struct {
+	address       string
+	notifications []*hub.Notification
+}
+
+// groupNotificationsByEmail groups the notifications provided by the email
+// address they must be sent to, preserving the order in which they were
+// provided.
+func groupNotificationsByEmail(notifications []*hub.Notification) []*notificationsEmail {
+	var emails []*notificationsEmail
+	index := make(map[string]*notificationsEmail)
+	for _, n := range notifications {
+		e, ok := index[n.Email]
+		if !ok {
+			e = &notificationsEmail{address: n.Email}
+			index[n.Email] = e
+			emails = append(emails, e)
+		}
+		e.notifications = append(e.notifications, n)
+	}
+	return emails
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cncf/hub/internal/email"
+	"github.com/cncf/hub/internal/hub"
+	"github.com/cncf/hub/internal/tests"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewNotificationsDispatcher(t *testing.T) {
+	t.Run("default base url", func(t *testing.T) {
+		d := newNotificationsDispatcher(viper.New(), hub.New(&tests.DBMock{}, nil))
+		assert.Equal(t, defaultBaseURL, d.baseURL)
+	})
+
+	t.Run("server base url", func(t *testing.T) {
+		cfg := viper.New()
+		cfg.Set("server.baseURL", "http://localhost:8000/")
+		d := newNotificationsDispatcher(cfg, hub.New(&tests.DBMock{}, nil))
+		assert.Equal(t, "http://localhost:8000", d.baseURL)
+	})
+}
+
+func TestNotificationsDispatcherDispatch(t *testing.T) {
+	dbQueryGenerate := "select generate_notifications()"
+	dbQueryClaim := "select claim_notifications($1::int, $2::int)"
+	dbQuerySent := `
+		update notification set processed = true, processed_at = current_timestamp, error = null
+		where notification_id = any($1::uuid[])`
+	dbQueryFailed := `
+		update notification set error = $2
+		where notification_id = any($1::uuid[])`
+	notificationsJSON := []byte(`
+	[{
+		"notification_id": "notification1",
+		"email": "user1@email.com",
+		"event_kind": 0,
+		"package": {"name": "package1", "version": "1.0.0", "chart_repository": {"name": "repo1"}}
+	}, {
+		"notification_id": "notification2",
+		"email": "user2@email.com",
+		"event_kind": 0,
+		"package": {"name": "package1", "version": "1.0.0", "chart_repository": {"name": "repo1"}}
+	}, {
+		"notification_id": "notification3",
+		"email": "user1@email.com",
+		"event_kind": 0,
+		"package": {"name": "package2", "version": "2.0.0", "chart_repository": {"name": "repo1"}}
+	}]
+	`)
+
+	t.Run("error generating notifications", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQueryGenerate).Return(errFakeDatabaseFailure)
+		d := newNotificationsDispatcher(viper.New(), hub.New(db, &tests.EmailSenderMock{}))
+
+		err := d.dispatch(context.Background())
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("error claiming notifications", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQueryGenerate).Return(nil)
+		db.On("QueryRow", dbQueryClaim, notificationsBatchSize, maxNotificationAttempts).
+			Return(nil, errFakeDatabaseFailure)
+		d := newNotificationsDispatcher(viper.New(), hub.New(db, &tests.EmailSenderMock{}))
+
+		err := d.dispatch(context.Background())
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("notifications are sent grouped by email", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQueryGenerate).Return(nil)
+		db.On("QueryRow", dbQueryClaim, notificationsBatchSize, maxNotificationAttempts).
+			Return(notificationsJSON, nil)
+		db.On("Exec", dbQuerySent, []string{"notification1", "notification3"}).Return(nil)
+		db.On("Exec", dbQueryFailed, []string{"notification2"}, errFakeEmailSenderFailure.Error()).Return(nil)
+		es := &tests.EmailSenderMock{}
+		es.On("SendEmail", mock.MatchedBy(func(data *email.Data) bool {
+			return data.To == "user1@email.com"
+		})).Return(nil).Once()
+		es.On("SendEmail", mock.MatchedBy(func(data *email.Data) bool {
+			return data.To == "user2@email.com"
+		})).Return(errFakeEmailSenderFailure).Once()
+		d := newNotificationsDispatcher(viper.New(), hub.New(db, es))
+
+		err := d.dispatch(context.Background())
+		assert.NoError(t, err)
+		db.AssertExpectations(t)
+		es.AssertExpectations(t)
+	})
+}
+
+func TestGroupNotificationsByEmail(t *testing.T) {
+	n1 := &hub.Notification{NotificationID: "1", Email: "user1@email.com"}
+	n2 := &hub.Notification{NotificationID: "2", Email: "user2@email.com"}
+	n3 := &hub.Notification{NotificationID: "3", Email: "user1@email.com"}
+
+	emails := groupNotificationsByEmail([]*hub.Notification{n1, n2, n3})
+	assert.Equal(t, []*notificationsEmail{
+		{address: "user1@email.com", notifications: []*hub.Notification{n1, n3}},
+		{address: "user2@email.com", notifications: []*hub.Notification{n2}},
+	}, emails)
+}
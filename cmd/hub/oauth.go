@@ -0,0 +1,341 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cncf/hub/internal/hub"
+	"github.com/go-chi/chi"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// OAuth state cookie
+	oauthStateCookieName = "oauth_state"
+	oauthStateDuration   = 10 * time.Minute
+
+	// GitHub provider defaults
+	defaultGitHubAuthURL  = "https://github.com/login/oauth/authorize"
+	defaultGitHubTokenURL = "https://github.com/login/oauth/access_token"
+	defaultGitHubAPIURL   = "https://api.github.com"
+
+	// Google provider defaults
+	defaultGoogleAuthURL     = "https://accounts.google.com/o/oauth2/auth"
+	defaultGoogleTokenURL    = "https://oauth2.googleapis.com/token"
+	defaultGoogleUserInfoURL = "https://openidconnect.googleapis.com/v1/userinfo"
+)
+
+var (
+	// errOAuthEmailNotVerified indicates that the identity provider did not
+	// return a verified email for the user.
+	errOAuthEmailNotVerified = errors.New("verified email not available")
+
+	// defaultOIDCScopes represents the scopes requested by default to OIDC
+	// providers (Google included).
+	defaultOIDCScopes = []string{"openid", "email", "profile"}
+
+	// defaultGitHubScopes represents the scopes requested by default to GitHub.
+	defaultGitHubScopes = []string{"read:user", "user:email"}
+)
+
+// oauthProvider represents an OAuth2 or OIDC identity provider users can use
+// to log in to the hub.
+type oauthProvider struct {
+	name        string
+	cfg         *oauth2.Config
+	getIdentity func(ctx context.Context, client *http.Client) (*hub.UserIdentity, error)
+}
+
+// setupOAuthProviders creates the OAuth providers enabled in the configuration
+// provided. Providers are enabled when a client id is set for them. All their
+// endpoints can be configured, which is required for generic OIDC providers.
+func setupOAuthProviders(cfg *viper.Viper) map[string]*oauthProvider {
+	providers := make(map[string]*oauthProvider)
+	for _, name := range []string{"github", "google", "oidc"} {
+		key := "server.oauth." + name
+		if cfg.GetString(key+".clientID") == "" {
+			continue
+		}
+		p := &oauthProvider{
+			name: name,
+			cfg: &oauth2.Config{
+				ClientID:     cfg.GetString(key + ".clientID"),
+				ClientSecret: cfg.GetString(key + ".clientSecret"),
+				RedirectURL:  cfg.GetString(key + ".redirectURL"),
+				Scopes:       cfg.GetStringSlice(key + ".scopes"),
+				Endpoint: oauth2.Endpoint{
+					AuthURL:  cfg.GetString(key + ".authURL"),
+					TokenURL: cfg.GetString(key + ".tokenURL"),
+				},
+			},
+		}
+		switch name {
+		case "github":
+			setDefaultEndpoints(p.cfg, defaultGitHubAuthURL, defaultGitHubTokenURL, defaultGitHubScopes)
+			apiURL := defaultGitHubAPIURL
+			if cfg.IsSet(key + ".apiURL") {
+				apiURL = cfg.GetString(key + ".apiURL")
+			}
+			p.getIdentity = getGitHubIdentity(name, apiURL)
+		case "google":
+			setDefaultEndpoints(p.cfg, defaultGoogleAuthURL, defaultGoogleTokenURL, defaultOIDCScopes)
+			userInfoURL := defaultGoogleUserInfoURL
+			if cfg.IsSet(key + ".userInfoURL") {
+				userInfoURL = cfg.GetString(key + ".userInfoURL")
+			}
+			p.getIdentity = getOIDCIdentity(name, userInfoURL)
+		case "oidc":
+			setDefaultEndpoints(p.cfg, "", "", defaultOIDCScopes)
+			userInfoURL := cfg.GetString(key + ".userInfoURL")
+			if p.cfg.Endpoint.AuthURL == "" || p.cfg.Endpoint.TokenURL == "" || userInfoURL == "" {
+				log.Error().Str("provider", name).Msg("OAuth provider endpoints not configured, provider disabled")
+				continue
+			}
+			p.getIdentity = getOIDCIdentity(name, userInfoURL)
+		}
+		providers[name] = p
+	}
+	return providers
+}
+
+// setDefaultEndpoints sets the endpoints and scopes provided in the OAuth2
+// configuration given when they haven't been configured explicitly.
+func setDefaultEndpoints(cfg *oauth2.Config, authURL, tokenURL string, scopes []string) {
+	if cfg.Endpoint.AuthURL == "" {
+		cfg.Endpoint.AuthURL = authURL
+	}
+	if cfg.Endpoint.TokenURL == "" {
+		cfg.Endpoint.TokenURL = tokenURL
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = scopes
+	}
+}
+
+// getGitHubIdentity returns a function that gets the identity of the user
+// authenticated from the GitHub API located at the url provided.
+func getGitHubIdentity(provider, apiURL string) func(context.Context, *http.Client) (*hub.UserIdentity, error) {
+	return func(ctx context.Context, client *http.Client) (*hub.UserIdentity, error) {
+		var user struct {
+			ID    int64  `json:"id"`
+			Login string `json:"login"`
+			Name  string `json:"name"`
+		}
+		if err := getJSON(ctx, client, apiURL+"/user", &user); err != nil {
+			return nil, err
+		}
+		var emails []struct {
+			Email    string `json:"email"`
+			Primary  bool   `json:"primary"`
+			Verified bool   `json:"verified"`
+		}
+		if err := getJSON(ctx, client, apiURL+"/user/emails", &emails); err != nil {
+			return nil, err
+		}
+		identity := &hub.UserIdentity{
+			Provider:       provider,
+			ProviderUserID: strconv.FormatInt(user.ID, 10),
+			Alias:          user.Login,
+		}
+		identity.FirstName, identity.LastName = splitName(user.Name)
+		for _, e := range emails {
+			if e.Primary && e.Verified {
+				identity.Email = e.Email
+			}
+		}
+		if identity.Email == "" {
+			return nil, errOAuthEmailNotVerified
+		}
+		return identity, nil
+	}
+}
+
+// getOIDCIdentity returns a function that gets the identity of the user
+// authenticated from the OIDC userinfo endpoint provided.
+func getOIDCIdentity(provider, userInfoURL string) func(context.Context, *http.Client) (*hub.UserIdentity, error) {
+	return func(ctx context.Context, client *http.Client) (*hub.UserIdentity, error) {
+		var claims struct {
+			Subject           string `json:"sub"`
+			Email             string `json:"email"`
+			EmailVerified     bool   `json:"email_verified"`
+			PreferredUsername string `json:"preferred_username"`
+			GivenName         string `json:"given_name"`
+			FamilyName        string `json:"family_name"`
+		}
+		if err := getJSON(ctx, client, userInfoURL, &claims); err != nil {
+			return nil, err
+		}
+		if claims.Email == "" || !claims.EmailVerified {
+			return nil, errOAuthEmailNotVerified
+		}
+		alias := claims.PreferredUsername
+		if alias == "" {
+			alias = strings.Split(claims.Email, "@")[0]
+		}
+		return &hub.UserIdentity{
+			Provider:       provider,
+			ProviderUserID: claims.Subject,
+			Alias:          alias,
+			FirstName:      claims.GivenName,
+			LastName:       claims.FamilyName,
+			Email:          claims.Email,
+		}, nil
+	}
+}
+
+// getJSON gets the url provided using the http client given and unmarshals the
+// json data returned into v.
+func getJSON(ctx context.Context, client *http.Client, url string, v interface{}) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code received from %s: %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// splitName splits the full name provided into first and last name.
+func splitName(name string) (string, string) {
+	parts := strings.SplitN(strings.TrimSpace(name), " ", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], strings.TrimSpace(parts[1])
+}
+
+// oauthRedirect is an http handler that redirects the user to the provider's
+// authorization page to start the OAuth authorization code flow.
+func (h *handlers) oauthRedirect(w http.ResponseWriter, r *http.Request) {
+	p, ok := h.oauthProviders[chi.URLParam(r, "provider")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Generate state and keep it in a cookie to validate it on the callback
+	randomBytes := make([]byte, 32)
+	if _, err := rand.Read(randomBytes); err != nil {
+		log.Error().Err(err).Msg("oauth state generation failed")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	state := hex.EncodeToString(randomBytes)
+	encodedState, err := h.sc.Encode(oauthStateCookieName, state)
+	if err != nil {
+		log.Error().Err(err).Msg("oauth state encoding failed")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	cookie := &http.Cookie{
+		Name:     oauthStateCookieName,
+		Value:    encodedState,
+		Path:     "/oauth",
+		Expires:  time.Now().Add(oauthStateDuration),
+		HttpOnly: true,
+	}
+	if h.cfg.GetBool("server.cookie.secure") {
+		cookie.Secure = true
+	}
+	http.SetCookie(w, cookie)
+
+	http.Redirect(w, r, p.cfg.AuthCodeURL(state), http.StatusFound)
+}
+
+// oauthCallback is an http handler that completes the OAuth authorization code
+// flow. The user linked to the identity returned by the provider (created if
+// needed) is logged in, setting the same session cookie used by login. When a
+// user is already logged in, the identity is linked to that user instead.
+func (h *handlers) oauthCallback(w http.ResponseWriter, r *http.Request) {
+	p, ok := h.oauthProviders[chi.URLParam(r, "provider")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Validate state
+	cookie, err := r.Cookie(oauthStateCookieName)
+	if err != nil {
+		http.Error(w, "oauth state not provided", http.StatusBadRequest)
+		return
+	}
+	var state string
+	if err := h.sc.Decode(oauthStateCookieName, cookie.Value, &state); err != nil || state != r.FormValue("state") {
+		http.Error(w, "invalid oauth state", http.StatusBadRequest)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:    oauthStateCookieName,
+		Path:    "/oauth",
+		Expires: time.Now().Add(-24 * time.Hour),
+	})
+
+	// Exchange authorization code and get user identity
+	code := r.FormValue("code")
+	if code == "" {
+		http.Error(w, "authorization code not provided", http.StatusUnauthorized)
+		return
+	}
+	token, err := p.cfg.Exchange(r.Context(), code)
+	if err != nil {
+		log.Error().Err(err).Str("provider", p.name).Msg("oauth code exchange failed")
+		http.Error(w, "", http.StatusUnauthorized)
+		return
+	}
+	identity, err := p.getIdentity(r.Context(), p.cfg.Client(r.Context(), token))
+	if err != nil {
+		if errors.Is(err, errOAuthEmailNotVerified) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+		} else {
+			log.Error().Err(err).Str("provider", p.name).Msg("get oauth identity failed")
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// Register user identity and log the user in, unless already logged in
+	identity.UserID, err = h.getSessionUserID(r)
+	if err != nil {
+		log.Error().Err(err).Msg("getSessionUserID failed")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	userID, err := h.hubAPI.RegisterUserIdentity(r.Context(), identity)
+	if err != nil {
+		if isUniqueViolationError(err) {
+			errMsg := "identity cannot be linked: log in to your account to link it"
+			http.Error(w, errMsg, http.StatusConflict)
+		} else {
+			log.Error().Err(err).Str("provider", p.name).Msg("registerUserIdentity failed")
+			http.Error(w, "", http.StatusInternalServerError)
+		}
+		return
+	}
+	if identity.UserID != "" {
+		http.Redirect(w, r, "/", http.StatusFound)
+		return
+	}
+	if err := h.registerSession(w, r, userID); err != nil {
+		log.Error().Err(err).Msg("registerSession failed")
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/", http.StatusFound)
+}
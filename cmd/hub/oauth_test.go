@@ -0,0 +1,408 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/cncf/hub/internal/hub"
+	"github.com/cncf/hub/internal/img/pg"
+	"github.com/cncf/hub/internal/tests"
+	"github.com/jackc/pgconn"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetupOAuthProviders(t *testing.T) {
+	t.Run("no providers configured", func(t *testing.T) {
+		providers := setupOAuthProviders(viper.New())
+		assert.Empty(t, providers)
+	})
+
+	t.Run("providers with empty client id are disabled", func(t *testing.T) {
+		cfg := viper.New()
+		cfg.Set("server.oauth.github.clientID", "")
+		providers := setupOAuthProviders(cfg)
+		assert.Empty(t, providers)
+	})
+
+	t.Run("providers configured with default endpoints", func(t *testing.T) {
+		cfg := viper.New()
+		cfg.Set("server.oauth.github.clientID", "githubClientID")
+		cfg.Set("server.oauth.google.clientID", "googleClientID")
+		providers := setupOAuthProviders(cfg)
+
+		require.Len(t, providers, 2)
+		assert.Equal(t, defaultGitHubAuthURL, providers["github"].cfg.Endpoint.AuthURL)
+		assert.Equal(t, defaultGitHubTokenURL, providers["github"].cfg.Endpoint.TokenURL)
+		assert.Equal(t, defaultGitHubScopes, providers["github"].cfg.Scopes)
+		assert.Equal(t, defaultGoogleAuthURL, providers["google"].cfg.Endpoint.AuthURL)
+		assert.Equal(t, defaultGoogleTokenURL, providers["google"].cfg.Endpoint.TokenURL)
+		assert.Equal(t, defaultOIDCScopes, providers["google"].cfg.Scopes)
+	})
+
+	t.Run("oidc provider without endpoints is disabled", func(t *testing.T) {
+		cfg := viper.New()
+		cfg.Set("server.oauth.oidc.clientID", "oidcClientID")
+		providers := setupOAuthProviders(cfg)
+		assert.Empty(t, providers)
+	})
+}
+
+func TestOAuthRedirect(t *testing.T) {
+	t.Run("provider not found", func(t *testing.T) {
+		th := setupTestHandlers()
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/oauth/unknown", nil)
+		th.h.router.ServeHTTP(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("user redirected to provider authorization page", func(t *testing.T) {
+		th := setupOAuthTestHandlers("http://provider.test")
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/oauth/oidc", nil)
+		th.h.router.ServeHTTP(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusFound, resp.StatusCode)
+		require.Len(t, resp.Cookies(), 1)
+		cookie := resp.Cookies()[0]
+		assert.Equal(t, oauthStateCookieName, cookie.Name)
+		var state string
+		require.NoError(t, th.h.sc.Decode(oauthStateCookieName, cookie.Value, &state))
+		location, _ := url.Parse(resp.Header.Get("Location"))
+		assert.Equal(t, "provider.test", location.Host)
+		assert.Equal(t, "/authorize", location.Path)
+		assert.Equal(t, state, location.Query().Get("state"))
+		assert.Equal(t, "oidcClientID", location.Query().Get("client_id"))
+	})
+}
+
+func TestOAuthCallback(t *testing.T) {
+	dbQueryIdentity := "select register_user_identity($1::jsonb)"
+	dbQuerySession := "select register_session($1::jsonb)"
+
+	t.Run("invalid state", func(t *testing.T) {
+		th := setupOAuthTestHandlers("http://provider.test")
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/oauth/oidc/callback?code=code&state=state", nil)
+		r.AddCookie(newOAuthStateCookie(t, th, "other"))
+		th.h.router.ServeHTTP(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("code exchange failed", func(t *testing.T) {
+		provider := newMockOAuthProvider(t, map[string]interface{}{})
+		defer provider.Close()
+		th := setupOAuthTestHandlers(provider.URL)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/oauth/oidc/callback?code=invalid&state=state", nil)
+		r.AddCookie(newOAuthStateCookie(t, th, "state"))
+		th.h.router.ServeHTTP(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("email not verified by provider", func(t *testing.T) {
+		provider := newMockOAuthProvider(t, map[string]interface{}{
+			"sub":            "1234",
+			"email":          "user1@email.com",
+			"email_verified": false,
+		})
+		defer provider.Close()
+		th := setupOAuthTestHandlers(provider.URL)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/oauth/oidc/callback?code=code&state=state", nil)
+		r.AddCookie(newOAuthStateCookie(t, th, "state"))
+		th.h.router.ServeHTTP(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("database error registering identity", func(t *testing.T) {
+		provider := newMockOAuthProvider(t, map[string]interface{}{
+			"sub":            "1234",
+			"email":          "user1@email.com",
+			"email_verified": true,
+		})
+		defer provider.Close()
+		th := setupOAuthTestHandlers(provider.URL)
+		th.db.On("QueryRow", dbQueryIdentity, mock.Anything).Return(nil, errFakeDatabaseFailure)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/oauth/oidc/callback?code=code&state=state", nil)
+		r.AddCookie(newOAuthStateCookie(t, th, "state"))
+		th.h.router.ServeHTTP(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		th.db.AssertExpectations(t)
+	})
+
+	t.Run("email already registered by another user", func(t *testing.T) {
+		provider := newMockOAuthProvider(t, map[string]interface{}{
+			"sub":            "1234",
+			"email":          "user1@email.com",
+			"email_verified": true,
+		})
+		defer provider.Close()
+		th := setupOAuthTestHandlers(provider.URL)
+		th.db.On("QueryRow", dbQueryIdentity, mock.Anything).Return(nil, &pgconn.PgError{Code: uniqueViolationErrCode})
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/oauth/oidc/callback?code=code&state=state", nil)
+		r.AddCookie(newOAuthStateCookie(t, th, "state"))
+		th.h.router.ServeHTTP(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+		th.db.AssertExpectations(t)
+	})
+
+	t.Run("identity linked to the user logged in", func(t *testing.T) {
+		dbQuerySessionCheck := `
+	select s.user_id, floor(extract(epoch from s.last_seen_at))
+	from session s
+	join "user" u using (user_id)
+	where s.session_id = $1
+	and u.email_verified = true
+	`
+		provider := newMockOAuthProvider(t, map[string]interface{}{
+			"sub":            "1234",
+			"email":          "user1@email.com",
+			"email_verified": true,
+		})
+		defer provider.Close()
+		th := setupOAuthTestHandlers(provider.URL)
+		th.db.On("QueryRow", dbQuerySessionCheck, []byte("sessionID")).Return([]interface{}{
+			"userID",
+			time.Now().Unix(),
+		}, nil)
+		th.db.On("QueryRow", dbQueryIdentity, mock.MatchedBy(func(identityJSON []byte) bool {
+			var identity *hub.UserIdentity
+			_ = json.Unmarshal(identityJSON, &identity)
+			return identity != nil && identity.UserID == "userID"
+		})).Return("userID", nil)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/oauth/oidc/callback?code=code&state=state", nil)
+		r.AddCookie(newOAuthStateCookie(t, th, "state"))
+		encodedSessionID, _ := th.h.sc.Encode(sessionCookieName, []byte("sessionID"))
+		r.AddCookie(&http.Cookie{
+			Name:  sessionCookieName,
+			Value: encodedSessionID,
+		})
+		th.h.router.ServeHTTP(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusFound, resp.StatusCode)
+		assert.Equal(t, "/", resp.Header.Get("Location"))
+		for _, cookie := range resp.Cookies() {
+			assert.NotEqual(t, sessionCookieName, cookie.Name)
+		}
+		th.db.AssertExpectations(t)
+	})
+
+	t.Run("user logged in", func(t *testing.T) {
+		provider := newMockOAuthProvider(t, map[string]interface{}{
+			"sub":                "1234",
+			"email":              "user1@email.com",
+			"email_verified":     true,
+			"preferred_username": "user1",
+			"given_name":         "first_name",
+			"family_name":        "last_name",
+		})
+		defer provider.Close()
+		th := setupOAuthTestHandlers(provider.URL)
+		th.db.On("QueryRow", dbQueryIdentity, mock.MatchedBy(func(identityJSON []byte) bool {
+			var identity *hub.UserIdentity
+			_ = json.Unmarshal(identityJSON, &identity)
+			return assert.ObjectsAreEqual(&hub.UserIdentity{
+				Provider:       "oidc",
+				ProviderUserID: "1234",
+				Alias:          "user1",
+				FirstName:      "first_name",
+				LastName:       "last_name",
+				Email:          "user1@email.com",
+			}, identity)
+		})).Return("userID", nil)
+		th.db.On("QueryRow", dbQuerySession, mock.Anything).Return([]byte("sessionID"), nil)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/oauth/oidc/callback?code=code&state=state", nil)
+		r.AddCookie(newOAuthStateCookie(t, th, "state"))
+		th.h.router.ServeHTTP(w, r)
+		resp := w.Result()
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusFound, resp.StatusCode)
+		assert.Equal(t, "/", resp.Header.Get("Location"))
+		var sessionCookie *http.Cookie
+		for _, cookie := range resp.Cookies() {
+			if cookie.Name == sessionCookieName {
+				sessionCookie = cookie
+			}
+		}
+		require.NotNil(t, sessionCookie)
+		assert.True(t, sessionCookie.HttpOnly)
+		var sessionID []byte
+		require.NoError(t, th.h.sc.Decode(sessionCookieName, sessionCookie.Value, &sessionID))
+		assert.Equal(t, []byte("sessionID"), sessionID)
+		th.db.AssertExpectations(t)
+	})
+}
+
+func TestGetGitHubIdentity(t *testing.T) {
+	t.Run("verified primary email available", func(t *testing.T) {
+		api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/user":
+				_, _ = w.Write([]byte(`{"id": 1234, "login": "user1", "name": "First Last Name"}`))
+			case "/user/emails":
+				_, _ = w.Write([]byte(`[
+					{"email": "other@email.com", "primary": false, "verified": true},
+					{"email": "user1@email.com", "primary": true, "verified": true}
+				]`))
+			}
+		}))
+		defer api.Close()
+
+		identity, err := getGitHubIdentity("github", api.URL)(context.Background(), api.Client())
+		require.NoError(t, err)
+		assert.Equal(t, &hub.UserIdentity{
+			Provider:       "github",
+			ProviderUserID: "1234",
+			Alias:          "user1",
+			FirstName:      "First",
+			LastName:       "Last Name",
+			Email:          "user1@email.com",
+		}, identity)
+	})
+
+	t.Run("verified primary email not available", func(t *testing.T) {
+		api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/user":
+				_, _ = w.Write([]byte(`{"id": 1234, "login": "user1"}`))
+			case "/user/emails":
+				_, _ = w.Write([]byte(`[{"email": "user1@email.com", "primary": true, "verified": false}]`))
+			}
+		}))
+		defer api.Close()
+
+		_, err := getGitHubIdentity("github", api.URL)(context.Background(), api.Client())
+		assert.Equal(t, errOAuthEmailNotVerified, err)
+	})
+
+	t.Run("api error", func(t *testing.T) {
+		api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer api.Close()
+
+		_, err := getGitHubIdentity("github", api.URL)(context.Background(), api.Client())
+		assert.Error(t, err)
+	})
+}
+
+func TestSplitName(t *testing.T) {
+	testCases := []struct {
+		name              string
+		expectedFirstName string
+		expectedLastName  string
+	}{
+		{"", "", ""},
+		{"First", "First", ""},
+		{"First Last", "First", "Last"},
+		{" First  Last Name ", "First", "Last Name"},
+	}
+	for _, tc := range testCases {
+		firstName, lastName := splitName(tc.name)
+		assert.Equal(t, tc.expectedFirstName, firstName, tc.name)
+		assert.Equal(t, tc.expectedLastName, lastName, tc.name)
+	}
+}
+
+// setupOAuthTestHandlers sets up some test handlers with a generic OIDC
+// provider enabled, using the endpoints of the provider url given.
+func setupOAuthTestHandlers(providerURL string) *testHandlers {
+	cfg := viper.New()
+	cfg.Set("server.webBuildPath", "testdata")
+	cfg.Set("server.oauth.oidc.clientID", "oidcClientID")
+	cfg.Set("server.oauth.oidc.clientSecret", "oidcClientSecret")
+	cfg.Set("server.oauth.oidc.redirectURL", "http://hub.test/oauth/oidc/callback")
+	cfg.Set("server.oauth.oidc.authURL", providerURL+"/authorize")
+	cfg.Set("server.oauth.oidc.tokenURL", providerURL+"/token")
+	cfg.Set("server.oauth.oidc.userInfoURL", providerURL+"/userinfo")
+	db := &tests.DBMock{}
+	es := &tests.EmailSenderMock{}
+	hubAPI := hub.New(db, es)
+	imageStore := pg.NewImageStore(db)
+
+	return &testHandlers{
+		cfg: cfg,
+		db:  db,
+		es:  es,
+		h:   setupHandlers(cfg, hubAPI, imageStore),
+	}
+}
+
+// newMockOAuthProvider creates a mock OIDC provider that issues an access
+// token for the authorization code "code" and returns the claims provided from
+// its userinfo endpoint.
+func newMockOAuthProvider(t *testing.T, claims map[string]interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			if r.FormValue("code") != "code" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error": "invalid_grant"}`))
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"access_token": "accessToken", "token_type": "bearer"}`))
+		case "/userinfo":
+			if r.Header.Get("Authorization") != "Bearer accessToken" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(claims)
+		default:
+			t.Errorf("unexpected request to mock provider: %s", r.URL.Path)
+		}
+	}))
+}
+
+// newOAuthStateCookie returns an oauth state cookie for the state provided.
+func newOAuthStateCookie(t *testing.T, th *testHandlers, state string) *http.Cookie {
+	encodedState, err := th.h.sc.Encode(oauthStateCookieName, state)
+	require.NoError(t, err)
+	return &http.Cookie{Name: oauthStateCookieName, Value: encodedState}
+}
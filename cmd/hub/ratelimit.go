@@ -0,0 +1,80 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// ipRateLimiterIdleTimeout represents how long the limiter of a given ip
+	// address is kept after its last request.
+	ipRateLimiterIdleTimeout = 30 * time.Minute
+)
+
+// ipRateLimiter limits the rate of requests accepted from each ip address.
+type ipRateLimiter struct {
+	r rate.Limit
+	b int
+
+	mu        sync.Mutex
+	limiters  map[string]*ipLimiter
+	lastPurge time.Time
+}
+
+// ipLimiter represents the rate limiter of a given ip address.
+type ipLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// newIPRateLimiter creates a new ipRateLimiter instance that allows r requests
+// per second from each ip address, with bursts of at most b requests.
+func newIPRateLimiter(r rate.Limit, b int) *ipRateLimiter {
+	return &ipRateLimiter{
+		r:         r,
+		b:         b,
+		limiters:  make(map[string]*ipLimiter),
+		lastPurge: time.Now(),
+	}
+}
+
+// allow reports whether a request from the ip address provided is allowed.
+// Limiters can also be keyed by other values identifying the requester, like
+// the email address a request targets.
+func (l *ipRateLimiter) allow(ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.lastPurge) > ipRateLimiterIdleTimeout {
+		for k, v := range l.limiters {
+			if now.Sub(v.lastSeen) > ipRateLimiterIdleTimeout {
+				delete(l.limiters, k)
+			}
+		}
+		l.lastPurge = now
+	}
+
+	v, ok := l.limiters[ip]
+	if !ok {
+		v = &ipLimiter{limiter: rate.NewLimiter(l.r, l.b)}
+		l.limiters[ip] = v
+	}
+	v.lastSeen = now
+	return v.limiter.Allow()
+}
+
+// handler is a middleware that rejects requests exceeding the rate allowed for
+// the ip address they come from.
+func (l *ipRateLimiter) handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !l.allow(getIP(r)) {
+			http.Error(w, "", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
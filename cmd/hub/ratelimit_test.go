@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+)
+
+func TestIPRateLimiter(t *testing.T) {
+	t.Run("requests are limited per ip address", func(t *testing.T) {
+		l := newIPRateLimiter(rate.Every(time.Hour), 2)
+
+		assert.True(t, l.allow("1.1.1.1"))
+		assert.True(t, l.allow("1.1.1.1"))
+		assert.False(t, l.allow("1.1.1.1"))
+		assert.True(t, l.allow("2.2.2.2"))
+	})
+
+	t.Run("idle limiters are purged", func(t *testing.T) {
+		l := newIPRateLimiter(rate.Every(time.Hour), 1)
+		assert.True(t, l.allow("1.1.1.1"))
+		l.limiters["1.1.1.1"].lastSeen = time.Now().Add(-2 * ipRateLimiterIdleTimeout)
+		l.lastPurge = time.Now().Add(-2 * ipRateLimiterIdleTimeout)
+
+		assert.True(t, l.allow("2.2.2.2"))
+		assert.NotContains(t, l.limiters, "1.1.1.1")
+	})
+
+	t.Run("handler rejects requests exceeding the rate allowed", func(t *testing.T) {
+		l := newIPRateLimiter(rate.Every(time.Hour), 1)
+		h := l.handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+		for _, expectedStatusCode := range []int{http.StatusOK, http.StatusTooManyRequests} {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("POST", "/", nil)
+			r.RemoteAddr = "1.1.1.1:1234"
+			h.ServeHTTP(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, expectedStatusCode, resp.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cncf/hub/internal/hub"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+const (
+	// Sessions purger defaults
+	defaultSessionsPurgeInterval = 1 * time.Hour
+)
+
+// sessionsPurger is in charge of deleting periodically the sessions that have
+// expired, as they are only checked when they are used.
+type sessionsPurger struct {
+	hubAPI   *hub.Hub
+	interval time.Duration
+}
+
+// newSessionsPurger creates a new sessionsPurger instance.
+func newSessionsPurger(cfg *viper.Viper, hubAPI *hub.Hub) *sessionsPurger {
+	p := &sessionsPurger{
+		hubAPI:   hubAPI,
+		interval: defaultSessionsPurgeInterval,
+	}
+	if cfg.IsSet("sessions.purgeInterval") {
+		p.interval = cfg.GetDuration("sessions.purgeInterval")
+	}
+	return p
+}
+
+// run starts the purger. Expired sessions are deleted periodically until the
+// context provided is done.
+func (p *sessionsPurger) run(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := p.hubAPI.DeleteExpiredSessions(ctx, sessionDuration); err != nil {
+				log.Error().Err(err).Msg("Error purging expired sessions")
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cncf/hub/internal/hub"
+	"github.com/cncf/hub/internal/tests"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSessionsPurgerRun(t *testing.T) {
+	dbQuery := "delete from session where last_seen_at < $1"
+
+	db := &tests.DBMock{}
+	purged := make(chan struct{})
+	var once sync.Once
+	db.On("Exec", dbQuery, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		once.Do(func() { close(purged) })
+	})
+	cfg := viper.New()
+	cfg.Set("sessions.purgeInterval", 10*time.Millisecond)
+	p := newSessionsPurger(cfg, hub.New(db, nil))
+	assert.Equal(t, 10*time.Millisecond, p.interval)
+
+	var wg sync.WaitGroup
+	ctx, stop := context.WithCancel(context.Background())
+	wg.Add(1)
+	go p.run(ctx, &wg)
+	select {
+	case <-purged:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expired sessions were not purged")
+	}
+	stop()
+	wg.Wait()
+	db.AssertExpectations(t)
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/cncf/hub/internal/hub"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+const (
+	// Webhooks dispatcher defaults
+	defaultWebhooksInterval    = 30 * time.Second
+	webhookDeliveriesBatchSize = 100
+	maxWebhookDeliveryAttempts = 5
+	webhookDeliveryTimeout     = 10 * time.Second
+	webhookDeliveryConcurrency = 10
+
+	// Outgoing webhooks headers
+	webhookDeliveryHeader = "X-Hub-Delivery"
+)
+
+var (
+	// errWebhookAddressNotAllowed indicates that the webhook url resolves to
+	// an address webhooks are not allowed to be delivered to.
+	errWebhookAddressNotAllowed = errors.New("webhook address not allowed")
+
+	// webhookBlockedNetworks represents the networks webhooks cannot be
+	// delivered to, like loopback, link-local or private ones.
+	webhookBlockedNetworks = parseCIDRs(
+		"0.0.0.0/8",
+		"10.0.0.0/8",
+		"100.64.0.0/10",
+		"127.0.0.0/8",
+		"169.254.0.0/16",
+		"172.16.0.0/12",
+		"192.0.0.0/24",
+		"192.168.0.0/16",
+		"198.18.0.0/15",
+		"224.0.0.0/4",
+		"240.0.0.0/4",
+		"::/128",
+		"::1/128",
+		"fc00::/7",
+		"fe80::/10",
+		"ff00::/8",
+	)
+)
+
+// webhooksDispatcher is in charge of delivering the payloads generated for the
+// outgoing webhooks registered by the chart repositories owners. Payloads are
+// sent signed using the webhook secret (HMAC-SHA256), and each attempt is
+// recorded. Deliveries that fail are retried with some backoff until the
+// maximum number of attempts is reached.
+type webhooksDispatcher struct {
+	hubAPI     *hub.Hub
+	interval   time.Duration
+	httpClient *http.Client
+}
+
+// newWebhooksDispatcher creates a new webhooksDispatcher instance.
+func newWebhooksDispatcher(cfg *viper.Viper, hubAPI *hub.Hub) *webhooksDispatcher {
+	d := &webhooksDispatcher{
+		hubAPI:     hubAPI,
+		interval:   defaultWebhooksInterval,
+		httpClient: newWebhooksHTTPClient(),
+	}
+	if cfg.IsSet("webhooks.interval") {
+		if interval := cfg.GetDuration("webhooks.interval"); interval > 0 {
+			d.interval = interval
+		} else {
+			log.Warn().Dur("default", defaultWebhooksInterval).Msg("Webhooks interval must be positive, using default")
+		}
+	}
+	return d
+}
+
+// run starts the dispatcher. Pending deliveries are dispatched periodically
+// until the context provided is done.
+func (d *webhooksDispatcher) run(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := d.dispatch(ctx); err != nil {
+				log.Error().Err(err).Msg("Error dispatching webhooks")
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// dispatch delivers the pending webhook deliveries in batches, recording the
+// result of each attempt.
+func (d *webhooksDispatcher) dispatch(ctx context.Context) error {
+	for {
+		deliveries, err := d.hubAPI.ClaimWebhookDeliveries(ctx, webhookDeliveriesBatchSize, maxWebhookDeliveryAttempts)
+		if err != nil {
+			return err
+		}
+		var wg sync.WaitGroup
+		limiter := make(chan struct{}, webhookDeliveryConcurrency)
+		for _, wd := range deliveries {
+			limiter <- struct{}{}
+			wg.Add(1)
+			go func(wd *hub.WebhookDelivery) {
+				defer func() {
+					<-limiter
+					wg.Done()
+				}()
+				statusCode, deliveryErr := d.deliver(ctx, wd)
+				if deliveryErr != nil {
+					log.Debug().Err(deliveryErr).Str("deliveryID", wd.WebhookDeliveryID).Msg("Webhook delivery failed")
+				}
+				err := d.hubAPI.RegisterWebhookDeliveryAttempt(ctx, wd.WebhookDeliveryID, statusCode, deliveryErr)
+				if err != nil {
+					log.Error().Err(err).Str("deliveryID", wd.WebhookDeliveryID).Msg("Error registering webhook delivery attempt")
+				}
+			}(wd)
+		}
+		wg.Wait()
+		if len(deliveries) < webhookDeliveriesBatchSize {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// deliver sends the payload of the webhook delivery provided to its url,
+// returning the status code received when available.
+func (d *webhooksDispatcher) deliver(ctx context.Context, wd *hub.WebhookDelivery) (int, error) {
+	req, err := http.NewRequest("POST", wd.URL, bytes.NewReader(wd.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookDeliveryHeader, wd.WebhookDeliveryID)
+	req.Header.Set(webhookSignatureHeader, signWebhookPayload(wd.Secret, wd.Payload))
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status code received: %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// newWebhooksHTTPClient returns the http client used to deliver webhooks. The
+// addresses the client connects to are checked once resolved, so webhooks
+// cannot be used to reach internal services, even when the url host resolves
+// to a different address on each lookup. Redirects are not followed.
+func newWebhooksHTTPClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: webhookDeliveryTimeout,
+		Control: checkWebhookAddress,
+	}
+	return &http.Client{
+		Timeout: webhookDeliveryTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: webhookDeliveryTimeout,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// checkWebhookAddress checks if webhooks can be delivered to the address
+// provided. It is used as the control function of the webhooks dialer, so it
+// is called with the address being dialed once the host has been resolved.
+func checkWebhookAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if !isWebhookIPAllowed(net.ParseIP(host)) {
+		return errWebhookAddressNotAllowed
+	}
+	return nil
+}
+
+// isWebhookIPAllowed checks if webhooks can be delivered to the ip provided.
+func isWebhookIPAllowed(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, n := range webhookBlockedNetworks {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseCIDRs parses the CIDR notation networks provided.
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, n)
+	}
+	return networks
+}
+
+// signWebhookPayload returns the signature of the payload provided using the
+// secret given, in the same format expected for incoming webhooks.
+func signWebhookPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(payload)
+	return webhookSignaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cncf/hub/internal/hub"
+	"github.com/cncf/hub/internal/tests"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewWebhooksDispatcher(t *testing.T) {
+	t.Run("valid interval", func(t *testing.T) {
+		cfg := viper.New()
+		cfg.Set("webhooks.interval", "10s")
+		d := newWebhooksDispatcher(cfg, hub.New(&tests.DBMock{}, nil))
+		assert.Equal(t, 10*time.Second, d.interval)
+	})
+
+	t.Run("non positive interval falls back to default", func(t *testing.T) {
+		for _, interval := range []string{"0s", "-10s"} {
+			cfg := viper.New()
+			cfg.Set("webhooks.interval", interval)
+			d := newWebhooksDispatcher(cfg, hub.New(&tests.DBMock{}, nil))
+			assert.Equal(t, defaultWebhooksInterval, d.interval)
+		}
+	})
+}
+
+func TestWebhooksDispatcherDispatch(t *testing.T) {
+	dbQueryClaim := "select claim_webhook_deliveries($1::int, $2::int)"
+	dbQueryAttempt := "select register_webhook_delivery_attempt($1::uuid, $2::int, $3::text)"
+	payload := `{"event_kind":"new-release","package":{"name":"package1","version":"1.0.0"}}`
+
+	t.Run("error claiming deliveries", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQueryClaim, webhookDeliveriesBatchSize, maxWebhookDeliveryAttempts).
+			Return(nil, errFakeDatabaseFailure)
+		d := newWebhooksDispatcher(viper.New(), hub.New(db, nil))
+
+		err := d.dispatch(context.Background())
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("signed payloads are delivered and attempts registered", func(t *testing.T) {
+		var mu sync.Mutex
+		var received []string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			assert.Equal(t, payload, string(body))
+			assert.True(t, isValidWebhookSignature("secret", body, r.Header.Get(webhookSignatureHeader)))
+			mu.Lock()
+			received = append(received, r.Header.Get(webhookDeliveryHeader))
+			mu.Unlock()
+			if r.URL.Path == "/fail" {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer srv.Close()
+		deliveriesJSON := []byte(fmt.Sprintf(`
+		[{
+			"webhook_delivery_id": "delivery1",
+			"url": "%[1]s/ok",
+			"secret": "secret",
+			"event_kind": 0,
+			"payload": %[2]s
+		}, {
+			"webhook_delivery_id": "delivery2",
+			"url": "%[1]s/fail",
+			"secret": "secret",
+			"event_kind": 0,
+			"payload": %[2]s
+		}]
+		`, srv.URL, payload))
+
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQueryClaim, webhookDeliveriesBatchSize, maxWebhookDeliveryAttempts).
+			Return(deliveriesJSON, nil)
+		db.On("Exec", dbQueryAttempt, "delivery1", http.StatusNoContent, nil).Return(nil)
+		db.On("Exec", dbQueryAttempt, "delivery2", http.StatusInternalServerError, mock.Anything).
+			Return(nil)
+		d := newWebhooksDispatcher(viper.New(), hub.New(db, nil))
+		d.httpClient = srv.Client()
+
+		err := d.dispatch(context.Background())
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{"delivery1", "delivery2"}, received)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("unreachable endpoint attempt is registered without status code", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		url := srv.URL
+		srv.Close()
+		deliveriesJSON := []byte(fmt.Sprintf(`
+		[{
+			"webhook_delivery_id": "delivery1",
+			"url": "%s",
+			"secret": "secret",
+			"event_kind": 1,
+			"payload": %s
+		}]
+		`, url, payload))
+
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQueryClaim, webhookDeliveriesBatchSize, maxWebhookDeliveryAttempts).
+			Return(deliveriesJSON, nil)
+		db.On("Exec", dbQueryAttempt, "delivery1", nil, mock.Anything).Return(nil)
+		d := newWebhooksDispatcher(viper.New(), hub.New(db, nil))
+		d.httpClient = srv.Client()
+
+		err := d.dispatch(context.Background())
+		assert.NoError(t, err)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("deliveries to internal addresses are not allowed", func(t *testing.T) {
+		var called bool
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+		}))
+		defer srv.Close()
+		deliveriesJSON := []byte(fmt.Sprintf(`
+		[{
+			"webhook_delivery_id": "delivery1",
+			"url": "%s",
+			"secret": "secret",
+			"event_kind": 0,
+			"payload": %s
+		}]
+		`, srv.URL, payload))
+
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQueryClaim, webhookDeliveriesBatchSize, maxWebhookDeliveryAttempts).
+			Return(deliveriesJSON, nil)
+		db.On("Exec", dbQueryAttempt, "delivery1", nil, mock.MatchedBy(func(errMsg string) bool {
+			return strings.Contains(errMsg, errWebhookAddressNotAllowed.Error())
+		})).Return(nil)
+		d := newWebhooksDispatcher(viper.New(), hub.New(db, nil))
+
+		err := d.dispatch(context.Background())
+		assert.NoError(t, err)
+		assert.False(t, called)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("redirects are not followed", func(t *testing.T) {
+		var redirected bool
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/internal" {
+				redirected = true
+				return
+			}
+			http.Redirect(w, r, "/internal", http.StatusFound)
+		}))
+		defer srv.Close()
+		deliveriesJSON := []byte(fmt.Sprintf(`
+		[{
+			"webhook_delivery_id": "delivery1",
+			"url": "%s",
+			"secret": "secret",
+			"event_kind": 0,
+			"payload": %s
+		}]
+		`, srv.URL, payload))
+
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQueryClaim, webhookDeliveriesBatchSize, maxWebhookDeliveryAttempts).
+			Return(deliveriesJSON, nil)
+		db.On("Exec", dbQueryAttempt, "delivery1", http.StatusFound, mock.Anything).Return(nil)
+		d := newWebhooksDispatcher(viper.New(), hub.New(db, nil))
+		httpClient := srv.Client()
+		httpClient.CheckRedirect = d.httpClient.CheckRedirect
+		d.httpClient = httpClient
+
+		err := d.dispatch(context.Background())
+		assert.NoError(t, err)
+		assert.False(t, redirected)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestIsWebhookIPAllowed(t *testing.T) {
+	testCases := []struct {
+		ip       string
+		expected bool
+	}{
+		{"127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"169.254.169.254", false},
+		{"192.168.1.1", false},
+		{"::1", false},
+		{"fd00::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"8.8.8.8", true},
+		{"2001:4860:4860::8888", true},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.ip, func(t *testing.T) {
+			assert.Equal(t, tc.expected, isWebhookIPAllowed(net.ParseIP(tc.ip)))
+		})
+	}
+}
+
+func TestSignWebhookPayload(t *testing.T) {
+	payload := []byte(`{"key": "value"}`)
+	sig := signWebhookPayload("secret", payload)
+	assert.True(t, isValidWebhookSignature("secret", payload, sig))
+	assert.False(t, isValidWebhookSignature("other", payload, sig))
+}
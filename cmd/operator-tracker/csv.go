@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/cncf/hub/internal/hub"
+	"sigs.k8s.io/yaml"
+)
+
+// clusterServiceVersion represents the subset of the fields of an OLM cluster
+// service version manifest used to build a hub package.
+type clusterServiceVersion struct {
+	Metadata struct {
+		Annotations map[string]string `json:"annotations"`
+	} `json:"metadata"`
+	Spec struct {
+		DisplayName string   `json:"displayName"`
+		Description string   `json:"description"`
+		Version     string   `json:"version"`
+		Keywords    []string `json:"keywords"`
+		Provider    struct {
+			Name string `json:"name"`
+		} `json:"provider"`
+		Maintainers []struct {
+			Name  string `json:"name"`
+			Email string `json:"email"`
+		} `json:"maintainers"`
+		Links []struct {
+			Name string `json:"name"`
+			URL  string `json:"url"`
+		} `json:"links"`
+		Icon []struct {
+			Data string `json:"base64data"`
+		} `json:"icon"`
+	} `json:"spec"`
+}
+
+// parseClusterServiceVersion parses the cluster service version manifest
+// provided, checking that the fields required are present.
+func parseClusterServiceVersion(data []byte) (*clusterServiceVersion, error) {
+	csv := &clusterServiceVersion{}
+	if err := yaml.Unmarshal(data, csv); err != nil {
+		return nil, err
+	}
+	if csv.Spec.Version == "" {
+		return nil, errors.New("version not provided")
+	}
+	if csv.Spec.Provider.Name == "" {
+		return nil, errors.New("provider name not provided")
+	}
+	return csv, nil
+}
+
+// toPackage builds a hub package with the name provided from the cluster
+// service version.
+func (csv *clusterServiceVersion) toPackage(name string) *hub.Package {
+	p := &hub.Package{
+		Kind:        hub.Operator,
+		Name:        name,
+		DisplayName: csv.Spec.DisplayName,
+		Description: csv.Metadata.Annotations["description"],
+		Keywords:    csv.Spec.Keywords,
+		Readme:      csv.Spec.Description,
+		Version:     csv.Spec.Version,
+		OperatorProvider: &hub.OperatorProvider{
+			Name: csv.Spec.Provider.Name,
+		},
+	}
+	for _, link := range csv.Spec.Links {
+		if link.URL == "" {
+			continue
+		}
+		p.Links = append(p.Links, &hub.Link{
+			Name: link.Name,
+			URL:  link.URL,
+		})
+	}
+	for _, entry := range csv.Spec.Maintainers {
+		if entry.Email != "" {
+			p.Maintainers = append(p.Maintainers, &hub.Maintainer{
+				Name:  entry.Name,
+				Email: entry.Email,
+			})
+		}
+	}
+	return p
+}
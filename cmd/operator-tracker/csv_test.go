@@ -0,0 +1,100 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/cncf/hub/internal/hub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseClusterServiceVersion(t *testing.T) {
+	testCases := []struct {
+		description   string
+		data          string
+		expectedError string
+	}{
+		{
+			"invalid yaml",
+			"spec: [",
+			"error converting YAML to JSON",
+		},
+		{
+			"version not provided",
+			"spec:\n  provider:\n    name: provider1\n",
+			"version not provided",
+		},
+		{
+			"provider name not provided",
+			"spec:\n  version: 1.0.0\n",
+			"provider name not provided",
+		},
+		{
+			"valid cluster service version",
+			"spec:\n  version: 1.0.0\n  provider:\n    name: provider1\n",
+			"",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			csv, err := parseClusterServiceVersion([]byte(tc.data))
+			if tc.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectedError)
+				assert.Nil(t, csv)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "1.0.0", csv.Spec.Version)
+				assert.Equal(t, "provider1", csv.Spec.Provider.Name)
+			}
+		})
+	}
+}
+
+func TestClusterServiceVersionToPackage(t *testing.T) {
+	data := `
+metadata:
+  annotations:
+    description: Operator description
+spec:
+  displayName: Operator 1
+  description: Operator readme
+  version: 1.0.0
+  keywords:
+    - kw1
+    - kw2
+  provider:
+    name: provider1
+  maintainers:
+    - name: maintainer1
+      email: maintainer1@email.com
+    - name: maintainer2
+  links:
+    - name: link1
+      url: https://link1.url
+    - name: link2
+`
+	csv, err := parseClusterServiceVersion([]byte(data))
+	require.NoError(t, err)
+
+	p := csv.toPackage("operator1")
+	assert.Equal(t, &hub.Package{
+		Kind:        hub.Operator,
+		Name:        "operator1",
+		DisplayName: "Operator 1",
+		Description: "Operator description",
+		Keywords:    []string{"kw1", "kw2"},
+		Readme:      "Operator readme",
+		Version:     "1.0.0",
+		OperatorProvider: &hub.OperatorProvider{
+			Name: "provider1",
+		},
+		Links: []*hub.Link{
+			{Name: "link1", URL: "https://link1.url"},
+		},
+		Maintainers: []*hub.Maintainer{
+			{Name: "maintainer1", Email: "maintainer1@email.com"},
+		},
+	}, p)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/cncf/hub/internal/hub"
+	"github.com/cncf/hub/internal/util"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+func main() {
+	// Setup configuration and logger
+	cfg, err := util.SetupConfig("operator-tracker")
+	if err != nil {
+		log.Fatal().Err(err).Msg("Configuration setup failed")
+	}
+	fields := map[string]interface{}{
+		"cmd": "operator-tracker",
+	}
+	if err := util.SetupLogger(cfg, fields); err != nil {
+		log.Fatal().Err(err).Msg("Logger setup failed")
+	}
+
+	// Shutdown gracefully when SIGINT or SIGTERM signal is received
+	log.Info().Int("pid", os.Getpid()).Msg("Operator tracker started")
+	ctx, cancel := context.WithCancel(context.Background())
+	shutdown := make(chan os.Signal, 1)
+	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-shutdown
+		cancel()
+		log.Info().Msg("Operator tracker shutting down..")
+	}()
+
+	// Setup hub api and image store instances
+	db, err := util.SetupDB(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Database setup failed")
+	}
+	hubAPI := hub.New(db, nil)
+	imageStore, err := util.SetupImageStore(cfg, db)
+	if err != nil {
+		log.Fatal().Err(err).Msg("ImageStore setup failed")
+	}
+
+	// Track operators available in the configured location
+	t := newTracker(ctx, hubAPI, imageStore)
+	if err := track(ctx, cfg, t); err != nil {
+		log.Fatal().Err(err).Msg("Error tracking operators")
+	}
+	log.Info().Msg("Operator tracker finished")
+}
+
+// track tracks the operators available in the location provided in the
+// configuration. Operators can be read from a local directory or from a git
+// repository, which will be cloned into a temporary directory.
+func track(ctx context.Context, cfg *viper.Viper, t *tracker) error {
+	basePath := cfg.GetString("tracker.operators.path")
+	if gitURL := cfg.GetString("tracker.operators.gitURL"); gitURL != "" {
+		tmpDir, err := ioutil.TempDir("", "operator-tracker")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmpDir)
+		gitBranch := cfg.GetString("tracker.operators.gitBranch")
+		log.Info().Str("url", gitURL).Str("branch", gitBranch).Msg("Cloning operators git repository")
+		if err := util.CloneGitRepository(ctx, gitURL, gitBranch, tmpDir); err != nil {
+			return err
+		}
+		basePath = filepath.Join(tmpDir, basePath)
+	}
+	if basePath == "" {
+		return errors.New("operators path or git url not provided")
+	}
+	return t.run(basePath)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cncf/hub/internal/hub"
+	"github.com/cncf/hub/internal/img"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// csvFileSuffix represents the suffix of the files containing a cluster
+	// service version manifest.
+	csvFileSuffix = ".clusterserviceversion.yaml"
+)
+
+// tracker is in charge of tracking the operators available in a given
+// directory, registering in the hub the versions not registered yet or whose
+// digest has changed.
+type tracker struct {
+	ctx        context.Context
+	hubAPI     *hub.Hub
+	imageStore img.Store
+
+	// K: operator provider name, V: packages digest
+	packagesDigest map[string]map[string]string
+}
+
+// newTracker creates a new tracker instance.
+func newTracker(ctx context.Context, hubAPI *hub.Hub, imageStore img.Store) *tracker {
+	return &tracker{
+		ctx:            ctx,
+		hubAPI:         hubAPI,
+		imageStore:     imageStore,
+		packagesDigest: make(map[string]map[string]string),
+	}
+}
+
+// run tracks the operators available in the base path provided. Each of the
+// directories in the base path is expected to contain an operator, with one
+// subdirectory per operator version (OLM package manifest layout). Cluster
+// service version manifests inside each version directory, including those
+// in nested directories like manifests (OLM bundle layout), are processed.
+func (t *tracker) run(basePath string) error {
+	operatorsDirs, err := ioutil.ReadDir(basePath)
+	if err != nil {
+		return err
+	}
+	for _, operatorDir := range operatorsDirs {
+		if !operatorDir.IsDir() || strings.HasPrefix(operatorDir.Name(), ".") {
+			continue
+		}
+		log.Info().Str("operator", operatorDir.Name()).Msg("Tracking operator")
+		if err := t.trackOperator(filepath.Join(basePath, operatorDir.Name())); err != nil {
+			log.Error().Err(err).Str("operator", operatorDir.Name()).Msg("Error tracking operator")
+		}
+		select {
+		case <-t.ctx.Done():
+			return t.ctx.Err()
+		default:
+		}
+	}
+	return nil
+}
+
+// trackOperator registers all the versions of the operator located in the
+// path provided, provided that that version has not been already registered
+// and its digest has not changed.
+func (t *tracker) trackOperator(operatorPath string) error {
+	return filepath.Walk(operatorPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(info.Name(), csvFileSuffix) {
+			return nil
+		}
+		if err := t.trackOperatorVersion(filepath.Base(operatorPath), path); err != nil {
+			log.Error().Err(err).Str("file", path).Msg("Error tracking operator version")
+		}
+		return t.ctx.Err()
+	})
+}
+
+// trackOperatorVersion registers the operator version described in the
+// cluster service version file provided when needed.
+func (t *tracker) trackOperatorVersion(name, csvPath string) error {
+	data, err := ioutil.ReadFile(csvPath)
+	if err != nil {
+		return err
+	}
+	csv, err := parseClusterServiceVersion(data)
+	if err != nil {
+		return fmt.Errorf("error parsing cluster service version: %w", err)
+	}
+	p := csv.toPackage(name)
+	p.Digest = fmt.Sprintf("%x", sha256.Sum256(data))
+
+	// Skip this version if it's already registered and hasn't changed
+	packagesDigest, err := t.getPackagesDigest(p.OperatorProvider.Name)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf("%s@%s", p.Name, p.Version)
+	if p.Digest == packagesDigest[key] {
+		return nil
+	}
+
+	// Store operator logo when available
+	if len(csv.Spec.Icon) > 0 && csv.Spec.Icon[0].Data != "" {
+		logoData, err := base64.StdEncoding.DecodeString(csv.Spec.Icon[0].Data)
+		if err != nil {
+			log.Warn().Err(err).Str("file", csvPath).Msg("Logo decoding failed")
+		} else {
+			p.LogoImageID, err = t.imageStore.SaveImage(t.ctx, logoData)
+			if err != nil && !errors.Is(err, image.ErrFormat) {
+				log.Warn().Err(err).Str("file", csvPath).Msg("Save image failed")
+			}
+		}
+	}
+
+	// Register package
+	log.Debug().Str("operator", p.Name).Str("version", p.Version).Msg("Registering operator version")
+	return t.hubAPI.RegisterPackage(t.ctx, p)
+}
+
+// getPackagesDigest returns the digests of the packages registered for the
+// operator provider provided, loading them from the database the first time
+// they are requested.
+func (t *tracker) getPackagesDigest(operatorProviderName string) (map[string]string, error) {
+	if pd, ok := t.packagesDigest[operatorProviderName]; ok {
+		return pd, nil
+	}
+	pd, err := t.hubAPI.GetOperatorProviderPackagesDigest(t.ctx, operatorProviderName)
+	if err != nil {
+		return nil, err
+	}
+	t.packagesDigest[operatorProviderName] = pd
+	return pd, nil
+}
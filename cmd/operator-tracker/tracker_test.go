@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cncf/hub/internal/hub"
+	"github.com/cncf/hub/internal/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackerRun(t *testing.T) {
+	dbQueryDigest := "select get_operator_provider_packages_digest($1::text)"
+	dbQueryRegister := "select register_package($1::jsonb)"
+	csv1 := csvData("1.0.0")
+	csv2 := csvData("0.9.0")
+
+	// Setup operators directory
+	basePath, err := ioutil.TempDir("", "operator-tracker-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(basePath)
+	writeFile(t, filepath.Join(basePath, "operator1", "1.0.0", "operator1.clusterserviceversion.yaml"), csv1)
+	writeFile(t, filepath.Join(basePath, "operator1", "0.9.0", "manifests", "operator1.clusterserviceversion.yaml"), csv2)
+	writeFile(t, filepath.Join(basePath, "operator1", "1.0.0", "crd.yaml"), "kind: CustomResourceDefinition")
+	writeFile(t, filepath.Join(basePath, ".hidden", "1.0.0", "hidden.clusterserviceversion.yaml"), csv1)
+
+	testCases := []struct {
+		description        string
+		packagesDigest     map[string]string
+		expectedRegistered []string
+	}{
+		{
+			"all versions are registered when none are registered yet",
+			map[string]string{},
+			[]string{"operator1@1.0.0", "operator1@0.9.0"},
+		},
+		{
+			"versions already registered are skipped",
+			map[string]string{
+				"operator1@0.9.0": digest(csv2),
+			},
+			[]string{"operator1@1.0.0"},
+		},
+		{
+			"versions whose digest has changed are registered again",
+			map[string]string{
+				"operator1@1.0.0": "outdated",
+				"operator1@0.9.0": digest(csv2),
+			},
+			[]string{"operator1@1.0.0"},
+		},
+		{
+			"nothing is registered when all versions are up to date",
+			map[string]string{
+				"operator1@1.0.0": digest(csv1),
+				"operator1@0.9.0": digest(csv2),
+			},
+			nil,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			db := &tests.DBMock{}
+			pd, _ := json.Marshal(tc.packagesDigest)
+			db.On("QueryRow", dbQueryDigest, "provider1").Return(pd, nil).Once()
+			var registered []string
+			if len(tc.expectedRegistered) > 0 {
+				db.On("Exec", dbQueryRegister, mock.Anything).Run(func(args mock.Arguments) {
+					var p *hub.Package
+					_ = json.Unmarshal(args.Get(1).([]byte), &p)
+					assert.Equal(t, hub.Operator, p.Kind)
+					registered = append(registered, p.Name+"@"+p.Version)
+				}).Return(nil)
+			}
+			tr := newTracker(context.Background(), hub.New(db, nil), &imageStoreMock{})
+
+			err := tr.run(basePath)
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, tc.expectedRegistered, registered)
+			db.AssertExpectations(t)
+		})
+	}
+
+	t.Run("error getting packages digest", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQueryDigest, "provider1").Return(nil, errors.New("fake database error"))
+		tr := newTracker(context.Background(), hub.New(db, nil), &imageStoreMock{})
+
+		err := tr.run(basePath)
+		assert.NoError(t, err)
+		db.AssertNotCalled(t, "Exec", dbQueryRegister, mock.Anything)
+	})
+
+	t.Run("invalid base path", func(t *testing.T) {
+		tr := newTracker(context.Background(), hub.New(&tests.DBMock{}, nil), &imageStoreMock{})
+		err := tr.run(filepath.Join(basePath, "not-found"))
+		assert.Error(t, err)
+	})
+}
+
+// csvData returns a cluster service version manifest for the version given.
+func csvData(version string) string {
+	return fmt.Sprintf("spec:\n  version: %s\n  provider:\n    name: provider1\n", version)
+}
+
+// digest returns the digest of the cluster service version data provided.
+func digest(data string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(data)))
+}
+
+// writeFile writes the data provided to the file given, creating the
+// directories needed.
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, ioutil.WriteFile(path, []byte(data), 0644))
+}
+
+// imageStoreMock is an image store that does not store anything.
+type imageStoreMock struct{}
+
+// SaveImage implements the img.Store interface.
+func (s *imageStoreMock) SaveImage(ctx context.Context, data []byte) (string, error) {
+	return "", nil
+}
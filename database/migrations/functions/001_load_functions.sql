@@ -1,21 +1,83 @@
+{{ template "functions/add_audit_event.sql" }}
 {{ template "functions/semver_gte.sql" }}
 {{ template "functions/add_chart_repository.sql" }}
 {{ template "functions/update_chart_repository.sql" }}
 {{ template "functions/delete_chart_repository.sql" }}
 {{ template "functions/get_chart_repositories.sql" }}
 {{ template "functions/get_chart_repositories_by_user.sql" }}
+{{ template "functions/get_chart_repositories_by_org.sql" }}
 {{ template "functions/get_chart_repository_by_name.sql" }}
 {{ template "functions/get_chart_repository_packages_digest.sql" }}
+{{ template "functions/get_operator_provider_packages_digest.sql" }}
 {{ template "functions/get_packages_stats.sql" }}
 {{ template "functions/get_packages_updates.sql" }}
 {{ template "functions/get_package.sql" }}
 {{ template "functions/register_package.sql" }}
+{{ template "functions/unregister_package.sql" }}
 {{ template "functions/search_packages.sql" }}
 {{ template "functions/get_image.sql" }}
 {{ template "functions/register_image.sql" }}
 {{ template "functions/register_user.sql" }}
 {{ template "functions/verify_email.sql" }}
+{{ template "functions/regenerate_email_verification_code.sql" }}
+{{ template "functions/update_user_email.sql" }}
+{{ template "functions/update_user_password.sql" }}
+{{ template "functions/delete_session.sql" }}
+{{ template "functions/delete_user_session.sql" }}
+{{ template "functions/delete_user_sessions.sql" }}
+{{ template "functions/request_password_reset_code.sql" }}
+{{ template "functions/reset_password.sql" }}
 {{ template "functions/register_session.sql" }}
+{{ template "functions/get_user_sessions.sql" }}
+{{ template "functions/register_user_identity.sql" }}
+{{ template "functions/add_api_key.sql" }}
+{{ template "functions/get_user_api_keys.sql" }}
+{{ template "functions/delete_api_key.sql" }}
+{{ template "functions/add_organization.sql" }}
+{{ template "functions/update_organization.sql" }}
+{{ template "functions/delete_organization.sql" }}
+{{ template "functions/get_user_organizations.sql" }}
+{{ template "functions/get_organization_members.sql" }}
+{{ template "functions/add_organization_member.sql" }}
+{{ template "functions/organization_keeps_owner.sql" }}
+{{ template "functions/delete_organization_member.sql" }}
+{{ template "functions/update_organization_member_role.sql" }}
+{{ template "functions/user_has_organization_role.sql" }}
+{{ template "functions/add_organization_invitation.sql" }}
+{{ template "functions/get_user_organization_invitations.sql" }}
+{{ template "functions/accept_organization_invitation.sql" }}
+{{ template "functions/decline_organization_invitation.sql" }}
+{{ template "functions/user_owns_chart_repository.sql" }}
+{{ template "functions/user_has_chart_repository_role.sql" }}
+{{ template "functions/transfer_chart_repository.sql" }}
+{{ template "functions/accept_chart_repository_transfer.sql" }}
+{{ template "functions/cancel_chart_repository_transfer.sql" }}
+{{ template "functions/decline_chart_repository_transfer.sql" }}
+{{ template "functions/get_organization_chart_repository_transfers.sql" }}
+{{ template "functions/add_tracking_request.sql" }}
+{{ template "functions/get_tracking_request.sql" }}
+{{ template "functions/claim_tracking_requests.sql" }}
+{{ template "functions/rotate_chart_repository_webhook_secret.sql" }}
+{{ template "functions/delete_chart_repository_webhook_secret.sql" }}
+{{ template "functions/generate_chart_repository_verification_token.sql" }}
+{{ template "functions/add_chart_version_tracking_request.sql" }}
+{{ template "functions/register_tracking_run.sql" }}
+{{ template "functions/get_tracking_runs.sql" }}
+{{ template "functions/get_chart_repository_tracking_errors.sql" }}
+{{ template "functions/get_tracking_errors_notification_recipients.sql" }}
+{{ template "functions/add_subscription.sql" }}
+{{ template "functions/delete_subscription.sql" }}
+{{ template "functions/get_user_subscriptions.sql" }}
+{{ template "functions/generate_notifications.sql" }}
+{{ template "functions/claim_notifications.sql" }}
+{{ template "functions/add_webhook.sql" }}
+{{ template "functions/add_webhook_deliveries.sql" }}
+{{ template "functions/delete_webhook.sql" }}
+{{ template "functions/get_chart_repository_webhooks.sql" }}
+{{ template "functions/get_webhook_deliveries.sql" }}
+{{ template "functions/claim_webhook_deliveries.sql" }}
+{{ template "functions/register_webhook_delivery_attempt.sql" }}
+{{ template "functions/get_audit_events.sql" }}
 
 ---- create above / drop below ----
 
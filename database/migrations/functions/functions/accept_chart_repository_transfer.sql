@@ -0,0 +1,66 @@
+-- accept_chart_repository_transfer completes the pending transfer of the chart
+-- repository provided, moving it to the destination organization. The
+-- transfer is kept as a record of the ownership change. Only admins of the
+-- destination organization are allowed to accept it, and only while the user
+-- who requested it is still an owner of the repository. The repository loses
+-- its verified publisher status and verification token, so that the new
+-- owners have to prove its ownership again.
+create or replace function accept_chart_repository_transfer(
+    p_user_id uuid,
+    p_chart_repository_name text,
+    p_request_info jsonb
+) returns void as $$
+declare
+    v_transfer chart_repository_transfer%rowtype;
+    v_chart_repository chart_repository%rowtype;
+begin
+    select t.* into v_transfer
+    from chart_repository_transfer t
+    join chart_repository r using (chart_repository_id)
+    where r.name = p_chart_repository_name
+    and t.accepted_at is null;
+    if not found then
+        raise no_data_found;
+    end if;
+    if not user_has_organization_role(p_user_id, v_transfer.to_organization_id, 'admin') then
+        raise insufficient_privilege;
+    end if;
+
+    -- The repository owner may have changed since the transfer was requested
+    select * into v_chart_repository
+    from chart_repository
+    where chart_repository_id = v_transfer.chart_repository_id;
+    if v_chart_repository.user_id is distinct from v_transfer.from_user_id
+    or v_chart_repository.organization_id is distinct from v_transfer.from_organization_id
+    or v_transfer.requested_by is null
+    or not user_has_chart_repository_role(v_transfer.requested_by, v_transfer.chart_repository_id, 'owner') then
+        raise insufficient_privilege using message = 'transfer requester is no longer an owner of the chart repository';
+    end if;
+
+    update chart_repository set
+        user_id = null,
+        organization_id = v_transfer.to_organization_id,
+        verification_token = null,
+        verified_publisher = false,
+        verification_failures = 0
+    where chart_repository_id = v_transfer.chart_repository_id;
+
+    update chart_repository_transfer set
+        accepted_by = p_user_id,
+        accepted_at = current_timestamp
+    where chart_repository_transfer_id = v_transfer.chart_repository_transfer_id;
+
+    perform add_audit_event(
+        p_user_id,
+        p_request_info,
+        'accept-chart-repository-transfer',
+        p_chart_repository_name,
+        v_transfer.chart_repository_id,
+        v_transfer.to_organization_id,
+        jsonb_build_object(
+            'from_user', (select alias from "user" where user_id = v_transfer.from_user_id),
+            'from_organization', (select name from organization where organization_id = v_transfer.from_organization_id)
+        )
+    );
+end
+$$ language plpgsql;
@@ -0,0 +1,43 @@
+-- accept_organization_invitation accepts the organization invitation
+-- identified by the token provided, adding the user as a member of the
+-- organization with the viewer role. The invitation must have been sent to the
+-- verified email of the user and must not be expired. It returns true if a
+-- valid invitation was found and accepted, or false otherwise.
+create or replace function accept_organization_invitation(
+    p_user_id uuid,
+    p_token uuid,
+    p_request_info jsonb
+) returns boolean as $$
+declare
+    v_organization_id uuid;
+begin
+    delete from organization_invitation
+    where organization_invitation_id = p_token
+    and email = (
+        select email from "user"
+        where user_id = p_user_id
+        and email_verified = true
+    )
+    and expires_at > current_timestamp
+    returning organization_id into v_organization_id;
+    if not found then
+        return false;
+    end if;
+
+    insert into user__organization (user_id, organization_id, organization_role_id)
+    values (p_user_id, v_organization_id, 3)
+    on conflict do nothing;
+
+    perform add_audit_event(
+        p_user_id,
+        p_request_info,
+        'accept-organization-invitation',
+        null,
+        null,
+        v_organization_id,
+        null
+    );
+
+    return true;
+end
+$$ language plpgsql;
@@ -0,0 +1,29 @@
+-- add_api_key adds the provided api key to the database, returning its id.
+-- Only the hash of the key is stored. The api key added is registered in the
+-- audit log.
+create or replace function add_api_key(p_user_id uuid, p_api_key jsonb, p_request_info jsonb)
+returns uuid as $$
+declare
+    v_api_key_id uuid;
+begin
+    insert into api_key (user_id, name, key_hash)
+    values (
+        p_user_id,
+        p_api_key->>'name',
+        p_api_key->>'key_hash'
+    )
+    returning api_key_id into v_api_key_id;
+
+    perform add_audit_event(
+        p_user_id,
+        p_request_info,
+        'add-api-key',
+        p_api_key->>'name',
+        null,
+        null,
+        null
+    );
+
+    return v_api_key_id;
+end
+$$ language plpgsql;
@@ -0,0 +1,34 @@
+-- add_audit_event registers an audit event for the action performed by the
+-- provided user. It is used by the functions performing administrative
+-- actions, so that the event is registered in the same transaction as the
+-- action itself. The request information provided includes the ip and the
+-- user agent of the request that triggered the action.
+create or replace function add_audit_event(
+    p_user_id uuid,
+    p_request_info jsonb,
+    p_action text,
+    p_target text,
+    p_chart_repository_id uuid,
+    p_organization_id uuid,
+    p_details jsonb
+) returns void as $$
+    insert into audit_event (
+        user_id,
+        action,
+        target,
+        chart_repository_id,
+        organization_id,
+        ip,
+        user_agent,
+        details
+    ) values (
+        p_user_id,
+        p_action,
+        p_target,
+        p_chart_repository_id,
+        p_organization_id,
+        nullif(p_request_info->>'ip', '')::inet,
+        nullif(p_request_info->>'user_agent', ''),
+        p_details
+    );
+$$ language sql;
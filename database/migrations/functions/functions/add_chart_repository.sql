@@ -1,21 +1,60 @@
 -- add_chart_repository adds the provided chart repository to the database.
-create or replace function add_chart_repository(p_chart_repository jsonb)
+-- When an organization name is provided, the repository will belong to that
+-- organization (the user must be an admin of it). Otherwise the repository
+-- will belong to the user. The addition is registered in the audit log.
+create or replace function add_chart_repository(p_chart_repository jsonb, p_request_info jsonb)
 returns void as $$
+declare
+    v_user_id uuid := (p_chart_repository->>'user_id')::uuid;
+    v_organization_name text := nullif(p_chart_repository->>'organization_name', '');
+    v_organization_id uuid;
+    v_chart_repository_id uuid;
 begin
-    if (p_chart_repository->>'user_id')::uuid is null then
+    if v_user_id is null then
         raise 'a valid user_id must be provided';
     end if;
 
+    if v_organization_name is not null then
+        select organization_id into v_organization_id
+        from organization
+        where name = v_organization_name;
+        if not found then
+            raise no_data_found;
+        end if;
+        if not user_has_organization_role(v_user_id, v_organization_id, 'admin') then
+            raise insufficient_privilege;
+        end if;
+    end if;
+
     insert into chart_repository (
         name,
         display_name,
         url,
-        user_id
+        chart_repository_kind_id,
+        git_branch,
+        git_path_glob,
+        user_id,
+        organization_id
     ) values (
         p_chart_repository->>'name',
         nullif(p_chart_repository->>'display_name', ''),
         p_chart_repository->>'url',
-        (p_chart_repository->>'user_id')::uuid
+        coalesce((p_chart_repository->>'kind')::int, 0),
+        nullif(p_chart_repository->>'git_branch', ''),
+        nullif(p_chart_repository->>'git_path_glob', ''),
+        case when v_organization_id is null then v_user_id else null end,
+        v_organization_id
+    )
+    returning chart_repository_id into v_chart_repository_id;
+
+    perform add_audit_event(
+        v_user_id,
+        p_request_info,
+        'add-chart-repository',
+        p_chart_repository->>'name',
+        v_chart_repository_id,
+        v_organization_id,
+        jsonb_build_object('url', p_chart_repository->>'url')
     );
 end
 $$ language plpgsql;
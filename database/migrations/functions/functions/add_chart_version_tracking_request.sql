@@ -0,0 +1,46 @@
+-- add_chart_version_tracking_request registers a request to process the chart
+-- version provided from the given repository as soon as possible, returning
+-- the request as a json object. When the same chart version already has a
+-- pending request, that one is returned instead.
+create or replace function add_chart_version_tracking_request(
+    p_chart_repository_name text,
+    p_chart_name text,
+    p_chart_version text
+) returns setof json as $$
+declare
+    v_chart_repository_id uuid;
+    v_tracking_request_id uuid;
+begin
+    select chart_repository_id into v_chart_repository_id
+    from chart_repository
+    where name = p_chart_repository_name;
+    if not found then
+        return;
+    end if;
+
+    select tracking_request_id into v_tracking_request_id
+    from tracking_request
+    where chart_repository_id = v_chart_repository_id
+    and chart_name = p_chart_name
+    and chart_version = p_chart_version
+    and status = 'pending';
+    if not found then
+        insert into tracking_request (chart_repository_id, chart_name, chart_version)
+        values (v_chart_repository_id, p_chart_name, p_chart_version)
+        returning tracking_request_id into v_tracking_request_id;
+    end if;
+
+    return query
+    select json_build_object(
+        'tracking_request_id', tracking_request_id,
+        'chart_name', chart_name,
+        'chart_version', chart_version,
+        'status', status,
+        'created_at', floor(extract(epoch from created_at)),
+        'started_at', floor(extract(epoch from started_at)),
+        'completed_at', floor(extract(epoch from completed_at))
+    )
+    from tracking_request
+    where tracking_request_id = v_tracking_request_id;
+end
+$$ language plpgsql;
@@ -0,0 +1,25 @@
+-- add_organization adds the provided organization to the database, adding the
+-- user provided as its first member with the owner role.
+create or replace function add_organization(p_user_id uuid, p_org jsonb)
+returns void as $$
+declare
+    v_organization_id uuid;
+begin
+    insert into organization (
+        name,
+        display_name,
+        description,
+        home_url,
+        logo_url
+    ) values (
+        p_org->>'name',
+        nullif(p_org->>'display_name', ''),
+        nullif(p_org->>'description', ''),
+        nullif(p_org->>'home_url', ''),
+        nullif(p_org->>'logo_url', '')
+    ) returning organization_id into v_organization_id;
+
+    insert into user__organization (user_id, organization_id, organization_role_id)
+    values (p_user_id, v_organization_id, 0);
+end
+$$ language plpgsql;
@@ -0,0 +1,65 @@
+-- add_organization_invitation invites the email provided to join the
+-- organization given with the viewer role. The email does not need to belong
+-- to a registered user. Inviting an email with a pending invitation renews
+-- it, replacing its token. The token of the invitation is returned, or
+-- nothing if the email already belongs to a member of the organization. Only
+-- owners of the organization are allowed to invite new members to it.
+create or replace function add_organization_invitation(
+    p_user_id uuid,
+    p_org_name text,
+    p_email text,
+    p_request_info jsonb
+) returns setof uuid as $$
+declare
+    v_organization_id uuid;
+    v_token uuid;
+begin
+    select organization_id into v_organization_id
+    from organization
+    where name = p_org_name;
+    if not found then
+        raise no_data_found;
+    end if;
+    if not user_has_organization_role(p_user_id, v_organization_id, 'owner') then
+        raise insufficient_privilege;
+    end if;
+
+    if exists (
+        select 1
+        from user__organization uo
+        join "user" u using (user_id)
+        where uo.organization_id = v_organization_id
+        and u.email = p_email
+    ) then
+        return;
+    end if;
+
+    insert into organization_invitation (
+        organization_id,
+        email,
+        invited_by
+    ) values (
+        v_organization_id,
+        p_email,
+        p_user_id
+    )
+    on conflict (organization_id, email) do update set
+        organization_invitation_id = gen_random_uuid(),
+        invited_by = excluded.invited_by,
+        created_at = current_timestamp,
+        expires_at = excluded.expires_at
+    returning organization_invitation_id into v_token;
+
+    perform add_audit_event(
+        p_user_id,
+        p_request_info,
+        'add-organization-invitation',
+        p_email,
+        null,
+        v_organization_id,
+        null
+    );
+
+    return next v_token;
+end
+$$ language plpgsql;
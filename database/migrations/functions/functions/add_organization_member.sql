@@ -0,0 +1,45 @@
+-- add_organization_member adds the user identified by the alias provided as a
+-- member of the organization with the viewer role. Only owners of the
+-- organization are allowed to add new members to it. New members are
+-- registered in the audit log.
+create or replace function add_organization_member(
+    p_user_id uuid,
+    p_org_name text,
+    p_user_alias text,
+    p_request_info jsonb
+) returns void as $$
+declare
+    v_organization_id uuid;
+begin
+    select organization_id into v_organization_id
+    from organization
+    where name = p_org_name;
+    if not found then
+        raise no_data_found;
+    end if;
+    if not user_has_organization_role(p_user_id, v_organization_id, 'owner') then
+        raise insufficient_privilege;
+    end if;
+
+    insert into user__organization (user_id, organization_id, organization_role_id)
+    values (
+        (select user_id from "user" where alias = p_user_alias),
+        v_organization_id,
+        3
+    )
+    on conflict do nothing;
+    if not found then
+        return;
+    end if;
+
+    perform add_audit_event(
+        p_user_id,
+        p_request_info,
+        'add-organization-member',
+        p_user_alias,
+        null,
+        v_organization_id,
+        jsonb_build_object('role', 'viewer')
+    );
+end
+$$ language plpgsql;
@@ -0,0 +1,45 @@
+-- add_tracking_request registers a request to track the chart repository
+-- provided as soon as possible, returning the request as a json object. When
+-- the repository already has a pending request to be tracked, that one is
+-- returned instead.
+-- Only users with the publisher role (or higher) in the repository are allowed
+-- to request it to be tracked.
+create or replace function add_tracking_request(p_user_id uuid, p_chart_repository_name text)
+returns setof json as $$
+declare
+    v_chart_repository_id uuid;
+    v_tracking_request_id uuid;
+begin
+    select chart_repository_id into v_chart_repository_id
+    from chart_repository
+    where name = p_chart_repository_name;
+    if not found then
+        return;
+    end if;
+    if not user_has_chart_repository_role(p_user_id, v_chart_repository_id, 'publisher') then
+        raise insufficient_privilege;
+    end if;
+
+    select tracking_request_id into v_tracking_request_id
+    from tracking_request
+    where chart_repository_id = v_chart_repository_id
+    and chart_name is null
+    and status = 'pending';
+    if not found then
+        insert into tracking_request (chart_repository_id)
+        values (v_chart_repository_id)
+        returning tracking_request_id into v_tracking_request_id;
+    end if;
+
+    return query
+    select json_build_object(
+        'tracking_request_id', tracking_request_id,
+        'status', status,
+        'created_at', floor(extract(epoch from created_at)),
+        'started_at', floor(extract(epoch from started_at)),
+        'completed_at', floor(extract(epoch from completed_at))
+    )
+    from tracking_request
+    where tracking_request_id = v_tracking_request_id;
+end
+$$ language plpgsql;
@@ -0,0 +1,46 @@
+-- add_webhook adds the provided webhook to the chart repository given. Only
+-- admins of the repository are allowed to add webhooks to it. The webhook
+-- added is registered in the audit log.
+create or replace function add_webhook(
+    p_user_id uuid,
+    p_chart_repository_name text,
+    p_webhook jsonb,
+    p_request_info jsonb
+) returns setof uuid as $$
+declare
+    v_chart_repository_id uuid;
+    v_organization_id uuid;
+    v_webhook_id uuid;
+begin
+    select chart_repository_id, organization_id into v_chart_repository_id, v_organization_id
+    from chart_repository
+    where name = p_chart_repository_name;
+    if not found then
+        return;
+    end if;
+    if not user_has_chart_repository_role(p_user_id, v_chart_repository_id, 'admin') then
+        raise insufficient_privilege;
+    end if;
+
+    insert into webhook (chart_repository_id, url, secret, event_kinds)
+    values (
+        v_chart_repository_id,
+        p_webhook->>'url',
+        p_webhook->>'secret',
+        (select array(select jsonb_array_elements_text(p_webhook->'event_kinds')))::int[]
+    )
+    returning webhook_id into v_webhook_id;
+
+    perform add_audit_event(
+        p_user_id,
+        p_request_info,
+        'add-webhook',
+        v_webhook_id::text,
+        v_chart_repository_id,
+        v_organization_id,
+        jsonb_build_object('url', p_webhook->>'url')
+    );
+
+    return next v_webhook_id;
+end
+$$ language plpgsql;
@@ -0,0 +1,34 @@
+-- add_webhook_deliveries registers a delivery for each of the webhooks of the
+-- chart repository the provided package belongs to that are interested in the
+-- event kind given. The payload delivered includes the details of the package
+-- version, so it must be called before the version snapshot is deleted.
+create or replace function add_webhook_deliveries(
+    p_package_id uuid,
+    p_version text,
+    p_event_kind_id int
+) returns void as $$
+    insert into webhook_delivery (webhook_id, event_kind_id, payload)
+    select
+        w.webhook_id,
+        p_event_kind_id,
+        jsonb_build_object(
+            'event_kind', ek.name,
+            'package', jsonb_build_object(
+                'package_id', p.package_id,
+                'name', p.name,
+                'version', p_version,
+                'digest', s.digest
+            ),
+            'chart_repository', jsonb_build_object(
+                'name', r.name,
+                'url', r.url
+            )
+        )
+    from package p
+    join chart_repository r using (chart_repository_id)
+    join webhook w using (chart_repository_id)
+    join event_kind ek on ek.event_kind_id = p_event_kind_id
+    left join snapshot s on s.package_id = p.package_id and s.version = p_version
+    where p.package_id = p_package_id
+    and p_event_kind_id = any(w.event_kinds);
+$$ language sql;
@@ -0,0 +1,39 @@
+-- cancel_chart_repository_transfer cancels the pending transfer of the chart
+-- repository provided. Only owners of the repository are allowed to cancel
+-- it.
+create or replace function cancel_chart_repository_transfer(
+    p_user_id uuid,
+    p_chart_repository_name text,
+    p_request_info jsonb
+) returns void as $$
+declare
+    v_transfer chart_repository_transfer%rowtype;
+begin
+    select t.* into v_transfer
+    from chart_repository_transfer t
+    join chart_repository r using (chart_repository_id)
+    where r.name = p_chart_repository_name
+    and t.accepted_at is null;
+    if not found then
+        raise no_data_found;
+    end if;
+    if not user_has_chart_repository_role(p_user_id, v_transfer.chart_repository_id, 'owner') then
+        raise insufficient_privilege;
+    end if;
+
+    delete from chart_repository_transfer
+    where chart_repository_transfer_id = v_transfer.chart_repository_transfer_id;
+
+    perform add_audit_event(
+        p_user_id,
+        p_request_info,
+        'cancel-chart-repository-transfer',
+        p_chart_repository_name,
+        v_transfer.chart_repository_id,
+        (select organization_id from chart_repository where chart_repository_id = v_transfer.chart_repository_id),
+        jsonb_build_object(
+            'to_organization', (select name from organization where organization_id = v_transfer.to_organization_id)
+        )
+    );
+end
+$$ language plpgsql;
@@ -0,0 +1,57 @@
+-- claim_notifications returns up to p_limit pending notifications as a json
+-- array, including the details of the event they belong to. Notifications
+-- returned have their attempts increased, so they won't be claimed again
+-- until the backoff period for the attempts made so far has elapsed.
+-- Notifications that have reached the maximum number of attempts provided
+-- are not claimed anymore.
+create or replace function claim_notifications(p_limit int, p_max_attempts int)
+returns setof json as $$
+    with claimed as (
+        update notification set
+            attempts = attempts + 1,
+            last_attempt_at = current_timestamp
+        where notification_id in (
+            select notification_id
+            from notification
+            where processed = false
+            and attempts < p_max_attempts
+            and (
+                last_attempt_at is null
+                or last_attempt_at < current_timestamp - interval '1 minute' * power(2, attempts - 1)
+            )
+            order by created_at asc
+            limit p_limit
+            for update skip locked
+        )
+        returning *
+    )
+    select coalesce(json_agg(json_build_object(
+        'notification_id', c.notification_id,
+        'email', u.email,
+        'event_kind', e.event_kind_id,
+        'package', json_build_object(
+            'package_id', p.package_id,
+            'kind', p.package_kind_id,
+            'name', p.name,
+            'display_name', p.display_name,
+            'version', e.package_version,
+            'chart_repository', case when r.chart_repository_id is not null then (
+                json_build_object(
+                    'name', r.name,
+                    'display_name', r.display_name
+                )
+            ) else null end,
+            'operator_provider', case when op.operator_provider_id is not null then (
+                json_build_object(
+                    'name', op.name
+                )
+            ) else null end
+        )
+    ) order by c.created_at asc), '[]')
+    from claimed c
+    join event e on e.event_id = c.event_id
+    join "user" u on u.user_id = c.user_id
+    join package p on p.package_id = e.package_id
+    left join chart_repository r on r.chart_repository_id = p.chart_repository_id
+    left join operator_provider op on op.operator_provider_id = p.operator_provider_id;
+$$ language sql;
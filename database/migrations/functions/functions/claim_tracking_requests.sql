@@ -0,0 +1,41 @@
+-- claim_tracking_requests returns the pending tracking requests as a json
+-- array, including the details of the repository they belong to. Requests
+-- returned are marked as processing, so they won't be claimed again. Requests
+-- that have been processing for over an hour are considered abandoned (i.e.
+-- the tracker crashed while processing them) and are claimed again.
+create or replace function claim_tracking_requests()
+returns setof json as $$
+    with claimed as (
+        update tracking_request set
+            status = 'processing',
+            started_at = current_timestamp
+        where tracking_request_id in (
+            select tracking_request_id
+            from tracking_request
+            where status = 'pending'
+            or (
+                status = 'processing'
+                and started_at < current_timestamp - '1 hour'::interval
+            )
+            for update skip locked
+        )
+        returning *
+    )
+    select coalesce(json_agg(json_build_object(
+        'tracking_request_id', c.tracking_request_id,
+        'chart_name', c.chart_name,
+        'chart_version', c.chart_version,
+        'chart_repository', json_build_object(
+            'chart_repository_id', r.chart_repository_id,
+            'name', r.name,
+            'display_name', r.display_name,
+            'url', r.url,
+            'kind', r.chart_repository_kind_id,
+            'git_branch', r.git_branch,
+            'git_path_glob', r.git_path_glob,
+            'last_tracking_ts', floor(extract(epoch from r.last_tracking_ts))
+        )
+    ) order by c.created_at asc), '[]')
+    from claimed c
+    join chart_repository r using (chart_repository_id);
+$$ language sql;
@@ -0,0 +1,37 @@
+-- claim_webhook_deliveries returns up to p_limit pending webhook deliveries as
+-- a json array, including the url and secret of the webhook they belong to.
+-- Deliveries returned have their attempts increased, so they won't be claimed
+-- again until the backoff period for the attempts made so far has elapsed.
+-- Deliveries that have reached the maximum number of attempts provided are
+-- not claimed anymore.
+create or replace function claim_webhook_deliveries(p_limit int, p_max_attempts int)
+returns setof json as $$
+    with claimed as (
+        update webhook_delivery set
+            attempts = attempts + 1,
+            last_attempt_at = current_timestamp
+        where webhook_delivery_id in (
+            select webhook_delivery_id
+            from webhook_delivery
+            where processed = false
+            and attempts < p_max_attempts
+            and (
+                last_attempt_at is null
+                or last_attempt_at < current_timestamp - interval '1 minute' * power(2, attempts - 1)
+            )
+            order by created_at asc
+            limit p_limit
+            for update skip locked
+        )
+        returning *
+    )
+    select coalesce(json_agg(json_build_object(
+        'webhook_delivery_id', c.webhook_delivery_id,
+        'url', w.url,
+        'secret', w.secret,
+        'event_kind', c.event_kind_id,
+        'payload', c.payload
+    ) order by c.created_at asc), '[]')
+    from claimed c
+    join webhook w using (webhook_id);
+$$ language sql;
@@ -0,0 +1,37 @@
+-- decline_chart_repository_transfer declines the pending transfer of the chart
+-- repository provided. Only admins of the destination organization are
+-- allowed to decline it.
+create or replace function decline_chart_repository_transfer(
+    p_user_id uuid,
+    p_chart_repository_name text,
+    p_request_info jsonb
+) returns void as $$
+declare
+    v_transfer chart_repository_transfer%rowtype;
+begin
+    select t.* into v_transfer
+    from chart_repository_transfer t
+    join chart_repository r using (chart_repository_id)
+    where r.name = p_chart_repository_name
+    and t.accepted_at is null;
+    if not found then
+        raise no_data_found;
+    end if;
+    if not user_has_organization_role(p_user_id, v_transfer.to_organization_id, 'admin') then
+        raise insufficient_privilege;
+    end if;
+
+    delete from chart_repository_transfer
+    where chart_repository_transfer_id = v_transfer.chart_repository_transfer_id;
+
+    perform add_audit_event(
+        p_user_id,
+        p_request_info,
+        'decline-chart-repository-transfer',
+        p_chart_repository_name,
+        v_transfer.chart_repository_id,
+        v_transfer.to_organization_id,
+        null
+    );
+end
+$$ language plpgsql;
@@ -0,0 +1,37 @@
+-- decline_organization_invitation declines the organization invitation
+-- identified by the token provided, which must have been sent to the verified
+-- email of the user. It returns true if a pending invitation was found and
+-- declined, or false otherwise.
+create or replace function decline_organization_invitation(
+    p_user_id uuid,
+    p_token uuid,
+    p_request_info jsonb
+) returns boolean as $$
+declare
+    v_organization_id uuid;
+begin
+    delete from organization_invitation
+    where organization_invitation_id = p_token
+    and email = (
+        select email from "user"
+        where user_id = p_user_id
+        and email_verified = true
+    )
+    returning organization_id into v_organization_id;
+    if not found then
+        return false;
+    end if;
+
+    perform add_audit_event(
+        p_user_id,
+        p_request_info,
+        'decline-organization-invitation',
+        null,
+        null,
+        v_organization_id,
+        null
+    );
+
+    return true;
+end
+$$ language plpgsql;
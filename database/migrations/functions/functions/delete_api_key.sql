@@ -0,0 +1,27 @@
+-- delete_api_key deletes the provided api key from the database. Only the
+-- user owning the key is allowed to delete it, so keys owned by other users
+-- are not found. The api key deleted is registered in the audit log.
+create or replace function delete_api_key(p_user_id uuid, p_api_key_id uuid, p_request_info jsonb)
+returns void as $$
+declare
+    v_name text;
+begin
+    delete from api_key
+    where user_id = p_user_id
+    and api_key_id = p_api_key_id
+    returning name into v_name;
+    if not found then
+        raise no_data_found;
+    end if;
+
+    perform add_audit_event(
+        p_user_id,
+        p_request_info,
+        'delete-api-key',
+        v_name,
+        null,
+        null,
+        null
+    );
+end
+$$ language plpgsql;
@@ -1,8 +1,39 @@
 -- delete_chart_repository deletes the provided chart repository from the
--- database.
-create or replace function delete_chart_repository(p_chart_repository jsonb)
+-- database. Repositories can be deleted by the user owning them or, when they
+-- belong to an organization, by its admins. The deletion is registered in the
+-- audit log.
+create or replace function delete_chart_repository(p_chart_repository jsonb, p_request_info jsonb)
 returns void as $$
+declare
+    v_chart_repository_id uuid;
+    v_organization_id uuid;
+    v_url text;
+begin
+    select chart_repository_id, organization_id, url into v_chart_repository_id, v_organization_id, v_url
+    from chart_repository
+    where name = p_chart_repository->>'name';
+    if not found then
+        raise no_data_found;
+    end if;
+    if not user_has_chart_repository_role(
+        (p_chart_repository->>'user_id')::uuid,
+        v_chart_repository_id,
+        'admin'
+    ) then
+        raise insufficient_privilege;
+    end if;
+
     delete from chart_repository
-    where name = p_chart_repository->>'name'
-    and user_id = (p_chart_repository->>'user_id')::uuid;
-$$ language sql;
+    where chart_repository_id = v_chart_repository_id;
+
+    perform add_audit_event(
+        (p_chart_repository->>'user_id')::uuid,
+        p_request_info,
+        'delete-chart-repository',
+        p_chart_repository->>'name',
+        null,
+        v_organization_id,
+        jsonb_build_object('url', v_url)
+    );
+end
+$$ language plpgsql;
@@ -0,0 +1,37 @@
+-- delete_chart_repository_webhook_secret deletes the webhook secret of the
+-- chart repository provided, disabling its webhook. Only admins of the
+-- repository are allowed to delete it. The deletion is registered in the
+-- audit log.
+create or replace function delete_chart_repository_webhook_secret(
+    p_user_id uuid,
+    p_chart_repository_name text,
+    p_request_info jsonb
+) returns void as $$
+declare
+    v_chart_repository_id uuid;
+    v_organization_id uuid;
+begin
+    select chart_repository_id, organization_id into v_chart_repository_id, v_organization_id
+    from chart_repository
+    where name = p_chart_repository_name;
+    if not found then
+        raise no_data_found;
+    end if;
+    if not user_has_chart_repository_role(p_user_id, v_chart_repository_id, 'admin') then
+        raise insufficient_privilege;
+    end if;
+
+    update chart_repository set webhook_secret = null
+    where chart_repository_id = v_chart_repository_id;
+
+    perform add_audit_event(
+        p_user_id,
+        p_request_info,
+        'delete-chart-repository-webhook-secret',
+        p_chart_repository_name,
+        v_chart_repository_id,
+        v_organization_id,
+        null
+    );
+end
+$$ language plpgsql;
@@ -0,0 +1,21 @@
+-- delete_organization deletes the provided organization from the database.
+-- Only owners of the organization are allowed to delete it.
+create or replace function delete_organization(p_user_id uuid, p_org_name text)
+returns void as $$
+declare
+    v_organization_id uuid;
+begin
+    select organization_id into v_organization_id
+    from organization
+    where name = p_org_name;
+    if not found then
+        raise no_data_found;
+    end if;
+    if not user_has_organization_role(p_user_id, v_organization_id, 'owner') then
+        raise insufficient_privilege;
+    end if;
+
+    delete from organization
+    where organization_id = v_organization_id;
+end
+$$ language plpgsql;
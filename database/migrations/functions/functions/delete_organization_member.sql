@@ -0,0 +1,51 @@
+-- delete_organization_member removes the user identified by the alias provided
+-- from the organization. Only owners of the organization are allowed to
+-- remove members from it. Organizations must always have at least one owner.
+-- Members removed are registered in the audit log.
+create or replace function delete_organization_member(
+    p_user_id uuid,
+    p_org_name text,
+    p_user_alias text,
+    p_request_info jsonb
+) returns void as $$
+declare
+    v_organization_id uuid;
+    v_member_user_id uuid;
+begin
+    select organization_id into v_organization_id
+    from organization
+    where name = p_org_name;
+    if not found then
+        raise no_data_found;
+    end if;
+    if not user_has_organization_role(p_user_id, v_organization_id, 'owner') then
+        raise insufficient_privilege;
+    end if;
+
+    select u.user_id into v_member_user_id
+    from "user" u
+    join user__organization uo using (user_id)
+    where u.alias = p_user_alias
+    and uo.organization_id = v_organization_id;
+    if not found then
+        return;
+    end if;
+    if not organization_keeps_owner(v_organization_id, v_member_user_id) then
+        raise check_violation using message = 'organization must have at least one owner';
+    end if;
+
+    delete from user__organization
+    where organization_id = v_organization_id
+    and user_id = v_member_user_id;
+
+    perform add_audit_event(
+        p_user_id,
+        p_request_info,
+        'delete-organization-member',
+        p_user_alias,
+        null,
+        v_organization_id,
+        null
+    );
+end
+$$ language plpgsql;
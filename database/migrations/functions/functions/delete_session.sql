@@ -0,0 +1,17 @@
+-- delete_session deletes the provided session from the database, logging the
+-- user out. The logout is registered in the audit log.
+create or replace function delete_session(p_session_id bytea, p_request_info jsonb)
+returns void as $$
+declare
+    v_user_id uuid;
+begin
+    delete from session
+    where session_id = p_session_id
+    returning user_id into v_user_id;
+    if not found then
+        return;
+    end if;
+
+    perform add_audit_event(v_user_id, p_request_info, 'logout', null, null, null, null);
+end
+$$ language plpgsql;
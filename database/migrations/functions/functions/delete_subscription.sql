@@ -0,0 +1,8 @@
+-- delete_subscription deletes the provided subscription from the database.
+create or replace function delete_subscription(p_user_id uuid, p_subscription jsonb)
+returns void as $$
+    delete from subscription
+    where user_id = p_user_id
+    and package_id = (p_subscription->>'package_id')::uuid
+    and event_kind_id = (p_subscription->>'event_kind')::int;
+$$ language sql;
@@ -0,0 +1,26 @@
+-- delete_user_session deletes the session identified by the public id
+-- provided of the user given. The deletion is registered in the audit log.
+create or replace function delete_user_session(
+    p_user_id uuid,
+    p_session_public_id uuid,
+    p_request_info jsonb
+) returns void as $$
+begin
+    delete from session
+    where public_id = p_session_public_id
+    and user_id = p_user_id;
+    if not found then
+        return;
+    end if;
+
+    perform add_audit_event(
+        p_user_id,
+        p_request_info,
+        'delete-session',
+        p_session_public_id::text,
+        null,
+        null,
+        null
+    );
+end
+$$ language plpgsql;
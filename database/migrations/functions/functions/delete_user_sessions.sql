@@ -0,0 +1,9 @@
+-- delete_user_sessions deletes all the sessions of the provided user, logging
+-- the user out everywhere. The api keys of the user are revoked as well. The
+-- deletion is registered in the audit log.
+create or replace function delete_user_sessions(p_user_id uuid, p_request_info jsonb)
+returns void as $$
+    delete from session where user_id = p_user_id;
+    delete from api_key where user_id = p_user_id;
+    select add_audit_event(p_user_id, p_request_info, 'delete-sessions', null, null, null, null);
+$$ language sql;
@@ -0,0 +1,46 @@
+-- delete_webhook deletes the webhook provided from the chart repository given,
+-- returning its id. Nothing is returned when the webhook is not found. Only
+-- admins of the repository are allowed to delete its webhooks. The webhook
+-- deleted is registered in the audit log.
+create or replace function delete_webhook(
+    p_user_id uuid,
+    p_chart_repository_name text,
+    p_webhook_id uuid,
+    p_request_info jsonb
+) returns setof uuid as $$
+declare
+    v_chart_repository_id uuid;
+    v_organization_id uuid;
+    v_url text;
+begin
+    select chart_repository_id, organization_id into v_chart_repository_id, v_organization_id
+    from chart_repository
+    where name = p_chart_repository_name;
+    if not found then
+        return;
+    end if;
+    if not user_has_chart_repository_role(p_user_id, v_chart_repository_id, 'admin') then
+        raise insufficient_privilege;
+    end if;
+
+    delete from webhook
+    where webhook_id = p_webhook_id
+    and chart_repository_id = v_chart_repository_id
+    returning url into v_url;
+    if not found then
+        return;
+    end if;
+
+    perform add_audit_event(
+        p_user_id,
+        p_request_info,
+        'delete-webhook',
+        p_webhook_id::text,
+        v_chart_repository_id,
+        v_organization_id,
+        jsonb_build_object('url', v_url)
+    );
+
+    return next p_webhook_id;
+end
+$$ language plpgsql;
@@ -0,0 +1,30 @@
+-- generate_chart_repository_verification_token generates a new verification
+-- token for the chart repository provided, replacing the existing one if any,
+-- and returns it. The repository is not considered a verified publisher until
+-- the tracker finds the new token in its metadata file. Only admins of the
+-- repository are allowed to generate it.
+create or replace function generate_chart_repository_verification_token(
+    p_user_id uuid,
+    p_chart_repository_name text
+) returns setof text as $$
+declare
+    v_chart_repository_id uuid;
+begin
+    select chart_repository_id into v_chart_repository_id
+    from chart_repository
+    where name = p_chart_repository_name;
+    if not found then
+        return;
+    end if;
+    if not user_has_chart_repository_role(p_user_id, v_chart_repository_id, 'admin') then
+        raise insufficient_privilege;
+    end if;
+
+    return query
+    update chart_repository set
+        verification_token = encode(gen_random_bytes(16), 'hex'),
+        verified_publisher = false
+    where chart_repository_id = v_chart_repository_id
+    returning verification_token;
+end
+$$ language plpgsql;
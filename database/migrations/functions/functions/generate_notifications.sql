@@ -0,0 +1,25 @@
+-- generate_notifications creates a notification for each of the users
+-- subscribed to the events not processed yet. Events are marked as processed
+-- once their notifications have been generated.
+create or replace function generate_notifications()
+returns void as $$
+    with processed_events as (
+        update event set
+            processed = true,
+            processed_at = current_timestamp
+        where event_id in (
+            select event_id
+            from event
+            where processed = false
+            for update skip locked
+        )
+        returning event_id, package_id, event_kind_id
+    )
+    insert into notification (event_id, user_id)
+    select e.event_id, s.user_id
+    from processed_events e
+    join subscription s using (package_id, event_kind_id)
+    join "user" u using (user_id)
+    where u.email_verified = true
+    on conflict do nothing;
+$$ language sql;
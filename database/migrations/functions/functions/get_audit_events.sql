@@ -0,0 +1,43 @@
+-- get_audit_events returns the audit events visible to the provided user as a
+-- json array, most recent first. Users can see the events they triggered, as
+-- well as the ones registered for the organizations they belong to. The
+-- organization recorded when the event was registered is used, so events are
+-- not exposed to the members of an organization a chart repository has been
+-- transferred to later. The ip and user agent of the request that triggered
+-- the event are only returned to the user who triggered it and to the owners
+-- and admins of the organization.
+create or replace function get_audit_events(
+    p_user_id uuid,
+    p_limit int,
+    p_offset int
+) returns setof json as $$
+    select coalesce(json_agg(json_build_object(
+        'audit_event_id', ae.audit_event_id,
+        'user_alias', u.alias,
+        'action', ae.action,
+        'target', ae.target,
+        'chart_repository_name', r.name,
+        'organization_name', o.name,
+        'ip', case when ae.privileged then host(ae.ip) end,
+        'user_agent', case when ae.privileged then ae.user_agent end,
+        'details', ae.details,
+        'created_at', floor(extract(epoch from ae.created_at))
+    ) order by ae.created_at desc), '[]')
+    from (
+        select
+            ae.*,
+            ae.user_id = p_user_id or coalesce(uo.organization_role_id <= 1, false) as privileged
+        from audit_event ae
+        left join user__organization uo
+            on uo.organization_id = ae.organization_id
+            and uo.user_id = p_user_id
+        where ae.user_id = p_user_id
+        or uo.user_id is not null
+        order by ae.created_at desc
+        limit p_limit
+        offset p_offset
+    ) ae
+    left join "user" u on u.user_id = ae.user_id
+    left join chart_repository r on r.chart_repository_id = ae.chart_repository_id
+    left join organization o on o.organization_id = ae.organization_id;
+$$ language sql;
@@ -6,7 +6,14 @@ returns setof json as $$
         'chart_repository_id', chart_repository_id,
         'name', name,
         'display_name', display_name,
-        'url', url
+        'url', url,
+        'kind', chart_repository_kind_id,
+        'git_branch', git_branch,
+        'git_path_glob', git_path_glob,
+        'last_tracking_ts', floor(extract(epoch from last_tracking_ts)),
+        'verification_token', verification_token,
+        'verified_publisher', verified_publisher,
+        'verification_failures', verification_failures
     )), '[]')
     from chart_repository;
 $$ language sql;
@@ -0,0 +1,24 @@
+-- get_chart_repositories_by_org returns all available chart repositories that
+-- belong to the provided organization as a json array. Only members of the
+-- organization are allowed to get them.
+create or replace function get_chart_repositories_by_org(p_user_id uuid, p_org_name text)
+returns setof json as $$
+    select coalesce(json_agg(json_build_object(
+        'chart_repository_id', r.chart_repository_id,
+        'name', r.name,
+        'display_name', r.display_name,
+        'url', r.url,
+        'kind', r.chart_repository_kind_id,
+        'git_branch', r.git_branch,
+        'git_path_glob', r.git_path_glob,
+        'last_tracking_ts', floor(extract(epoch from r.last_tracking_ts)),
+        'last_tracking_errors', r.last_tracking_errors
+    )), '[]')
+    from chart_repository r
+    join organization o using (organization_id)
+    where o.name = p_org_name
+    and o.organization_id in (
+        select organization_id from user__organization
+        where user_id = p_user_id
+    );
+$$ language sql;
@@ -7,6 +7,9 @@ returns setof json as $$
         'name', name,
         'display_name', display_name,
         'url', url,
+        'kind', chart_repository_kind_id,
+        'git_branch', git_branch,
+        'git_path_glob', git_path_glob,
         'last_tracking_ts', floor(extract(epoch from last_tracking_ts)),
         'last_tracking_errors', last_tracking_errors
     )), '[]')
@@ -1,13 +1,23 @@
 -- get_chart_repository_by_name returns the repository identified by the name
--- provided as a json object.
+-- provided as a json object, including the name of the organization owning
+-- it, if any.
 create or replace function get_chart_repository_by_name(p_name text)
 returns setof json as $$
     select json_build_object(
-        'chart_repository_id', chart_repository_id,
-        'name', name,
-        'display_name', display_name,
-        'url', url
+        'chart_repository_id', r.chart_repository_id,
+        'name', r.name,
+        'display_name', r.display_name,
+        'url', r.url,
+        'kind', r.chart_repository_kind_id,
+        'git_branch', r.git_branch,
+        'git_path_glob', r.git_path_glob,
+        'last_tracking_ts', floor(extract(epoch from r.last_tracking_ts)),
+        'verification_token', r.verification_token,
+        'verified_publisher', r.verified_publisher,
+        'verification_failures', r.verification_failures,
+        'organization_name', o.name
     )
-    from chart_repository
-    where name = p_name;
+    from chart_repository r
+    left join organization o using (organization_id)
+    where r.name = p_name;
 $$ language sql;
@@ -0,0 +1,37 @@
+-- get_chart_repository_tracking_errors returns the errors that happened during
+-- the last tracking of the chart repository provided as a json object. When
+-- some kinds are provided, only errors of those kinds are returned. Only the
+-- repository owner is allowed to get them.
+create or replace function get_chart_repository_tracking_errors(
+    p_user_id uuid,
+    p_chart_repository_name text,
+    p_kinds text[]
+) returns setof json as $$
+declare
+    v_chart_repository_id uuid;
+    v_last_tracking_errors jsonb;
+begin
+    select chart_repository_id, last_tracking_errors
+    into v_chart_repository_id, v_last_tracking_errors
+    from chart_repository
+    where name = p_chart_repository_name;
+    if not found then
+        return;
+    end if;
+    if not user_owns_chart_repository(p_user_id, v_chart_repository_id) then
+        raise insufficient_privilege;
+    end if;
+
+    return query
+    select json_build_object(
+        'errors', (
+            select coalesce(json_agg(e), '[]')
+            from jsonb_array_elements(v_last_tracking_errors->'errors') e
+            where cardinality(p_kinds) is null
+            or cardinality(p_kinds) = 0
+            or e->>'kind' = any(p_kinds)
+        ),
+        'suppressed', coalesce((v_last_tracking_errors->>'suppressed')::int, 0)
+    );
+end
+$$ language plpgsql;
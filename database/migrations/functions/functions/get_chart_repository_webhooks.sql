@@ -0,0 +1,31 @@
+-- get_chart_repository_webhooks returns the webhooks of the chart repository
+-- provided as a json array. Secrets are not included. Only admins of the
+-- repository are allowed to get them.
+create or replace function get_chart_repository_webhooks(
+    p_user_id uuid,
+    p_chart_repository_name text
+) returns setof json as $$
+declare
+    v_chart_repository_id uuid;
+begin
+    select chart_repository_id into v_chart_repository_id
+    from chart_repository
+    where name = p_chart_repository_name;
+    if not found then
+        return;
+    end if;
+    if not user_has_chart_repository_role(p_user_id, v_chart_repository_id, 'admin') then
+        raise insufficient_privilege;
+    end if;
+
+    return query
+    select coalesce(json_agg(json_build_object(
+        'webhook_id', webhook_id,
+        'url', url,
+        'event_kinds', event_kinds,
+        'created_at', floor(extract(epoch from created_at))
+    ) order by created_at asc), '[]')
+    from webhook
+    where chart_repository_id = v_chart_repository_id;
+end
+$$ language plpgsql;
@@ -0,0 +1,10 @@
+-- get_operator_provider_packages_digest returns the digest of all packages
+-- that belong to the operator provider identified by the name provided.
+create or replace function get_operator_provider_packages_digest(p_operator_provider_name text)
+returns setof json as $$
+    select coalesce(json_object_agg(format('%s@%s', p.name, s.version), s.digest), '{}')
+    from package p
+    join snapshot s using (package_id)
+    join operator_provider op using (operator_provider_id)
+    where op.name = p_operator_provider_name;
+$$ language sql;
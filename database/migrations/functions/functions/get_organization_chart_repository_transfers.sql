@@ -0,0 +1,25 @@
+-- get_organization_chart_repository_transfers returns the pending transfers
+-- of chart repositories to the provided organization as a json array. Only
+-- members of the organization are allowed to get them.
+create or replace function get_organization_chart_repository_transfers(p_user_id uuid, p_org_name text)
+returns setof json as $$
+    select coalesce(json_agg(json_build_object(
+        'chart_repository_name', r.name,
+        'from_user_alias', fu.alias,
+        'from_organization_name', fo.name,
+        'requested_by', ru.alias,
+        'created_at', floor(extract(epoch from t.created_at))
+    ) order by t.created_at desc), '[]')
+    from chart_repository_transfer t
+    join chart_repository r using (chart_repository_id)
+    join organization o on o.organization_id = t.to_organization_id
+    left join "user" fu on fu.user_id = t.from_user_id
+    left join organization fo on fo.organization_id = t.from_organization_id
+    left join "user" ru on ru.user_id = t.requested_by
+    where o.name = p_org_name
+    and t.accepted_at is null
+    and o.organization_id in (
+        select organization_id from user__organization
+        where user_id = p_user_id
+    );
+$$ language sql;
@@ -0,0 +1,20 @@
+-- get_organization_members returns the members of the provided organization
+-- as a json array. Only members of the organization are allowed to get them.
+create or replace function get_organization_members(p_user_id uuid, p_org_name text)
+returns setof json as $$
+    select coalesce(json_agg(json_build_object(
+        'alias', u.alias,
+        'first_name', u.first_name,
+        'last_name', u.last_name,
+        'role', r.name
+    ) order by u.alias asc), '[]')
+    from "user" u
+    join user__organization uo using (user_id)
+    join organization_role r using (organization_role_id)
+    join organization o using (organization_id)
+    where o.name = p_org_name
+    and o.organization_id in (
+        select organization_id from user__organization
+        where user_id = p_user_id
+    );
+$$ language sql;
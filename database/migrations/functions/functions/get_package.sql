@@ -6,6 +6,7 @@ declare
     v_package_id uuid;
     v_package_name text := p_input->>'package_name';
     v_chart_repository_name text := p_input->>'chart_repository_name';
+    v_operator_provider_name text := p_input->>'operator_provider_name';
 begin
     case (p_input->>'kind')::int
         when 0 then -- chart
@@ -21,6 +22,19 @@ begin
             join chart_repository r using (chart_repository_id)
             where r.name = v_chart_repository_name
             and p.name = v_package_name;
+        when 1 then -- operator
+            if v_operator_provider_name is null or v_operator_provider_name = '' then
+                raise 'a valid operator provider name must be provided';
+            end if;
+            if v_package_name is null or v_package_name = '' then
+                raise 'a valid package name must be provided';
+            end if;
+
+            select p.package_id into v_package_id
+            from package p
+            join operator_provider op using (operator_provider_id)
+            where op.name = v_operator_provider_name
+            and p.name = v_package_name;
         else
             raise 'a valid package kind must be provided';
     end case;
@@ -54,18 +68,26 @@ begin
             join package__maintainer pm using (maintainer_id)
             where pm.package_id = v_package_id
         ),
-        'chart_repository', (
+        'chart_repository', case when r.chart_repository_id is not null then (
             select json_build_object(
                 'chart_repository_id', r.chart_repository_id,
                 'name', r.name,
                 'display_name', r.display_name,
-                'url', r.url
+                'url', r.url,
+                'verified_publisher', r.verified_publisher
+            )
+        ) else null end,
+        'operator_provider', case when op.operator_provider_id is not null then (
+            select json_build_object(
+                'operator_provider_id', op.operator_provider_id,
+                'name', op.name
             )
-        )
+        ) else null end
     )
     from package p
     join snapshot s using (package_id)
-    join chart_repository r using (chart_repository_id)
+    left join chart_repository r using (chart_repository_id)
+    left join operator_provider op using (operator_provider_id)
     where p.package_id = v_package_id
     and
         case when p_input->>'version' <> '' then
@@ -11,13 +11,19 @@ returns setof json as $$
                 'display_name', display_name,
                 'logo_image_id', logo_image_id,
                 'app_version', app_version,
-                'chart_repository', (
+                'chart_repository', case when chart_repository_id is not null then (
                     select json_build_object(
                         'chart_repository_id', chart_repository_id,
                         'name', chart_repository_name,
                         'display_name', chart_repository_display_name
                     )
-                )
+                ) else null end,
+                'operator_provider', case when operator_provider_id is not null then (
+                    select json_build_object(
+                        'operator_provider_id', operator_provider_id,
+                        'name', operator_provider_name
+                    )
+                ) else null end
             )), '[]')
             from (
                 select
@@ -29,10 +35,13 @@ returns setof json as $$
                     s.app_version,
                     r.chart_repository_id,
                     r.name as chart_repository_name,
-                    r.display_name as chart_repository_display_name
+                    r.display_name as chart_repository_display_name,
+                    op.operator_provider_id,
+                    op.name as operator_provider_name
                 from package p
                 join snapshot s using (package_id)
-                join chart_repository r using (chart_repository_id)
+                left join chart_repository r using (chart_repository_id)
+                left join operator_provider op using (operator_provider_id)
                 where s.version = p.latest_version
                 order by created_at desc limit 5
             ) as lpa
@@ -45,13 +54,19 @@ returns setof json as $$
                 'display_name', display_name,
                 'logo_image_id', logo_image_id,
                 'app_version', app_version,
-                'chart_repository', (
+                'chart_repository', case when chart_repository_id is not null then (
                     select json_build_object(
                         'chart_repository_id', chart_repository_id,
                         'name', chart_repository_name,
                         'display_name', chart_repository_display_name
                     )
-                )
+                ) else null end,
+                'operator_provider', case when operator_provider_id is not null then (
+                    select json_build_object(
+                        'operator_provider_id', operator_provider_id,
+                        'name', operator_provider_name
+                    )
+                ) else null end
             )), '[]')
             from (
                 select
@@ -63,10 +78,13 @@ returns setof json as $$
                     s.app_version,
                     r.chart_repository_id,
                     r.name as chart_repository_name,
-                    r.display_name as chart_repository_display_name
+                    r.display_name as chart_repository_display_name,
+                    op.operator_provider_id,
+                    op.name as operator_provider_name
                 from package p
                 join snapshot s using (package_id)
-                join chart_repository r using (chart_repository_id)
+                left join chart_repository r using (chart_repository_id)
+                left join operator_provider op using (operator_provider_id)
                 where s.version = p.latest_version
                 order by updated_at desc limit 5
             ) as pru
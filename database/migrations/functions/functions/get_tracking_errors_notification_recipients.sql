@@ -0,0 +1,13 @@
+-- get_tracking_errors_notification_recipients returns the emails of the users
+-- that should be notified about the tracking errors of the chart repository
+-- provided as a json array. Those are the user owning the repository or the
+-- members of the organization owning it, as long as they have not opted out
+-- and their email has been verified.
+create or replace function get_tracking_errors_notification_recipients(p_chart_repository_id uuid)
+returns setof json as $$
+    select coalesce(json_agg(email order by email), '[]')
+    from "user" u
+    where u.tracking_errors_notifications = true
+    and u.email_verified = true
+    and user_owns_chart_repository(u.user_id, p_chart_repository_id);
+$$ language sql;
@@ -0,0 +1,23 @@
+-- get_tracking_request returns the tracking request identified by the id
+-- provided as a json object. Only the owner of the repository the request
+-- belongs to is allowed to get it.
+create or replace function get_tracking_request(
+    p_user_id uuid,
+    p_chart_repository_name text,
+    p_tracking_request_id uuid
+) returns setof json as $$
+    select json_build_object(
+        'tracking_request_id', tr.tracking_request_id,
+        'chart_name', tr.chart_name,
+        'chart_version', tr.chart_version,
+        'status', tr.status,
+        'created_at', floor(extract(epoch from tr.created_at)),
+        'started_at', floor(extract(epoch from tr.started_at)),
+        'completed_at', floor(extract(epoch from tr.completed_at))
+    )
+    from tracking_request tr
+    join chart_repository r using (chart_repository_id)
+    where tr.tracking_request_id = p_tracking_request_id
+    and r.name = p_chart_repository_name
+    and user_owns_chart_repository(p_user_id, r.chart_repository_id);
+$$ language sql;
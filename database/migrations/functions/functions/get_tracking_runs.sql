@@ -0,0 +1,43 @@
+-- get_tracking_runs returns the tracking runs of the chart repository provided
+-- as a json array, most recent first. Only the repository owner is allowed to
+-- get them.
+create or replace function get_tracking_runs(
+    p_user_id uuid,
+    p_chart_repository_name text,
+    p_limit int,
+    p_offset int
+) returns setof json as $$
+declare
+    v_chart_repository_id uuid;
+begin
+    select chart_repository_id into v_chart_repository_id
+    from chart_repository
+    where name = p_chart_repository_name;
+    if not found then
+        return;
+    end if;
+    if not user_owns_chart_repository(p_user_id, v_chart_repository_id) then
+        raise insufficient_privilege;
+    end if;
+
+    return query
+    select coalesce(json_agg(json_build_object(
+        'tracking_run_id', tracking_run_id,
+        'started_at', floor(extract(epoch from started_at)),
+        'finished_at', floor(extract(epoch from finished_at)),
+        'versions_processed', versions_processed,
+        'versions_registered', versions_registered,
+        'versions_failed', versions_failed,
+        'errors', errors,
+        'suppressed_errors', suppressed_errors
+    ) order by started_at desc), '[]')
+    from (
+        select *
+        from tracking_run
+        where chart_repository_id = v_chart_repository_id
+        order by started_at desc
+        limit p_limit
+        offset p_offset
+    ) tr;
+end
+$$ language plpgsql;
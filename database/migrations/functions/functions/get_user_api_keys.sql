@@ -0,0 +1,12 @@
+-- get_user_api_keys returns the api keys of the provided user as a json
+-- array. The keys hashes are not included.
+create or replace function get_user_api_keys(p_user_id uuid)
+returns setof json as $$
+    select coalesce(json_agg(json_build_object(
+        'api_key_id', api_key_id,
+        'name', name,
+        'created_at', floor(extract(epoch from created_at))
+    ) order by name asc), '[]')
+    from api_key
+    where user_id = p_user_id;
+$$ language sql;
@@ -0,0 +1,19 @@
+-- get_user_organization_invitations returns the pending invitations to join
+-- organizations sent to the verified email of the provided user as a json
+-- array. Expired invitations are not returned.
+create or replace function get_user_organization_invitations(p_user_id uuid)
+returns setof json as $$
+    select coalesce(json_agg(json_build_object(
+        'organization_name', o.name,
+        'organization_display_name', o.display_name,
+        'organization_logo_url', o.logo_url,
+        'invited_at', floor(extract(epoch from oi.created_at)),
+        'token', oi.organization_invitation_id
+    ) order by oi.created_at desc), '[]')
+    from organization o
+    join organization_invitation oi using (organization_id)
+    join "user" u on u.email = oi.email
+    where u.user_id = p_user_id
+    and u.email_verified = true
+    and oi.expires_at > current_timestamp;
+$$ language sql;
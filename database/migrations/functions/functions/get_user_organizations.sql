@@ -0,0 +1,18 @@
+-- get_user_organizations returns all the organizations the provided user
+-- belongs to as a json array.
+create or replace function get_user_organizations(p_user_id uuid)
+returns setof json as $$
+    select coalesce(json_agg(json_build_object(
+        'organization_id', o.organization_id,
+        'name', o.name,
+        'display_name', o.display_name,
+        'description', o.description,
+        'home_url', o.home_url,
+        'logo_url', o.logo_url,
+        'role', r.name
+    ) order by o.name asc), '[]')
+    from organization o
+    join user__organization uo using (organization_id)
+    join organization_role r using (organization_role_id)
+    where uo.user_id = p_user_id;
+$$ language sql;
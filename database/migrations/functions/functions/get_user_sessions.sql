@@ -0,0 +1,16 @@
+-- get_user_sessions returns the sessions of the provided user as a json
+-- array, most recently used first. The session provided is flagged as the
+-- current one. Sessions are identified by their public id.
+create or replace function get_user_sessions(p_user_id uuid, p_current_session_id bytea)
+returns setof json as $$
+    select coalesce(json_agg(json_build_object(
+        'session_id', public_id,
+        'ip', host(ip),
+        'user_agent', user_agent,
+        'created_at', floor(extract(epoch from created_at)),
+        'last_seen_at', floor(extract(epoch from last_seen_at)),
+        'current', session_id is not distinct from p_current_session_id
+    ) order by last_seen_at desc), '[]')
+    from session
+    where user_id = p_user_id;
+$$ language sql;
@@ -0,0 +1,33 @@
+-- get_user_subscriptions returns the packages the provided user is subscribed
+-- to as a json array, including the kinds of events subscribed for each.
+create or replace function get_user_subscriptions(p_user_id uuid)
+returns setof json as $$
+    select coalesce(json_agg(json_build_object(
+        'package_id', p.package_id,
+        'kind', p.package_kind_id,
+        'name', p.name,
+        'display_name', p.display_name,
+        'logo_image_id', p.logo_image_id,
+        'chart_repository', case when r.chart_repository_id is not null then (
+            json_build_object(
+                'name', r.name,
+                'display_name', r.display_name
+            )
+        ) else null end,
+        'operator_provider', case when op.operator_provider_id is not null then (
+            json_build_object(
+                'name', op.name
+            )
+        ) else null end,
+        'event_kinds', s.event_kinds
+    ) order by p.name asc), '[]')
+    from (
+        select package_id, array_agg(event_kind_id order by event_kind_id) as event_kinds
+        from subscription
+        where user_id = p_user_id
+        group by package_id
+    ) s
+    join package p using (package_id)
+    left join chart_repository r using (chart_repository_id)
+    left join operator_provider op using (operator_provider_id);
+$$ language sql;
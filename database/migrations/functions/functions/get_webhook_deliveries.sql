@@ -0,0 +1,53 @@
+-- get_webhook_deliveries returns the deliveries of the webhook provided as a
+-- json array, most recent first, including the attempts made for each. Only
+-- admins of the repository are allowed to get them.
+create or replace function get_webhook_deliveries(
+    p_user_id uuid,
+    p_chart_repository_name text,
+    p_webhook_id uuid,
+    p_limit int,
+    p_offset int
+) returns setof json as $$
+declare
+    v_chart_repository_id uuid;
+begin
+    select chart_repository_id into v_chart_repository_id
+    from chart_repository
+    where name = p_chart_repository_name;
+    if not found then
+        return;
+    end if;
+    if not user_has_chart_repository_role(p_user_id, v_chart_repository_id, 'admin') then
+        raise insufficient_privilege;
+    end if;
+
+    return query
+    select coalesce(json_agg(json_build_object(
+        'webhook_delivery_id', wd.webhook_delivery_id,
+        'event_kind', wd.event_kind_id,
+        'payload', wd.payload,
+        'created_at', floor(extract(epoch from wd.created_at)),
+        'processed', wd.processed,
+        'processed_at', floor(extract(epoch from wd.processed_at)),
+        'attempts', (
+            select coalesce(json_agg(json_build_object(
+                'created_at', floor(extract(epoch from wda.created_at)),
+                'status_code', wda.status_code,
+                'error', wda.error
+            ) order by wda.created_at asc), '[]')
+            from webhook_delivery_attempt wda
+            where wda.webhook_delivery_id = wd.webhook_delivery_id
+        )
+    ) order by wd.created_at desc), '[]')
+    from (
+        select wd.*
+        from webhook_delivery wd
+        join webhook w using (webhook_id)
+        where w.webhook_id = p_webhook_id
+        and w.chart_repository_id = v_chart_repository_id
+        order by wd.created_at desc
+        limit p_limit
+        offset p_offset
+    ) wd;
+end
+$$ language plpgsql;
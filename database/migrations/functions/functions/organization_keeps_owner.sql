@@ -0,0 +1,12 @@
+-- organization_keeps_owner checks if the provided organization would still
+-- have at least one owner without the member given.
+create or replace function organization_keeps_owner(p_organization_id uuid, p_user_id uuid)
+returns boolean as $$
+    select exists (
+        select 1
+        from user__organization
+        where organization_id = p_organization_id
+        and user_id <> p_user_id
+        and organization_role_id = 0
+    );
+$$ language sql;
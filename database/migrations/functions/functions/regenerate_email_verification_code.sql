@@ -0,0 +1,34 @@
+-- regenerate_email_verification_code replaces the email verification code of
+-- the user with the email provided, as long as the email hasn't been verified
+-- yet. To prevent abuse, no code is returned when the current one was created
+-- less than a minute ago.
+create or replace function regenerate_email_verification_code(p_email text)
+returns setof uuid as $$
+declare
+    v_user_id uuid;
+begin
+    -- Get user pending email verification
+    select user_id into v_user_id
+    from "user"
+    where email = p_email
+    and email_verified = false;
+    if not found then
+        return;
+    end if;
+
+    -- Check the current code wasn't created too recently
+    perform from email_verification_code
+    where user_id = v_user_id
+    and created_at + '1 minute'::interval > current_timestamp;
+    if found then
+        return;
+    end if;
+
+    -- Register new email verification code
+    delete from email_verification_code where user_id = v_user_id;
+    return query
+    insert into email_verification_code (user_id)
+    values (v_user_id)
+    returning email_verification_code_id;
+end
+$$ language plpgsql;
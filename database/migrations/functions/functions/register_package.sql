@@ -7,11 +7,24 @@ create or replace function register_package(p_pkg jsonb)
 returns void as $$
 declare
     v_package_id uuid;
-    v_chart_repository_id text := (p_pkg->'chart_repository')->>'chart_repository_id';
-    v_package_latest_version_needs_update boolean := false;
+    v_chart_repository_id uuid := nullif((p_pkg->'chart_repository')->>'chart_repository_id', '')::uuid;
+    v_operator_provider_id uuid;
+    v_operator_provider_name text := nullif((p_pkg->'operator_provider')->>'name', '');
     v_maintainer jsonb;
     v_maintainer_id uuid;
 begin
+    -- Operator provider
+    if v_operator_provider_name is not null then
+        insert into operator_provider (name) values (v_operator_provider_name)
+        on conflict (name) do nothing
+        returning operator_provider_id into v_operator_provider_id;
+        if not found then
+            select operator_provider_id into v_operator_provider_id
+            from operator_provider
+            where name = v_operator_provider_name;
+        end if;
+    end if;
+
     -- Package
     insert into package (
         name,
@@ -23,7 +36,8 @@ begin
         keywords,
         latest_version,
         package_kind_id,
-        chart_repository_id
+        chart_repository_id,
+        operator_provider_id
     ) values (
         p_pkg->>'name',
         nullif(p_pkg->>'display_name', ''),
@@ -34,22 +48,30 @@ begin
         (select (array(select jsonb_array_elements_text(nullif(p_pkg->'keywords', 'null'::jsonb))))::text[]),
         p_pkg->>'version',
         (p_pkg->>'kind')::int,
-        nullif(v_chart_repository_id, '')::uuid
+        v_chart_repository_id,
+        v_operator_provider_id
     )
-    on conflict (chart_repository_id, name) do update
-    set
-        name = excluded.name,
-        display_name = excluded.display_name,
-        description = excluded.description,
-        home_url = excluded.home_url,
-        logo_url = excluded.logo_url,
-        logo_image_id = excluded.logo_image_id,
-        keywords = excluded.keywords,
-        latest_version = excluded.latest_version,
-        updated_at = current_timestamp
-    where semver_gte(p_pkg->>'version', package.latest_version) = true
+    on conflict do nothing
     returning package_id into v_package_id;
 
+    -- If the package was already registered, update it when the version
+    -- provided is greater or equal than the latest one registered
+    if not found then
+        update package set
+            display_name = nullif(p_pkg->>'display_name', ''),
+            description = nullif(p_pkg->>'description', ''),
+            home_url = nullif(p_pkg->>'home_url', ''),
+            logo_url = nullif(p_pkg->>'logo_url', ''),
+            logo_image_id = nullif(p_pkg->>'logo_image_id', '')::uuid,
+            keywords = (select (array(select jsonb_array_elements_text(nullif(p_pkg->'keywords', 'null'::jsonb))))::text[]),
+            latest_version = p_pkg->>'version',
+            updated_at = current_timestamp
+        where (chart_repository_id = v_chart_repository_id or operator_provider_id = v_operator_provider_id)
+        and name = p_pkg->>'name'
+        and semver_gte(p_pkg->>'version', latest_version) = true
+        returning package_id into v_package_id;
+    end if;
+
     if found then
         -- Maintainers
         for v_maintainer in select * from jsonb_array_elements(nullif(p_pkg->'maintainers', 'null'::jsonb))
@@ -90,14 +112,23 @@ begin
     else
         select package_id into v_package_id
         from package
-        where chart_repository_id = v_chart_repository_id::uuid
+        where (chart_repository_id = v_chart_repository_id or operator_provider_id = v_operator_provider_id)
         and name = p_pkg->>'name';
     end if;
 
-    -- Package snapshot
+    -- Package snapshot. When a new version is registered, an event is
+    -- created so that the users subscribed to the package can be notified,
+    -- and the repository webhooks interested in it are delivered.
     insert into snapshot (
         package_id,
         version,
+        display_name,
+        description,
+        home_url,
+        logo_url,
+        logo_image_id,
+        keywords,
+        maintainers,
         app_version,
         digest,
         readme,
@@ -105,16 +136,38 @@ begin
     ) values (
         v_package_id,
         p_pkg->>'version',
+        nullif(p_pkg->>'display_name', ''),
+        nullif(p_pkg->>'description', ''),
+        nullif(p_pkg->>'home_url', ''),
+        nullif(p_pkg->>'logo_url', ''),
+        nullif(p_pkg->>'logo_image_id', '')::uuid,
+        (select (array(select jsonb_array_elements_text(nullif(p_pkg->'keywords', 'null'::jsonb))))::text[]),
+        nullif(p_pkg->'maintainers', 'null'::jsonb),
         nullif(p_pkg->>'app_version', ''),
         p_pkg->>'digest',
         nullif(p_pkg->>'readme', ''),
         p_pkg->'links'
     )
-    on conflict (package_id, version) do update
-    set
-        app_version = excluded.app_version,
-        digest = excluded.digest,
-        readme = excluded.readme,
-        links = excluded.links;
+    on conflict (package_id, version) do nothing;
+    if found then
+        insert into event (package_id, package_version, event_kind_id)
+        values (v_package_id, p_pkg->>'version', 0);
+        perform add_webhook_deliveries(v_package_id, p_pkg->>'version', 0);
+    else
+        update snapshot set
+            display_name = nullif(p_pkg->>'display_name', ''),
+            description = nullif(p_pkg->>'description', ''),
+            home_url = nullif(p_pkg->>'home_url', ''),
+            logo_url = nullif(p_pkg->>'logo_url', ''),
+            logo_image_id = nullif(p_pkg->>'logo_image_id', '')::uuid,
+            keywords = (select (array(select jsonb_array_elements_text(nullif(p_pkg->'keywords', 'null'::jsonb))))::text[]),
+            maintainers = nullif(p_pkg->'maintainers', 'null'::jsonb),
+            app_version = nullif(p_pkg->>'app_version', ''),
+            digest = p_pkg->>'digest',
+            readme = nullif(p_pkg->>'readme', ''),
+            links = p_pkg->'links'
+        where package_id = v_package_id
+        and version = p_pkg->>'version';
+    end if;
 end
 $$ language plpgsql;
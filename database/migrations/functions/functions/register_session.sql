@@ -1,6 +1,10 @@
--- register_session registers the provided session in the database.
+-- register_session registers the provided session in the database. The login
+-- is registered in the audit log.
 create or replace function register_session(p_session jsonb)
 returns bytea as $$
+declare
+    v_session_id bytea;
+begin
     insert into session (
         user_id,
         ip,
@@ -9,5 +13,18 @@ returns bytea as $$
         (p_session->>'user_id')::uuid,
         nullif(p_session->>'ip', '')::inet,
         nullif(p_session->>'user_agent', '')
-    ) returning session_id;
-$$ language sql;
+    ) returning session_id into v_session_id;
+
+    perform add_audit_event(
+        (p_session->>'user_id')::uuid,
+        p_session,
+        'login',
+        null,
+        null,
+        null,
+        null
+    );
+
+    return v_session_id;
+end
+$$ language plpgsql;
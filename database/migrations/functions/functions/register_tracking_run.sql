@@ -0,0 +1,24 @@
+-- register_tracking_run registers the provided chart repository tracking run
+-- in the database.
+create or replace function register_tracking_run(p_tracking_run jsonb)
+returns void as $$
+    insert into tracking_run (
+        chart_repository_id,
+        started_at,
+        finished_at,
+        versions_processed,
+        versions_registered,
+        versions_failed,
+        errors,
+        suppressed_errors
+    ) values (
+        (p_tracking_run->>'chart_repository_id')::uuid,
+        to_timestamp((p_tracking_run->>'started_at')::bigint),
+        to_timestamp((p_tracking_run->>'finished_at')::bigint),
+        coalesce((p_tracking_run->>'versions_processed')::int, 0),
+        coalesce((p_tracking_run->>'versions_registered')::int, 0),
+        coalesce((p_tracking_run->>'versions_failed')::int, 0),
+        nullif(p_tracking_run->'errors', 'null'::jsonb),
+        coalesce((p_tracking_run->>'suppressed_errors')::int, 0)
+    );
+$$ language sql;
@@ -8,12 +8,14 @@ declare
 begin
     -- If there is a user already registered with the email provided and the
     -- email wasn't verified within the allowed period, delete both the user
-    -- and the email verification code
+    -- and the email verification code (codes created for email changes are
+    -- ignored, as those users already verified their current email)
     delete from "user" where user_id = (
         select user_id
         from "user" u
         join email_verification_code c using (user_id)
         where u.email = p_user->>'email'
+        and c.email is null
         and c.created_at + '1 day'::interval < current_timestamp
     );
 
@@ -0,0 +1,73 @@
+-- register_user_identity returns the id of the user linked to the external
+-- identity provided, linking it to the user logged in (if any) or creating a
+-- new user when needed. Identities are expected to provide a verified email.
+-- Identities are never linked automatically to users who already verified the
+-- same email, as they must log in to prove they own the account first.
+create or replace function register_user_identity(p_identity jsonb)
+returns uuid as $$
+declare
+    v_user_id uuid;
+    v_logged_in_user_id uuid := nullif(p_identity->>'user_id', '')::uuid;
+    v_alias text := p_identity->>'alias';
+    v_email_verified boolean;
+begin
+    -- Return user already linked to the identity provided if any
+    select user_id into v_user_id
+    from user_identity
+    where provider = p_identity->>'provider'
+    and provider_user_id = p_identity->>'provider_user_id';
+    if found then
+        if v_logged_in_user_id is not null and v_user_id <> v_logged_in_user_id then
+            raise unique_violation using message = 'identity already linked to another user';
+        end if;
+        return v_user_id;
+    end if;
+
+    if v_logged_in_user_id is not null then
+        -- Link identity to the user logged in
+        v_user_id := v_logged_in_user_id;
+    else
+        -- Get user with the same email. Users who have verified it must log in
+        -- to link the identity. Otherwise the identity provider has verified
+        -- its ownership, so the password is discarded as it was set by someone
+        -- who had not proved owning it.
+        select user_id, email_verified into v_user_id, v_email_verified
+        from "user"
+        where email = p_identity->>'email';
+        if found then
+            if v_email_verified then
+                raise unique_violation using message = 'email already registered';
+            end if;
+            update "user" set
+                password = null,
+                email_verified = true
+            where user_id = v_user_id;
+        else
+            -- Create a new user, making sure the alias is available
+            perform from "user" where alias = v_alias;
+            if found then
+                v_alias := v_alias || '-' || substr(md5(random()::text), 1, 6);
+            end if;
+            insert into "user" (
+                alias,
+                first_name,
+                last_name,
+                email,
+                email_verified
+            ) values (
+                v_alias,
+                nullif(p_identity->>'first_name', ''),
+                nullif(p_identity->>'last_name', ''),
+                p_identity->>'email',
+                true
+            ) returning user_id into v_user_id;
+        end if;
+    end if;
+
+    -- Link identity to user
+    insert into user_identity (user_id, provider, provider_user_id)
+    values (v_user_id, p_identity->>'provider', p_identity->>'provider_user_id');
+
+    return v_user_id;
+end
+$$ language plpgsql;
@@ -0,0 +1,20 @@
+-- register_webhook_delivery_attempt registers an attempt to deliver the
+-- webhook delivery provided. When the remote endpoint replied with a 2xx
+-- status code, the delivery is marked as processed.
+create or replace function register_webhook_delivery_attempt(
+    p_webhook_delivery_id uuid,
+    p_status_code int,
+    p_error text
+) returns void as $$
+begin
+    insert into webhook_delivery_attempt (webhook_delivery_id, status_code, error)
+    values (p_webhook_delivery_id, p_status_code, p_error);
+
+    if p_status_code between 200 and 299 then
+        update webhook_delivery set
+            processed = true,
+            processed_at = current_timestamp
+        where webhook_delivery_id = p_webhook_delivery_id;
+    end if;
+end
+$$ language plpgsql;
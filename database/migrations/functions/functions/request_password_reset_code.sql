@@ -0,0 +1,25 @@
+-- request_password_reset_code creates a password reset code for the user with
+-- the email provided, replacing any code previously requested. No code is
+-- returned when the email does not belong to a verified user.
+create or replace function request_password_reset_code(p_email text)
+returns setof uuid as $$
+declare
+    v_user_id uuid;
+begin
+    -- Get user with the email provided
+    select user_id into v_user_id
+    from "user"
+    where email = p_email
+    and email_verified = true;
+    if not found then
+        return;
+    end if;
+
+    -- Register password reset code, replacing the existing one if any
+    delete from password_reset_code where user_id = v_user_id;
+    return query
+    insert into password_reset_code (user_id)
+    values (v_user_id)
+    returning password_reset_code_id;
+end
+$$ language plpgsql;
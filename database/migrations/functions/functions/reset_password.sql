@@ -0,0 +1,30 @@
+-- reset_password updates the password of the user the provided password reset
+-- code belongs to, returning true if the password was updated successfully or
+-- false otherwise. All sessions of the user are deleted and all api keys are
+-- revoked once the password has been updated.
+create or replace function reset_password(p_code uuid, p_password text)
+returns boolean as $$
+declare
+    v_user_id uuid;
+begin
+    -- Check if password reset code exists and is not expired
+    select user_id into v_user_id
+    from password_reset_code
+    where password_reset_code_id = p_code
+    and created_at + '1 day'::interval > current_timestamp;
+    if not found then
+        return false;
+    end if;
+
+    -- Update user password
+    update "user" set password = p_password where user_id = v_user_id;
+
+    -- Delete password reset code, invalidate existing sessions and revoke
+    -- api keys
+    delete from password_reset_code where password_reset_code_id = p_code;
+    delete from session where user_id = v_user_id;
+    delete from api_key where user_id = v_user_id;
+
+    return true;
+end
+$$ language plpgsql;
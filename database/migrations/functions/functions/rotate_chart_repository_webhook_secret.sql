@@ -0,0 +1,42 @@
+-- rotate_chart_repository_webhook_secret generates a new webhook secret for
+-- the chart repository provided, replacing the existing one if any, and
+-- returns it. Only admins of the repository are allowed to rotate it. The
+-- rotation is registered in the audit log.
+create or replace function rotate_chart_repository_webhook_secret(
+    p_user_id uuid,
+    p_chart_repository_name text,
+    p_request_info jsonb
+) returns setof text as $$
+declare
+    v_chart_repository_id uuid;
+    v_organization_id uuid;
+    v_webhook_secret text;
+begin
+    select chart_repository_id, organization_id into v_chart_repository_id, v_organization_id
+    from chart_repository
+    where name = p_chart_repository_name;
+    if not found then
+        return;
+    end if;
+    if not user_has_chart_repository_role(p_user_id, v_chart_repository_id, 'admin') then
+        raise insufficient_privilege;
+    end if;
+
+    update chart_repository set
+        webhook_secret = encode(gen_random_bytes(32), 'hex')
+    where chart_repository_id = v_chart_repository_id
+    returning webhook_secret into v_webhook_secret;
+
+    perform add_audit_event(
+        p_user_id,
+        p_request_info,
+        'rotate-chart-repository-webhook-secret',
+        p_chart_repository_name,
+        v_chart_repository_id,
+        v_organization_id,
+        null
+    );
+
+    return next v_webhook_secret;
+end
+$$ language plpgsql;
@@ -25,11 +25,14 @@ begin
             p.logo_image_id,
             s.app_version,
             r.name as chart_repository_name,
-            r.display_name as chart_repository_display_name
+            r.display_name as chart_repository_display_name,
+            r.verified_publisher as chart_repository_verified_publisher,
+            op.name as operator_provider_name
         from package p
         join package_kind pk using (package_kind_id)
-        join chart_repository r using (chart_repository_id)
         join snapshot s using (package_id)
+        left join chart_repository r using (chart_repository_id)
+        left join operator_provider op using (operator_provider_id)
         where s.version = p.latest_version
         and
             case when p_input ? 'text' and p_input->>'text' <> '' then
@@ -56,12 +59,18 @@ begin
                         'description', description,
                         'logo_image_id', logo_image_id,
                         'app_version', app_version,
-                        'chart_repository', (
+                        'chart_repository', case when chart_repository_name is not null then (
                             select json_build_object(
                                 'name', chart_repository_name,
-                                'display_name', chart_repository_display_name
+                                'display_name', chart_repository_display_name,
+                                'verified_publisher', chart_repository_verified_publisher
                             )
-                        )
+                        ) else null end,
+                        'operator_provider', case when operator_provider_name is not null then (
+                            select json_build_object(
+                                'name', operator_provider_name
+                            )
+                        ) else null end
                     )), '[]')
                     from (
                         select * from packages_applying_all_filters
@@ -109,6 +118,7 @@ begin
                                             chart_repository_name,
                                             count(*) as total
                                         from packages_applying_text_filter
+                                        where chart_repository_name is not null
                                         group by chart_repository_name
                                         order by total desc
                                     ) as breakdown
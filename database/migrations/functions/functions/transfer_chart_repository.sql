@@ -0,0 +1,69 @@
+-- transfer_chart_repository requests the transfer of the chart repository
+-- provided to the organization given, replacing any other pending transfer of
+-- the repository. The transfer is completed once an admin of the destination
+-- organization accepts it. The id of the transfer is returned, or nothing if
+-- the repository or the organization do not exist. Only owners of the
+-- repository are allowed to transfer it.
+create or replace function transfer_chart_repository(
+    p_user_id uuid,
+    p_chart_repository_name text,
+    p_org_name text,
+    p_request_info jsonb
+) returns setof uuid as $$
+declare
+    v_chart_repository chart_repository%rowtype;
+    v_organization_id uuid;
+    v_chart_repository_transfer_id uuid;
+begin
+    select * into v_chart_repository
+    from chart_repository
+    where name = p_chart_repository_name;
+    if not found then
+        return;
+    end if;
+    if not user_has_chart_repository_role(p_user_id, v_chart_repository.chart_repository_id, 'owner') then
+        raise insufficient_privilege;
+    end if;
+
+    select organization_id into v_organization_id
+    from organization
+    where name = p_org_name;
+    if not found then
+        return;
+    end if;
+    if v_chart_repository.organization_id = v_organization_id then
+        raise check_violation using message = 'chart repository already belongs to the organization';
+    end if;
+
+    delete from chart_repository_transfer
+    where chart_repository_id = v_chart_repository.chart_repository_id
+    and accepted_at is null;
+
+    insert into chart_repository_transfer (
+        chart_repository_id,
+        from_user_id,
+        from_organization_id,
+        to_organization_id,
+        requested_by
+    ) values (
+        v_chart_repository.chart_repository_id,
+        v_chart_repository.user_id,
+        v_chart_repository.organization_id,
+        v_organization_id,
+        p_user_id
+    )
+    returning chart_repository_transfer_id into v_chart_repository_transfer_id;
+
+    perform add_audit_event(
+        p_user_id,
+        p_request_info,
+        'request-chart-repository-transfer',
+        p_chart_repository_name,
+        v_chart_repository.chart_repository_id,
+        v_chart_repository.organization_id,
+        jsonb_build_object('to_organization', p_org_name)
+    );
+
+    return next v_chart_repository_transfer_id;
+end
+$$ language plpgsql;
@@ -0,0 +1,96 @@
+-- unregister_package unregisters the provided package version from the
+-- database. This involves deleting the package version snapshot and, when the
+-- latest version changes, refreshing the package details and maintainers from
+-- the snapshot of the greatest of the remaining versions. When no versions
+-- remain, the package is deleted as well.
+create or replace function unregister_package(p_pkg jsonb)
+returns void as $$
+declare
+    v_package_id uuid;
+    v_chart_repository_id uuid := nullif((p_pkg->'chart_repository')->>'chart_repository_id', '')::uuid;
+    v_operator_provider_name text := nullif((p_pkg->'operator_provider')->>'name', '');
+    v_latest_version text;
+    v_version text;
+    v_snapshot snapshot%rowtype;
+    v_maintainer jsonb;
+    v_maintainer_id uuid;
+begin
+    -- Get package id
+    select package_id into v_package_id
+    from package p
+    left join operator_provider op using (operator_provider_id)
+    where (p.chart_repository_id = v_chart_repository_id or op.name = v_operator_provider_name)
+    and p.name = p_pkg->>'name';
+    if not found then
+        return;
+    end if;
+
+    -- Deliver the repository webhooks interested in the version removal
+    perform 1 from snapshot
+    where package_id = v_package_id
+    and version = p_pkg->>'version';
+    if found then
+        perform add_webhook_deliveries(v_package_id, p_pkg->>'version', 1);
+    end if;
+
+    -- Delete package version snapshot
+    delete from snapshot
+    where package_id = v_package_id
+    and version = p_pkg->>'version';
+
+    -- Refresh package from the greatest of the remaining versions
+    for v_version in select version from snapshot where package_id = v_package_id
+    loop
+        if v_latest_version is null or semver_gte(v_version, v_latest_version) then
+            v_latest_version := v_version;
+        end if;
+    end loop;
+    if v_latest_version is not null then
+        select * into v_snapshot
+        from snapshot
+        where package_id = v_package_id
+        and version = v_latest_version;
+
+        update package set
+            display_name = v_snapshot.display_name,
+            description = v_snapshot.description,
+            home_url = v_snapshot.home_url,
+            logo_url = v_snapshot.logo_url,
+            logo_image_id = v_snapshot.logo_image_id,
+            keywords = v_snapshot.keywords,
+            latest_version = v_latest_version,
+            updated_at = current_timestamp
+        where package_id = v_package_id
+        and latest_version <> v_latest_version;
+        if not found then
+            return;
+        end if;
+
+        -- Bind package to the latest version maintainers
+        delete from package__maintainer where package_id = v_package_id;
+        for v_maintainer in select * from jsonb_array_elements(v_snapshot.maintainers)
+        loop
+            insert into maintainer (name, email)
+            values (v_maintainer->>'name', v_maintainer->>'email')
+            on conflict (email) do nothing
+            returning maintainer_id into v_maintainer_id;
+            if not found then
+                select maintainer_id into v_maintainer_id
+                from maintainer
+                where email = v_maintainer->>'email';
+            end if;
+            insert into package__maintainer (package_id, maintainer_id)
+            values (v_package_id, v_maintainer_id)
+            on conflict do nothing;
+        end loop;
+    else
+        -- Delete package when it has no versions left
+        delete from package where package_id = v_package_id;
+    end if;
+
+    -- Clean up orphan maintainers not bound to any package
+    delete from maintainer where maintainer_id not in (
+        select maintainer_id from package__maintainer
+    );
+end
+$$ language plpgsql;
@@ -1,10 +1,52 @@
 -- updates_chart_repository updates the provided chart repository in the
--- database.
-create or replace function update_chart_repository(p_chart_repository jsonb)
+-- database. Repositories can be updated by the user owning them or, when they
+-- belong to an organization, by its admins. The verified publisher flag is
+-- reset when the url changes, as the new location has to be verified again.
+-- The update is registered in the audit log.
+create or replace function update_chart_repository(p_chart_repository jsonb, p_request_info jsonb)
 returns void as $$
+declare
+    v_chart_repository_id uuid;
+    v_organization_id uuid;
+begin
+    select chart_repository_id, organization_id into v_chart_repository_id, v_organization_id
+    from chart_repository
+    where name = p_chart_repository->>'name';
+    if not found then
+        raise no_data_found;
+    end if;
+    if not user_has_chart_repository_role(
+        (p_chart_repository->>'user_id')::uuid,
+        v_chart_repository_id,
+        'admin'
+    ) then
+        raise insufficient_privilege;
+    end if;
+
     update chart_repository set
         display_name = nullif(p_chart_repository->>'display_name', ''),
-        url = p_chart_repository->>'url'
-    where name = p_chart_repository->>'name'
-    and user_id = (p_chart_repository->>'user_id')::uuid;
-$$ language sql;
+        url = p_chart_repository->>'url',
+        chart_repository_kind_id = (p_chart_repository->>'kind')::int,
+        git_branch = nullif(p_chart_repository->>'git_branch', ''),
+        git_path_glob = nullif(p_chart_repository->>'git_path_glob', ''),
+        verified_publisher = case
+            when url = p_chart_repository->>'url' then verified_publisher
+            else false
+        end,
+        verification_failures = case
+            when url = p_chart_repository->>'url' then verification_failures
+            else 0
+        end
+    where chart_repository_id = v_chart_repository_id;
+
+    perform add_audit_event(
+        (p_chart_repository->>'user_id')::uuid,
+        p_request_info,
+        'update-chart-repository',
+        p_chart_repository->>'name',
+        v_chart_repository_id,
+        v_organization_id,
+        jsonb_build_object('url', p_chart_repository->>'url')
+    );
+end
+$$ language plpgsql;
@@ -0,0 +1,25 @@
+-- update_organization updates the provided organization in the database. Only
+-- admins of the organization are allowed to update it.
+create or replace function update_organization(p_user_id uuid, p_org jsonb)
+returns void as $$
+declare
+    v_organization_id uuid;
+begin
+    select organization_id into v_organization_id
+    from organization
+    where name = p_org->>'name';
+    if not found then
+        raise no_data_found;
+    end if;
+    if not user_has_organization_role(p_user_id, v_organization_id, 'admin') then
+        raise insufficient_privilege;
+    end if;
+
+    update organization set
+        display_name = nullif(p_org->>'display_name', ''),
+        description = nullif(p_org->>'description', ''),
+        home_url = nullif(p_org->>'home_url', ''),
+        logo_url = nullif(p_org->>'logo_url', '')
+    where organization_id = v_organization_id;
+end
+$$ language plpgsql;
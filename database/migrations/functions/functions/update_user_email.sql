@@ -0,0 +1,23 @@
+-- update_user_email registers a request to change the email of the provided
+-- user, returning an email verification code that should be used to confirm
+-- the ownership of the new email. The email is not updated until verified.
+create or replace function update_user_email(p_user_id uuid, p_email text)
+returns uuid as $$
+declare
+    v_email_verification_code uuid;
+begin
+    -- Check the email provided is not being used by another user
+    perform from "user" where email = p_email and user_id <> p_user_id;
+    if found then
+        raise unique_violation;
+    end if;
+
+    -- Register email verification code, replacing the existing one if any
+    delete from email_verification_code where user_id = p_user_id;
+    insert into email_verification_code (user_id, email)
+    values (p_user_id, p_email)
+    returning email_verification_code_id into v_email_verification_code;
+
+    return v_email_verification_code;
+end
+$$ language plpgsql;
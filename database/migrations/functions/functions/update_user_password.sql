@@ -0,0 +1,16 @@
+-- update_user_password updates the password of the provided user. All the
+-- sessions of the user except the one provided (the one used to request the
+-- change, if any) are deleted once the password has been updated, and the api
+-- keys of the user are revoked.
+create or replace function update_user_password(
+    p_user_id uuid,
+    p_password text,
+    p_current_session_id bytea
+) returns void as $$
+    update "user" set password = p_password where user_id = p_user_id;
+
+    delete from session
+    where user_id = p_user_id
+    and session_id is distinct from p_current_session_id;
+    delete from api_key where user_id = p_user_id;
+$$ language sql;
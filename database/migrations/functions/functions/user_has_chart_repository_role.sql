@@ -0,0 +1,19 @@
+-- user_has_chart_repository_role checks if the provided user has a role in the
+-- chart repository given that includes the privileges of the role provided.
+-- The user owning the repository is its owner. When the repository belongs to
+-- an organization, the user's role in it is used.
+create or replace function user_has_chart_repository_role(
+    p_user_id uuid,
+    p_chart_repository_id uuid,
+    p_role text
+) returns boolean as $$
+    select exists (
+        select 1
+        from chart_repository r
+        where r.chart_repository_id = p_chart_repository_id
+        and (
+            r.user_id = p_user_id
+            or user_has_organization_role(p_user_id, r.organization_id, p_role)
+        )
+    );
+$$ language sql;
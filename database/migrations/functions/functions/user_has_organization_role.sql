@@ -0,0 +1,20 @@
+-- user_has_organization_role checks if the provided user is a member of the
+-- organization given with a role that includes the privileges of the role
+-- provided. Roles are ordered from owner (most privileged) to viewer.
+create or replace function user_has_organization_role(
+    p_user_id uuid,
+    p_organization_id uuid,
+    p_role text
+) returns boolean as $$
+    select exists (
+        select 1
+        from user__organization uo
+        where uo.user_id = p_user_id
+        and uo.organization_id = p_organization_id
+        and uo.organization_role_id <= (
+            select organization_role_id
+            from organization_role
+            where name = p_role
+        )
+    );
+$$ language sql;
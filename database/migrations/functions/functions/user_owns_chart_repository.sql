@@ -0,0 +1,19 @@
+-- user_owns_chart_repository checks if the provided user owns the chart
+-- repository identified by the id provided, either directly or by being a
+-- member of the organization owning it.
+create or replace function user_owns_chart_repository(p_user_id uuid, p_chart_repository_id uuid)
+returns boolean as $$
+    select exists (
+        select 1
+        from chart_repository
+        where chart_repository_id = p_chart_repository_id
+        and (
+            user_id = p_user_id
+            or organization_id in (
+                select organization_id
+                from user__organization
+                where user_id = p_user_id
+            )
+        )
+    );
+$$ language sql;
@@ -1,23 +1,36 @@
 -- verify_email verifies an email using the provided email verification code,
 -- returning true if the email was verified successfully or false otherwise.
+-- When the code was created for an email change, the user's email is updated
+-- to the new one as long as it hasn't been taken by another user meanwhile.
 create or replace function verify_email(p_code uuid)
 returns boolean as $$
+declare
+    v_user_id uuid;
+    v_email text;
 begin
     -- Check if email verification code exists and is not expired
-    perform from email_verification_code
+    select user_id, email into v_user_id, v_email
+    from email_verification_code
     where email_verification_code_id = p_code
     and created_at + '1 day'::interval > current_timestamp;
     if not found then
         return false;
     end if;
 
+    -- Check the new email is still available when changing it
+    if v_email is not null then
+        perform from "user" where email = v_email and user_id <> v_user_id;
+        if found then
+            return false;
+        end if;
+    end if;
+
     -- Mark email as verified in user record
     update "user"
-    set email_verified = true
-    where user_id = (
-        select user_id from email_verification_code
-        where email_verification_code_id = p_code
-    );
+    set
+        email = coalesce(v_email, email),
+        email_verified = true
+    where user_id = v_user_id;
 
     -- Delete email verification code
     delete from email_verification_code
@@ -0,0 +1,13 @@
+create table if not exists operator_provider (
+    operator_provider_id uuid primary key default gen_random_uuid(),
+    name text not null check (name <> '') unique
+);
+
+alter table package
+    add column operator_provider_id uuid references operator_provider on delete restrict,
+    add check (package_kind_id <> 1 or operator_provider_id is not null),
+    add unique (operator_provider_id, name);
+
+create index package_operator_provider_id_idx on package (operator_provider_id);
+
+insert into package_kind values (1, 'operator');
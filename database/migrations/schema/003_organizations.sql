@@ -0,0 +1,3 @@
+alter table organization
+    add column display_name text check (display_name <> ''),
+    drop constraint organization_description_key;
@@ -0,0 +1,14 @@
+create table if not exists chart_repository_kind (
+    chart_repository_kind_id integer primary key,
+    name text not null check (name <> '')
+);
+
+insert into chart_repository_kind values (0, 'helm');
+insert into chart_repository_kind values (1, 'git');
+
+alter table chart_repository
+    add column chart_repository_kind_id integer not null default 0
+        references chart_repository_kind on delete restrict,
+    add column git_branch text check (git_branch <> ''),
+    add column git_path_glob text check (git_path_glob <> '');
+
@@ -0,0 +1,12 @@
+create table if not exists tracking_request (
+    tracking_request_id uuid primary key default gen_random_uuid(),
+    chart_repository_id uuid not null references chart_repository on delete cascade,
+    status text not null default 'pending'
+        check (status in ('pending', 'processing', 'completed', 'failed')),
+    created_at timestamptz default current_timestamp not null,
+    started_at timestamptz,
+    completed_at timestamptz
+);
+
+create index tracking_request_chart_repository_id_idx on tracking_request (chart_repository_id);
+create index tracking_request_status_idx on tracking_request (status);
@@ -0,0 +1,4 @@
+alter table chart_repository add column webhook_secret text;
+
+alter table tracking_request add column chart_name text;
+alter table tracking_request add column chart_version text;
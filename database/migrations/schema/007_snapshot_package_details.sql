@@ -0,0 +1,27 @@
+alter table snapshot add column display_name text check (display_name <> '');
+alter table snapshot add column description text check (description <> '');
+alter table snapshot add column home_url text check (home_url <> '');
+alter table snapshot add column logo_url text check (logo_url <> '');
+alter table snapshot add column logo_image_id uuid;
+alter table snapshot add column keywords text[];
+alter table snapshot add column maintainers jsonb;
+
+update snapshot s set
+    display_name = p.display_name,
+    description = p.description,
+    home_url = p.home_url,
+    logo_url = p.logo_url,
+    logo_image_id = p.logo_image_id,
+    keywords = p.keywords,
+    maintainers = (
+        select json_agg(json_build_object(
+            'name', m.name,
+            'email', m.email
+        ))
+        from package__maintainer pm
+        join maintainer m using (maintainer_id)
+        where pm.package_id = p.package_id
+    )
+from package p
+where s.package_id = p.package_id
+and s.version = p.latest_version;
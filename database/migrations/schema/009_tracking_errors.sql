@@ -0,0 +1,13 @@
+alter table chart_repository rename column last_tracking_errors to last_tracking_errors_text;
+alter table chart_repository add column last_tracking_errors jsonb;
+update chart_repository set last_tracking_errors = jsonb_build_object(
+    'errors', (
+        select jsonb_agg(jsonb_build_object('kind', 'unknown', 'message', e))
+        from regexp_split_to_table(trim(trailing E'\n' from last_tracking_errors_text), E'\n') e
+    ),
+    'suppressed', 0
+)
+where nullif(trim(last_tracking_errors_text), '') is not null;
+alter table chart_repository drop column last_tracking_errors_text;
+
+alter table tracking_run add column suppressed_errors integer not null default 0;
@@ -0,0 +1 @@
+alter table "user" add column tracking_errors_notifications boolean not null default true;
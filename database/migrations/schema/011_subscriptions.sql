@@ -0,0 +1,42 @@
+create table if not exists event_kind (
+    event_kind_id integer primary key,
+    name text not null check (name <> '')
+);
+
+insert into event_kind values (0, 'new-release');
+
+create table if not exists subscription (
+    user_id uuid not null references "user" on delete cascade,
+    package_id uuid not null references package on delete cascade,
+    event_kind_id integer not null references event_kind on delete restrict,
+    primary key (user_id, package_id, event_kind_id)
+);
+
+create index subscription_package_id_event_kind_id_idx on subscription (package_id, event_kind_id);
+
+create table if not exists event (
+    event_id uuid primary key default gen_random_uuid(),
+    package_id uuid not null references package on delete cascade,
+    package_version text not null check (package_version <> ''),
+    event_kind_id integer not null references event_kind on delete restrict,
+    created_at timestamptz default current_timestamp not null,
+    processed boolean not null default false,
+    processed_at timestamptz
+);
+
+create index event_not_processed_idx on event (created_at) where processed = false;
+
+create table if not exists notification (
+    notification_id uuid primary key default gen_random_uuid(),
+    event_id uuid not null references event on delete cascade,
+    user_id uuid not null references "user" on delete cascade,
+    created_at timestamptz default current_timestamp not null,
+    processed boolean not null default false,
+    processed_at timestamptz,
+    attempts integer not null default 0,
+    last_attempt_at timestamptz,
+    error text,
+    unique (event_id, user_id)
+);
+
+create index notification_not_processed_idx on notification (created_at) where processed = false;
@@ -0,0 +1,37 @@
+insert into event_kind values (1, 'version-removed');
+
+create table if not exists webhook (
+    webhook_id uuid primary key default gen_random_uuid(),
+    chart_repository_id uuid not null references chart_repository on delete cascade,
+    url text not null check (url <> ''),
+    secret text not null check (secret <> ''),
+    event_kinds integer[] not null check (cardinality(event_kinds) > 0),
+    created_at timestamptz default current_timestamp not null
+);
+
+create index webhook_chart_repository_id_idx on webhook (chart_repository_id);
+
+create table if not exists webhook_delivery (
+    webhook_delivery_id uuid primary key default gen_random_uuid(),
+    webhook_id uuid not null references webhook on delete cascade,
+    event_kind_id integer not null references event_kind on delete restrict,
+    payload jsonb not null,
+    created_at timestamptz default current_timestamp not null,
+    processed boolean not null default false,
+    processed_at timestamptz,
+    attempts integer not null default 0,
+    last_attempt_at timestamptz
+);
+
+create index webhook_delivery_webhook_id_created_at_idx on webhook_delivery (webhook_id, created_at);
+create index webhook_delivery_not_processed_idx on webhook_delivery (created_at) where processed = false;
+
+create table if not exists webhook_delivery_attempt (
+    webhook_delivery_attempt_id uuid primary key default gen_random_uuid(),
+    webhook_delivery_id uuid not null references webhook_delivery on delete cascade,
+    created_at timestamptz default current_timestamp not null,
+    status_code integer,
+    error text
+);
+
+create index webhook_delivery_attempt_webhook_delivery_id_idx on webhook_delivery_attempt (webhook_delivery_id);
@@ -0,0 +1 @@
+alter table email_verification_code add column email text check (email <> '');
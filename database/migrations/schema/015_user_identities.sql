@@ -0,0 +1,10 @@
+create table if not exists user_identity (
+    user_identity_id uuid primary key default gen_random_uuid(),
+    user_id uuid not null references "user" on delete cascade,
+    provider text not null check (provider <> ''),
+    provider_user_id text not null check (provider_user_id <> ''),
+    created_at timestamptz default current_timestamp not null,
+    unique (provider, provider_user_id)
+);
+
+create index user_identity_user_id_idx on user_identity (user_id);
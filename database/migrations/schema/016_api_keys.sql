@@ -0,0 +1,10 @@
+create table if not exists api_key (
+    api_key_id uuid primary key default gen_random_uuid(),
+    user_id uuid not null references "user" on delete cascade,
+    name text not null check (name <> ''),
+    key_hash text not null unique check (key_hash <> ''),
+    created_at timestamptz default current_timestamp not null,
+    unique (user_id, name)
+);
+
+create index api_key_user_id_idx on api_key (user_id);
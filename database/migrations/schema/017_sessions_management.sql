@@ -0,0 +1,6 @@
+alter table session add column public_id uuid not null unique default gen_random_uuid();
+alter table session add column last_seen_at timestamptz default current_timestamp not null;
+update session set last_seen_at = created_at;
+
+create index session_user_id_idx on session (user_id);
+create index session_last_seen_at_idx on session (last_seen_at);
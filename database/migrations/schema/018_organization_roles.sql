@@ -0,0 +1,15 @@
+create table if not exists organization_role (
+    organization_role_id integer primary key,
+    name text not null check (name <> '')
+);
+
+insert into organization_role values (0, 'owner');
+insert into organization_role values (1, 'admin');
+insert into organization_role values (2, 'publisher');
+insert into organization_role values (3, 'viewer');
+
+-- Existing members keep full access to their organizations as owners
+alter table user__organization
+    add column organization_role_id integer not null default 0
+        references organization_role on delete restrict;
+alter table user__organization alter column organization_role_id set default 3;
@@ -0,0 +1,13 @@
+-- Organization invitations are sent to an email address, which may not belong
+-- to a registered user yet, and are identified by a random token
+create table if not exists organization_invitation (
+    organization_invitation_id uuid primary key default gen_random_uuid(),
+    organization_id uuid not null references organization on delete cascade,
+    email text not null check (email <> ''),
+    invited_by uuid references "user" on delete set null,
+    created_at timestamptz default current_timestamp not null,
+    expires_at timestamptz default current_timestamp + '7 days'::interval not null,
+    unique (organization_id, email)
+);
+
+create index organization_invitation_email_idx on organization_invitation (email);
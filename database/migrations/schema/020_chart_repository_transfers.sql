@@ -0,0 +1,15 @@
+create table if not exists chart_repository_transfer (
+    chart_repository_transfer_id uuid primary key default gen_random_uuid(),
+    chart_repository_id uuid not null references chart_repository on delete cascade,
+    from_user_id uuid references "user" on delete set null,
+    from_organization_id uuid references organization on delete set null,
+    to_organization_id uuid not null references organization on delete cascade,
+    requested_by uuid references "user" on delete set null,
+    accepted_by uuid references "user" on delete set null,
+    created_at timestamptz default current_timestamp not null,
+    accepted_at timestamptz
+);
+
+create unique index chart_repository_transfer_pending_idx on chart_repository_transfer (chart_repository_id)
+where accepted_at is null;
+create index chart_repository_transfer_to_organization_id_idx on chart_repository_transfer (to_organization_id);
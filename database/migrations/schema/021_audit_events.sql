@@ -0,0 +1,40 @@
+create table if not exists audit_event (
+    audit_event_id uuid primary key default gen_random_uuid(),
+    user_id uuid references "user" on delete set null,
+    action text not null check (action <> ''),
+    target text,
+    chart_repository_id uuid references chart_repository on delete set null,
+    organization_id uuid references organization on delete set null,
+    ip inet,
+    user_agent text,
+    details jsonb,
+    created_at timestamptz default current_timestamp not null
+);
+
+create index audit_event_user_id_idx on audit_event (user_id);
+create index audit_event_chart_repository_id_idx on audit_event (chart_repository_id);
+create index audit_event_organization_id_idx on audit_event (organization_id);
+create index audit_event_created_at_idx on audit_event (created_at);
+
+-- Audit events are registered by the functions performing the actions, which
+-- now receive the request information used to register them
+drop function if exists add_chart_repository(jsonb);
+drop function if exists update_chart_repository(jsonb);
+drop function if exists delete_chart_repository(jsonb);
+drop function if exists transfer_chart_repository(uuid, text, text);
+drop function if exists accept_chart_repository_transfer(uuid, text);
+drop function if exists cancel_chart_repository_transfer(uuid, text);
+drop function if exists decline_chart_repository_transfer(uuid, text);
+drop function if exists add_organization_member(uuid, text, text);
+drop function if exists delete_organization_member(uuid, text, text);
+drop function if exists update_organization_member_role(uuid, text, text, text);
+drop function if exists add_organization_invitation(uuid, text, text);
+drop function if exists accept_organization_invitation(uuid, uuid);
+drop function if exists decline_organization_invitation(uuid, uuid);
+drop function if exists add_webhook(uuid, text, jsonb);
+drop function if exists delete_webhook(uuid, text, uuid);
+drop function if exists rotate_chart_repository_webhook_secret(uuid, text);
+drop function if exists delete_chart_repository_webhook_secret(uuid, text);
+drop function if exists add_api_key(uuid, jsonb);
+drop function if exists delete_api_key(uuid, uuid);
+drop function if exists delete_user_sessions(uuid);
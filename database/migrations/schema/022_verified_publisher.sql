@@ -0,0 +1,6 @@
+alter table chart_repository add column verification_token text;
+alter table chart_repository add column verified_publisher boolean not null default false;
+
+-- Number of consecutive tracking runs in which the metadata file of the chart
+-- repository could not be fetched to check its verification token
+alter table chart_repository add column verification_failures integer not null default 0;
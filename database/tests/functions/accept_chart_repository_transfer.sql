@@ -0,0 +1,129 @@
+-- Start transaction and plan tests
+begin;
+select plan(11);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set user3ID '00000000-0000-0000-0000-000000000003'
+\set user4ID '00000000-0000-0000-0000-000000000004'
+\set org1ID '00000000-0000-0000-0000-000000000001'
+\set org2ID '00000000-0000-0000-0000-000000000002'
+\set repo1ID '00000000-0000-0000-0000-000000000001'
+\set repo2ID '00000000-0000-0000-0000-000000000002'
+\set repo3ID '00000000-0000-0000-0000-000000000003'
+
+-- Seed some data
+insert into "user" (user_id, alias, email)
+values (:'user1ID', 'user1', 'user1@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user2ID', 'user2', 'user2@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user3ID', 'user3', 'user3@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user4ID', 'user4', 'user4@email.com');
+insert into organization (organization_id, name)
+values (:'org1ID', 'org1');
+insert into organization (organization_id, name)
+values (:'org2ID', 'org2');
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user2ID', :'org1ID', 1);
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user3ID', :'org1ID', 2);
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user4ID', :'org2ID', 0);
+insert into chart_repository (chart_repository_id, name, url, user_id, verification_token, verified_publisher)
+values (:'repo1ID', 'repo1', 'https://repo1.com', :'user1ID', 'token', true);
+insert into chart_repository (chart_repository_id, name, url, organization_id)
+values (:'repo2ID', 'repo2', 'https://repo2.com', :'org2ID');
+insert into chart_repository (chart_repository_id, name, url, user_id)
+values (:'repo3ID', 'repo3', 'https://repo3.com', :'user1ID');
+insert into chart_repository_transfer (chart_repository_id, from_user_id, to_organization_id, requested_by)
+values (:'repo1ID', :'user1ID', :'org1ID', :'user1ID');
+insert into chart_repository_transfer (chart_repository_id, from_organization_id, to_organization_id, requested_by)
+values (:'repo2ID', :'org2ID', :'org1ID', :'user4ID');
+insert into chart_repository_transfer (chart_repository_id, from_user_id, to_organization_id, requested_by)
+values (:'repo3ID', :'user2ID', :'org1ID', :'user2ID');
+
+-- Run some tests
+select throws_ok(
+    $$ select accept_chart_repository_transfer('00000000-0000-0000-0000-000000000002', 'repo4', '{}') $$,
+    'P0002',
+    null,
+    'Repositories without a pending transfer should be reported'
+);
+select throws_ok(
+    $$ select accept_chart_repository_transfer('00000000-0000-0000-0000-000000000001', 'repo1', '{}') $$,
+    42501,
+    null,
+    'Only members of the destination organization can accept the transfer'
+);
+select throws_ok(
+    $$ select accept_chart_repository_transfer('00000000-0000-0000-0000-000000000003', 'repo1', '{}') $$,
+    42501,
+    null,
+    'Publishers of the destination organization cannot accept the transfer'
+);
+update user__organization set organization_role_id = 2 where user_id = :'user4ID';
+select throws_ok(
+    $$ select accept_chart_repository_transfer('00000000-0000-0000-0000-000000000002', 'repo2', '{}') $$,
+    42501,
+    'transfer requester is no longer an owner of the chart repository',
+    'Transfers whose requester is no longer an owner of the repository cannot be accepted'
+);
+select throws_ok(
+    $$ select accept_chart_repository_transfer('00000000-0000-0000-0000-000000000002', 'repo3', '{}') $$,
+    42501,
+    'transfer requester is no longer an owner of the chart repository',
+    'Transfers of repositories whose owner has changed cannot be accepted'
+);
+select lives_ok(
+    $$ select accept_chart_repository_transfer('00000000-0000-0000-0000-000000000002', 'repo1', '{"ip": "192.168.1.1", "user_agent": "ua"}') $$,
+    'Admins of the destination organization should be able to accept the transfer'
+);
+select results_eq(
+    $$ select user_id, organization_id from chart_repository where name = 'repo1' $$,
+    $$ values (null::uuid, '00000000-0000-0000-0000-000000000001'::uuid) $$,
+    'Repository should belong to the destination organization'
+);
+select results_eq(
+    $$ select verification_token, verified_publisher from chart_repository where name = 'repo1' $$,
+    $$ values (null::text, false) $$,
+    'Repository should have to be verified again by its new owners'
+);
+select results_eq(
+    $$
+        select from_user_id, accepted_by, accepted_at is not null
+        from chart_repository_transfer
+        where chart_repository_id = '00000000-0000-0000-0000-000000000001'
+    $$,
+    $$ values ('00000000-0000-0000-0000-000000000001'::uuid, '00000000-0000-0000-0000-000000000002'::uuid, true) $$,
+    'Transfer should be kept as accepted'
+);
+select results_eq(
+    $$
+        select user_id, action, target, chart_repository_id, organization_id, host(ip), user_agent, details
+        from audit_event
+    $$,
+    $$
+        values (
+            '00000000-0000-0000-0000-000000000002'::uuid,
+            'accept-chart-repository-transfer',
+            'repo1',
+            '00000000-0000-0000-0000-000000000001'::uuid,
+            '00000000-0000-0000-0000-000000000001'::uuid,
+            '192.168.1.1',
+            'ua',
+            '{"from_user": "user1", "from_organization": null}'::jsonb
+        )
+    $$,
+    'Transfer acceptance should be registered in the audit log'
+);
+select is_empty(
+    $$ select 1 from chart_repository_transfer where accepted_at is null and chart_repository_id = '00000000-0000-0000-0000-000000000001' $$,
+    'No pending transfer should remain for the repository'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -0,0 +1,88 @@
+-- Start transaction and plan tests
+begin;
+select plan(8);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set user3ID '00000000-0000-0000-0000-000000000003'
+\set org1ID '00000000-0000-0000-0000-000000000001'
+\set org2ID '00000000-0000-0000-0000-000000000002'
+\set invitation1ID '00000000-0000-0000-0000-000000000001'
+\set invitation2ID '00000000-0000-0000-0000-000000000002'
+\set invitation3ID '00000000-0000-0000-0000-000000000003'
+
+-- Seed some data
+insert into "user" (user_id, alias, email, email_verified)
+values (:'user1ID', 'user1', 'user1@email.com', true);
+insert into "user" (user_id, alias, email, email_verified)
+values (:'user2ID', 'user2', 'user2@email.com', true);
+insert into "user" (user_id, alias, email, email_verified)
+values (:'user3ID', 'user3', 'user3@email.com', false);
+insert into organization (organization_id, name)
+values (:'org1ID', 'org1');
+insert into organization (organization_id, name)
+values (:'org2ID', 'org2');
+insert into organization_invitation (organization_invitation_id, organization_id, email)
+values (:'invitation1ID', :'org1ID', 'user1@email.com');
+insert into organization_invitation (organization_invitation_id, organization_id, email, expires_at)
+values (:'invitation2ID', :'org2ID', 'user1@email.com', current_timestamp - '1 day'::interval);
+insert into organization_invitation (organization_invitation_id, organization_id, email)
+values (:'invitation3ID', :'org1ID', 'user3@email.com');
+
+-- Run some tests
+select is(
+    accept_organization_invitation(:'user1ID', :'invitation2ID', '{}'),
+    false,
+    'Expired invitations should not be accepted'
+);
+select is(
+    accept_organization_invitation(:'user2ID', :'invitation1ID', '{}'),
+    false,
+    'Invitations sent to other emails should not be accepted'
+);
+select is(
+    accept_organization_invitation(:'user3ID', :'invitation3ID', '{}'),
+    false,
+    'Invitations should not be accepted by users whose email has not been verified'
+);
+select is(
+    accept_organization_invitation(:'user1ID', '00000000-0000-0000-0000-000000000009', '{}'),
+    false,
+    'Non existing invitations should not be accepted'
+);
+select is(
+    accept_organization_invitation(:'user1ID', :'invitation1ID', '{}'),
+    true,
+    'Pending invitation should be accepted'
+);
+select results_eq(
+    $$
+        select o.name, uo.organization_role_id
+        from user__organization uo
+        join organization o using (organization_id)
+        where uo.user_id = '00000000-0000-0000-0000-000000000001'
+    $$,
+    $$ values ('org1', 3) $$,
+    'User should have been added to the organization with the viewer role'
+);
+select results_eq(
+    $$ select user_id, action, organization_id from audit_event $$,
+    $$
+        values (
+            '00000000-0000-0000-0000-000000000001'::uuid,
+            'accept-organization-invitation',
+            '00000000-0000-0000-0000-000000000001'::uuid
+        )
+    $$,
+    'Invitation acceptance should be registered in the audit log'
+);
+select is(
+    accept_organization_invitation(:'user1ID', :'invitation1ID', '{}'),
+    false,
+    'Invitations already accepted should not be accepted again'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -0,0 +1,49 @@
+-- Start transaction and plan tests
+begin;
+select plan(3);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+
+-- Seed some data
+insert into "user" (user_id, alias, email)
+values (:'user1ID', 'user1', 'user1@email.com');
+
+-- Add api key
+select add_api_key(:'user1ID', '{
+    "name": "key1",
+    "key_hash": "hash1"
+}'::jsonb, '{}');
+
+-- Check if api key was added successfully
+select results_eq(
+    $$
+        select user_id, name, key_hash from api_key
+    $$,
+    $$
+        values ('00000000-0000-0000-0000-000000000001'::uuid, 'key1', 'hash1')
+    $$,
+    'Api key should exist'
+);
+select results_eq(
+    $$ select user_id, action, target from audit_event $$,
+    $$ values ('00000000-0000-0000-0000-000000000001'::uuid, 'add-api-key', 'key1') $$,
+    'Api key addition should be registered in the audit log'
+);
+
+-- Api key names must be unique for each user
+select throws_ok(
+    $$
+        select add_api_key('00000000-0000-0000-0000-000000000001', '{
+            "name": "key1",
+            "key_hash": "hash2"
+        }'::jsonb, '{}')
+    $$,
+    23505,
+    'duplicate key value violates unique constraint "api_key_user_id_name_key"',
+    'Api key names must be unique for each user'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
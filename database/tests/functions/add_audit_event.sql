@@ -0,0 +1,61 @@
+-- Start transaction and plan tests
+begin;
+select plan(2);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set org1ID '00000000-0000-0000-0000-000000000001'
+\set repo1ID '00000000-0000-0000-0000-000000000001'
+
+-- Seed some data
+insert into "user" (user_id, alias, email)
+values (:'user1ID', 'user1', 'user1@email.com');
+insert into organization (organization_id, name)
+values (:'org1ID', 'org1');
+insert into chart_repository (chart_repository_id, name, url, organization_id)
+values (:'repo1ID', 'repo1', 'https://repo1.com', :'org1ID');
+
+-- Run some tests
+select add_audit_event(
+    :'user1ID',
+    '{"ip": "192.168.1.1", "user_agent": "ua"}',
+    'update-chart-repository',
+    'repo1',
+    :'repo1ID',
+    :'org1ID',
+    '{"url": "https://repo1.com"}'
+);
+select add_audit_event(:'user1ID', '{}', 'logout', null, null, null, null);
+select results_eq(
+    $$
+        select user_id, action, target, chart_repository_id, organization_id, host(ip), user_agent, details
+        from audit_event
+        where action = 'update-chart-repository'
+    $$,
+    $$
+        values (
+            '00000000-0000-0000-0000-000000000001'::uuid,
+            'update-chart-repository',
+            'repo1',
+            '00000000-0000-0000-0000-000000000001'::uuid,
+            '00000000-0000-0000-0000-000000000001'::uuid,
+            '192.168.1.1',
+            'ua',
+            '{"url": "https://repo1.com"}'::jsonb
+        )
+    $$,
+    'Audit event should be registered with the request information provided'
+);
+select results_eq(
+    $$
+        select user_id, target, ip, user_agent, details
+        from audit_event
+        where action = 'logout'
+    $$,
+    $$ values ('00000000-0000-0000-0000-000000000001'::uuid, null::text, null::inet, null::text, null::jsonb) $$,
+    'Audit event should be registered without request information'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -1,10 +1,18 @@
 -- Start transaction and plan tests
 begin;
-select plan(3);
+select plan(9);
 
--- Seed user
+-- Seed users and organization
 insert into "user" (user_id, alias, email)
 values ('00000000-0000-0000-0000-000000000001', 'user1', 'user1@email.com');
+insert into "user" (user_id, alias, email)
+values ('00000000-0000-0000-0000-000000000002', 'user2', 'user2@email.com');
+insert into organization (organization_id, name)
+values ('00000000-0000-0000-0000-000000000001', 'org1');
+insert into user__organization (user_id, organization_id, organization_role_id)
+values ('00000000-0000-0000-0000-000000000001', '00000000-0000-0000-0000-000000000001', 1);
+insert into user__organization (user_id, organization_id, organization_role_id)
+values ('00000000-0000-0000-0000-000000000002', '00000000-0000-0000-0000-000000000001', 2);
 
 -- Add chart repository
 select add_chart_repository('
@@ -14,7 +22,7 @@ select add_chart_repository('
     "url": "repo1_url",
     "user_id": "00000000-0000-0000-0000-000000000001"
 }
-'::jsonb);
+'::jsonb, '{"ip": "192.168.1.1", "user_agent": "ua"}');
 
 -- Check if chart repository was added successfully
 select results_eq(
@@ -36,6 +44,24 @@ select results_eq(
     $$,
     'Chart repository should exist'
 );
+select results_eq(
+    $$
+        select user_id, action, target, organization_id, host(ip), user_agent, details
+        from audit_event
+    $$,
+    $$
+        values (
+            '00000000-0000-0000-0000-000000000001'::uuid,
+            'add-chart-repository',
+            'repo1',
+            null::uuid,
+            '192.168.1.1',
+            'ua',
+            '{"url": "repo1_url"}'::jsonb
+        )
+    $$,
+    'Chart repository addition should be registered in the audit log'
+);
 
 -- Try adding a repository with an empty user id or not providing a user id
 select throws_ok(
@@ -47,7 +73,7 @@ select throws_ok(
             "url": "repo2_url",
             "user_id": ""
         }
-        ')
+        ', '{}')
     $$,
     'invalid input syntax for type uuid: ""',
     'User id cannot be empty'
@@ -60,12 +86,110 @@ select throws_ok(
             "display_name": "Repository 2",
             "url": "repo2_url"
         }
-        ')
+        ', '{}')
     $$,
     'a valid user_id must be provided',
     'User id must be provided'
 );
 
+-- Add chart repository owned by an organization
+select add_chart_repository('
+{
+    "name": "repo3",
+    "display_name": "Repository 3",
+    "url": "repo3_url",
+    "user_id": "00000000-0000-0000-0000-000000000001",
+    "organization_name": "org1"
+}
+'::jsonb, '{}');
+select results_eq(
+    $$
+        select user_id, organization_id
+        from chart_repository
+        where name = 'repo3'
+    $$,
+    $$
+        values (
+            null::uuid,
+            '00000000-0000-0000-0000-000000000001'::uuid
+        )
+    $$,
+    'Chart repository should belong to the organization'
+);
+
+-- Try adding a repository to an organization that does not exist
+select throws_ok(
+    $$
+        select add_chart_repository('
+        {
+            "name": "repo4",
+            "url": "repo4_url",
+            "user_id": "00000000-0000-0000-0000-000000000001",
+            "organization_name": "org2"
+        }
+        ', '{}')
+    $$,
+    'P0002',
+    null,
+    'Repositories cannot be added to non existing organizations'
+);
+
+-- Try adding a repository to an organization as a publisher and as a user
+-- who does not belong to it
+select throws_ok(
+    $$
+        select add_chart_repository('
+        {
+            "name": "repo4",
+            "display_name": "Repository 4",
+            "url": "repo4_url",
+            "user_id": "00000000-0000-0000-0000-000000000002",
+            "organization_name": "org1"
+        }
+        ', '{}')
+    $$,
+    42501,
+    null,
+    'Publishers cannot add repositories to the organization'
+);
+delete from user__organization where user_id = '00000000-0000-0000-0000-000000000002';
+select throws_ok(
+    $$
+        select add_chart_repository('
+        {
+            "name": "repo4",
+            "display_name": "Repository 4",
+            "url": "repo4_url",
+            "user_id": "00000000-0000-0000-0000-000000000002",
+            "organization_name": "org1"
+        }
+        ', '{}')
+    $$,
+    42501,
+    null,
+    'Only organization members can add repositories to it'
+);
+
+-- Add chart repository backed by a git repository
+select add_chart_repository('
+{
+    "name": "repo5",
+    "url": "https://github.com/org1/charts",
+    "kind": 1,
+    "git_branch": "main",
+    "git_path_glob": "charts/*",
+    "user_id": "00000000-0000-0000-0000-000000000001"
+}
+'::jsonb, '{}');
+select results_eq(
+    $$
+        select chart_repository_kind_id, git_branch, git_path_glob
+        from chart_repository
+        where name = 'repo5'
+    $$,
+    $$ values (1, 'main', 'charts/*') $$,
+    'Git chart repository should exist'
+);
 
 -- Finish tests and rollback transaction
 select * from finish();
@@ -0,0 +1,39 @@
+-- Start transaction and plan tests
+begin;
+select plan(4);
+
+-- Declare some variables
+\set repo1ID '00000000-0000-0000-0000-000000000001'
+
+-- Seed some data
+insert into chart_repository (chart_repository_id, name, url)
+values (:'repo1ID', 'repo1', 'https://repo1.com');
+
+-- Non existing repository
+select is_empty(
+    $$ select add_chart_version_tracking_request('repo2', 'chart1', '1.0.0') $$,
+    'No request should be returned for a non existing repository'
+);
+
+-- Add chart version tracking request
+select add_chart_version_tracking_request('repo1', 'chart1', '1.0.0');
+select results_eq(
+    $$ select chart_repository_id, chart_name, chart_version, status from tracking_request $$,
+    $$ values ('00000000-0000-0000-0000-000000000001'::uuid, 'chart1', '1.0.0', 'pending') $$,
+    'Chart version tracking request should have been added as pending'
+);
+select is(
+    (add_chart_version_tracking_request('repo1', 'chart1', '1.0.0')::jsonb)->>'tracking_request_id',
+    (select tracking_request_id::text from tracking_request),
+    'Pending request for the same chart version should be returned'
+);
+select add_chart_version_tracking_request('repo1', 'chart1', '1.1.0');
+select results_eq(
+    $$ select count(*) from tracking_request $$,
+    $$ values (2::bigint) $$,
+    'Requests for other chart versions should be added'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -0,0 +1,53 @@
+-- Start transaction and plan tests
+begin;
+select plan(2);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+
+-- Seed user
+insert into "user" (user_id, alias, email)
+values (:'user1ID', 'user1', 'user1@email.com');
+
+-- Add organization
+select add_organization(:'user1ID', '
+{
+    "name": "org1",
+    "display_name": "Organization 1",
+    "description": "Description 1",
+    "home_url": "https://org1.com",
+    "logo_url": "https://org1.com/logo.png"
+}
+'::jsonb);
+
+-- Check if organization was added successfully
+select results_eq(
+    $$
+        select name, display_name, description, home_url, logo_url
+        from organization
+    $$,
+    $$
+        values (
+            'org1',
+            'Organization 1',
+            'Description 1',
+            'https://org1.com',
+            'https://org1.com/logo.png'
+        )
+    $$,
+    'Organization should exist'
+);
+select results_eq(
+    $$
+        select uo.user_id, uo.organization_role_id
+        from user__organization uo
+        join organization o using (organization_id)
+        where o.name = 'org1'
+    $$,
+    $$ values ('00000000-0000-0000-0000-000000000001'::uuid, 0) $$,
+    'User who added the organization should be a member of it as owner'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -0,0 +1,112 @@
+-- Start transaction and plan tests
+begin;
+select plan(10);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set user3ID '00000000-0000-0000-0000-000000000003'
+\set org1ID '00000000-0000-0000-0000-000000000001'
+\set invitation1ID '00000000-0000-0000-0000-000000000001'
+
+-- Seed some data
+insert into "user" (user_id, alias, email, email_verified)
+values (:'user1ID', 'user1', 'user1@email.com', true);
+insert into "user" (user_id, alias, email, email_verified)
+values (:'user2ID', 'user2', 'user2@email.com', true);
+insert into "user" (user_id, alias, email, email_verified)
+values (:'user3ID', 'user3', 'user3@email.com', true);
+insert into organization (organization_id, name)
+values (:'org1ID', 'org1');
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user1ID', :'org1ID', 0);
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user3ID', :'org1ID', 1);
+insert into organization_invitation (organization_invitation_id, organization_id, email, created_at, expires_at)
+values (
+    :'invitation1ID',
+    :'org1ID',
+    'user4@email.com',
+    current_timestamp - '1 day'::interval,
+    current_timestamp + '6 days'::interval
+);
+
+-- Run some tests
+select throws_ok(
+    $$ select add_organization_invitation('00000000-0000-0000-0000-000000000001', 'org2', 'user2@email.com', '{}') $$,
+    'P0002',
+    null,
+    'Invitations to non existing organizations should fail'
+);
+select throws_ok(
+    $$ select add_organization_invitation('00000000-0000-0000-0000-000000000002', 'org1', 'user5@email.com', '{}') $$,
+    42501,
+    null,
+    'Non members should not be allowed to invite users'
+);
+select throws_ok(
+    $$ select add_organization_invitation('00000000-0000-0000-0000-000000000003', 'org1', 'user2@email.com', '{}') $$,
+    42501,
+    null,
+    'Admins should not be allowed to invite users'
+);
+select is_empty(
+    $$ select add_organization_invitation('00000000-0000-0000-0000-000000000001', 'org1', 'user3@email.com', '{}') $$,
+    'Members should not be invited again'
+);
+select isnt_empty(
+    $$ select add_organization_invitation('00000000-0000-0000-0000-000000000001', 'org1', 'user2@email.com', '{}') $$,
+    'Registered users should be invited'
+);
+select isnt_empty(
+    $$ select add_organization_invitation('00000000-0000-0000-0000-000000000001', 'org1', 'user5@email.com', '{}') $$,
+    'Emails not registered yet should be invited'
+);
+select results_eq(
+    $$
+        select email, invited_by::text
+        from organization_invitation
+        where email in ('user2@email.com', 'user5@email.com')
+        order by email asc
+    $$,
+    $$
+        values
+            ('user2@email.com', '00000000-0000-0000-0000-000000000001'),
+            ('user5@email.com', '00000000-0000-0000-0000-000000000001')
+    $$,
+    'Invitations should be registered as pending'
+);
+select isnt_empty(
+    $$ select add_organization_invitation('00000000-0000-0000-0000-000000000001', 'org1', 'user4@email.com', '{}') $$,
+    'Emails with a pending invitation should be invited again'
+);
+select results_eq(
+    $$
+        select
+            organization_invitation_id <> '00000000-0000-0000-0000-000000000001',
+            created_at = current_timestamp,
+            expires_at = current_timestamp + '7 days'::interval
+        from organization_invitation
+        where email = 'user4@email.com'
+    $$,
+    $$ values (true, true, true) $$,
+    'Pending invitation should have been renewed with a new token'
+);
+select results_eq(
+    $$
+        select user_id, action, target, organization_id
+        from audit_event
+        order by target asc
+    $$,
+    $$
+        values
+            ('00000000-0000-0000-0000-000000000001'::uuid, 'add-organization-invitation', 'user2@email.com', '00000000-0000-0000-0000-000000000001'::uuid),
+            ('00000000-0000-0000-0000-000000000001'::uuid, 'add-organization-invitation', 'user4@email.com', '00000000-0000-0000-0000-000000000001'::uuid),
+            ('00000000-0000-0000-0000-000000000001'::uuid, 'add-organization-invitation', 'user5@email.com', '00000000-0000-0000-0000-000000000001'::uuid)
+    $$,
+    'Invitations sent should be registered in the audit log'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
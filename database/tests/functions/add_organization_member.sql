@@ -0,0 +1,79 @@
+-- Start transaction and plan tests
+begin;
+select plan(5);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set user3ID '00000000-0000-0000-0000-000000000003'
+\set org1ID '00000000-0000-0000-0000-000000000001'
+
+-- Seed users and organization
+insert into "user" (user_id, alias, email)
+values (:'user1ID', 'user1', 'user1@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user2ID', 'user2', 'user2@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user3ID', 'user3', 'user3@email.com');
+insert into organization (organization_id, name)
+values (:'org1ID', 'org1');
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user1ID', :'org1ID', 0);
+
+-- Try adding a member to an organization that does not exist
+select throws_ok(
+    $$ select add_organization_member('00000000-0000-0000-0000-000000000001', 'org2', 'user2', '{}') $$,
+    'P0002',
+    null,
+    'Members cannot be added to non existing organizations'
+);
+
+-- Try adding a member as a user who is not a member
+select throws_ok(
+    $$ select add_organization_member('00000000-0000-0000-0000-000000000002', 'org1', 'user3', '{}') $$,
+    42501,
+    null,
+    'Non members cannot add members to the organization'
+);
+
+-- Add a member as an owner
+select add_organization_member(:'user1ID', 'org1', 'user2', '{"ip": "192.168.1.1", "user_agent": "ua"}');
+select results_eq(
+    $$ select user_id, organization_role_id from user__organization order by user_id asc $$,
+    $$ values
+        ('00000000-0000-0000-0000-000000000001'::uuid, 0),
+        ('00000000-0000-0000-0000-000000000002'::uuid, 3)
+    $$,
+    'Owners can add members to the organization, who are added as viewers'
+);
+select results_eq(
+    $$
+        select user_id, action, target, organization_id, host(ip), user_agent, details
+        from audit_event
+    $$,
+    $$
+        values (
+            '00000000-0000-0000-0000-000000000001'::uuid,
+            'add-organization-member',
+            'user2',
+            '00000000-0000-0000-0000-000000000001'::uuid,
+            '192.168.1.1',
+            'ua',
+            '{"role": "viewer"}'::jsonb
+        )
+    $$,
+    'Member addition should be registered in the audit log'
+);
+
+-- Try adding a member as an admin
+update user__organization set organization_role_id = 1 where user_id = :'user2ID';
+select throws_ok(
+    $$ select add_organization_member('00000000-0000-0000-0000-000000000002', 'org1', 'user3', '{}') $$,
+    42501,
+    null,
+    'Admins cannot add members to the organization'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -0,0 +1,46 @@
+-- Start transaction and plan tests
+begin;
+select plan(2);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set repo1ID '00000000-0000-0000-0000-000000000001'
+\set package1ID '00000000-0000-0000-0000-000000000001'
+
+-- Seed some data
+insert into "user" (user_id, alias, email)
+values (:'user1ID', 'user1', 'user1@email.com');
+insert into chart_repository (chart_repository_id, name, url)
+values (:'repo1ID', 'repo1', 'https://repo1.com');
+insert into package (package_id, name, latest_version, package_kind_id, chart_repository_id)
+values (:'package1ID', 'package1', '1.0.0', 0, :'repo1ID');
+
+-- Add subscription
+select add_subscription(:'user1ID', '{
+    "package_id": "00000000-0000-0000-0000-000000000001",
+    "event_kind": 0
+}'::jsonb);
+
+-- Check if subscription was added
+select results_eq(
+    $$ select user_id, package_id, event_kind_id from subscription $$,
+    $$ values (
+        '00000000-0000-0000-0000-000000000001'::uuid,
+        '00000000-0000-0000-0000-000000000001'::uuid,
+        0
+    ) $$,
+    'Subscription should exist'
+);
+
+-- Adding the same subscription again should not fail
+select lives_ok(
+    $$ select add_subscription('00000000-0000-0000-0000-000000000001', '{
+        "package_id": "00000000-0000-0000-0000-000000000001",
+        "event_kind": 0
+    }'::jsonb) $$,
+    'Adding an existing subscription should succeed'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -0,0 +1,78 @@
+-- Start transaction and plan tests
+begin;
+select plan(7);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set user3ID '00000000-0000-0000-0000-000000000003'
+\set org1ID '00000000-0000-0000-0000-000000000001'
+\set repo1ID '00000000-0000-0000-0000-000000000001'
+\set repo2ID '00000000-0000-0000-0000-000000000002'
+
+-- Seed some data
+insert into "user" (user_id, alias, email)
+values (:'user1ID', 'user1', 'user1@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user2ID', 'user2', 'user2@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user3ID', 'user3', 'user3@email.com');
+insert into organization (organization_id, name)
+values (:'org1ID', 'org1');
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user2ID', :'org1ID', 2);
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user3ID', :'org1ID', 3);
+insert into chart_repository (chart_repository_id, name, url, user_id)
+values (:'repo1ID', 'repo1', 'https://repo1.com', :'user1ID');
+insert into chart_repository (chart_repository_id, name, url, organization_id)
+values (:'repo2ID', 'repo2', 'https://repo2.com', :'org1ID');
+
+-- Non existing repository
+select is_empty(
+    $$ select add_tracking_request('00000000-0000-0000-0000-000000000001', 'repo3') $$,
+    'No request should be returned for a non existing repository'
+);
+
+-- Repository not owned by the user
+select throws_ok(
+    $$ select add_tracking_request('00000000-0000-0000-0000-000000000002', 'repo1') $$,
+    42501,
+    null,
+    'Only the repository owner can request it to be tracked'
+);
+
+-- Organization repository requested by a viewer and by a publisher
+select throws_ok(
+    $$ select add_tracking_request('00000000-0000-0000-0000-000000000003', 'repo2') $$,
+    42501,
+    null,
+    'Viewers cannot request the organization repository to be tracked'
+);
+select isnt_empty(
+    $$ select add_tracking_request('00000000-0000-0000-0000-000000000002', 'repo2') $$,
+    'Publishers can request the organization repository to be tracked'
+);
+delete from tracking_request;
+
+-- Add tracking request
+select add_tracking_request(:'user1ID', 'repo1');
+select results_eq(
+    $$ select chart_repository_id, status from tracking_request $$,
+    $$ values ('00000000-0000-0000-0000-000000000001'::uuid, 'pending') $$,
+    'Tracking request should have been added as pending'
+);
+select is(
+    (add_tracking_request(:'user1ID', 'repo1')::jsonb)->>'tracking_request_id',
+    (select tracking_request_id::text from tracking_request),
+    'Pending request should be returned when adding a new one'
+);
+select results_eq(
+    $$ select count(*) from tracking_request $$,
+    $$ values (1::bigint) $$,
+    'Only one pending request should exist per repository'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -0,0 +1,87 @@
+-- Start transaction and plan tests
+begin;
+select plan(6);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set user3ID '00000000-0000-0000-0000-000000000003'
+\set org1ID '00000000-0000-0000-0000-000000000001'
+\set repo1ID '00000000-0000-0000-0000-000000000001'
+\set repo2ID '00000000-0000-0000-0000-000000000002'
+
+-- Seed some data
+insert into "user" (user_id, alias, email)
+values (:'user1ID', 'user1', 'user1@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user2ID', 'user2', 'user2@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user3ID', 'user3', 'user3@email.com');
+insert into organization (organization_id, name)
+values (:'org1ID', 'org1');
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user2ID', :'org1ID', 1);
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user3ID', :'org1ID', 2);
+insert into chart_repository (chart_repository_id, name, url, user_id)
+values (:'repo1ID', 'repo1', 'https://repo1.com', :'user1ID');
+insert into chart_repository (chart_repository_id, name, url, organization_id)
+values (:'repo2ID', 'repo2', 'https://repo2.com', :'org1ID');
+
+-- Run some tests
+select is_empty(
+    $$ select add_webhook('00000000-0000-0000-0000-000000000001', 'repo3', '{"url": "https://hook.com", "secret": "s", "event_kinds": [0]}', '{}') $$,
+    'Nothing should be added for a non existing repository'
+);
+select throws_ok(
+    $$ select add_webhook('00000000-0000-0000-0000-000000000002', 'repo1', '{"url": "https://hook.com", "secret": "s", "event_kinds": [0]}', '{}') $$,
+    42501,
+    null,
+    'Only the repository owner should be allowed to add webhooks'
+);
+select throws_ok(
+    $$ select add_webhook('00000000-0000-0000-0000-000000000003', 'repo2', '{"url": "https://hook.com", "secret": "s", "event_kinds": [0]}', '{}') $$,
+    42501,
+    null,
+    'Publishers should not be allowed to add webhooks to the organization repository'
+);
+select isnt_empty(
+    $$ select add_webhook('00000000-0000-0000-0000-000000000002', 'repo2', '{"url": "https://hook.com", "secret": "s", "event_kinds": [0]}', '{}') $$,
+    'Admins should be allowed to add webhooks to the organization repository'
+);
+delete from webhook;
+select add_webhook(:'user1ID', 'repo1', '{
+    "url": "https://hook.com",
+    "secret": "secret1",
+    "event_kinds": [0, 1]
+}', '{}');
+select results_eq(
+    $$ select chart_repository_id, url, secret, event_kinds from webhook $$,
+    $$ values (
+        '00000000-0000-0000-0000-000000000001'::uuid,
+        'https://hook.com',
+        'secret1',
+        '{0,1}'::int[]
+    ) $$,
+    'Webhook should exist'
+);
+select results_eq(
+    $$
+        select user_id, action, target, details
+        from audit_event
+        where chart_repository_id = '00000000-0000-0000-0000-000000000001'
+    $$,
+    $$
+        values (
+            '00000000-0000-0000-0000-000000000001'::uuid,
+            'add-webhook',
+            (select webhook_id::text from webhook),
+            '{"url": "https://hook.com"}'::jsonb
+        )
+    $$,
+    'Webhook addition should be registered in the audit log'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -0,0 +1,51 @@
+-- Start transaction and plan tests
+begin;
+select plan(1);
+
+-- Declare some variables
+\set repo1ID '00000000-0000-0000-0000-000000000001'
+\set package1ID '00000000-0000-0000-0000-000000000001'
+\set webhook1ID '00000000-0000-0000-0000-000000000001'
+\set webhook2ID '00000000-0000-0000-0000-000000000002'
+
+-- Seed some data
+insert into chart_repository (chart_repository_id, name, url)
+values (:'repo1ID', 'repo1', 'https://repo1.com');
+insert into package (package_id, name, latest_version, package_kind_id, chart_repository_id)
+values (:'package1ID', 'package1', '1.0.0', 0, :'repo1ID');
+insert into snapshot (package_id, version, digest)
+values (:'package1ID', '1.0.0', 'digest-1.0.0');
+insert into webhook (webhook_id, chart_repository_id, url, secret, event_kinds)
+values (:'webhook1ID', :'repo1ID', 'https://hook1.com', 'secret1', '{0}');
+insert into webhook (webhook_id, chart_repository_id, url, secret, event_kinds)
+values (:'webhook2ID', :'repo1ID', 'https://hook2.com', 'secret2', '{1}');
+
+-- Add deliveries for a new release event
+select add_webhook_deliveries(:'package1ID', '1.0.0', 0);
+
+-- Only webhooks interested in the event should have a delivery
+select results_eq(
+    $$ select webhook_id, event_kind_id, payload from webhook_delivery $$,
+    $$ values (
+        '00000000-0000-0000-0000-000000000001'::uuid,
+        0,
+        '{
+            "event_kind": "new-release",
+            "package": {
+                "package_id": "00000000-0000-0000-0000-000000000001",
+                "name": "package1",
+                "version": "1.0.0",
+                "digest": "digest-1.0.0"
+            },
+            "chart_repository": {
+                "name": "repo1",
+                "url": "https://repo1.com"
+            }
+        }'::jsonb
+    ) $$,
+    'Deliveries should have been added for webhooks interested in the event'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -0,0 +1,68 @@
+-- Start transaction and plan tests
+begin;
+select plan(5);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set org1ID '00000000-0000-0000-0000-000000000001'
+\set repo1ID '00000000-0000-0000-0000-000000000001'
+
+-- Seed some data
+insert into "user" (user_id, alias, email)
+values (:'user1ID', 'user1', 'user1@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user2ID', 'user2', 'user2@email.com');
+insert into organization (organization_id, name)
+values (:'org1ID', 'org1');
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user2ID', :'org1ID', 0);
+insert into chart_repository (chart_repository_id, name, url, user_id)
+values (:'repo1ID', 'repo1', 'https://repo1.com', :'user1ID');
+insert into chart_repository_transfer (chart_repository_id, from_user_id, to_organization_id, requested_by)
+values (:'repo1ID', :'user1ID', :'org1ID', :'user1ID');
+
+-- Run some tests
+select throws_ok(
+    $$ select cancel_chart_repository_transfer('00000000-0000-0000-0000-000000000001', 'repo2', '{}') $$,
+    'P0002',
+    null,
+    'Repositories without a pending transfer should be reported'
+);
+select throws_ok(
+    $$ select cancel_chart_repository_transfer('00000000-0000-0000-0000-000000000002', 'repo1', '{}') $$,
+    42501,
+    null,
+    'Members of the destination organization cannot cancel the transfer'
+);
+select lives_ok(
+    $$ select cancel_chart_repository_transfer('00000000-0000-0000-0000-000000000001', 'repo1', '{"ip": "192.168.1.1", "user_agent": "ua"}') $$,
+    'Owners of the repository should be able to cancel the transfer'
+);
+select is_empty(
+    $$ select 1 from chart_repository_transfer $$,
+    'Transfer should have been deleted'
+);
+select results_eq(
+    $$
+        select user_id, action, target, chart_repository_id, organization_id, host(ip), user_agent, details
+        from audit_event
+    $$,
+    $$
+        values (
+            '00000000-0000-0000-0000-000000000001'::uuid,
+            'cancel-chart-repository-transfer',
+            'repo1',
+            '00000000-0000-0000-0000-000000000001'::uuid,
+            null::uuid,
+            '192.168.1.1',
+            'ua',
+            '{"to_organization": "org1"}'::jsonb
+        )
+    $$,
+    'Transfer cancellation should be registered in the audit log'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
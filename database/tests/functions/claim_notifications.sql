@@ -0,0 +1,85 @@
+-- Start transaction and plan tests
+begin;
+select plan(5);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set repo1ID '00000000-0000-0000-0000-000000000001'
+\set package1ID '00000000-0000-0000-0000-000000000001'
+\set event1ID '00000000-0000-0000-0000-000000000001'
+\set event2ID '00000000-0000-0000-0000-000000000002'
+\set notification1ID '00000000-0000-0000-0000-000000000001'
+\set notification2ID '00000000-0000-0000-0000-000000000002'
+\set notification3ID '00000000-0000-0000-0000-000000000003'
+
+-- No notifications at this point
+select is(
+    claim_notifications(10, 5)::jsonb,
+    '[]'::jsonb,
+    'With no pending notifications an empty json array is returned'
+);
+
+-- Seed some data
+insert into "user" (user_id, alias, email, email_verified)
+values (:'user1ID', 'user1', 'user1@email.com', true);
+insert into chart_repository (chart_repository_id, name, display_name, url)
+values (:'repo1ID', 'repo1', 'Repo 1', 'https://repo1.com');
+insert into package (package_id, name, display_name, latest_version, package_kind_id, chart_repository_id)
+values (:'package1ID', 'package1', 'Package 1', '2.0.0', 0, :'repo1ID');
+insert into event (event_id, package_id, package_version, event_kind_id, processed)
+values (:'event1ID', :'package1ID', '1.0.0', 0, true);
+insert into event (event_id, package_id, package_version, event_kind_id, processed)
+values (:'event2ID', :'package1ID', '2.0.0', 0, true);
+insert into notification (notification_id, event_id, user_id)
+values (:'notification1ID', :'event1ID', :'user1ID');
+insert into notification (notification_id, event_id, user_id, processed)
+values (:'notification2ID', :'event2ID', :'user1ID', true);
+
+-- Pending notifications are claimed
+select is(
+    claim_notifications(10, 5)::jsonb,
+    '[{
+        "notification_id": "00000000-0000-0000-0000-000000000001",
+        "email": "user1@email.com",
+        "event_kind": 0,
+        "package": {
+            "package_id": "00000000-0000-0000-0000-000000000001",
+            "kind": 0,
+            "name": "package1",
+            "display_name": "Package 1",
+            "version": "1.0.0",
+            "chart_repository": {
+                "name": "repo1",
+                "display_name": "Repo 1"
+            },
+            "operator_provider": null
+        }
+    }]'::jsonb,
+    'Pending notifications are returned as a json array'
+);
+select results_eq(
+    $$ select attempts, last_attempt_at is not null from notification where notification_id = '00000000-0000-0000-0000-000000000001' $$,
+    $$ values (1, true) $$,
+    'Claimed notifications attempts should have been increased'
+);
+
+-- Claimed notifications are not claimed again until the backoff period elapses
+select is(
+    claim_notifications(10, 5)::jsonb,
+    '[]'::jsonb,
+    'Recently attempted notifications should not be claimed'
+);
+
+-- Notifications that reached the maximum number of attempts are not claimed
+delete from notification where notification_id = :'notification2ID';
+insert into notification (notification_id, event_id, user_id, attempts, last_attempt_at)
+values (:'notification3ID', :'event2ID', :'user1ID', 5, current_timestamp - interval '1 day');
+select is(
+    claim_notifications(10, 5)::jsonb,
+    '[]'::jsonb,
+    'Notifications that reached the maximum number of attempts should not be claimed'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
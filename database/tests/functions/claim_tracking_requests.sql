@@ -0,0 +1,88 @@
+-- Start transaction and plan tests
+begin;
+select plan(4);
+
+-- Declare some variables
+\set repo1ID '00000000-0000-0000-0000-000000000001'
+\set request1ID '00000000-0000-0000-0000-000000000001'
+\set request2ID '00000000-0000-0000-0000-000000000002'
+\set request3ID '00000000-0000-0000-0000-000000000003'
+\set request4ID '00000000-0000-0000-0000-000000000004'
+
+-- No requests at this point
+select is(
+    claim_tracking_requests()::jsonb,
+    '[]'::jsonb,
+    'With no pending requests an empty json array is returned'
+);
+
+-- Seed some data
+insert into chart_repository (chart_repository_id, name, display_name, url)
+values (:'repo1ID', 'repo1', 'Repo 1', 'https://repo1.com');
+insert into tracking_request (tracking_request_id, chart_repository_id, status)
+values (:'request1ID', :'repo1ID', 'pending');
+insert into tracking_request (tracking_request_id, chart_repository_id, status)
+values (:'request2ID', :'repo1ID', 'completed');
+insert into tracking_request (tracking_request_id, chart_repository_id, status, created_at, started_at)
+values (:'request3ID', :'repo1ID', 'processing', current_timestamp - '3 hours'::interval, current_timestamp - '2 hours'::interval);
+insert into tracking_request (tracking_request_id, chart_repository_id, status, started_at)
+values (:'request4ID', :'repo1ID', 'processing', current_timestamp);
+
+-- Pending and abandoned requests are claimed
+select is(
+    claim_tracking_requests()::jsonb,
+    '[{
+        "tracking_request_id": "00000000-0000-0000-0000-000000000003",
+        "chart_name": null,
+        "chart_version": null,
+        "chart_repository": {
+            "chart_repository_id": "00000000-0000-0000-0000-000000000001",
+            "name": "repo1",
+            "display_name": "Repo 1",
+            "url": "https://repo1.com",
+            "kind": 0,
+            "git_branch": null,
+            "git_path_glob": null,
+            "last_tracking_ts": null
+        }
+    }, {
+        "tracking_request_id": "00000000-0000-0000-0000-000000000001",
+        "chart_name": null,
+        "chart_version": null,
+        "chart_repository": {
+            "chart_repository_id": "00000000-0000-0000-0000-000000000001",
+            "name": "repo1",
+            "display_name": "Repo 1",
+            "url": "https://repo1.com",
+            "kind": 0,
+            "git_branch": null,
+            "git_path_glob": null,
+            "last_tracking_ts": null
+        }
+    }]'::jsonb,
+    'Pending and abandoned requests are returned as a json array'
+);
+select results_eq(
+    $$ select status from tracking_request where tracking_request_id = '00000000-0000-0000-0000-000000000001' $$,
+    $$ values ('processing') $$,
+    'Claimed requests should be marked as processing'
+);
+select results_eq(
+    $$
+        select tracking_request_id::text
+        from tracking_request
+        where status = 'processing'
+        and started_at = current_timestamp
+        order by tracking_request_id
+    $$,
+    $$ values
+        ('00000000-0000-0000-0000-000000000001'),
+        ('00000000-0000-0000-0000-000000000003'),
+        ('00000000-0000-0000-0000-000000000004')
+    $$,
+    'Abandoned requests claimed again should have been restarted'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
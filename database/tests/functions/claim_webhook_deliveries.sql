@@ -0,0 +1,53 @@
+-- Start transaction and plan tests
+begin;
+select plan(4);
+
+-- Declare some variables
+\set repo1ID '00000000-0000-0000-0000-000000000001'
+\set webhook1ID '00000000-0000-0000-0000-000000000001'
+\set delivery1ID '00000000-0000-0000-0000-000000000001'
+\set delivery2ID '00000000-0000-0000-0000-000000000002'
+
+-- No deliveries at this point
+select is(
+    claim_webhook_deliveries(10, 5)::jsonb,
+    '[]'::jsonb,
+    'With no pending deliveries an empty json array is returned'
+);
+
+-- Seed some data
+insert into chart_repository (chart_repository_id, name, url)
+values (:'repo1ID', 'repo1', 'https://repo1.com');
+insert into webhook (webhook_id, chart_repository_id, url, secret, event_kinds)
+values (:'webhook1ID', :'repo1ID', 'https://hook.com', 'secret1', '{0}');
+insert into webhook_delivery (webhook_delivery_id, webhook_id, event_kind_id, payload)
+values (:'delivery1ID', :'webhook1ID', 0, '{"key": "value1"}');
+insert into webhook_delivery (webhook_delivery_id, webhook_id, event_kind_id, payload, processed)
+values (:'delivery2ID', :'webhook1ID', 0, '{"key": "value2"}', true);
+
+-- Pending deliveries are claimed
+select is(
+    claim_webhook_deliveries(10, 5)::jsonb,
+    '[{
+        "webhook_delivery_id": "00000000-0000-0000-0000-000000000001",
+        "url": "https://hook.com",
+        "secret": "secret1",
+        "event_kind": 0,
+        "payload": {"key": "value1"}
+    }]'::jsonb,
+    'Pending deliveries are returned as a json array'
+);
+select results_eq(
+    $$ select attempts, last_attempt_at is not null from webhook_delivery where webhook_delivery_id = '00000000-0000-0000-0000-000000000001' $$,
+    $$ values (1, true) $$,
+    'Claimed deliveries attempts should have been increased'
+);
+select is(
+    claim_webhook_deliveries(10, 5)::jsonb,
+    '[]'::jsonb,
+    'Recently attempted deliveries should not be claimed'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
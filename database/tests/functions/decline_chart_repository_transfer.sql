@@ -0,0 +1,77 @@
+-- Start transaction and plan tests
+begin;
+select plan(6);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set user3ID '00000000-0000-0000-0000-000000000003'
+\set org1ID '00000000-0000-0000-0000-000000000001'
+\set repo1ID '00000000-0000-0000-0000-000000000001'
+
+-- Seed some data
+insert into "user" (user_id, alias, email)
+values (:'user1ID', 'user1', 'user1@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user2ID', 'user2', 'user2@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user3ID', 'user3', 'user3@email.com');
+insert into organization (organization_id, name)
+values (:'org1ID', 'org1');
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user2ID', :'org1ID', 1);
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user3ID', :'org1ID', 2);
+insert into chart_repository (chart_repository_id, name, url, user_id)
+values (:'repo1ID', 'repo1', 'https://repo1.com', :'user1ID');
+insert into chart_repository_transfer (chart_repository_id, from_user_id, to_organization_id, requested_by)
+values (:'repo1ID', :'user1ID', :'org1ID', :'user1ID');
+
+-- Run some tests
+select throws_ok(
+    $$ select decline_chart_repository_transfer('00000000-0000-0000-0000-000000000002', 'repo2', '{}') $$,
+    'P0002',
+    null,
+    'Repositories without a pending transfer should be reported'
+);
+select throws_ok(
+    $$ select decline_chart_repository_transfer('00000000-0000-0000-0000-000000000001', 'repo1', '{}') $$,
+    42501,
+    null,
+    'Only members of the destination organization can decline the transfer'
+);
+select throws_ok(
+    $$ select decline_chart_repository_transfer('00000000-0000-0000-0000-000000000003', 'repo1', '{}') $$,
+    42501,
+    null,
+    'Publishers of the destination organization cannot decline the transfer'
+);
+select lives_ok(
+    $$ select decline_chart_repository_transfer('00000000-0000-0000-0000-000000000002', 'repo1', '{}') $$,
+    'Admins of the destination organization should be able to decline the transfer'
+);
+select results_eq(
+    $$ select user_id, organization_id from chart_repository where name = 'repo1' $$,
+    $$ values ('00000000-0000-0000-0000-000000000001'::uuid, null::uuid) $$,
+    'Repository should still belong to its owner'
+);
+select results_eq(
+    $$
+        select user_id, action, target, chart_repository_id, organization_id
+        from audit_event
+    $$,
+    $$
+        values (
+            '00000000-0000-0000-0000-000000000002'::uuid,
+            'decline-chart-repository-transfer',
+            'repo1',
+            '00000000-0000-0000-0000-000000000001'::uuid,
+            '00000000-0000-0000-0000-000000000001'::uuid
+        )
+    $$,
+    'Transfer decline should be registered in the audit log'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -0,0 +1,62 @@
+-- Start transaction and plan tests
+begin;
+select plan(5);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set org1ID '00000000-0000-0000-0000-000000000001'
+\set org2ID '00000000-0000-0000-0000-000000000002'
+\set invitation1ID '00000000-0000-0000-0000-000000000001'
+\set invitation2ID '00000000-0000-0000-0000-000000000002'
+
+-- Seed some data
+insert into "user" (user_id, alias, email, email_verified)
+values (:'user1ID', 'user1', 'user1@email.com', true);
+insert into "user" (user_id, alias, email, email_verified)
+values (:'user2ID', 'user2', 'user2@email.com', true);
+insert into organization (organization_id, name)
+values (:'org1ID', 'org1');
+insert into organization (organization_id, name)
+values (:'org2ID', 'org2');
+insert into organization_invitation (organization_invitation_id, organization_id, email)
+values (:'invitation1ID', :'org1ID', 'user1@email.com');
+insert into organization_invitation (organization_invitation_id, organization_id, email)
+values (:'invitation2ID', :'org2ID', 'user1@email.com');
+
+-- Run some tests
+select is(
+    decline_organization_invitation(:'user2ID', :'invitation1ID', '{}'),
+    false,
+    'Invitations sent to other emails should not be declined'
+);
+select is(
+    decline_organization_invitation(:'user1ID', :'invitation1ID', '{}'),
+    true,
+    'Pending invitation should be declined'
+);
+select results_eq(
+    $$ select organization_invitation_id::text from organization_invitation $$,
+    $$ values ('00000000-0000-0000-0000-000000000002') $$,
+    'Only the invitation declined should have been deleted'
+);
+select results_eq(
+    $$ select user_id, action, organization_id from audit_event $$,
+    $$
+        values (
+            '00000000-0000-0000-0000-000000000001'::uuid,
+            'decline-organization-invitation',
+            '00000000-0000-0000-0000-000000000001'::uuid
+        )
+    $$,
+    'Invitation decline should be registered in the audit log'
+);
+select is(
+    decline_organization_invitation(:'user1ID', :'invitation1ID', '{}'),
+    false,
+    'Invitations already declined should not be declined again'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
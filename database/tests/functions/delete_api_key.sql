@@ -0,0 +1,46 @@
+-- Start transaction and plan tests
+begin;
+select plan(3);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set apiKey1ID '00000000-0000-0000-0000-000000000001'
+\set apiKey2ID '00000000-0000-0000-0000-000000000002'
+
+-- Seed some data
+insert into "user" (user_id, alias, email)
+values (:'user1ID', 'user1', 'user1@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user2ID', 'user2', 'user2@email.com');
+insert into api_key (api_key_id, user_id, name, key_hash)
+values (:'apiKey1ID', :'user1ID', 'key1', 'hash1');
+insert into api_key (api_key_id, user_id, name, key_hash)
+values (:'apiKey2ID', :'user2ID', 'key2', 'hash2');
+
+-- Try to delete an api key owned by other user
+select throws_ok(
+    $$ select delete_api_key('00000000-0000-0000-0000-000000000001', '00000000-0000-0000-0000-000000000002', '{}') $$,
+    'P0002',
+    null,
+    'Api keys owned by other users should not be found'
+);
+
+-- Delete api key
+select delete_api_key(:'user1ID', :'apiKey1ID', '{}');
+
+-- Check only the api key of the user was deleted
+select results_eq(
+    $$ select api_key_id from api_key $$,
+    $$ values ('00000000-0000-0000-0000-000000000002'::uuid) $$,
+    'Only the api key owned by the user should have been deleted'
+);
+select results_eq(
+    $$ select user_id, action, target from audit_event $$,
+    $$ values ('00000000-0000-0000-0000-000000000001'::uuid, 'delete-api-key', 'key1') $$,
+    'Only the api key deleted should be registered in the audit log'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -1,11 +1,15 @@
 -- Start transaction and plan tests
 begin;
-select plan(4);
+select plan(8);
 
 -- Declare some variables
 \set user1ID '00000000-0000-0000-0000-000000000001'
 \set repo1ID '00000000-0000-0000-0000-000000000001'
 \set repo2ID '00000000-0000-0000-0000-000000000002'
+\set repo3ID '00000000-0000-0000-0000-000000000003'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set user3ID '00000000-0000-0000-0000-000000000003'
+\set org1ID '00000000-0000-0000-0000-000000000001'
 
 -- Seed user and two chart repositories
 insert into "user" (user_id, alias, email)
@@ -15,27 +19,28 @@ values (:'repo1ID', 'repo1', 'Repo 1', 'https://repo1.com', :'user1ID');
 insert into chart_repository (chart_repository_id, name, display_name, url)
 values (:'repo2ID', 'repo2', 'Repo 2', 'https://repo2.com');
 
+-- Try deleting a repo that does not exist
+select throws_ok(
+    $$ select delete_chart_repository('{"name": "repo4", "user_id": "00000000-0000-0000-0000-000000000001"}'::jsonb, '{}') $$,
+    'P0002',
+    null,
+    'Non existing repositories cannot be deleted'
+);
+
 -- Try deleting a repo without providing the user id owning the repo
-select delete_chart_repository('
-    {
-        "name": "repo1"
-    }
-'::jsonb);
-select isnt_empty(
-    $$ select name from chart_repository where name='repo1' $$,
+select throws_ok(
+    $$ select delete_chart_repository('{"name": "repo1"}'::jsonb, '{}') $$,
+    42501,
+    null,
     'Should not be deleted if a user id is not provided'
 );
 
 -- Try deleting a repo providing a user id who does not own the repo
-select delete_chart_repository('
-    {
-        "name": "repo1",
-        "user_id": "00000000-0000-0000-0000-000000000002"
-    }
-'::jsonb);
-select isnt_empty(
-    $$ select name from chart_repository where name='repo1' $$,
-    'Should not be deleted if a user id who does not own the repo is not provided'
+select throws_ok(
+    $$ select delete_chart_repository('{"name": "repo1", "user_id": "00000000-0000-0000-0000-000000000002"}'::jsonb, '{}') $$,
+    42501,
+    null,
+    'Should not be deleted if a user id who does not own the repo is provided'
 );
 
 -- Try deleting a repo providing the user id who owns the repo
@@ -44,21 +49,70 @@ select delete_chart_repository('
         "name": "repo1",
         "user_id": "00000000-0000-0000-0000-000000000001"
     }
-'::jsonb);
+'::jsonb, '{}');
 select is_empty(
     $$ select name from chart_repository where name='repo1' $$,
     'Should be deleted when the owner user id is provided'
 );
 
--- Try deleting a repo not owned by any user without providing the user id
+-- Seed organization with an admin and a publisher owning another repository
+insert into "user" (user_id, alias, email)
+values (:'user2ID', 'user2', 'user2@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user3ID', 'user3', 'user3@email.com');
+insert into organization (organization_id, name)
+values (:'org1ID', 'org1');
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user2ID', :'org1ID', 1);
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user3ID', :'org1ID', 2);
+insert into chart_repository (chart_repository_id, name, display_name, url, organization_id)
+values (:'repo3ID', 'repo3', 'Repo 3', 'https://repo3.com', :'org1ID');
+
+-- Try deleting an organization repo providing a user id who is not a member
+select throws_ok(
+    $$ select delete_chart_repository('{"name": "repo3", "user_id": "00000000-0000-0000-0000-000000000001"}'::jsonb, '{}') $$,
+    42501,
+    null,
+    'Should not be deleted if the user is not a member of the organization'
+);
+
+-- Try deleting an organization repo as a publisher
+select throws_ok(
+    $$ select delete_chart_repository('{"name": "repo3", "user_id": "00000000-0000-0000-0000-000000000003"}'::jsonb, '{}') $$,
+    42501,
+    null,
+    'Should not be deleted by publishers of the organization'
+);
+
+-- Delete an organization repo as an admin
 select delete_chart_repository('
     {
-        "name": "repo2"
+        "name": "repo3",
+        "user_id": "00000000-0000-0000-0000-000000000002"
     }
-'::jsonb);
-select isnt_empty(
-    $$ select name from chart_repository where name='repo2' $$,
-    'Should not be deleted if a user id is not provided'
+'::jsonb, '{}');
+select is_empty(
+    $$ select name from chart_repository where name='repo3' $$,
+    'Should be deleted by admins of the organization'
+);
+select results_eq(
+    $$
+        select user_id, action, target, chart_repository_id, organization_id, details
+        from audit_event
+        where target = 'repo3'
+    $$,
+    $$
+        values (
+            '00000000-0000-0000-0000-000000000002'::uuid,
+            'delete-chart-repository',
+            'repo3',
+            null::uuid,
+            '00000000-0000-0000-0000-000000000001'::uuid,
+            '{"url": "https://repo3.com"}'::jsonb
+        )
+    $$,
+    'Organization chart repository deletion should be registered in the audit log'
 );
 
 -- Finish tests and rollback transaction
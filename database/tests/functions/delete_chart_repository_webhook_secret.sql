@@ -0,0 +1,84 @@
+-- Start transaction and plan tests
+begin;
+select plan(6);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set user3ID '00000000-0000-0000-0000-000000000003'
+\set org1ID '00000000-0000-0000-0000-000000000001'
+\set repo1ID '00000000-0000-0000-0000-000000000001'
+\set repo2ID '00000000-0000-0000-0000-000000000002'
+
+-- Seed some data
+insert into "user" (user_id, alias, email)
+values (:'user1ID', 'user1', 'user1@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user2ID', 'user2', 'user2@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user3ID', 'user3', 'user3@email.com');
+insert into organization (organization_id, name)
+values (:'org1ID', 'org1');
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user2ID', :'org1ID', 1);
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user3ID', :'org1ID', 2);
+insert into chart_repository (chart_repository_id, name, url, user_id, webhook_secret)
+values (:'repo1ID', 'repo1', 'https://repo1.com', :'user1ID', 'secret');
+insert into chart_repository (chart_repository_id, name, url, organization_id, webhook_secret)
+values (:'repo2ID', 'repo2', 'https://repo2.com', :'org1ID', 'secret');
+
+-- Secrets of non existing repositories cannot be deleted
+select throws_ok(
+    $$ select delete_chart_repository_webhook_secret('00000000-0000-0000-0000-000000000001', 'repo3', '{}') $$,
+    'P0002',
+    null,
+    'Non existing repositories should be reported'
+);
+
+-- Other users cannot delete the secret
+select throws_ok(
+    $$ select delete_chart_repository_webhook_secret('00000000-0000-0000-0000-000000000002', 'repo1', '{}') $$,
+    42501,
+    null,
+    'Secret should not be deleted by users not owning the repository'
+);
+
+-- Owner deletes the secret
+select delete_chart_repository_webhook_secret(:'user1ID', 'repo1', '{}');
+select results_eq(
+    $$ select webhook_secret from chart_repository where name = 'repo1' $$,
+    $$ values (null::text) $$,
+    'Secret should be deleted by the repository owner'
+);
+
+-- Organization publishers cannot delete the secret, but admins can
+select throws_ok(
+    $$ select delete_chart_repository_webhook_secret('00000000-0000-0000-0000-000000000003', 'repo2', '{}') $$,
+    42501,
+    null,
+    'Secret should not be deleted by publishers of the organization'
+);
+select delete_chart_repository_webhook_secret(:'user2ID', 'repo2', '{}');
+select results_eq(
+    $$ select webhook_secret from chart_repository where name = 'repo2' $$,
+    $$ values (null::text) $$,
+    'Secret should be deleted by admins of the organization'
+);
+select results_eq(
+    $$
+        select user_id, action, target, chart_repository_id, organization_id
+        from audit_event
+        order by target asc
+    $$,
+    $$
+        values
+            ('00000000-0000-0000-0000-000000000001'::uuid, 'delete-chart-repository-webhook-secret', 'repo1', '00000000-0000-0000-0000-000000000001'::uuid, null::uuid),
+            ('00000000-0000-0000-0000-000000000002'::uuid, 'delete-chart-repository-webhook-secret', 'repo2', '00000000-0000-0000-0000-000000000002'::uuid, '00000000-0000-0000-0000-000000000001'::uuid)
+    $$,
+    'Secret deletions should be registered in the audit log'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -0,0 +1,58 @@
+-- Start transaction and plan tests
+begin;
+select plan(4);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set user3ID '00000000-0000-0000-0000-000000000003'
+\set org1ID '00000000-0000-0000-0000-000000000001'
+
+-- Seed users and organization
+insert into "user" (user_id, alias, email)
+values (:'user1ID', 'user1', 'user1@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user2ID', 'user2', 'user2@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user3ID', 'user3', 'user3@email.com');
+insert into organization (organization_id, name)
+values (:'org1ID', 'org1');
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user1ID', :'org1ID', 0);
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user3ID', :'org1ID', 1);
+
+-- Try deleting an organization that does not exist
+select throws_ok(
+    $$ select delete_organization('00000000-0000-0000-0000-000000000001', 'org2') $$,
+    'P0002',
+    null,
+    'Non existing organizations cannot be deleted'
+);
+
+-- Try deleting the organization as a user who is not a member
+select throws_ok(
+    $$ select delete_organization('00000000-0000-0000-0000-000000000002', 'org1') $$,
+    42501,
+    null,
+    'Organization should not be deleted by a non member'
+);
+
+-- Try deleting the organization as an admin
+select throws_ok(
+    $$ select delete_organization('00000000-0000-0000-0000-000000000003', 'org1') $$,
+    42501,
+    null,
+    'Organization should not be deleted by an admin'
+);
+
+-- Delete the organization as an owner
+select delete_organization(:'user1ID', 'org1');
+select is_empty(
+    $$ select name from organization where name = 'org1' $$,
+    'Organization should have been deleted by an owner'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -0,0 +1,74 @@
+-- Start transaction and plan tests
+begin;
+select plan(5);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set user3ID '00000000-0000-0000-0000-000000000003'
+\set org1ID '00000000-0000-0000-0000-000000000001'
+
+-- Seed users and organization
+insert into "user" (user_id, alias, email)
+values (:'user1ID', 'user1', 'user1@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user2ID', 'user2', 'user2@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user3ID', 'user3', 'user3@email.com');
+insert into organization (organization_id, name)
+values (:'org1ID', 'org1');
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user1ID', :'org1ID', 0);
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user2ID', :'org1ID', 1);
+
+-- Try deleting a member as a user who is not a member
+select throws_ok(
+    $$ select delete_organization_member('00000000-0000-0000-0000-000000000003', 'org1', 'user2', '{}') $$,
+    42501,
+    null,
+    'Non members cannot remove members from the organization'
+);
+
+-- Try deleting a member as an admin
+select throws_ok(
+    $$ select delete_organization_member('00000000-0000-0000-0000-000000000002', 'org1', 'user1', '{}') $$,
+    42501,
+    null,
+    'Admins cannot remove members from the organization'
+);
+
+-- Delete a member as an owner
+select delete_organization_member(:'user1ID', 'org1', 'user2', '{}');
+select results_eq(
+    $$ select user_id from user__organization $$,
+    $$ values ('00000000-0000-0000-0000-000000000001'::uuid) $$,
+    'Owners can remove members from the organization'
+);
+select results_eq(
+    $$
+        select user_id, action, target, organization_id
+        from audit_event
+    $$,
+    $$
+        values (
+            '00000000-0000-0000-0000-000000000001'::uuid,
+            'delete-organization-member',
+            'user2',
+            '00000000-0000-0000-0000-000000000001'::uuid
+        )
+    $$,
+    'Member removal should be registered in the audit log'
+);
+
+-- Try deleting the last owner of the organization
+select throws_ok(
+    $$ select delete_organization_member('00000000-0000-0000-0000-000000000001', 'org1', 'user1', '{}') $$,
+    23514,
+    'organization must have at least one owner',
+    'The last owner of the organization cannot be removed'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -0,0 +1,36 @@
+-- Start transaction and plan tests
+begin;
+select plan(3);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+
+-- Seed some data
+insert into "user" (user_id, alias, email) values (:'user1ID', 'user1', 'user1@email.com');
+insert into session (session_id, user_id) values ('session1', :'user1ID');
+insert into session (session_id, user_id) values ('session2', :'user1ID');
+
+-- Delete session
+select delete_session('session1', '{"ip": "192.168.1.1", "user_agent": "ua"}');
+select results_eq(
+    $$ select session_id from session $$,
+    $$ values ('session2'::bytea) $$,
+    'Session should have been deleted'
+);
+select results_eq(
+    $$ select user_id, action, host(ip), user_agent from audit_event $$,
+    $$ values ('00000000-0000-0000-0000-000000000001'::uuid, 'logout', '192.168.1.1', 'ua') $$,
+    'Logout should be registered in the audit log'
+);
+
+-- Delete session that does not exist
+select delete_session('session3', '{}');
+select results_eq(
+    $$ select count(*) from audit_event $$,
+    $$ values (1::bigint) $$,
+    'Nothing should be registered when the session does not exist'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -0,0 +1,40 @@
+-- Start transaction and plan tests
+begin;
+select plan(1);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set repo1ID '00000000-0000-0000-0000-000000000001'
+\set package1ID '00000000-0000-0000-0000-000000000001'
+
+-- Seed some data
+insert into "user" (user_id, alias, email)
+values (:'user1ID', 'user1', 'user1@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user2ID', 'user2', 'user2@email.com');
+insert into chart_repository (chart_repository_id, name, url)
+values (:'repo1ID', 'repo1', 'https://repo1.com');
+insert into package (package_id, name, latest_version, package_kind_id, chart_repository_id)
+values (:'package1ID', 'package1', '1.0.0', 0, :'repo1ID');
+insert into subscription (user_id, package_id, event_kind_id)
+values (:'user1ID', :'package1ID', 0);
+insert into subscription (user_id, package_id, event_kind_id)
+values (:'user2ID', :'package1ID', 0);
+
+-- Delete subscription
+select delete_subscription(:'user1ID', '{
+    "package_id": "00000000-0000-0000-0000-000000000001",
+    "event_kind": 0
+}'::jsonb);
+
+-- Check if only the subscription of the user was deleted
+select results_eq(
+    $$ select user_id from subscription $$,
+    $$ values ('00000000-0000-0000-0000-000000000002'::uuid) $$,
+    'Only the subscription of the user should have been deleted'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
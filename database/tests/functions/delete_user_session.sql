@@ -0,0 +1,43 @@
+-- Start transaction and plan tests
+begin;
+select plan(2);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set session1PublicID '00000000-0000-0000-0000-000000000001'
+\set session2PublicID '00000000-0000-0000-0000-000000000002'
+
+-- Seed some data
+insert into "user" (user_id, alias, email) values (:'user1ID', 'user1', 'user1@email.com');
+insert into "user" (user_id, alias, email) values (:'user2ID', 'user2', 'user2@email.com');
+insert into session (session_id, public_id, user_id) values ('session1', :'session1PublicID', :'user1ID');
+insert into session (session_id, public_id, user_id) values ('session2', :'session2PublicID', :'user2ID');
+
+-- Try to delete a session of other user
+select delete_user_session(:'user1ID', :'session2PublicID', '{}');
+
+-- Delete session
+select delete_user_session(:'user1ID', :'session1PublicID', '{}');
+
+-- Check only the session of the user was deleted
+select results_eq(
+    $$ select session_id from session $$,
+    $$ values ('session2'::bytea) $$,
+    'Only the session of the user should have been deleted'
+);
+select results_eq(
+    $$ select user_id, action, target from audit_event $$,
+    $$
+        values (
+            '00000000-0000-0000-0000-000000000001'::uuid,
+            'delete-session',
+            '00000000-0000-0000-0000-000000000001'
+        )
+    $$,
+    'Only the session deleted should be registered in the audit log'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -0,0 +1,38 @@
+-- Start transaction and plan tests
+begin;
+select plan(3);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+
+-- Seed some data
+insert into "user" (user_id, alias, email) values (:'user1ID', 'user1', 'user1@email.com');
+insert into "user" (user_id, alias, email) values (:'user2ID', 'user2', 'user2@email.com');
+insert into session (session_id, user_id) values ('session1', :'user1ID');
+insert into session (session_id, user_id) values ('session2', :'user1ID');
+insert into session (session_id, user_id) values ('session3', :'user2ID');
+insert into api_key (user_id, name, key_hash) values (:'user1ID', 'key1', 'hash1');
+insert into api_key (user_id, name, key_hash) values (:'user2ID', 'key2', 'hash2');
+
+-- Delete sessions
+select delete_user_sessions(:'user1ID', '{}');
+select results_eq(
+    $$ select session_id from session $$,
+    $$ values ('session3'::bytea) $$,
+    'User sessions should have been deleted'
+);
+select results_eq(
+    $$ select key_hash from api_key $$,
+    $$ values ('hash2') $$,
+    'User api keys should have been revoked'
+);
+select results_eq(
+    $$ select user_id, action from audit_event $$,
+    $$ values ('00000000-0000-0000-0000-000000000001'::uuid, 'delete-sessions') $$,
+    'Sessions deletion should be registered in the audit log'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -0,0 +1,53 @@
+-- Start transaction and plan tests
+begin;
+select plan(4);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set repo1ID '00000000-0000-0000-0000-000000000001'
+\set webhook1ID '00000000-0000-0000-0000-000000000001'
+
+-- Seed some data
+insert into "user" (user_id, alias, email)
+values (:'user1ID', 'user1', 'user1@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user2ID', 'user2', 'user2@email.com');
+insert into chart_repository (chart_repository_id, name, url, user_id)
+values (:'repo1ID', 'repo1', 'https://repo1.com', :'user1ID');
+insert into webhook (webhook_id, chart_repository_id, url, secret, event_kinds)
+values (:'webhook1ID', :'repo1ID', 'https://hook.com', 'secret1', '{0}');
+
+-- Run some tests
+select throws_ok(
+    $$ select delete_webhook('00000000-0000-0000-0000-000000000002', 'repo1', '00000000-0000-0000-0000-000000000001', '{}') $$,
+    42501,
+    null,
+    'Only the repository owner should be allowed to delete webhooks'
+);
+select results_eq(
+    $$ select delete_webhook('00000000-0000-0000-0000-000000000001', 'repo1', '00000000-0000-0000-0000-000000000001', '{}') $$,
+    $$ values ('00000000-0000-0000-0000-000000000001'::uuid) $$,
+    'Deleted webhook id should be returned'
+);
+select results_eq(
+    $$ select user_id, action, target, chart_repository_id, details from audit_event $$,
+    $$
+        values (
+            '00000000-0000-0000-0000-000000000001'::uuid,
+            'delete-webhook',
+            '00000000-0000-0000-0000-000000000001',
+            '00000000-0000-0000-0000-000000000001'::uuid,
+            '{"url": "https://hook.com"}'::jsonb
+        )
+    $$,
+    'Webhook deletion should be registered in the audit log'
+);
+select is_empty(
+    $$ select delete_webhook('00000000-0000-0000-0000-000000000001', 'repo1', '00000000-0000-0000-0000-000000000001', '{}') $$,
+    'Nothing should be returned when the webhook does not exist'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -0,0 +1,57 @@
+-- Start transaction and plan tests
+begin;
+select plan(5);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set repo1ID '00000000-0000-0000-0000-000000000001'
+
+-- Seed some data
+insert into "user" (user_id, alias, email)
+values (:'user1ID', 'user1', 'user1@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user2ID', 'user2', 'user2@email.com');
+insert into chart_repository (chart_repository_id, name, url, user_id, verified_publisher)
+values (:'repo1ID', 'repo1', 'https://repo1.com', :'user1ID', true);
+
+-- Non existing repository
+select is_empty(
+    $$ select generate_chart_repository_verification_token('00000000-0000-0000-0000-000000000001', 'repo2') $$,
+    'No token should be returned for a non existing repository'
+);
+
+-- Repository not owned by the user
+select throws_ok(
+    $$ select generate_chart_repository_verification_token('00000000-0000-0000-0000-000000000002', 'repo1') $$,
+    42501,
+    null,
+    'Only the repository owner can generate its verification token'
+);
+
+-- Generate and regenerate token
+select generate_chart_repository_verification_token(:'user1ID', 'repo1') as token1 \gset
+select results_eq(
+    $$
+        select verification_token, verified_publisher
+        from chart_repository
+        where chart_repository_id = '00000000-0000-0000-0000-000000000001'
+    $$,
+    format('values (%L::text, false)', :'token1'),
+    'Token generated should be stored and returned'
+);
+select generate_chart_repository_verification_token(:'user1ID', 'repo1') as token2 \gset
+select isnt(
+    :'token1'::text,
+    :'token2'::text,
+    'Token should be replaced when regenerated'
+);
+select is(
+    (select verification_token from chart_repository where chart_repository_id = :'repo1ID'),
+    :'token2'::text,
+    'Latest token generated should be stored'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -0,0 +1,68 @@
+-- Start transaction and plan tests
+begin;
+select plan(3);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set user3ID '00000000-0000-0000-0000-000000000003'
+\set repo1ID '00000000-0000-0000-0000-000000000001'
+\set package1ID '00000000-0000-0000-0000-000000000001'
+\set package2ID '00000000-0000-0000-0000-000000000002'
+\set event1ID '00000000-0000-0000-0000-000000000001'
+\set event2ID '00000000-0000-0000-0000-000000000002'
+
+-- Seed some data
+insert into "user" (user_id, alias, email, email_verified)
+values (:'user1ID', 'user1', 'user1@email.com', true);
+insert into "user" (user_id, alias, email, email_verified)
+values (:'user2ID', 'user2', 'user2@email.com', true);
+insert into "user" (user_id, alias, email, email_verified)
+values (:'user3ID', 'user3', 'user3@email.com', false);
+insert into chart_repository (chart_repository_id, name, url)
+values (:'repo1ID', 'repo1', 'https://repo1.com');
+insert into package (package_id, name, latest_version, package_kind_id, chart_repository_id)
+values (:'package1ID', 'package1', '1.0.0', 0, :'repo1ID');
+insert into package (package_id, name, latest_version, package_kind_id, chart_repository_id)
+values (:'package2ID', 'package2', '1.0.0', 0, :'repo1ID');
+insert into subscription (user_id, package_id, event_kind_id)
+values (:'user1ID', :'package1ID', 0);
+insert into subscription (user_id, package_id, event_kind_id)
+values (:'user2ID', :'package2ID', 0);
+insert into subscription (user_id, package_id, event_kind_id)
+values (:'user3ID', :'package1ID', 0);
+insert into event (event_id, package_id, package_version, event_kind_id)
+values (:'event1ID', :'package1ID', '1.0.0', 0);
+insert into event (event_id, package_id, package_version, event_kind_id, processed)
+values (:'event2ID', :'package2ID', '1.0.0', 0, true);
+
+-- Generate notifications
+select generate_notifications();
+
+-- Check notifications were generated only for pending events subscribers
+select results_eq(
+    $$ select event_id, user_id, processed from notification $$,
+    $$ values (
+        '00000000-0000-0000-0000-000000000001'::uuid,
+        '00000000-0000-0000-0000-000000000001'::uuid,
+        false
+    ) $$,
+    'Notifications should have been generated for verified subscribers of pending events'
+);
+select results_eq(
+    $$ select processed, processed_at is not null from event where event_id = '00000000-0000-0000-0000-000000000001' $$,
+    $$ values (true, true) $$,
+    'Event should have been marked as processed'
+);
+
+-- Generating notifications again should not create new ones
+select generate_notifications();
+select results_eq(
+    $$ select count(*) from notification $$,
+    $$ values (1::bigint) $$,
+    'No new notifications should have been generated'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
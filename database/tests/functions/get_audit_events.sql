@@ -0,0 +1,177 @@
+-- Start transaction and plan tests
+begin;
+select plan(6);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set user3ID '00000000-0000-0000-0000-000000000003'
+\set user4ID '00000000-0000-0000-0000-000000000004'
+\set org1ID '00000000-0000-0000-0000-000000000001'
+\set org2ID '00000000-0000-0000-0000-000000000002'
+\set repo1ID '00000000-0000-0000-0000-000000000001'
+\set event1ID '00000000-0000-0000-0000-000000000001'
+\set event2ID '00000000-0000-0000-0000-000000000002'
+\set event3ID '00000000-0000-0000-0000-000000000003'
+
+-- Seed some data
+insert into "user" (user_id, alias, email)
+values (:'user1ID', 'user1', 'user1@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user2ID', 'user2', 'user2@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user3ID', 'user3', 'user3@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user4ID', 'user4', 'user4@email.com');
+insert into organization (organization_id, name)
+values (:'org1ID', 'org1');
+insert into organization (organization_id, name)
+values (:'org2ID', 'org2');
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user1ID', :'org1ID', 1);
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user2ID', :'org1ID', 2);
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user4ID', :'org1ID', 3);
+insert into chart_repository (chart_repository_id, name, url, organization_id)
+values (:'repo1ID', 'repo1', 'https://repo1.com', :'org1ID');
+insert into audit_event (audit_event_id, user_id, action, ip, user_agent, created_at)
+values (:'event1ID', :'user1ID', 'login', '192.168.1.100', 'Safari 13.0.5', '1970-01-01 00:00:01 UTC');
+insert into audit_event (
+    audit_event_id,
+    user_id,
+    action,
+    target,
+    chart_repository_id,
+    organization_id,
+    ip,
+    user_agent,
+    details,
+    created_at
+) values (
+    :'event2ID',
+    :'user2ID',
+    'update-chart-repository',
+    'repo1',
+    :'repo1ID',
+    :'org1ID',
+    '192.168.1.200',
+    'Firefox 74.0',
+    '{"url": "https://repo1.com"}',
+    '1970-01-01 00:00:02 UTC'
+);
+insert into audit_event (audit_event_id, user_id, action, created_at)
+values (:'event3ID', :'user3ID', 'logout', '1970-01-01 00:00:03 UTC');
+
+-- Run some tests
+select is(
+    get_audit_events(:'user1ID', 10, 0)::jsonb,
+    '[{
+        "audit_event_id": "00000000-0000-0000-0000-000000000002",
+        "user_alias": "user2",
+        "action": "update-chart-repository",
+        "target": "repo1",
+        "chart_repository_name": "repo1",
+        "organization_name": "org1",
+        "ip": "192.168.1.200",
+        "user_agent": "Firefox 74.0",
+        "details": {"url": "https://repo1.com"},
+        "created_at": 2
+    }, {
+        "audit_event_id": "00000000-0000-0000-0000-000000000001",
+        "user_alias": "user1",
+        "action": "login",
+        "target": null,
+        "chart_repository_name": null,
+        "organization_name": null,
+        "ip": "192.168.1.100",
+        "user_agent": "Safari 13.0.5",
+        "details": null,
+        "created_at": 1
+    }]'::jsonb,
+    'Own events and the ones of the user organizations should be returned most recent first'
+);
+select is(
+    get_audit_events(:'user1ID', 1, 1)::jsonb,
+    '[{
+        "audit_event_id": "00000000-0000-0000-0000-000000000001",
+        "user_alias": "user1",
+        "action": "login",
+        "target": null,
+        "chart_repository_name": null,
+        "organization_name": null,
+        "ip": "192.168.1.100",
+        "user_agent": "Safari 13.0.5",
+        "details": null,
+        "created_at": 1
+    }]'::jsonb,
+    'Events should be paginated'
+);
+select is(
+    get_audit_events(:'user4ID', 10, 0)::jsonb,
+    '[{
+        "audit_event_id": "00000000-0000-0000-0000-000000000002",
+        "user_alias": "user2",
+        "action": "update-chart-repository",
+        "target": "repo1",
+        "chart_repository_name": "repo1",
+        "organization_name": "org1",
+        "ip": null,
+        "user_agent": null,
+        "details": {"url": "https://repo1.com"},
+        "created_at": 2
+    }]'::jsonb,
+    'Ip and user agent of events of other users should only be returned to organization admins'
+);
+select is(
+    get_audit_events(:'user3ID', 10, 0)::jsonb,
+    '[{
+        "audit_event_id": "00000000-0000-0000-0000-000000000003",
+        "user_alias": "user3",
+        "action": "logout",
+        "target": null,
+        "chart_repository_name": null,
+        "organization_name": null,
+        "ip": null,
+        "user_agent": null,
+        "details": null,
+        "created_at": 3
+    }]'::jsonb,
+    'Events of other users not related to the user should not be returned'
+);
+
+-- Members of the organization a repository is transferred to should not see
+-- the events registered before the transfer
+update chart_repository set organization_id = :'org2ID' where chart_repository_id = :'repo1ID';
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user3ID', :'org2ID', 0);
+select is(
+    get_audit_events(:'user3ID', 10, 0)::jsonb,
+    '[{
+        "audit_event_id": "00000000-0000-0000-0000-000000000003",
+        "user_alias": "user3",
+        "action": "logout",
+        "target": null,
+        "chart_repository_name": null,
+        "organization_name": null,
+        "ip": null,
+        "user_agent": null,
+        "details": null,
+        "created_at": 3
+    }]'::jsonb,
+    'Events registered for other organizations should not be returned after a transfer'
+);
+
+-- Pending members of the organization should not see its events
+delete from user__organization where user_id = :'user4ID';
+insert into organization_invitation (organization_id, email)
+values (:'org1ID', 'user4@email.com');
+select is(
+    get_audit_events(:'user4ID', 10, 0)::jsonb,
+    '[]'::jsonb,
+    'No events should be returned to pending members of the organization'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
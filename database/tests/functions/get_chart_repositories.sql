@@ -24,17 +24,38 @@ select is(
         "chart_repository_id": "00000000-0000-0000-0000-000000000001",
         "name": "repo1",
         "display_name": "Repo 1",
-        "url": "https://repo1.com"
+        "url": "https://repo1.com",
+        "kind": 0,
+        "git_branch": null,
+        "git_path_glob": null,
+        "last_tracking_ts": null,
+        "verification_token": null,
+        "verified_publisher": false,
+        "verification_failures": 0
     }, {
         "chart_repository_id": "00000000-0000-0000-0000-000000000002",
         "name": "repo2",
         "display_name": "Repo 2",
-        "url": "https://repo2.com"
+        "url": "https://repo2.com",
+        "kind": 0,
+        "git_branch": null,
+        "git_path_glob": null,
+        "last_tracking_ts": null,
+        "verification_token": null,
+        "verified_publisher": false,
+        "verification_failures": 0
     }, {
         "chart_repository_id": "00000000-0000-0000-0000-000000000003",
         "name": "repo3",
         "display_name": "Repo 3",
-        "url": "https://repo3.com"
+        "url": "https://repo3.com",
+        "kind": 0,
+        "git_branch": null,
+        "git_path_glob": null,
+        "last_tracking_ts": null,
+        "verification_token": null,
+        "verified_publisher": false,
+        "verification_failures": 0
     }]'::jsonb,
     'Repositories are returned as a json array of objects'
 );
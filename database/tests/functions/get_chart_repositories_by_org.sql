@@ -0,0 +1,81 @@
+-- Start transaction and plan tests
+begin;
+select plan(3);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set org1ID '00000000-0000-0000-0000-000000000001'
+
+-- Seed users and organization
+insert into "user" (user_id, alias, email)
+values (:'user1ID', 'user1', 'user1@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user2ID', 'user2', 'user2@email.com');
+insert into organization (organization_id, name)
+values (:'org1ID', 'org1');
+insert into user__organization (user_id, organization_id)
+values (:'user1ID', :'org1ID');
+
+-- No repositories at this point
+select is(
+    get_chart_repositories_by_org(:'user1ID', 'org1')::jsonb,
+    '[]'::jsonb,
+    'With no repositories an empty json array is returned'
+);
+
+-- Seed some chart repositories
+insert into chart_repository (
+    chart_repository_id,
+    name,
+    display_name,
+    url,
+    organization_id
+) values (
+    '00000000-0000-0000-0000-000000000001',
+    'repo1',
+    'Repo 1',
+    'https://repo1.com',
+    :'org1ID'
+);
+insert into chart_repository (
+    chart_repository_id,
+    name,
+    display_name,
+    url,
+    user_id
+) values (
+    '00000000-0000-0000-0000-000000000002',
+    'repo2',
+    'Repo 2',
+    'https://repo2.com',
+    :'user1ID'
+);
+
+-- Organization repositories are returned to members
+select is(
+    get_chart_repositories_by_org(:'user1ID', 'org1')::jsonb,
+    '[{
+        "chart_repository_id": "00000000-0000-0000-0000-000000000001",
+        "name": "repo1",
+        "display_name": "Repo 1",
+        "url": "https://repo1.com",
+        "kind": 0,
+        "git_branch": null,
+        "git_path_glob": null,
+        "last_tracking_ts": null,
+        "last_tracking_errors": null
+    }]'::jsonb,
+    'Organization repositories are returned as a json array'
+);
+
+-- Organization repositories are not returned to non members
+select is(
+    get_chart_repositories_by_org(:'user2ID', 'org1')::jsonb,
+    '[]'::jsonb,
+    'No repositories are returned to non members'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
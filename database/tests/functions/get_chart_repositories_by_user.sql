@@ -31,7 +31,7 @@ insert into chart_repository (
     'Repo 1',
     'https://repo1.com',
     '1970-01-01 00:00:00 UTC',
-    'error1\nerror2\nerror3',
+    '{"errors": [{"kind": "register", "message": "error1"}], "suppressed": 0}',
     :'user1ID'
 );
 insert into chart_repository (
@@ -67,13 +67,19 @@ select is(
         "name": "repo1",
         "display_name": "Repo 1",
         "url": "https://repo1.com",
+        "kind": 0,
+        "git_branch": null,
+        "git_path_glob": null,
         "last_tracking_ts": 0,
-        "last_tracking_errors": "error1\\nerror2\\nerror3"
+        "last_tracking_errors": {"errors": [{"kind": "register", "message": "error1"}], "suppressed": 0}
     }, {
         "chart_repository_id": "00000000-0000-0000-0000-000000000002",
         "name": "repo2",
         "display_name": "Repo 2",
         "url": "https://repo2.com",
+        "kind": 0,
+        "git_branch": null,
+        "git_path_glob": null,
         "last_tracking_ts": null,
         "last_tracking_errors": null
     }]'::jsonb,
@@ -19,7 +19,15 @@ select is(
         "chart_repository_id": "00000000-0000-0000-0000-000000000001",
         "name": "repo1",
         "display_name": "Repo 1",
-        "url": "https://repo1.com"
+        "url": "https://repo1.com",
+        "kind": 0,
+        "git_branch": null,
+        "git_path_glob": null,
+        "last_tracking_ts": null,
+        "verification_token": null,
+        "verified_publisher": false,
+        "verification_failures": 0,
+        "organization_name": null
     }'::jsonb,
     'Repository just seeded is returned as a json object'
 );
@@ -0,0 +1,69 @@
+-- Start transaction and plan tests
+begin;
+select plan(5);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set repo1ID '00000000-0000-0000-0000-000000000001'
+\set repo2ID '00000000-0000-0000-0000-000000000002'
+
+-- Seed some data
+insert into "user" (user_id, alias, email)
+values (:'user1ID', 'user1', 'user1@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user2ID', 'user2', 'user2@email.com');
+insert into chart_repository (chart_repository_id, name, url, user_id, last_tracking_errors)
+values (:'repo1ID', 'repo1', 'https://repo1.com', :'user1ID', '
+{
+    "errors": [
+        {"kind": "chart-download", "chart_name": "chart1", "http_status": 404, "message": "error1"},
+        {"kind": "register", "chart_name": "chart2", "message": "error2"}
+    ],
+    "suppressed": 3
+}
+');
+insert into chart_repository (chart_repository_id, name, url, user_id)
+values (:'repo2ID', 'repo2', 'https://repo2.com', :'user1ID');
+
+-- Run some tests
+select is_empty(
+    $$ select get_chart_repository_tracking_errors('00000000-0000-0000-0000-000000000001', 'repo3', null) $$,
+    'No errors should be returned for a non existing repository'
+);
+select throws_ok(
+    $$ select get_chart_repository_tracking_errors('00000000-0000-0000-0000-000000000002', 'repo1', null) $$,
+    42501,
+    null,
+    'Only the repository owner can get its tracking errors'
+);
+select is(
+    get_chart_repository_tracking_errors(:'user1ID', 'repo1', null)::jsonb,
+    '{
+        "errors": [
+            {"kind": "chart-download", "chart_name": "chart1", "http_status": 404, "message": "error1"},
+            {"kind": "register", "chart_name": "chart2", "message": "error2"}
+        ],
+        "suppressed": 3
+    }'::jsonb,
+    'All errors should be returned when no kinds are provided'
+);
+select is(
+    get_chart_repository_tracking_errors(:'user1ID', 'repo1', '{register}')::jsonb,
+    '{
+        "errors": [
+            {"kind": "register", "chart_name": "chart2", "message": "error2"}
+        ],
+        "suppressed": 3
+    }'::jsonb,
+    'Only errors of the kinds provided should be returned'
+);
+select is(
+    get_chart_repository_tracking_errors(:'user1ID', 'repo2', null)::jsonb,
+    '{"errors": [], "suppressed": 0}'::jsonb,
+    'No errors should be returned for repositories without errors'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
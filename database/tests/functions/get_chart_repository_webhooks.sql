@@ -0,0 +1,45 @@
+-- Start transaction and plan tests
+begin;
+select plan(3);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set repo1ID '00000000-0000-0000-0000-000000000001'
+\set webhook1ID '00000000-0000-0000-0000-000000000001'
+
+-- Seed some data
+insert into "user" (user_id, alias, email)
+values (:'user1ID', 'user1', 'user1@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user2ID', 'user2', 'user2@email.com');
+insert into chart_repository (chart_repository_id, name, url, user_id)
+values (:'repo1ID', 'repo1', 'https://repo1.com', :'user1ID');
+insert into webhook (webhook_id, chart_repository_id, url, secret, event_kinds, created_at)
+values (:'webhook1ID', :'repo1ID', 'https://hook.com', 'secret1', '{0,1}', '1970-01-01 00:00:01 UTC');
+
+-- Run some tests
+select is_empty(
+    $$ select get_chart_repository_webhooks('00000000-0000-0000-0000-000000000001', 'repo2') $$,
+    'No webhooks should be returned for a non existing repository'
+);
+select throws_ok(
+    $$ select get_chart_repository_webhooks('00000000-0000-0000-0000-000000000002', 'repo1') $$,
+    42501,
+    null,
+    'Only the repository owner should be allowed to get its webhooks'
+);
+select is(
+    get_chart_repository_webhooks(:'user1ID', 'repo1')::jsonb,
+    '[{
+        "webhook_id": "00000000-0000-0000-0000-000000000001",
+        "url": "https://hook.com",
+        "event_kinds": [0, 1],
+        "created_at": 1
+    }]'::jsonb,
+    'Webhooks should be returned as a json array without their secrets'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -0,0 +1,67 @@
+-- Start transaction and plan tests
+begin;
+select plan(2);
+
+-- Declare some variables
+\set operatorProvider1ID '00000000-0000-0000-0000-000000000001'
+\set package1ID '00000000-0000-0000-0000-000000000001'
+
+-- No packages at this point
+select is(
+    get_operator_provider_packages_digest('provider1')::jsonb,
+    '{}'::jsonb,
+    'With no providers/packages an empty json object is returned'
+);
+
+-- Seed some packages
+insert into operator_provider (operator_provider_id, name)
+values (:'operatorProvider1ID', 'provider1');
+insert into package (
+    package_id,
+    name,
+    display_name,
+    description,
+    latest_version,
+    package_kind_id,
+    operator_provider_id
+) values (
+    :'package1ID',
+    'package1',
+    'Package 1',
+    'description',
+    '1.0.0',
+    1,
+    :'operatorProvider1ID'
+);
+insert into snapshot (
+    package_id,
+    version,
+    digest
+) values (
+    :'package1ID',
+    '1.0.0',
+    'digest-package1-1.0.0'
+);
+insert into snapshot (
+    package_id,
+    version,
+    digest
+) values (
+    :'package1ID',
+    '0.0.9',
+    'digest-package1-0.0.9'
+);
+
+-- Some packages have just been seeded
+select is(
+    get_operator_provider_packages_digest('provider1')::jsonb,
+    '{
+        "package1@1.0.0": "digest-package1-1.0.0",
+        "package1@0.0.9": "digest-package1-0.0.9"
+    }'::jsonb,
+    'Operator provider packages digest are returned as a json object'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
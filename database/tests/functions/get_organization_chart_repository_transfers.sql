@@ -0,0 +1,75 @@
+-- Start transaction and plan tests
+begin;
+select plan(2);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set org1ID '00000000-0000-0000-0000-000000000001'
+\set org2ID '00000000-0000-0000-0000-000000000002'
+\set repo1ID '00000000-0000-0000-0000-000000000001'
+\set repo2ID '00000000-0000-0000-0000-000000000002'
+\set repo3ID '00000000-0000-0000-0000-000000000003'
+
+-- Seed some data
+insert into "user" (user_id, alias, email)
+values (:'user1ID', 'user1', 'user1@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user2ID', 'user2', 'user2@email.com');
+insert into organization (organization_id, name)
+values (:'org1ID', 'org1');
+insert into organization (organization_id, name)
+values (:'org2ID', 'org2');
+insert into user__organization (user_id, organization_id)
+values (:'user1ID', :'org1ID');
+insert into user__organization (user_id, organization_id)
+values (:'user2ID', :'org2ID');
+insert into chart_repository (chart_repository_id, name, url, user_id)
+values (:'repo1ID', 'repo1', 'https://repo1.com', :'user2ID');
+insert into chart_repository (chart_repository_id, name, url, organization_id)
+values (:'repo2ID', 'repo2', 'https://repo2.com', :'org2ID');
+insert into chart_repository (chart_repository_id, name, url, organization_id)
+values (:'repo3ID', 'repo3', 'https://repo3.com', :'org1ID');
+insert into chart_repository_transfer (
+    chart_repository_id, from_user_id, to_organization_id, requested_by, created_at
+) values (
+    :'repo1ID', :'user2ID', :'org1ID', :'user2ID', '2020-06-16 11:20:34+02'
+);
+insert into chart_repository_transfer (
+    chart_repository_id, from_organization_id, to_organization_id, requested_by, created_at
+) values (
+    :'repo2ID', :'org2ID', :'org1ID', :'user2ID', '2020-06-17 11:20:34+02'
+);
+insert into chart_repository_transfer (
+    chart_repository_id, from_organization_id, to_organization_id, requested_by, accepted_by, accepted_at
+) values (
+    :'repo3ID', :'org2ID', :'org1ID', :'user2ID', :'user1ID', current_timestamp
+);
+
+-- Run some tests
+select is(
+    get_organization_chart_repository_transfers(:'user1ID', 'org1')::jsonb,
+    '[{
+        "chart_repository_name": "repo2",
+        "from_user_alias": null,
+        "from_organization_name": "org2",
+        "requested_by": "user2",
+        "created_at": 1592385634
+    }, {
+        "chart_repository_name": "repo1",
+        "from_user_alias": "user2",
+        "from_organization_name": null,
+        "requested_by": "user2",
+        "created_at": 1592299234
+    }]'::jsonb,
+    'Pending transfers to the organization should be returned'
+);
+select is(
+    get_organization_chart_repository_transfers(:'user2ID', 'org1')::jsonb,
+    '[]'::jsonb,
+    'No transfers should be returned to non members'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
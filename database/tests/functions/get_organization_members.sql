@@ -0,0 +1,61 @@
+-- Start transaction and plan tests
+begin;
+select plan(3);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set user3ID '00000000-0000-0000-0000-000000000003'
+\set user4ID '00000000-0000-0000-0000-000000000004'
+\set org1ID '00000000-0000-0000-0000-000000000001'
+
+-- Seed users and organization
+insert into "user" (user_id, alias, first_name, last_name, email)
+values (:'user1ID', 'user1', 'first_name1', 'last_name1', 'user1@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user2ID', 'user2', 'user2@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user3ID', 'user3', 'user3@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user4ID', 'user4', 'user4@email.com');
+insert into organization (organization_id, name)
+values (:'org1ID', 'org1');
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user1ID', :'org1ID', 0);
+insert into user__organization (user_id, organization_id)
+values (:'user2ID', :'org1ID');
+insert into organization_invitation (organization_id, email)
+values (:'org1ID', 'user4@email.com');
+
+-- Members are returned to members
+select is(
+    get_organization_members(:'user1ID', 'org1')::jsonb,
+    '[{
+        "alias": "user1",
+        "first_name": "first_name1",
+        "last_name": "last_name1",
+        "role": "owner"
+    }, {
+        "alias": "user2",
+        "first_name": null,
+        "last_name": null,
+        "role": "viewer"
+    }]'::jsonb,
+    'Organization members are returned as a json array'
+);
+
+-- Members are not returned to users who do not belong to the organization
+select is(
+    get_organization_members(:'user3ID', 'org1')::jsonb,
+    '[]'::jsonb,
+    'No members are returned to non members'
+);
+select is(
+    get_organization_members(:'user4ID', 'org1')::jsonb,
+    '[]'::jsonb,
+    'No members are returned to users with a pending invitation'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
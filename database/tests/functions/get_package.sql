@@ -1,6 +1,6 @@
 -- Start transaction and plan tests
 begin;
-select plan(9);
+select plan(12);
 
 -- Declare some variables
 \set repo1ID '00000000-0000-0000-0000-000000000001'
@@ -8,6 +8,8 @@ select plan(9);
 \set maintainer1ID '00000000-0000-0000-0000-000000000001'
 \set maintainer2ID '00000000-0000-0000-0000-000000000002'
 \set image1ID '00000000-0000-0000-0000-000000000001'
+\set operatorProvider1ID '00000000-0000-0000-0000-000000000001'
+\set package2ID '00000000-0000-0000-0000-000000000002'
 
 -- Some invalid queries
 select throws_ok(
@@ -74,6 +76,27 @@ select throws_ok(
     'A valid package name must be provided if kind is chart'
 );
 
+select throws_ok(
+    $$
+        select get_package('{
+            "kind": 1,
+            "package_name": "package2"
+        }')
+    $$,
+    'a valid operator provider name must be provided',
+    'Operator provider name must be provided if kind is operator'
+);
+select throws_ok(
+    $$
+        select get_package('{
+            "kind": 1,
+            "operator_provider_name": "provider1"
+        }')
+    $$,
+    'a valid package name must be provided',
+    'Package name must be provided if kind is operator'
+);
+
 -- No packages at this point
 select is_empty(
     $$
@@ -87,8 +110,8 @@ select is_empty(
 );
 
 -- Seed package with 2 versions
-insert into chart_repository (chart_repository_id, name, display_name, url)
-values (:'repo1ID', 'repo1', 'Repo 1', 'https://repo1.com');
+insert into chart_repository (chart_repository_id, name, display_name, url, verified_publisher)
+values (:'repo1ID', 'repo1', 'Repo 1', 'https://repo1.com', true);
 insert into maintainer (maintainer_id, name, email)
 values (:'maintainer1ID', 'name1', 'email1');
 insert into maintainer (maintainer_id, name, email)
@@ -186,11 +209,13 @@ select is(
                 "email": "email2"
             }
         ],
+        "operator_provider": null,
         "chart_repository": {
             "chart_repository_id": "00000000-0000-0000-0000-000000000001",
             "name": "repo1",
             "display_name": "Repo 1",
-            "url": "https://repo1.com"
+            "url": "https://repo1.com",
+            "verified_publisher": true
         }
     }'::jsonb,
     'Last package version is returned as a json object'
@@ -230,16 +255,82 @@ select is(
                 "email": "email2"
             }
         ],
+        "operator_provider": null,
         "chart_repository": {
             "chart_repository_id": "00000000-0000-0000-0000-000000000001",
             "name": "repo1",
             "display_name": "Repo 1",
-            "url": "https://repo1.com"
+            "url": "https://repo1.com",
+            "verified_publisher": true
         }
     }'::jsonb,
     'Requested package version is returned as a json object'
 );
 
+-- Seed operator package
+insert into operator_provider (operator_provider_id, name)
+values (:'operatorProvider1ID', 'provider1');
+insert into package (
+    package_id,
+    name,
+    display_name,
+    description,
+    latest_version,
+    package_kind_id,
+    operator_provider_id
+) values (
+    :'package2ID',
+    'package2',
+    'Package 2',
+    'description',
+    '1.0.0',
+    1,
+    :'operatorProvider1ID'
+);
+insert into snapshot (
+    package_id,
+    version,
+    digest,
+    readme
+) values (
+    :'package2ID',
+    '1.0.0',
+    'digest-package2-1.0.0',
+    'readme-version-1.0.0'
+);
+
+-- Operator package has just been seeded
+select is(
+    get_package('{
+        "kind": 1,
+        "package_name": "package2",
+        "operator_provider_name": "provider1"
+    }')::jsonb,
+    '{
+        "package_id": "00000000-0000-0000-0000-000000000002",
+        "kind": 1,
+        "name": "package2",
+        "display_name": "Package 2",
+        "description": "description",
+        "home_url": null,
+        "logo_image_id": null,
+        "keywords": null,
+        "readme": "readme-version-1.0.0",
+        "links": null,
+        "version": "1.0.0",
+        "available_versions": ["1.0.0"],
+        "app_version": null,
+        "digest": "digest-package2-1.0.0",
+        "maintainers": null,
+        "chart_repository": null,
+        "operator_provider": {
+            "operator_provider_id": "00000000-0000-0000-0000-000000000001",
+            "name": "provider1"
+        }
+    }'::jsonb,
+    'Operator package is returned as a json object'
+);
+
 -- Finish tests and rollback transaction
 select * from finish();
 rollback;
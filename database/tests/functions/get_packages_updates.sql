@@ -121,6 +121,7 @@ select is(
             "display_name": "Package 1",
             "logo_image_id": "00000000-0000-0000-0000-000000000001",
             "app_version": "12.1.0",
+            "operator_provider": null,
             "chart_repository": {
                 "chart_repository_id": "00000000-0000-0000-0000-000000000001",
                 "name": "repo1",
@@ -133,6 +134,7 @@ select is(
             "display_name": "Package 2",
             "logo_image_id": "00000000-0000-0000-0000-000000000002",
             "app_version": "12.1.0",
+            "operator_provider": null,
             "chart_repository": {
                 "chart_repository_id": "00000000-0000-0000-0000-000000000002",
                 "name": "repo2",
@@ -146,6 +148,7 @@ select is(
             "display_name": "Package 1",
             "logo_image_id": "00000000-0000-0000-0000-000000000001",
             "app_version": "12.1.0",
+            "operator_provider": null,
             "chart_repository": {
                 "chart_repository_id": "00000000-0000-0000-0000-000000000001",
                 "name": "repo1",
@@ -158,6 +161,7 @@ select is(
             "display_name": "Package 2",
             "logo_image_id": "00000000-0000-0000-0000-000000000002",
             "app_version": "12.1.0",
+            "operator_provider": null,
             "chart_repository": {
                 "chart_repository_id": "00000000-0000-0000-0000-000000000002",
                 "name": "repo2",
@@ -188,6 +192,7 @@ select register_package('
             "email": "email1"
         }
     ],
+    "operator_provider": null,
     "chart_repository": {
         "chart_repository_id": "00000000-0000-0000-0000-000000000002"
     }
@@ -205,6 +210,7 @@ select is(
             "display_name": "Package 1",
             "logo_image_id": "00000000-0000-0000-0000-000000000001",
             "app_version": "12.1.0",
+            "operator_provider": null,
             "chart_repository": {
                 "chart_repository_id": "00000000-0000-0000-0000-000000000001",
                 "name": "repo1",
@@ -217,6 +223,7 @@ select is(
             "display_name": "Package 2 v2",
             "logo_image_id": "00000000-0000-0000-0000-000000000002",
             "app_version": "13.0.0",
+            "operator_provider": null,
             "chart_repository": {
                 "chart_repository_id": "00000000-0000-0000-0000-000000000002",
                 "name": "repo2",
@@ -230,6 +237,7 @@ select is(
             "display_name": "Package 2 v2",
             "logo_image_id": "00000000-0000-0000-0000-000000000002",
             "app_version": "13.0.0",
+            "operator_provider": null,
             "chart_repository": {
                 "chart_repository_id": "00000000-0000-0000-0000-000000000002",
                 "name": "repo2",
@@ -242,6 +250,7 @@ select is(
             "display_name": "Package 1",
             "logo_image_id": "00000000-0000-0000-0000-000000000001",
             "app_version": "12.1.0",
+            "operator_provider": null,
             "chart_repository": {
                 "chart_repository_id": "00000000-0000-0000-0000-000000000001",
                 "name": "repo1",
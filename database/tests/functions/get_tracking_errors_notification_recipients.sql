@@ -0,0 +1,48 @@
+-- Start transaction and plan tests
+begin;
+select plan(2);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set user3ID '00000000-0000-0000-0000-000000000003'
+\set user4ID '00000000-0000-0000-0000-000000000004'
+\set org1ID '00000000-0000-0000-0000-000000000001'
+\set repo1ID '00000000-0000-0000-0000-000000000001'
+\set repo2ID '00000000-0000-0000-0000-000000000002'
+
+-- Seed some data
+insert into "user" (user_id, alias, email, email_verified)
+values (:'user1ID', 'user1', 'user1@email.com', true);
+insert into "user" (user_id, alias, email, email_verified)
+values (:'user2ID', 'user2', 'user2@email.com', true);
+insert into "user" (user_id, alias, email, email_verified, tracking_errors_notifications)
+values (:'user3ID', 'user3', 'user3@email.com', true, false);
+insert into "user" (user_id, alias, email, email_verified)
+values (:'user4ID', 'user4', 'user4@email.com', false);
+insert into organization (organization_id, name)
+values (:'org1ID', 'org1');
+insert into user__organization (user_id, organization_id) values (:'user1ID', :'org1ID');
+insert into user__organization (user_id, organization_id) values (:'user2ID', :'org1ID');
+insert into user__organization (user_id, organization_id) values (:'user3ID', :'org1ID');
+insert into user__organization (user_id, organization_id) values (:'user4ID', :'org1ID');
+insert into chart_repository (chart_repository_id, name, url, user_id)
+values (:'repo1ID', 'repo1', 'https://repo1.com', :'user2ID');
+insert into chart_repository (chart_repository_id, name, url, organization_id)
+values (:'repo2ID', 'repo2', 'https://repo2.com', :'org1ID');
+
+-- Run some tests
+select is(
+    get_tracking_errors_notification_recipients(:'repo1ID')::jsonb,
+    '["user2@email.com"]'::jsonb,
+    'User owning the repository should be notified'
+);
+select is(
+    get_tracking_errors_notification_recipients(:'repo2ID')::jsonb,
+    '["user1@email.com", "user2@email.com"]'::jsonb,
+    'Verified organization members not opted out should be notified'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
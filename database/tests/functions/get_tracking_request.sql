@@ -0,0 +1,48 @@
+-- Start transaction and plan tests
+begin;
+select plan(2);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set repo1ID '00000000-0000-0000-0000-000000000001'
+\set request1ID '00000000-0000-0000-0000-000000000001'
+
+-- Seed some data
+insert into "user" (user_id, alias, email)
+values (:'user1ID', 'user1', 'user1@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user2ID', 'user2', 'user2@email.com');
+insert into chart_repository (chart_repository_id, name, url, user_id)
+values (:'repo1ID', 'repo1', 'https://repo1.com', :'user1ID');
+insert into tracking_request (tracking_request_id, chart_repository_id, status, created_at)
+values (:'request1ID', :'repo1ID', 'pending', '1970-01-01 00:00:00 UTC');
+
+-- Run some tests
+select is(
+    get_tracking_request(:'user1ID', 'repo1', :'request1ID')::jsonb,
+    '{
+        "tracking_request_id": "00000000-0000-0000-0000-000000000001",
+        "chart_name": null,
+        "chart_version": null,
+        "status": "pending",
+        "created_at": 0,
+        "started_at": null,
+        "completed_at": null
+    }'::jsonb,
+    'Tracking request should be returned to the repository owner'
+);
+select is_empty(
+    $$
+        select get_tracking_request(
+            '00000000-0000-0000-0000-000000000002',
+            'repo1',
+            '00000000-0000-0000-0000-000000000001'
+        )
+    $$,
+    'Tracking request should not be returned to other users'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -0,0 +1,94 @@
+-- Start transaction and plan tests
+begin;
+select plan(4);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set repo1ID '00000000-0000-0000-0000-000000000001'
+\set run1ID '00000000-0000-0000-0000-000000000001'
+\set run2ID '00000000-0000-0000-0000-000000000002'
+
+-- Seed some data
+insert into "user" (user_id, alias, email)
+values (:'user1ID', 'user1', 'user1@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user2ID', 'user2', 'user2@email.com');
+insert into chart_repository (chart_repository_id, name, url, user_id)
+values (:'repo1ID', 'repo1', 'https://repo1.com', :'user1ID');
+insert into tracking_run (
+    tracking_run_id,
+    chart_repository_id,
+    started_at,
+    finished_at,
+    versions_processed,
+    versions_registered,
+    versions_failed,
+    errors,
+    suppressed_errors
+) values (
+    :'run1ID',
+    :'repo1ID',
+    '1970-01-01 00:00:01 UTC',
+    '1970-01-01 00:00:02 UTC',
+    2,
+    1,
+    1,
+    '[{"kind": "register", "message": "error1"}]',
+    2
+);
+insert into tracking_run (tracking_run_id, chart_repository_id, started_at, finished_at)
+values (:'run2ID', :'repo1ID', '1970-01-01 00:00:03 UTC', '1970-01-01 00:00:04 UTC');
+
+-- Run some tests
+select is_empty(
+    $$ select get_tracking_runs('00000000-0000-0000-0000-000000000001', 'repo2', 10, 0) $$,
+    'No runs should be returned for a non existing repository'
+);
+select throws_ok(
+    $$ select get_tracking_runs('00000000-0000-0000-0000-000000000002', 'repo1', 10, 0) $$,
+    42501,
+    null,
+    'Only the repository owner can get its tracking runs'
+);
+select is(
+    get_tracking_runs(:'user1ID', 'repo1', 10, 0)::jsonb,
+    '[{
+        "tracking_run_id": "00000000-0000-0000-0000-000000000002",
+        "started_at": 3,
+        "finished_at": 4,
+        "versions_processed": 0,
+        "versions_registered": 0,
+        "versions_failed": 0,
+        "errors": null,
+        "suppressed_errors": 0
+    }, {
+        "tracking_run_id": "00000000-0000-0000-0000-000000000001",
+        "started_at": 1,
+        "finished_at": 2,
+        "versions_processed": 2,
+        "versions_registered": 1,
+        "versions_failed": 1,
+        "errors": [{"kind": "register", "message": "error1"}],
+        "suppressed_errors": 2
+    }]'::jsonb,
+    'Tracking runs should be returned most recent first'
+);
+select is(
+    get_tracking_runs(:'user1ID', 'repo1', 1, 1)::jsonb,
+    '[{
+        "tracking_run_id": "00000000-0000-0000-0000-000000000001",
+        "started_at": 1,
+        "finished_at": 2,
+        "versions_processed": 2,
+        "versions_registered": 1,
+        "versions_failed": 1,
+        "errors": [{"kind": "register", "message": "error1"}],
+        "suppressed_errors": 2
+    }]'::jsonb,
+    'Tracking runs should be paginated'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -0,0 +1,43 @@
+-- Start transaction and plan tests
+begin;
+select plan(2);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set apiKey1ID '00000000-0000-0000-0000-000000000001'
+\set apiKey2ID '00000000-0000-0000-0000-000000000002'
+
+-- Seed some data
+insert into "user" (user_id, alias, email)
+values (:'user1ID', 'user1', 'user1@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user2ID', 'user2', 'user2@email.com');
+
+-- No api keys at this point
+select is(
+    get_user_api_keys(:'user1ID')::jsonb,
+    '[]'::jsonb,
+    'With no api keys an empty json array is returned'
+);
+
+-- Seed some api keys
+insert into api_key (api_key_id, user_id, name, key_hash, created_at)
+values (:'apiKey1ID', :'user1ID', 'key1', 'hash1', '2020-06-16 11:20:34+02');
+insert into api_key (api_key_id, user_id, name, key_hash)
+values (:'apiKey2ID', :'user2ID', 'key2', 'hash2');
+
+-- Only the api keys of the user should be returned
+select is(
+    get_user_api_keys(:'user1ID')::jsonb,
+    '[{
+        "api_key_id": "00000000-0000-0000-0000-000000000001",
+        "name": "key1",
+        "created_at": 1592299234
+    }]'::jsonb,
+    'Api keys of the user are returned as a json array'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
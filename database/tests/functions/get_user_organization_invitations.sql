@@ -0,0 +1,56 @@
+-- Start transaction and plan tests
+begin;
+select plan(3);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set user3ID '00000000-0000-0000-0000-000000000003'
+\set org1ID '00000000-0000-0000-0000-000000000001'
+\set org2ID '00000000-0000-0000-0000-000000000002'
+\set invitation1ID '00000000-0000-0000-0000-000000000001'
+
+-- Seed some data
+insert into "user" (user_id, alias, email, email_verified)
+values (:'user1ID', 'user1', 'user1@email.com', true);
+insert into "user" (user_id, alias, email, email_verified)
+values (:'user2ID', 'user2', 'user2@email.com', true);
+insert into "user" (user_id, alias, email, email_verified)
+values (:'user3ID', 'user3', 'user3@email.com', false);
+insert into organization (organization_id, name, display_name, logo_url)
+values (:'org1ID', 'org1', 'Organization 1', 'logo_url');
+insert into organization (organization_id, name)
+values (:'org2ID', 'org2');
+insert into organization_invitation (organization_invitation_id, organization_id, email)
+values (:'invitation1ID', :'org1ID', 'user1@email.com');
+insert into organization_invitation (organization_id, email, created_at, expires_at)
+values (:'org2ID', 'user1@email.com', '2020-01-01 00:00:00+02', '2020-01-08 00:00:00+02');
+insert into organization_invitation (organization_id, email)
+values (:'org1ID', 'user3@email.com');
+
+-- Run some tests
+select is(
+    get_user_organization_invitations(:'user2ID')::jsonb,
+    '[]'::jsonb,
+    'No invitations should be returned to users who have not been invited'
+);
+select is(
+    get_user_organization_invitations(:'user3ID')::jsonb,
+    '[]'::jsonb,
+    'No invitations should be returned to users whose email has not been verified'
+);
+select is(
+    get_user_organization_invitations(:'user1ID')::jsonb,
+    ('[{
+        "organization_name": "org1",
+        "organization_display_name": "Organization 1",
+        "organization_logo_url": "logo_url",
+        "invited_at": ' || floor(extract(epoch from current_timestamp)) || ',
+        "token": "00000000-0000-0000-0000-000000000001"
+    }]')::jsonb,
+    'Only pending invitations not expired should be returned'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -0,0 +1,59 @@
+-- Start transaction and plan tests
+begin;
+select plan(2);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set org1ID '00000000-0000-0000-0000-000000000001'
+\set org2ID '00000000-0000-0000-0000-000000000002'
+\set org3ID '00000000-0000-0000-0000-000000000003'
+
+-- Seed user
+insert into "user" (user_id, alias, email)
+values (:'user1ID', 'user1', 'user1@email.com');
+
+-- No organizations at this point
+select is(
+    get_user_organizations(:'user1ID')::jsonb,
+    '[]'::jsonb,
+    'With no organizations an empty json array is returned'
+);
+
+-- Seed some organizations
+insert into organization (organization_id, name, display_name, description, home_url)
+values (:'org1ID', 'org1', 'Organization 1', 'Description 1', 'https://org1.com');
+insert into organization (organization_id, name, display_name, description, home_url)
+values (:'org2ID', 'org2', 'Organization 2', 'Description 2', 'https://org2.com');
+insert into organization (organization_id, name)
+values (:'org3ID', 'org3');
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user1ID', :'org1ID', 0);
+insert into user__organization (user_id, organization_id)
+values (:'user1ID', :'org2ID');
+
+-- Only the organizations the user belongs to should be returned
+select is(
+    get_user_organizations(:'user1ID')::jsonb,
+    '[{
+        "organization_id": "00000000-0000-0000-0000-000000000001",
+        "name": "org1",
+        "display_name": "Organization 1",
+        "description": "Description 1",
+        "home_url": "https://org1.com",
+        "logo_url": null,
+        "role": "owner"
+    }, {
+        "organization_id": "00000000-0000-0000-0000-000000000002",
+        "name": "org2",
+        "display_name": "Organization 2",
+        "description": "Description 2",
+        "home_url": "https://org2.com",
+        "logo_url": null,
+        "role": "viewer"
+    }]'::jsonb,
+    'Organizations the user belongs to are returned as a json array'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -0,0 +1,70 @@
+-- Start transaction and plan tests
+begin;
+select plan(2);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set session1ID 'session1'
+\set session2ID 'session2'
+\set session3ID 'session3'
+
+-- Seed some data
+insert into "user" (user_id, alias, email)
+values (:'user1ID', 'user1', 'user1@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user2ID', 'user2', 'user2@email.com');
+
+-- No sessions at this point
+select is(
+    get_user_sessions(:'user1ID', null)::jsonb,
+    '[]'::jsonb,
+    'With no sessions an empty json array is returned'
+);
+
+-- Seed some sessions
+insert into session (session_id, public_id, user_id, ip, user_agent, created_at, last_seen_at)
+values (
+    :'session1ID',
+    '00000000-0000-0000-0000-000000000001',
+    :'user1ID',
+    '192.168.1.100',
+    'Safari 13.0.5',
+    '2020-06-16 11:20:34+02',
+    '2020-06-16 11:20:34+02'
+);
+insert into session (session_id, public_id, user_id, created_at, last_seen_at)
+values (
+    :'session2ID',
+    '00000000-0000-0000-0000-000000000002',
+    :'user1ID',
+    '2020-06-16 11:20:34+02',
+    '2020-06-17 11:20:34+02'
+);
+insert into session (session_id, public_id, user_id)
+values (:'session3ID', '00000000-0000-0000-0000-000000000003', :'user2ID');
+
+-- Only the sessions of the user should be returned, flagging the current one
+select is(
+    get_user_sessions(:'user1ID', :'session1ID')::jsonb,
+    '[{
+        "session_id": "00000000-0000-0000-0000-000000000002",
+        "ip": null,
+        "user_agent": null,
+        "created_at": 1592299234,
+        "last_seen_at": 1592385634,
+        "current": false
+    }, {
+        "session_id": "00000000-0000-0000-0000-000000000001",
+        "ip": "192.168.1.100",
+        "user_agent": "Safari 13.0.5",
+        "created_at": 1592299234,
+        "last_seen_at": 1592299234,
+        "current": true
+    }]'::jsonb,
+    'Sessions of the user are returned as a json array, most recent first'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -0,0 +1,58 @@
+-- Start transaction and plan tests
+begin;
+select plan(2);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set repo1ID '00000000-0000-0000-0000-000000000001'
+\set package1ID '00000000-0000-0000-0000-000000000001'
+\set package2ID '00000000-0000-0000-0000-000000000002'
+
+-- Seed some data
+insert into "user" (user_id, alias, email)
+values (:'user1ID', 'user1', 'user1@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user2ID', 'user2', 'user2@email.com');
+insert into chart_repository (chart_repository_id, name, display_name, url)
+values (:'repo1ID', 'repo1', 'Repo 1', 'https://repo1.com');
+insert into package (package_id, name, display_name, latest_version, package_kind_id, chart_repository_id)
+values (:'package1ID', 'package1', 'Package 1', '1.0.0', 0, :'repo1ID');
+insert into package (package_id, name, latest_version, package_kind_id, chart_repository_id)
+values (:'package2ID', 'package2', '1.0.0', 0, :'repo1ID');
+
+-- No subscriptions at this point
+select is(
+    get_user_subscriptions(:'user1ID')::jsonb,
+    '[]'::jsonb,
+    'With no subscriptions an empty json array is returned'
+);
+
+-- Seed some subscriptions
+insert into subscription (user_id, package_id, event_kind_id)
+values (:'user1ID', :'package1ID', 0);
+insert into subscription (user_id, package_id, event_kind_id)
+values (:'user2ID', :'package2ID', 0);
+
+-- Only the subscriptions of the user should be returned
+select is(
+    get_user_subscriptions(:'user1ID')::jsonb,
+    '[{
+        "package_id": "00000000-0000-0000-0000-000000000001",
+        "kind": 0,
+        "name": "package1",
+        "display_name": "Package 1",
+        "logo_image_id": null,
+        "chart_repository": {
+            "name": "repo1",
+            "display_name": "Repo 1"
+        },
+        "operator_provider": null,
+        "event_kinds": [0]
+    }]'::jsonb,
+    'Subscriptions of the user are returned as a json array'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
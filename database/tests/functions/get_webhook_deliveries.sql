@@ -0,0 +1,81 @@
+-- Start transaction and plan tests
+begin;
+select plan(3);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set repo1ID '00000000-0000-0000-0000-000000000001'
+\set webhook1ID '00000000-0000-0000-0000-000000000001'
+\set delivery1ID '00000000-0000-0000-0000-000000000001'
+\set delivery2ID '00000000-0000-0000-0000-000000000002'
+
+-- Seed some data
+insert into "user" (user_id, alias, email)
+values (:'user1ID', 'user1', 'user1@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user2ID', 'user2', 'user2@email.com');
+insert into chart_repository (chart_repository_id, name, url, user_id)
+values (:'repo1ID', 'repo1', 'https://repo1.com', :'user1ID');
+insert into webhook (webhook_id, chart_repository_id, url, secret, event_kinds)
+values (:'webhook1ID', :'repo1ID', 'https://hook.com', 'secret1', '{0}');
+insert into webhook_delivery (webhook_delivery_id, webhook_id, event_kind_id, payload, created_at)
+values (:'delivery1ID', :'webhook1ID', 0, '{"key": "value1"}', '1970-01-01 00:00:01 UTC');
+insert into webhook_delivery (
+    webhook_delivery_id,
+    webhook_id,
+    event_kind_id,
+    payload,
+    created_at,
+    processed,
+    processed_at,
+    attempts
+) values (
+    :'delivery2ID',
+    :'webhook1ID',
+    0,
+    '{"key": "value2"}',
+    '1970-01-01 00:00:02 UTC',
+    true,
+    '1970-01-01 00:00:03 UTC',
+    1
+);
+insert into webhook_delivery_attempt (webhook_delivery_id, created_at, status_code)
+values (:'delivery2ID', '1970-01-01 00:00:03 UTC', 200);
+
+-- Run some tests
+select is_empty(
+    $$ select get_webhook_deliveries('00000000-0000-0000-0000-000000000001', 'repo2', '00000000-0000-0000-0000-000000000001', 10, 0) $$,
+    'No deliveries should be returned for a non existing repository'
+);
+select throws_ok(
+    $$ select get_webhook_deliveries('00000000-0000-0000-0000-000000000002', 'repo1', '00000000-0000-0000-0000-000000000001', 10, 0) $$,
+    42501,
+    null,
+    'Only the repository owner should be allowed to get the webhook deliveries'
+);
+select is(
+    get_webhook_deliveries(:'user1ID', 'repo1', :'webhook1ID', 10, 0)::jsonb,
+    '[{
+        "webhook_delivery_id": "00000000-0000-0000-0000-000000000002",
+        "event_kind": 0,
+        "payload": {"key": "value2"},
+        "created_at": 2,
+        "processed": true,
+        "processed_at": 3,
+        "attempts": [{"created_at": 3, "status_code": 200, "error": null}]
+    }, {
+        "webhook_delivery_id": "00000000-0000-0000-0000-000000000001",
+        "event_kind": 0,
+        "payload": {"key": "value1"},
+        "created_at": 1,
+        "processed": false,
+        "processed_at": null,
+        "attempts": []
+    }]'::jsonb,
+    'Deliveries should be returned as a json array, most recent first'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
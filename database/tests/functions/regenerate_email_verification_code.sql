@@ -0,0 +1,52 @@
+-- Start transaction and plan tests
+begin;
+select plan(5);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set code1ID '00000000-0000-0000-0000-000000000001'
+
+-- Seed some data
+insert into "user" (user_id, alias, email, email_verified)
+values (:'user1ID', 'user1', 'user1@email.com', false);
+insert into "user" (user_id, alias, email, email_verified)
+values (:'user2ID', 'user2', 'user2@email.com', true);
+insert into email_verification_code (email_verification_code_id, user_id)
+values (:'code1ID', :'user1ID');
+
+-- Regenerate email verification codes
+select is_empty(
+    $$ select regenerate_email_verification_code('unknown@email.com') $$,
+    'No code should be returned for an unknown email'
+);
+select is_empty(
+    $$ select regenerate_email_verification_code('user2@email.com') $$,
+    'No code should be returned for a user whose email is already verified'
+);
+select is_empty(
+    $$ select regenerate_email_verification_code('user1@email.com') $$,
+    'No code should be returned when the current one was created too recently'
+);
+
+-- Set email verification code created_at timestamp to two days ago
+update email_verification_code
+set created_at = created_at - '2 days'::interval
+where email_verification_code_id = :'code1ID';
+
+-- Regenerate code again
+select regenerate_email_verification_code('user1@email.com') as code2 \gset
+select isnt(
+    :'code2'::uuid,
+    :'code1ID'::uuid,
+    'A new code should be returned'
+);
+select results_eq(
+    $$ select email_verification_code_id, user_id from email_verification_code $$,
+    $$ values (:'code2'::uuid, :'user1ID'::uuid) $$,
+    'Previous email verification code should have been replaced'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -1,6 +1,6 @@
 -- Start transaction and plan tests
 begin;
-select plan(10);
+select plan(15);
 
 -- Declare some variables
 \set repo1ID '00000000-0000-0000-0000-000000000001'
@@ -81,6 +81,13 @@ select results_eq(
     $$
         select
             s.version,
+            s.display_name,
+            s.description,
+            s.home_url,
+            s.logo_url,
+            s.logo_image_id,
+            s.keywords,
+            s.maintainers,
             s.app_version,
             s.digest,
             s.readme,
@@ -93,6 +100,13 @@ select results_eq(
     $$
         values (
             '1.0.0',
+            'Package 1',
+            'description',
+            'home_url',
+            'logo_url',
+            '00000000-0000-0000-0000-000000000001'::uuid,
+            '{kw1,kw2}'::text[],
+            '[{"name": "name1", "email": "email1"}, {"name": "name2", "email": "email2"}]'::jsonb,
             '12.1.0',
             'digest-package1-1.0.0',
             'readme-version-1.0.0',
@@ -278,6 +292,119 @@ select results_eq(
     'Package maintainers should not have been updated'
 );
 
+-- Register again an existing version of the package with a different digest
+select register_package('
+{
+    "kind": 0,
+    "name": "package1",
+    "display_name": "Package 1 v2",
+    "description": "description v2",
+    "readme": "readme-version-2.0.0-updated",
+    "version": "2.0.0",
+    "app_version": "13.0.0",
+    "digest": "digest-package1-2.0.0-updated",
+    "chart_repository": {
+        "chart_repository_id": "00000000-0000-0000-0000-000000000001"
+    }
+}
+');
+
+-- Check if snapshot was updated and events were created only for new versions
+select results_eq(
+    $$
+        select s.digest, s.readme
+        from snapshot s
+        join package p using (package_id)
+        where name='package1'
+        and version='2.0.0'
+    $$,
+    $$ values ('digest-package1-2.0.0-updated', 'readme-version-2.0.0-updated') $$,
+    'Existing snapshot should have been updated'
+);
+select results_eq(
+    $$
+        select e.package_version, e.event_kind_id, e.processed
+        from event e
+        join package p using (package_id)
+        where p.name='package1'
+        order by e.package_version asc
+    $$,
+    $$
+        values
+        ('0.0.9', 0, false),
+        ('1.0.0', 0, false),
+        ('2.0.0', 0, false)
+    $$,
+    'New release events should have been created once per version'
+);
+
+-- Register operator package
+select register_package('
+{
+    "kind": 1,
+    "name": "package2",
+    "display_name": "Package 2",
+    "description": "description",
+    "readme": "readme-version-1.0.0",
+    "version": "1.0.0",
+    "digest": "digest-package2-1.0.0",
+    "operator_provider": {
+        "name": "provider1"
+    }
+}
+');
+
+-- Check if operator package registration succeeded
+select results_eq(
+    $$ select name from operator_provider $$,
+    $$ values ('provider1') $$,
+    'Operator provider should have been registered'
+);
+select results_eq(
+    $$
+        select
+            p.display_name,
+            p.latest_version,
+            p.package_kind_id,
+            p.chart_repository_id
+        from package p
+        join operator_provider op using (operator_provider_id)
+        where p.name = 'package2'
+        and op.name = 'provider1'
+    $$,
+    $$ values ('Package 2', '1.0.0', 1, null::uuid) $$,
+    'Operator package should exist'
+);
+
+-- Register a newer version of the operator package
+select register_package('
+{
+    "kind": 1,
+    "name": "package2",
+    "display_name": "Package 2 v2",
+    "description": "description v2",
+    "readme": "readme-version-1.1.0",
+    "version": "1.1.0",
+    "digest": "digest-package2-1.1.0",
+    "operator_provider": {
+        "name": "provider1"
+    }
+}
+');
+
+-- Check if operator package was updated
+select results_eq(
+    $$
+        select p.display_name, p.latest_version, count(s.*)
+        from package p
+        join snapshot s using (package_id)
+        where p.name = 'package2'
+        group by p.display_name, p.latest_version
+    $$,
+    $$ values ('Package 2 v2', '1.1.0', 2::bigint) $$,
+    'Operator package should have been updated and have two snapshots'
+);
+
 -- Finish tests and rollback transaction
 select * from finish();
 rollback;
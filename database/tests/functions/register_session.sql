@@ -1,6 +1,6 @@
 -- Start transaction and plan tests
 begin;
-select plan(2);
+select plan(3);
 
 -- Seed user
 insert into "user" (user_id, alias, email)
@@ -40,6 +40,18 @@ select is(
     'Returned session_id returned should be registered'
 )
 from session where user_id = '00000000-0000-0000-0000-000000000001';
+select results_eq(
+    $$ select user_id, action, host(ip), user_agent from audit_event $$,
+    $$
+        values (
+            '00000000-0000-0000-0000-000000000001'::uuid,
+            'login',
+            '192.168.1.100',
+            'Safari 13.0.5'
+        )
+    $$,
+    'Login should be registered in the audit log'
+);
 
 -- Finish tests and rollback transaction
 select * from finish();
@@ -0,0 +1,55 @@
+-- Start transaction and plan tests
+begin;
+select plan(1);
+
+-- Declare some variables
+\set repo1ID '00000000-0000-0000-0000-000000000001'
+
+-- Seed some data
+insert into chart_repository (chart_repository_id, name, url)
+values (:'repo1ID', 'repo1', 'https://repo1.com');
+
+-- Register tracking run
+select register_tracking_run('
+{
+    "chart_repository_id": "00000000-0000-0000-0000-000000000001",
+    "started_at": 1,
+    "finished_at": 2,
+    "versions_processed": 3,
+    "versions_registered": 2,
+    "versions_failed": 1,
+    "errors": [{"kind": "register", "message": "error1"}],
+    "suppressed_errors": 4
+}
+');
+select results_eq(
+    $$
+        select
+            chart_repository_id,
+            floor(extract(epoch from started_at)),
+            floor(extract(epoch from finished_at)),
+            versions_processed,
+            versions_registered,
+            versions_failed,
+            errors,
+            suppressed_errors
+        from tracking_run
+    $$,
+    $$
+        values (
+            '00000000-0000-0000-0000-000000000001'::uuid,
+            1::double precision,
+            2::double precision,
+            3,
+            2,
+            1,
+            '[{"kind": "register", "message": "error1"}]'::jsonb,
+            4
+        )
+    $$,
+    'Tracking run should have been registered'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
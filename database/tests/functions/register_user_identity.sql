@@ -0,0 +1,139 @@
+-- Start transaction and plan tests
+begin;
+select plan(11);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+
+-- Seed some data
+insert into "user" (user_id, alias, email, email_verified, password)
+values (:'user1ID', 'user1', 'user1@email.com', true, 'password');
+insert into "user" (user_id, alias, email, email_verified, password)
+values (:'user2ID', 'user2', 'user2@email.com', false, 'password');
+
+-- Register identity with the email of an existing verified user
+select throws_ok(
+    $$
+        select register_user_identity('
+        {
+            "provider": "github",
+            "provider_user_id": "1",
+            "alias": "user1-github",
+            "email": "user1@email.com"
+        }
+        ')
+    $$,
+    23505,
+    'email already registered',
+    'Identity should not be linked to a verified user who is not logged in'
+);
+select is_empty(
+    $$ select * from user_identity $$,
+    'No identity should have been registered'
+);
+
+-- Register identity while logged in
+select is(
+    register_user_identity('
+    {
+        "provider": "github",
+        "provider_user_id": "1",
+        "alias": "user1-github",
+        "email": "user1@email.com",
+        "user_id": "00000000-0000-0000-0000-000000000001"
+    }
+    '),
+    '00000000-0000-0000-0000-000000000001'::uuid,
+    'Identity should be linked to the user logged in'
+);
+select results_eq(
+    $$ select provider, provider_user_id from user_identity where user_id = '00000000-0000-0000-0000-000000000001' $$,
+    $$ values ('github', '1') $$,
+    'User identity should have been registered'
+);
+
+-- Register the same identity again, even if the email changed
+select is(
+    register_user_identity('
+    {
+        "provider": "github",
+        "provider_user_id": "1",
+        "alias": "user1-github",
+        "email": "user1-new@email.com"
+    }
+    '),
+    '00000000-0000-0000-0000-000000000001'::uuid,
+    'User already linked to the identity should be returned'
+);
+
+-- Register identity linked to another user while logged in
+select throws_ok(
+    $$
+        select register_user_identity('
+        {
+            "provider": "github",
+            "provider_user_id": "1",
+            "alias": "user1-github",
+            "email": "user1@email.com",
+            "user_id": "00000000-0000-0000-0000-000000000002"
+        }
+        ')
+    $$,
+    23505,
+    'identity already linked to another user',
+    'Identity linked to another user should not be linked again'
+);
+
+-- Register identity with the email of an existing user not verified
+select is(
+    register_user_identity('
+    {
+        "provider": "google",
+        "provider_user_id": "2",
+        "alias": "user2",
+        "email": "user2@email.com"
+    }
+    '),
+    '00000000-0000-0000-0000-000000000002'::uuid,
+    'Identity should be linked to the user with the same email'
+);
+select results_eq(
+    $$ select email_verified, password from "user" where user_id = '00000000-0000-0000-0000-000000000002' $$,
+    $$ values (true, null::text) $$,
+    'User email should be verified and the password discarded'
+);
+
+-- Register identity of a new user whose alias is already taken
+select register_user_identity('
+{
+    "provider": "oidc",
+    "provider_user_id": "3",
+    "alias": "user1",
+    "first_name": "first_name",
+    "last_name": "last_name",
+    "email": "user3@email.com"
+}
+') as user3ID \gset
+select results_eq(
+    $$
+        select first_name, last_name, email, email_verified, password is null
+        from "user" where email = 'user3@email.com'
+    $$,
+    $$ values ('first_name', 'last_name', 'user3@email.com', true, true) $$,
+    'New user should have been created'
+);
+select matches(
+    (select alias from "user" where email = 'user3@email.com'),
+    '^user1-[0-9a-f]{6}$',
+    'New user alias should have a random suffix as the one provided was taken'
+);
+select results_eq(
+    $$ select provider, provider_user_id from user_identity where user_id = (select user_id from "user" where email = 'user3@email.com') $$,
+    $$ values ('oidc', '3') $$,
+    'New user identity should have been registered'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
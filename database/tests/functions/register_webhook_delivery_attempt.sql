@@ -0,0 +1,41 @@
+-- Start transaction and plan tests
+begin;
+select plan(3);
+
+-- Declare some variables
+\set repo1ID '00000000-0000-0000-0000-000000000001'
+\set webhook1ID '00000000-0000-0000-0000-000000000001'
+\set delivery1ID '00000000-0000-0000-0000-000000000001'
+
+-- Seed some data
+insert into chart_repository (chart_repository_id, name, url)
+values (:'repo1ID', 'repo1', 'https://repo1.com');
+insert into webhook (webhook_id, chart_repository_id, url, secret, event_kinds)
+values (:'webhook1ID', :'repo1ID', 'https://hook.com', 'secret1', '{0}');
+insert into webhook_delivery (webhook_delivery_id, webhook_id, event_kind_id, payload)
+values (:'delivery1ID', :'webhook1ID', 0, '{"key": "value1"}');
+
+-- Register a failed attempt
+select register_webhook_delivery_attempt(:'delivery1ID', 500, 'unexpected status code received: 500');
+select results_eq(
+    $$ select processed from webhook_delivery $$,
+    $$ values (false) $$,
+    'Delivery should not be marked as processed after a failed attempt'
+);
+
+-- Register a successful attempt
+select register_webhook_delivery_attempt(:'delivery1ID', 200, null);
+select results_eq(
+    $$ select processed, processed_at is not null from webhook_delivery $$,
+    $$ values (true, true) $$,
+    'Delivery should be marked as processed after a successful attempt'
+);
+select results_eq(
+    $$ select status_code, error from webhook_delivery_attempt order by status_code desc $$,
+    $$ values (500, 'unexpected status code received: 500'), (200, null) $$,
+    'Delivery attempts should have been registered'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -0,0 +1,44 @@
+-- Start transaction and plan tests
+begin;
+select plan(5);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+
+-- Seed some data
+insert into "user" (user_id, alias, email, email_verified)
+values (:'user1ID', 'user1', 'user1@email.com', true);
+insert into "user" (user_id, alias, email, email_verified)
+values (:'user2ID', 'user2', 'user2@email.com', false);
+
+-- Request password reset codes
+select is_empty(
+    $$ select request_password_reset_code('unknown@email.com') $$,
+    'No code should be returned for an unknown email'
+);
+select is_empty(
+    $$ select request_password_reset_code('user2@email.com') $$,
+    'No code should be returned for a user whose email is not verified'
+);
+select request_password_reset_code('user1@email.com') as code1 \gset
+select results_eq(
+    $$ select password_reset_code_id, user_id from password_reset_code $$,
+    $$ values (:'code1'::uuid, :'user1ID'::uuid) $$,
+    'Password reset code should have been registered'
+);
+select request_password_reset_code('user1@email.com') as code2 \gset
+select isnt(
+    :'code2'::uuid,
+    :'code1'::uuid,
+    'A new code should be returned when requested again'
+);
+select results_eq(
+    $$ select password_reset_code_id from password_reset_code $$,
+    $$ values (:'code2'::uuid) $$,
+    'Previous password reset code should have been replaced'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
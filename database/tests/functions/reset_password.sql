@@ -0,0 +1,73 @@
+-- Start transaction and plan tests
+begin;
+select plan(8);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+
+-- Seed some data
+insert into "user" (user_id, alias, email, email_verified, password)
+values (:'user1ID', 'user1', 'user1@email.com', true, 'password');
+insert into "user" (user_id, alias, email, email_verified, password)
+values (:'user2ID', 'user2', 'user2@email.com', true, 'password');
+insert into session (user_id) values (:'user1ID');
+insert into session (user_id) values (:'user1ID');
+insert into session (user_id) values (:'user2ID');
+insert into api_key (user_id, name, key_hash) values (:'user1ID', 'key1', 'hash1');
+insert into api_key (user_id, name, key_hash) values (:'user2ID', 'key2', 'hash2');
+select request_password_reset_code('user1@email.com') as code1 \gset
+select request_password_reset_code('user2@email.com') as code2 \gset
+
+-- Reset password
+select is(
+    reset_password(:'code1', 'new-password'),
+    true,
+    'Password should be reset successfully'
+);
+select results_eq(
+    $$ select password from "user" where user_id = '00000000-0000-0000-0000-000000000001' $$,
+    $$ values ('new-password') $$,
+    'User password should have been updated'
+);
+select results_eq(
+    $$ select user_id from password_reset_code $$,
+    $$ values ('00000000-0000-0000-0000-000000000002'::uuid) $$,
+    'Password reset code used should have been deleted'
+);
+select results_eq(
+    $$ select user_id from session $$,
+    $$ values ('00000000-0000-0000-0000-000000000002'::uuid) $$,
+    'User sessions should have been deleted'
+);
+select results_eq(
+    $$ select key_hash from api_key $$,
+    $$ values ('hash2') $$,
+    'User api keys should have been revoked'
+);
+select is(
+    reset_password(:'code1', 'another-password'),
+    false,
+    'Trying to use the same code again should not succeed'
+);
+
+-- Set second user's password reset code created_at timestamp to two days ago
+update password_reset_code
+set created_at = created_at - '2 days'::interval
+where password_reset_code_id = :'code2';
+
+-- Reset password using expired code
+select is(
+    reset_password(:'code2', 'new-password'),
+    false,
+    'Password reset should not succeed as code is expired'
+);
+select results_eq(
+    $$ select password from "user" where user_id = '00000000-0000-0000-0000-000000000002' $$,
+    $$ values ('password') $$,
+    'User password should not have been updated'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
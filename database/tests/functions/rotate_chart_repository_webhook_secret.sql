@@ -0,0 +1,57 @@
+-- Start transaction and plan tests
+begin;
+select plan(5);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set repo1ID '00000000-0000-0000-0000-000000000001'
+
+-- Seed some data
+insert into "user" (user_id, alias, email)
+values (:'user1ID', 'user1', 'user1@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user2ID', 'user2', 'user2@email.com');
+insert into chart_repository (chart_repository_id, name, url, user_id)
+values (:'repo1ID', 'repo1', 'https://repo1.com', :'user1ID');
+
+-- Non existing repository
+select is_empty(
+    $$ select rotate_chart_repository_webhook_secret('00000000-0000-0000-0000-000000000001', 'repo2', '{}') $$,
+    'No secret should be returned for a non existing repository'
+);
+
+-- Repository not owned by the user
+select throws_ok(
+    $$ select rotate_chart_repository_webhook_secret('00000000-0000-0000-0000-000000000002', 'repo1', '{}') $$,
+    42501,
+    null,
+    'Only the repository owner can rotate its webhook secret'
+);
+
+-- Generate and rotate secret
+select rotate_chart_repository_webhook_secret(:'user1ID', 'repo1', '{}') as secret1 \gset
+select is(
+    (select webhook_secret from chart_repository where chart_repository_id = :'repo1ID'),
+    :'secret1'::text,
+    'Secret generated should be stored and returned'
+);
+select rotate_chart_repository_webhook_secret(:'user1ID', 'repo1', '{}') as secret2 \gset
+select isnt(
+    :'secret1'::text,
+    :'secret2'::text,
+    'Secret should be replaced when rotated'
+);
+select results_eq(
+    $$ select user_id, action, target, chart_repository_id from audit_event $$,
+    $$
+        values
+            ('00000000-0000-0000-0000-000000000001'::uuid, 'rotate-chart-repository-webhook-secret', 'repo1', '00000000-0000-0000-0000-000000000001'::uuid),
+            ('00000000-0000-0000-0000-000000000001'::uuid, 'rotate-chart-repository-webhook-secret', 'repo1', '00000000-0000-0000-0000-000000000001'::uuid)
+    $$,
+    'Secret rotations should be registered in the audit log'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -30,8 +30,8 @@ select is(
 );
 
 -- Seed some packages
-insert into chart_repository (chart_repository_id, name, display_name, url)
-values (:'repo1ID', 'repo1', 'Repo 1', 'https://repo1.com');
+insert into chart_repository (chart_repository_id, name, display_name, url, verified_publisher)
+values (:'repo1ID', 'repo1', 'Repo 1', 'https://repo1.com', true);
 insert into chart_repository (chart_repository_id, name, display_name, url)
 values (:'repo2ID', 'repo2', 'Repo 2', 'https://repo2.com');
 insert into package (
@@ -154,9 +154,11 @@ select is(
                 "app_version": "12.1.0",
                 "description": "description",
                 "display_name": "Package 1",
+                "operator_provider": null,
                 "chart_repository": {
                     "name": "repo1",
-                    "display_name": "Repo 1"
+                    "display_name": "Repo 1",
+                    "verified_publisher": true
                 }
             }, {
                 "kind": 0,
@@ -166,9 +168,11 @@ select is(
                 "app_version": "12.1.0",
                 "description": "description",
                 "display_name": "Package 2",
+                "operator_provider": null,
                 "chart_repository": {
                     "name": "repo2",
-                    "display_name": "Repo 2"
+                    "display_name": "Repo 2",
+                    "verified_publisher": false
                 }
             }],
             "facets": null
@@ -196,9 +200,11 @@ select is(
                 "app_version": "12.1.0",
                 "description": "description",
                 "display_name": "Package 1",
+                "operator_provider": null,
                 "chart_repository": {
                     "name": "repo1",
-                    "display_name": "Repo 1"
+                    "display_name": "Repo 1",
+                    "verified_publisher": true
                 }
             }, {
                 "kind": 0,
@@ -208,9 +214,11 @@ select is(
                 "app_version": "12.1.0",
                 "description": "description",
                 "display_name": "Package 2",
+                "operator_provider": null,
                 "chart_repository": {
                     "name": "repo2",
-                    "display_name": "Repo 2"
+                    "display_name": "Repo 2",
+                    "verified_publisher": false
                 }
             }],
             "facets": [{
@@ -258,9 +266,11 @@ select is(
                 "app_version": "12.1.0",
                 "description": "description",
                 "display_name": "Package 1",
+                "operator_provider": null,
                 "chart_repository": {
                     "name": "repo1",
-                    "display_name": "Repo 1"
+                    "display_name": "Repo 1",
+                    "verified_publisher": true
                 }
             }],
             "facets": [{
@@ -324,9 +334,11 @@ select is(
                 "app_version": "12.1.0",
                 "description": "description",
                 "display_name": "Package 1",
+                "operator_provider": null,
                 "chart_repository": {
                     "name": "repo1",
-                    "display_name": "Repo 1"
+                    "display_name": "Repo 1",
+                    "verified_publisher": true
                 }
             }],
             "facets": null
@@ -356,9 +368,11 @@ select is(
                 "app_version": "12.1.0",
                 "description": "description",
                 "display_name": "Package 1",
+                "operator_provider": null,
                 "chart_repository": {
                     "name": "repo1",
-                    "display_name": "Repo 1"
+                    "display_name": "Repo 1",
+                    "verified_publisher": true
                 }
             }],
             "facets": null
@@ -390,9 +404,11 @@ select is(
                 "app_version": "12.1.0",
                 "description": "description",
                 "display_name": "Package 2",
+                "operator_provider": null,
                 "chart_repository": {
                     "name": "repo2",
-                    "display_name": "Repo 2"
+                    "display_name": "Repo 2",
+                    "verified_publisher": false
                 }
             }],
             "facets": [{
@@ -503,9 +519,11 @@ select is(
                 "app_version": "12.1.0",
                 "description": "description",
                 "display_name": "Package 1",
+                "operator_provider": null,
                 "chart_repository": {
                     "name": "repo1",
-                    "display_name": "Repo 1"
+                    "display_name": "Repo 1",
+                    "verified_publisher": true
                 }
             }, {
                 "kind": 0,
@@ -515,9 +533,11 @@ select is(
                 "app_version": "12.1.0",
                 "description": "description",
                 "display_name": "Package 2",
+                "operator_provider": null,
                 "chart_repository": {
                     "name": "repo2",
-                    "display_name": "Repo 2"
+                    "display_name": "Repo 2",
+                    "verified_publisher": false
                 }
             }],
             "facets": null
@@ -546,9 +566,11 @@ select is(
                 "app_version": "12.1.0",
                 "description": "description",
                 "display_name": "Package 1",
+                "operator_provider": null,
                 "chart_repository": {
                     "name": "repo1",
-                    "display_name": "Repo 1"
+                    "display_name": "Repo 1",
+                    "verified_publisher": true
                 }
             }],
             "facets": null
@@ -596,9 +618,11 @@ select is(
                 "app_version": "12.1.0",
                 "description": "description",
                 "display_name": "Package 2",
+                "operator_provider": null,
                 "chart_repository": {
                     "name": "repo2",
-                    "display_name": "Repo 2"
+                    "display_name": "Repo 2",
+                    "verified_publisher": false
                 }
             }],
             "facets": null
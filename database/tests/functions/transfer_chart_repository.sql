@@ -0,0 +1,105 @@
+-- Start transaction and plan tests
+begin;
+select plan(9);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set org1ID '00000000-0000-0000-0000-000000000001'
+\set org2ID '00000000-0000-0000-0000-000000000002'
+\set repo1ID '00000000-0000-0000-0000-000000000001'
+\set repo2ID '00000000-0000-0000-0000-000000000002'
+
+-- Seed some data
+insert into "user" (user_id, alias, email)
+values (:'user1ID', 'user1', 'user1@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user2ID', 'user2', 'user2@email.com');
+insert into organization (organization_id, name)
+values (:'org1ID', 'org1');
+insert into organization (organization_id, name)
+values (:'org2ID', 'org2');
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user1ID', :'org1ID', 0);
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user2ID', :'org1ID', 1);
+insert into chart_repository (chart_repository_id, name, url, user_id)
+values (:'repo1ID', 'repo1', 'https://repo1.com', :'user1ID');
+insert into chart_repository (chart_repository_id, name, url, organization_id)
+values (:'repo2ID', 'repo2', 'https://repo2.com', :'org1ID');
+
+-- Run some tests
+select is_empty(
+    $$ select transfer_chart_repository('00000000-0000-0000-0000-000000000001', 'repo3', 'org1', '{}') $$,
+    'Nothing should be returned for a non existing repository'
+);
+select throws_ok(
+    $$ select transfer_chart_repository('00000000-0000-0000-0000-000000000002', 'repo1', 'org1', '{}') $$,
+    42501,
+    null,
+    'Only the repository owner can transfer it'
+);
+select throws_ok(
+    $$ select transfer_chart_repository('00000000-0000-0000-0000-000000000002', 'repo2', 'org2', '{}') $$,
+    42501,
+    null,
+    'Admins of the organization owning the repository cannot transfer it'
+);
+select is_empty(
+    $$ select transfer_chart_repository('00000000-0000-0000-0000-000000000001', 'repo1', 'org3', '{}') $$,
+    'Nothing should be returned for a non existing organization'
+);
+select throws_ok(
+    $$ select transfer_chart_repository('00000000-0000-0000-0000-000000000001', 'repo2', 'org1', '{}') $$,
+    23514,
+    'chart repository already belongs to the organization',
+    'Repositories cannot be transferred to the organization owning them'
+);
+select isnt_empty(
+    $$ select transfer_chart_repository('00000000-0000-0000-0000-000000000001', 'repo1', 'org1', '{}') $$,
+    'Transfer from user to organization should be requested'
+);
+select transfer_chart_repository(:'user1ID', 'repo1', 'org2', '{"ip": "192.168.1.1", "user_agent": "ua"}') as transfer_id \gset
+select results_eq(
+    $$
+        select chart_repository_transfer_id, from_user_id, from_organization_id, to_organization_id, requested_by
+        from chart_repository_transfer
+        where chart_repository_id = '00000000-0000-0000-0000-000000000001'
+    $$,
+    format(
+        $$ values (%L::uuid, %L::uuid, null::uuid, %L::uuid, %L::uuid) $$,
+        :'transfer_id', :'user1ID', :'org2ID', :'user1ID'
+    ),
+    'Only the last pending transfer should be kept'
+);
+select transfer_chart_repository(:'user1ID', 'repo2', 'org2', '{"ip": "192.168.1.1", "user_agent": "ua"}') as transfer_id \gset
+select results_eq(
+    format(
+        $$
+            select from_user_id, from_organization_id, to_organization_id
+            from chart_repository_transfer
+            where chart_repository_transfer_id = %L
+        $$,
+        :'transfer_id'
+    ),
+    $$ values (null::uuid, '00000000-0000-0000-0000-000000000001'::uuid, '00000000-0000-0000-0000-000000000002'::uuid) $$,
+    'Transfer from organization to organization should be requested'
+);
+select results_eq(
+    $$
+        select user_id, action, target, chart_repository_id, organization_id, host(ip), user_agent, details
+        from audit_event
+        order by target asc, details->>'to_organization' asc
+    $$,
+    $$
+        values
+            ('00000000-0000-0000-0000-000000000001'::uuid, 'request-chart-repository-transfer', 'repo1', '00000000-0000-0000-0000-000000000001'::uuid, null::uuid, null, null, '{"to_organization": "org1"}'::jsonb),
+            ('00000000-0000-0000-0000-000000000001'::uuid, 'request-chart-repository-transfer', 'repo1', '00000000-0000-0000-0000-000000000001'::uuid, null::uuid, '192.168.1.1', 'ua', '{"to_organization": "org2"}'::jsonb),
+            ('00000000-0000-0000-0000-000000000001'::uuid, 'request-chart-repository-transfer', 'repo2', '00000000-0000-0000-0000-000000000002'::uuid, '00000000-0000-0000-0000-000000000001'::uuid, '192.168.1.1', 'ua', '{"to_organization": "org2"}'::jsonb)
+    $$,
+    'Transfer requests should be registered in the audit log'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -0,0 +1,114 @@
+-- Start transaction and plan tests
+begin;
+select plan(8);
+
+-- Declare some variables
+\set repo1ID '00000000-0000-0000-0000-000000000001'
+\set package1ID '00000000-0000-0000-0000-000000000001'
+\set maintainer1ID '00000000-0000-0000-0000-000000000001'
+\set maintainer2ID '00000000-0000-0000-0000-000000000002'
+\set webhook1ID '00000000-0000-0000-0000-000000000001'
+
+-- Seed some data
+insert into chart_repository (chart_repository_id, name, display_name, url)
+values (:'repo1ID', 'repo1', 'Repo 1', 'https://repo1.com');
+insert into package (package_id, name, display_name, description, keywords, latest_version, package_kind_id, chart_repository_id)
+values (:'package1ID', 'package1', 'Package 1 v2', 'description v2', '{kw2}', '2.0.0', 0, :'repo1ID');
+insert into snapshot (package_id, version, digest) values (:'package1ID', '1.0.0', 'digest-1.0.0');
+insert into snapshot (package_id, version, display_name, description, keywords, maintainers, digest)
+values (:'package1ID', '1.1.0', 'Package 1', 'description', '{kw1}', '[{"name": "name1", "email": "email1"}]', 'digest-1.1.0');
+insert into snapshot (package_id, version, display_name, description, keywords, maintainers, digest)
+values (:'package1ID', '2.0.0', 'Package 1 v2', 'description v2', '{kw2}', '[{"name": "name2", "email": "email2"}]', 'digest-2.0.0');
+insert into maintainer (maintainer_id, name, email)
+values (:'maintainer1ID', 'name1', 'email1');
+insert into maintainer (maintainer_id, name, email)
+values (:'maintainer2ID', 'name2', 'email2');
+insert into package__maintainer (package_id, maintainer_id)
+values (:'package1ID', :'maintainer2ID');
+insert into webhook (webhook_id, chart_repository_id, url, secret, event_kinds)
+values (:'webhook1ID', :'repo1ID', 'https://hook.com', 'secret1', '{1}');
+
+-- Unregister latest version
+select unregister_package('
+{
+    "name": "package1",
+    "version": "2.0.0",
+    "chart_repository": {
+        "chart_repository_id": "00000000-0000-0000-0000-000000000001"
+    }
+}
+');
+select results_eq(
+    $$ select version from snapshot order by version $$,
+    $$ values ('1.0.0'), ('1.1.0') $$,
+    'Package version snapshot should have been deleted'
+);
+select results_eq(
+    $$ select latest_version, display_name, description, keywords from package $$,
+    $$ values ('1.1.0', 'Package 1', 'description', '{kw1}'::text[]) $$,
+    'Package should have been refreshed from the new latest version'
+);
+select results_eq(
+    $$
+        select m.email
+        from package__maintainer pm
+        join maintainer m using (maintainer_id)
+    $$,
+    $$ values ('email1') $$,
+    'Package maintainers should have been refreshed from the new latest version'
+);
+select results_eq(
+    $$ select email from maintainer $$,
+    $$ values ('email1') $$,
+    'Orphan maintainers should have been deleted'
+);
+
+-- Unregister remaining versions
+select unregister_package('
+{
+    "name": "package1",
+    "version": "1.0.0",
+    "chart_repository": {
+        "chart_repository_id": "00000000-0000-0000-0000-000000000001"
+    }
+}
+');
+select unregister_package('
+{
+    "name": "package1",
+    "version": "1.1.0",
+    "chart_repository": {
+        "chart_repository_id": "00000000-0000-0000-0000-000000000001"
+    }
+}
+');
+select is_empty(
+    $$ select * from snapshot $$,
+    'All package snapshots should have been deleted'
+);
+select is_empty(
+    $$ select * from package $$,
+    'Package without versions should have been deleted'
+);
+select is_empty(
+    $$ select * from maintainer $$,
+    'Maintainers of the deleted package should have been deleted'
+);
+select results_eq(
+    $$
+        select event_kind_id, payload->'package'->>'version', payload->'package'->>'digest'
+        from webhook_delivery
+        order by payload->'package'->>'version'
+    $$,
+    $$
+        values
+        (1, '1.0.0', 'digest-1.0.0'),
+        (1, '1.1.0', 'digest-1.1.0'),
+        (1, '2.0.0', 'digest-2.0.0')
+    $$,
+    'Webhook deliveries should have been added for the versions removed'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -1,17 +1,21 @@
 -- Start transaction and plan tests
 begin;
-select plan(1);
+select plan(8);
 
 -- Declare some variables
 \set user1ID '00000000-0000-0000-0000-000000000001'
 \set repo1ID '00000000-0000-0000-0000-000000000001'
 \set repo2ID '00000000-0000-0000-0000-000000000002'
+\set repo3ID '00000000-0000-0000-0000-000000000003'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set user3ID '00000000-0000-0000-0000-000000000003'
+\set org1ID '00000000-0000-0000-0000-000000000001'
 
 -- Seed user and chart repository
 insert into "user" (user_id, alias, email)
 values (:'user1ID', 'user1', 'user1@email.com');
-insert into chart_repository (chart_repository_id, name, display_name, url, user_id)
-values (:'repo1ID', 'repo1', 'Repo 1', 'https://repo1.com', :'user1ID');
+insert into chart_repository (chart_repository_id, name, display_name, url, chart_repository_kind_id, user_id)
+values (:'repo1ID', 'repo1', 'Repo 1', 'https://repo1.com', 1, :'user1ID');
 insert into chart_repository (chart_repository_id, name, display_name, url)
 values (:'repo2ID', 'repo2', 'Repo 2', 'https://repo2.com');
 
@@ -20,21 +24,133 @@ select update_chart_repository('
 {
     "name": "repo1",
     "display_name": "Repo 1 updated",
+    "kind": 1,
     "url": "https://repo1.com/updated",
     "user_id": "00000000-0000-0000-0000-000000000001"
 }
-'::jsonb);
+'::jsonb, '{}');
 
 -- Check the chart repository was updated as expected
 select results_eq(
-    'select name, display_name, url from chart_repository order by name asc',
+    'select name, display_name, url, chart_repository_kind_id from chart_repository order by name asc',
     $$ values
-        ('repo1', 'Repo 1 updated', 'https://repo1.com/updated'),
-        ('repo2', 'Repo 2', 'https://repo2.com')
+        ('repo1', 'Repo 1 updated', 'https://repo1.com/updated', 1),
+        ('repo2', 'Repo 2', 'https://repo2.com', 0)
     $$,
     'Chart repository should have been updated'
 );
 
+-- Seed organization with an admin and a publisher owning another repository
+insert into "user" (user_id, alias, email)
+values (:'user2ID', 'user2', 'user2@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user3ID', 'user3', 'user3@email.com');
+insert into organization (organization_id, name)
+values (:'org1ID', 'org1');
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user2ID', :'org1ID', 1);
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user3ID', :'org1ID', 2);
+insert into chart_repository (chart_repository_id, name, display_name, url, organization_id, verified_publisher)
+values (:'repo3ID', 'repo3', 'Repo 3', 'https://repo3.com', :'org1ID', true);
+
+-- Try updating a chart repository that does not exist
+select throws_ok(
+    $$
+        select update_chart_repository('{
+            "name": "repo4",
+            "url": "https://repo4.com",
+            "user_id": "00000000-0000-0000-0000-000000000001"
+        }'::jsonb, '{}')
+    $$,
+    'P0002',
+    null,
+    'Non existing chart repositories cannot be updated'
+);
+
+-- Try updating the organization chart repository as a non member and as a
+-- publisher
+select throws_ok(
+    $$
+        select update_chart_repository('{
+            "name": "repo3",
+            "url": "https://repo3.com/updated",
+            "user_id": "00000000-0000-0000-0000-000000000001"
+        }'::jsonb, '{}')
+    $$,
+    42501,
+    null,
+    'Non members cannot update the organization chart repository'
+);
+select throws_ok(
+    $$
+        select update_chart_repository('{
+            "name": "repo3",
+            "url": "https://repo3.com/updated",
+            "user_id": "00000000-0000-0000-0000-000000000003"
+        }'::jsonb, '{}')
+    $$,
+    42501,
+    null,
+    'Publishers cannot update the organization chart repository'
+);
+
+-- Update the organization chart repository as an admin, keeping its url
+select update_chart_repository('
+{
+    "name": "repo3",
+    "display_name": "Repo 3",
+    "kind": 0,
+    "url": "https://repo3.com",
+    "user_id": "00000000-0000-0000-0000-000000000002"
+}
+'::jsonb, '{}');
+select results_eq(
+    $$ select verified_publisher from chart_repository where name = 'repo3' $$,
+    $$ values (true) $$,
+    'Verified publisher flag should be kept when the url does not change'
+);
+delete from audit_event;
+
+-- Update the organization chart repository as an admin
+select update_chart_repository('
+{
+    "name": "repo3",
+    "display_name": "Repo 3 updated",
+    "kind": 0,
+    "url": "https://repo3.com/updated",
+    "user_id": "00000000-0000-0000-0000-000000000002"
+}
+'::jsonb, '{}');
+select results_eq(
+    $$ select display_name, url from chart_repository where name = 'repo3' $$,
+    $$ values ('Repo 3 updated', 'https://repo3.com/updated') $$,
+    'Organization chart repository should have been updated by its admin'
+);
+select results_eq(
+    $$ select verified_publisher from chart_repository where name = 'repo3' $$,
+    $$ values (false) $$,
+    'Verified publisher flag should be reset when the url changes'
+);
+select results_eq(
+    $$
+        select user_id, action, target, chart_repository_id, organization_id, details
+        from audit_event
+        where target = 'repo3'
+    $$,
+    $$
+        values (
+            '00000000-0000-0000-0000-000000000002'::uuid,
+            'update-chart-repository',
+            'repo3',
+            '00000000-0000-0000-0000-000000000003'::uuid,
+            '00000000-0000-0000-0000-000000000001'::uuid,
+            '{"url": "https://repo3.com/updated"}'::jsonb
+        )
+    $$,
+    'Organization chart repository update should be registered in the audit log'
+);
+
 -- Finish tests and rollback transaction
 select * from finish();
 rollback;
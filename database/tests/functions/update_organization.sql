@@ -0,0 +1,65 @@
+-- Start transaction and plan tests
+begin;
+select plan(4);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set user3ID '00000000-0000-0000-0000-000000000003'
+\set org1ID '00000000-0000-0000-0000-000000000001'
+
+-- Seed users and organization
+insert into "user" (user_id, alias, email)
+values (:'user1ID', 'user1', 'user1@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user2ID', 'user2', 'user2@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user3ID', 'user3', 'user3@email.com');
+insert into organization (organization_id, name, display_name)
+values (:'org1ID', 'org1', 'Organization 1');
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user1ID', :'org1ID', 1);
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user3ID', :'org1ID', 2);
+
+-- Try updating an organization that does not exist
+select throws_ok(
+    $$ select update_organization('00000000-0000-0000-0000-000000000001', '{"name": "org2"}'::jsonb) $$,
+    'P0002',
+    null,
+    'Non existing organizations cannot be updated'
+);
+
+-- Try updating the organization as a user who is not a member
+select throws_ok(
+    $$ select update_organization('00000000-0000-0000-0000-000000000002', '{"name": "org1"}'::jsonb) $$,
+    42501,
+    null,
+    'Organization should not be updated by a non member'
+);
+
+-- Try updating the organization as a publisher
+select throws_ok(
+    $$ select update_organization('00000000-0000-0000-0000-000000000003', '{"name": "org1"}'::jsonb) $$,
+    42501,
+    null,
+    'Organization should not be updated by a publisher'
+);
+
+-- Update the organization as an admin
+select update_organization(:'user1ID', '
+{
+    "name": "org1",
+    "display_name": "Organization 1 updated",
+    "description": "Description updated"
+}
+'::jsonb);
+select results_eq(
+    $$ select display_name, description from organization where name = 'org1' $$,
+    $$ values ('Organization 1 updated', 'Description updated') $$,
+    'Organization should have been updated by an admin'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -0,0 +1,92 @@
+-- Start transaction and plan tests
+begin;
+select plan(6);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set user3ID '00000000-0000-0000-0000-000000000003'
+\set org1ID '00000000-0000-0000-0000-000000000001'
+
+-- Seed users and organization
+insert into "user" (user_id, alias, email)
+values (:'user1ID', 'user1', 'user1@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user2ID', 'user2', 'user2@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user3ID', 'user3', 'user3@email.com');
+insert into organization (organization_id, name)
+values (:'org1ID', 'org1');
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user1ID', :'org1ID', 0);
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user2ID', :'org1ID', 3);
+
+-- Try updating a member role as a user who is not a member
+select throws_ok(
+    $$ select update_organization_member_role('00000000-0000-0000-0000-000000000003', 'org1', 'user2', 'admin', '{}') $$,
+    42501,
+    null,
+    'Non members cannot update members roles'
+);
+
+-- Try updating a member role as an admin
+update user__organization set organization_role_id = 1 where user_id = :'user2ID';
+select throws_ok(
+    $$ select update_organization_member_role('00000000-0000-0000-0000-000000000002', 'org1', 'user2', 'owner', '{}') $$,
+    42501,
+    null,
+    'Admins cannot update members roles'
+);
+update user__organization set organization_role_id = 3 where user_id = :'user2ID';
+
+-- Update a member role as an owner
+select update_organization_member_role(:'user1ID', 'org1', 'user2', 'admin', '{}');
+select results_eq(
+    $$ select user_id, organization_role_id from user__organization order by user_id asc $$,
+    $$ values
+        ('00000000-0000-0000-0000-000000000001'::uuid, 0),
+        ('00000000-0000-0000-0000-000000000002'::uuid, 1)
+    $$,
+    'Owners can update members roles'
+);
+select results_eq(
+    $$
+        select user_id, action, target, organization_id, details
+        from audit_event
+    $$,
+    $$
+        values (
+            '00000000-0000-0000-0000-000000000001'::uuid,
+            'update-organization-member-role',
+            'user2',
+            '00000000-0000-0000-0000-000000000001'::uuid,
+            '{"role": "admin"}'::jsonb
+        )
+    $$,
+    'Member role update should be registered in the audit log'
+);
+
+-- Try removing the owner role from the last owner
+select throws_ok(
+    $$ select update_organization_member_role('00000000-0000-0000-0000-000000000001', 'org1', 'user1', 'admin', '{}') $$,
+    23514,
+    'organization must have at least one owner',
+    'The last owner of the organization cannot lose the owner role'
+);
+
+-- Owners can hand over the organization once there is another owner
+select update_organization_member_role(:'user1ID', 'org1', 'user2', 'owner', '{}');
+select update_organization_member_role(:'user1ID', 'org1', 'user1', 'viewer', '{}');
+select results_eq(
+    $$ select user_id, organization_role_id from user__organization order by user_id asc $$,
+    $$ values
+        ('00000000-0000-0000-0000-000000000001'::uuid, 3),
+        ('00000000-0000-0000-0000-000000000002'::uuid, 0)
+    $$,
+    'Owner role can be removed when the organization has other owners'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -0,0 +1,46 @@
+-- Start transaction and plan tests
+begin;
+select plan(4);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+
+-- Seed some data
+insert into "user" (user_id, alias, email, email_verified)
+values (:'user1ID', 'user1', 'user1@email.com', true);
+insert into "user" (user_id, alias, email, email_verified)
+values (:'user2ID', 'user2', 'user2@email.com', true);
+
+-- Request email change
+select update_user_email(:'user1ID', 'user1-new@email.com') as code \gset
+select results_eq(
+    $$ select email_verification_code_id, user_id, email from email_verification_code $$,
+    $$ values (:'code'::uuid, :'user1ID'::uuid, 'user1-new@email.com') $$,
+    'Email verification code should have been registered with the new email'
+);
+select results_eq(
+    $$ select email, email_verified from "user" where alias = 'user1' $$,
+    $$ values ('user1@email.com', true) $$,
+    'User email should not change until the new one is verified'
+);
+
+-- Request another email change
+select update_user_email(:'user1ID', 'user1-other@email.com') as code2 \gset
+select results_eq(
+    $$ select email_verification_code_id, email from email_verification_code $$,
+    $$ values (:'code2'::uuid, 'user1-other@email.com') $$,
+    'Previous email verification code should have been replaced'
+);
+
+-- Try using an email that belongs to another user
+select throws_ok(
+    $$ select update_user_email('00000000-0000-0000-0000-000000000001', 'user2@email.com') $$,
+    23505,
+    'unique_violation',
+    'Email already used by another user should not be accepted'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -0,0 +1,51 @@
+-- Start transaction and plan tests
+begin;
+select plan(4);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set session1ID 'session1'
+\set session2ID 'session2'
+\set session3ID 'session3'
+
+-- Seed some data
+insert into "user" (user_id, alias, email, email_verified, password)
+values (:'user1ID', 'user1', 'user1@email.com', true, 'password');
+insert into "user" (user_id, alias, email, email_verified, password)
+values (:'user2ID', 'user2', 'user2@email.com', true, 'password');
+insert into session (session_id, user_id) values (:'session1ID', :'user1ID');
+insert into session (session_id, user_id) values (:'session2ID', :'user1ID');
+insert into session (session_id, user_id) values (:'session3ID', :'user2ID');
+insert into api_key (user_id, name, key_hash) values (:'user1ID', 'key1', 'hash1');
+insert into api_key (user_id, name, key_hash) values (:'user2ID', 'key2', 'hash2');
+
+-- Update password
+select update_user_password(:'user1ID', 'new-password', :'session1ID');
+select results_eq(
+    $$ select password from "user" order by alias $$,
+    $$ values ('new-password'), ('password') $$,
+    'User password should have been updated'
+);
+select results_eq(
+    $$ select session_id from session order by session_id $$,
+    $$ values ('session1'::bytea), ('session3'::bytea) $$,
+    'Other sessions of the user should have been deleted'
+);
+select results_eq(
+    $$ select key_hash from api_key $$,
+    $$ values ('hash2') $$,
+    'User api keys should have been revoked'
+);
+
+-- Update password without a current session
+select update_user_password(:'user1ID', 'another-password', null);
+select results_eq(
+    $$ select session_id from session $$,
+    $$ values ('session3'::bytea) $$,
+    'All sessions of the user should have been deleted'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -0,0 +1,61 @@
+-- Start transaction and plan tests
+begin;
+select plan(7);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set user3ID '00000000-0000-0000-0000-000000000003'
+\set org1ID '00000000-0000-0000-0000-000000000001'
+\set repo1ID '00000000-0000-0000-0000-000000000001'
+\set repo2ID '00000000-0000-0000-0000-000000000002'
+
+-- Seed some data
+insert into "user" (user_id, alias, email)
+values (:'user1ID', 'user1', 'user1@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user2ID', 'user2', 'user2@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user3ID', 'user3', 'user3@email.com');
+insert into organization (organization_id, name)
+values (:'org1ID', 'org1');
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user2ID', :'org1ID', 2);
+insert into chart_repository (chart_repository_id, name, url, user_id)
+values (:'repo1ID', 'repo1', 'https://repo1.com', :'user1ID');
+insert into chart_repository (chart_repository_id, name, url, organization_id)
+values (:'repo2ID', 'repo2', 'https://repo2.com', :'org1ID');
+
+-- Run some tests
+select ok(
+    not user_has_chart_repository_role(:'user1ID', '00000000-0000-0000-0000-000000000003', 'viewer'),
+    'Users should not have any role in non existing repositories'
+);
+select ok(
+    user_has_chart_repository_role(:'user1ID', :'repo1ID', 'owner'),
+    'User owning the repository should be its owner'
+);
+select ok(
+    not user_has_chart_repository_role(:'user2ID', :'repo1ID', 'viewer'),
+    'Users should not have any role in other users repositories'
+);
+select ok(
+    user_has_chart_repository_role(:'user2ID', :'repo2ID', 'publisher'),
+    'Role of the member in the organization owning the repository should be used'
+);
+select ok(
+    user_has_chart_repository_role(:'user2ID', :'repo2ID', 'viewer'),
+    'Publishers should have the privileges of viewers'
+);
+select ok(
+    not user_has_chart_repository_role(:'user2ID', :'repo2ID', 'admin'),
+    'Publishers should not have the admin role'
+);
+select ok(
+    not user_has_chart_repository_role(:'user3ID', :'repo2ID', 'viewer'),
+    'Non members of the organization should not have any role'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -0,0 +1,71 @@
+-- Start transaction and plan tests
+begin;
+select plan(8);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set user3ID '00000000-0000-0000-0000-000000000003'
+\set user4ID '00000000-0000-0000-0000-000000000004'
+\set org1ID '00000000-0000-0000-0000-000000000001'
+\set org2ID '00000000-0000-0000-0000-000000000002'
+
+-- Seed some data
+insert into "user" (user_id, alias, email)
+values (:'user1ID', 'user1', 'user1@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user2ID', 'user2', 'user2@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user3ID', 'user3', 'user3@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user4ID', 'user4', 'user4@email.com');
+insert into organization (organization_id, name)
+values (:'org1ID', 'org1');
+insert into organization (organization_id, name)
+values (:'org2ID', 'org2');
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user1ID', :'org1ID', 0);
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user2ID', :'org1ID', 1);
+insert into user__organization (user_id, organization_id, organization_role_id)
+values (:'user3ID', :'org1ID', 3);
+insert into organization_invitation (organization_id, email)
+values (:'org1ID', 'user4@email.com');
+
+-- Run some tests
+select ok(
+    user_has_organization_role(:'user1ID', :'org1ID', 'owner'),
+    'Owners should have the owner role'
+);
+select ok(
+    user_has_organization_role(:'user1ID', :'org1ID', 'viewer'),
+    'Owners should have the privileges of the other roles'
+);
+select ok(
+    not user_has_organization_role(:'user2ID', :'org1ID', 'owner'),
+    'Admins should not have the owner role'
+);
+select ok(
+    user_has_organization_role(:'user2ID', :'org1ID', 'admin'),
+    'Admins should have the admin role'
+);
+select ok(
+    user_has_organization_role(:'user2ID', :'org1ID', 'publisher'),
+    'Admins should have the privileges of publishers'
+);
+select ok(
+    not user_has_organization_role(:'user3ID', :'org1ID', 'publisher'),
+    'Viewers should not have the publisher role'
+);
+select ok(
+    not user_has_organization_role(:'user1ID', :'org2ID', 'viewer'),
+    'Members should not have any role in other organizations'
+);
+select ok(
+    not user_has_organization_role(:'user4ID', :'org1ID', 'viewer'),
+    'Users with a pending invitation should not have any role'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
@@ -0,0 +1,46 @@
+-- Start transaction and plan tests
+begin;
+select plan(4);
+
+-- Declare some variables
+\set user1ID '00000000-0000-0000-0000-000000000001'
+\set user2ID '00000000-0000-0000-0000-000000000002'
+\set org1ID '00000000-0000-0000-0000-000000000001'
+\set repo1ID '00000000-0000-0000-0000-000000000001'
+\set repo2ID '00000000-0000-0000-0000-000000000002'
+
+-- Seed some data
+insert into "user" (user_id, alias, email)
+values (:'user1ID', 'user1', 'user1@email.com');
+insert into "user" (user_id, alias, email)
+values (:'user2ID', 'user2', 'user2@email.com');
+insert into organization (organization_id, name)
+values (:'org1ID', 'org1');
+insert into user__organization (user_id, organization_id)
+values (:'user2ID', :'org1ID');
+insert into chart_repository (chart_repository_id, name, url, user_id)
+values (:'repo1ID', 'repo1', 'https://repo1.com', :'user1ID');
+insert into chart_repository (chart_repository_id, name, url, organization_id)
+values (:'repo2ID', 'repo2', 'https://repo2.com', :'org1ID');
+
+-- Run some tests
+select ok(
+    user_owns_chart_repository(:'user1ID', :'repo1ID'),
+    'User owning the repository should own it'
+);
+select ok(
+    not user_owns_chart_repository(:'user2ID', :'repo1ID'),
+    'User not owning the repository should not own it'
+);
+select ok(
+    user_owns_chart_repository(:'user2ID', :'repo2ID'),
+    'Members of the organization owning the repository should own it'
+);
+select ok(
+    not user_owns_chart_repository(:'user1ID', :'repo2ID'),
+    'Non members of the organization owning the repository should not own it'
+);
+
+-- Finish tests and rollback transaction
+select * from finish();
+rollback;
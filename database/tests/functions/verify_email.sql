@@ -1,6 +1,6 @@
 -- Start transaction and plan tests
 begin;
-select plan(6);
+select plan(9);
 
 -- Register user
 select register_user('
@@ -64,6 +64,29 @@ select is(
     'Email verification should not succeed as code is expired'
 );
 
+-- Request an email change for the first user
+select user_id as user_id from "user" where alias = 'alias' \gset
+select update_user_email(:'user_id', 'email-new') as code3 \gset
+select is(
+    verify_email(:'code3'),
+    true,
+    'Email change should be verified successfully'
+);
+select results_eq(
+    $$ select email, email_verified from "user" where alias = 'alias' $$,
+    $$ values ('email-new', true) $$,
+    'User email should have been updated'
+);
+
+-- Request an email change to an email taken after the code was created
+select update_user_email(:'user_id', 'email3') as code4 \gset
+update "user" set email = 'email3' where alias = 'alias2';
+select is(
+    verify_email(:'code4'),
+    false,
+    'Email change should not succeed as the new email is not available'
+);
+
 -- Finish tests and rollback transaction
 select * from finish();
 rollback;
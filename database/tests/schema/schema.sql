@@ -1,6 +1,6 @@
 -- Start transaction and plan tests
 begin;
-select plan(44);
+select plan(145);
 
 -- Check default_text_search_config is correct
 select results_eq(
@@ -14,24 +14,61 @@ select has_extension('pgcrypto');
 
 -- Check expected tables exist
 select tables_are(array[
+    'api_key',
+    'audit_event',
     'chart_repository',
+    'chart_repository_kind',
+    'chart_repository_transfer',
     'email_verification_code',
+    'event',
+    'event_kind',
     'image',
     'image_version',
     'maintainer',
+    'notification',
+    'operator_provider',
     'organization',
+    'organization_invitation',
+    'organization_role',
     'package',
     'package__maintainer',
     'package_kind',
+    'password_reset_code',
     'session',
     'snapshot',
+    'subscription',
+    'tracking_request',
+    'tracking_run',
     'user',
     'user__organization',
+    'user_identity',
     'version_functions',
-    'version_schema'
+    'version_schema',
+    'webhook',
+    'webhook_delivery',
+    'webhook_delivery_attempt'
 ]);
 
 -- Check tables have expected columns
+select columns_are('api_key', array[
+    'api_key_id',
+    'user_id',
+    'name',
+    'key_hash',
+    'created_at'
+]);
+select columns_are('audit_event', array[
+    'audit_event_id',
+    'user_id',
+    'action',
+    'target',
+    'chart_repository_id',
+    'organization_id',
+    'ip',
+    'user_agent',
+    'details',
+    'created_at'
+]);
 select columns_are('chart_repository', array[
     'chart_repository_id',
     'name',
@@ -40,12 +77,48 @@ select columns_are('chart_repository', array[
     'last_tracking_ts',
     'last_tracking_errors',
     'user_id',
-    'organization_id'
+    'organization_id',
+    'chart_repository_kind_id',
+    'git_branch',
+    'git_path_glob',
+    'webhook_secret',
+    'verification_token',
+    'verified_publisher',
+    'verification_failures'
+]);
+select columns_are('chart_repository_kind', array[
+    'chart_repository_kind_id',
+    'name'
+]);
+select columns_are('chart_repository_transfer', array[
+    'chart_repository_transfer_id',
+    'chart_repository_id',
+    'from_user_id',
+    'from_organization_id',
+    'to_organization_id',
+    'requested_by',
+    'accepted_by',
+    'created_at',
+    'accepted_at'
 ]);
 select columns_are('email_verification_code', array[
     'email_verification_code_id',
     'user_id',
-    'created_at'
+    'created_at',
+    'email'
+]);
+select columns_are('event', array[
+    'event_id',
+    'package_id',
+    'package_version',
+    'event_kind_id',
+    'created_at',
+    'processed',
+    'processed_at'
+]);
+select columns_are('event_kind', array[
+    'event_kind_id',
+    'name'
 ]);
 select columns_are('image', array[
     'image_id',
@@ -61,6 +134,21 @@ select columns_are('maintainer', array[
     'name',
     'email'
 ]);
+select columns_are('notification', array[
+    'notification_id',
+    'event_id',
+    'user_id',
+    'created_at',
+    'processed',
+    'processed_at',
+    'attempts',
+    'last_attempt_at',
+    'error'
+]);
+select columns_are('operator_provider', array[
+    'operator_provider_id',
+    'name'
+]);
 select columns_are('organization', array[
     'organization_id',
     'name',
@@ -68,7 +156,20 @@ select columns_are('organization', array[
     'home_url',
     'logo_url',
     'logo_image_id',
-    'created_at'
+    'created_at',
+    'display_name'
+]);
+select columns_are('organization_invitation', array[
+    'organization_invitation_id',
+    'organization_id',
+    'email',
+    'invited_by',
+    'created_at',
+    'expires_at'
+]);
+select columns_are('organization_role', array[
+    'organization_role_id',
+    'name'
 ]);
 select columns_are('package', array[
     'package_id',
@@ -84,7 +185,8 @@ select columns_are('package', array[
     'updated_at',
     'tsdoc',
     'package_kind_id',
-    'chart_repository_id'
+    'chart_repository_id',
+    'operator_provider_id'
 ]);
 select columns_are('package__maintainer', array[
     'package_id',
@@ -94,12 +196,19 @@ select columns_are('package_kind', array[
     'package_kind_id',
     'name'
 ]);
+select columns_are('password_reset_code', array[
+    'password_reset_code_id',
+    'user_id',
+    'created_at'
+]);
 select columns_are('session', array[
     'session_id',
     'user_id',
     'ip',
     'user_agent',
-    'created_at'
+    'created_at',
+    'public_id',
+    'last_seen_at'
 ]);
 select columns_are('snapshot', array[
     'package_id',
@@ -107,7 +216,40 @@ select columns_are('snapshot', array[
     'app_version',
     'digest',
     'readme',
-    'links'
+    'links',
+    'display_name',
+    'description',
+    'home_url',
+    'logo_url',
+    'logo_image_id',
+    'keywords',
+    'maintainers'
+]);
+select columns_are('subscription', array[
+    'user_id',
+    'package_id',
+    'event_kind_id'
+]);
+select columns_are('tracking_request', array[
+    'tracking_request_id',
+    'chart_repository_id',
+    'status',
+    'created_at',
+    'started_at',
+    'completed_at',
+    'chart_name',
+    'chart_version'
+]);
+select columns_are('tracking_run', array[
+    'tracking_run_id',
+    'chart_repository_id',
+    'started_at',
+    'finished_at',
+    'versions_processed',
+    'versions_registered',
+    'versions_failed',
+    'errors',
+    'suppressed_errors'
 ]);
 select columns_are('user', array[
     'user_id',
@@ -117,11 +259,20 @@ select columns_are('user', array[
     'email',
     'email_verified',
     'password',
-    'created_at'
+    'created_at',
+    'tracking_errors_notifications'
 ]);
 select columns_are('user__organization', array[
     'user_id',
-    'organization_id'
+    'organization_id',
+    'organization_role_id'
+]);
+select columns_are('user_identity', array[
+    'user_identity_id',
+    'user_id',
+    'provider',
+    'provider_user_id',
+    'created_at'
 ]);
 select columns_are('version_functions', array[
     'version'
@@ -129,21 +280,87 @@ select columns_are('version_functions', array[
 select columns_are('version_schema', array[
     'version'
 ]);
+select columns_are('webhook', array[
+    'webhook_id',
+    'chart_repository_id',
+    'url',
+    'secret',
+    'event_kinds',
+    'created_at'
+]);
+select columns_are('webhook_delivery', array[
+    'webhook_delivery_id',
+    'webhook_id',
+    'event_kind_id',
+    'payload',
+    'created_at',
+    'processed',
+    'processed_at',
+    'attempts',
+    'last_attempt_at'
+]);
+select columns_are('webhook_delivery_attempt', array[
+    'webhook_delivery_attempt_id',
+    'webhook_delivery_id',
+    'created_at',
+    'status_code',
+    'error'
+]);
 
 -- Check tables have expected indexes
+select indexes_are('api_key', array[
+    'api_key_pkey',
+    'api_key_key_hash_key',
+    'api_key_user_id_name_key',
+    'api_key_user_id_idx'
+]);
+select indexes_are('audit_event', array[
+    'audit_event_pkey',
+    'audit_event_user_id_idx',
+    'audit_event_chart_repository_id_idx',
+    'audit_event_organization_id_idx',
+    'audit_event_created_at_idx'
+]);
 select indexes_are('chart_repository', array[
     'chart_repository_pkey',
     'chart_repository_name_key',
     'chart_repository_url_key'
 ]);
+select indexes_are('chart_repository_kind', array[
+    'chart_repository_kind_pkey'
+]);
+select indexes_are('chart_repository_transfer', array[
+    'chart_repository_transfer_pkey',
+    'chart_repository_transfer_pending_idx',
+    'chart_repository_transfer_to_organization_id_idx'
+]);
+select indexes_are('event', array[
+    'event_pkey',
+    'event_not_processed_idx'
+]);
 select indexes_are('maintainer', array[
     'maintainer_pkey',
     'maintainer_email_key'
 ]);
+select indexes_are('notification', array[
+    'notification_pkey',
+    'notification_event_id_user_id_key',
+    'notification_not_processed_idx'
+]);
+select indexes_are('organization_invitation', array[
+    'organization_invitation_pkey',
+    'organization_invitation_organization_id_email_key',
+    'organization_invitation_email_idx'
+]);
+select indexes_are('organization_role', array[
+    'organization_role_pkey'
+]);
 select indexes_are('package', array[
     'package_pkey',
     'package_chart_repository_id_name_key',
+    'package_operator_provider_id_name_key',
     'package_chart_repository_id_idx',
+    'package_operator_provider_id_idx',
     'package_package_kind_id_idx',
     'package_tsdoc_idx',
     'package_created_at_idx',
@@ -155,9 +372,53 @@ select indexes_are('package__maintainer', array[
 select indexes_are('package_kind', array[
     'package_kind_pkey'
 ]);
+select indexes_are('password_reset_code', array[
+    'password_reset_code_pkey',
+    'password_reset_code_user_id_key'
+]);
+select indexes_are('session', array[
+    'session_pkey',
+    'session_public_id_key',
+    'session_user_id_idx',
+    'session_last_seen_at_idx'
+]);
 select indexes_are('snapshot', array[
     'snapshot_pkey'
 ]);
+select indexes_are('subscription', array[
+    'subscription_pkey',
+    'subscription_package_id_event_kind_id_idx'
+]);
+select indexes_are('tracking_request', array[
+    'tracking_request_pkey',
+    'tracking_request_chart_repository_id_idx',
+    'tracking_request_status_idx'
+]);
+select indexes_are('tracking_run', array[
+    'tracking_run_pkey',
+    'tracking_run_chart_repository_id_started_at_idx'
+]);
+select indexes_are('user__organization', array[
+    'user__organization_pkey'
+]);
+select indexes_are('user_identity', array[
+    'user_identity_pkey',
+    'user_identity_provider_provider_user_id_key',
+    'user_identity_user_id_idx'
+]);
+select indexes_are('webhook', array[
+    'webhook_pkey',
+    'webhook_chart_repository_id_idx'
+]);
+select indexes_are('webhook_delivery', array[
+    'webhook_delivery_pkey',
+    'webhook_delivery_webhook_id_created_at_idx',
+    'webhook_delivery_not_processed_idx'
+]);
+select indexes_are('webhook_delivery_attempt', array[
+    'webhook_delivery_attempt_pkey',
+    'webhook_delivery_attempt_webhook_delivery_id_idx'
+]);
 
 -- Check expected functions exist
 select has_function('generate_package_tsdoc');
@@ -167,26 +428,109 @@ select has_function('update_chart_repository');
 select has_function('delete_chart_repository');
 select has_function('get_chart_repositories');
 select has_function('get_chart_repositories_by_user');
+select has_function('get_chart_repositories_by_org');
 select has_function('get_chart_repository_by_name');
 select has_function('get_chart_repository_packages_digest');
+select has_function('get_operator_provider_packages_digest');
 select has_function('get_packages_stats');
 select has_function('get_packages_updates');
 select has_function('get_package');
 select has_function('register_package');
+select has_function('unregister_package');
 select has_function('search_packages');
 select has_function('get_image');
 select has_function('register_image');
 select has_function('register_user');
 select has_function('verify_email');
+select has_function('regenerate_email_verification_code');
+select has_function('update_user_email');
+select has_function('update_user_password');
+select has_function('delete_session');
+select has_function('delete_user_session');
+select has_function('delete_user_sessions');
+select has_function('request_password_reset_code');
+select has_function('reset_password');
 select has_function('register_session');
+select has_function('get_user_sessions');
+select has_function('register_user_identity');
+select has_function('add_api_key');
+select has_function('get_user_api_keys');
+select has_function('delete_api_key');
+select has_function('add_organization');
+select has_function('update_organization');
+select has_function('delete_organization');
+select has_function('get_user_organizations');
+select has_function('get_organization_members');
+select has_function('add_organization_member');
+select has_function('delete_organization_member');
+select has_function('organization_keeps_owner');
+select has_function('update_organization_member_role');
+select has_function('user_has_organization_role');
+select has_function('add_organization_invitation');
+select has_function('get_user_organization_invitations');
+select has_function('accept_organization_invitation');
+select has_function('decline_organization_invitation');
+select has_function('user_owns_chart_repository');
+select has_function('user_has_chart_repository_role');
+select has_function('transfer_chart_repository');
+select has_function('accept_chart_repository_transfer');
+select has_function('cancel_chart_repository_transfer');
+select has_function('decline_chart_repository_transfer');
+select has_function('get_organization_chart_repository_transfers');
+select has_function('add_tracking_request');
+select has_function('get_tracking_request');
+select has_function('claim_tracking_requests');
+select has_function('rotate_chart_repository_webhook_secret');
+select has_function('delete_chart_repository_webhook_secret');
+select has_function('generate_chart_repository_verification_token');
+select has_function('add_chart_version_tracking_request');
+select has_function('register_tracking_run');
+select has_function('get_tracking_runs');
+select has_function('get_chart_repository_tracking_errors');
+select has_function('get_tracking_errors_notification_recipients');
+select has_function('add_subscription');
+select has_function('delete_subscription');
+select has_function('get_user_subscriptions');
+select has_function('generate_notifications');
+select has_function('claim_notifications');
+select has_function('add_webhook');
+select has_function('add_webhook_deliveries');
+select has_function('delete_webhook');
+select has_function('get_chart_repository_webhooks');
+select has_function('get_webhook_deliveries');
+select has_function('claim_webhook_deliveries');
+select has_function('register_webhook_delivery_attempt');
+select has_function('add_audit_event');
+select has_function('get_audit_events');
 
 -- Check package kinds exist
 select results_eq(
     'select * from package_kind',
-    $$ values (0, 'chart') $$,
+    $$ values (0, 'chart'), (1, 'operator') $$,
     'Package kinds should exist'
 );
 
+-- Check chart repository kinds exist
+select results_eq(
+    'select * from chart_repository_kind',
+    $$ values (0, 'helm'), (1, 'git') $$,
+    'Chart repository kinds should exist'
+);
+
+-- Check event kinds exist
+select results_eq(
+    'select * from event_kind',
+    $$ values (0, 'new-release'), (1, 'version-removed') $$,
+    'Event kinds should exist'
+);
+
+-- Check organization roles exist
+select results_eq(
+    'select * from organization_role',
+    $$ values (0, 'owner'), (1, 'admin'), (2, 'publisher'), (3, 'viewer') $$,
+    'Organization roles should exist'
+);
+
 -- Finish tests and rollback transaction
 select * from finish();
 rollback;
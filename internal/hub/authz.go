@@ -0,0 +1,35 @@
+package hub
+
+// OrganizationRole represents the role of a user in an organization. The
+// user owning a chart repository has the owner role in it. Roles include the
+// privileges of the less privileged ones, and are enforced by the database
+// functions that perform the actions that require them.
+type OrganizationRole string
+
+const (
+	// OwnerRole represents the role of the users who own an organization.
+	// Owners can perform any action, including managing the members.
+	OwnerRole OrganizationRole = "owner"
+
+	// AdminRole represents the role of the users who administer an
+	// organization and its chart repositories.
+	AdminRole OrganizationRole = "admin"
+
+	// PublisherRole represents the role of the users who publish packages
+	// in the organization chart repositories, being able to track them.
+	PublisherRole OrganizationRole = "publisher"
+
+	// ViewerRole represents the role of the users who can only view the
+	// organization and its chart repositories.
+	ViewerRole OrganizationRole = "viewer"
+)
+
+// IsValid checks if the role is one of the supported ones.
+func (r OrganizationRole) IsValid() bool {
+	switch r {
+	case OwnerRole, AdminRole, PublisherRole, ViewerRole:
+		return true
+	default:
+		return false
+	}
+}
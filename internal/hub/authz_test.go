@@ -0,0 +1,14 @@
+package hub
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrganizationRoleIsValid(t *testing.T) {
+	assert.True(t, OwnerRole.IsValid())
+	assert.True(t, ViewerRole.IsValid())
+	assert.False(t, OrganizationRole("").IsValid())
+	assert.False(t, OrganizationRole("superuser").IsValid())
+}
@@ -0,0 +1,18 @@
+package hub
+
+var emailChangeTmpl = newEmailTemplate(`
+{{ define "title" }}Email change requested{{ end }}
+{{ define "preheader" }}Your CNCF Hub email is being changed{{ end }}
+{{ define "content" }}
+                        <p style="font-family: sans-serif; font-size: 14px; font-weight: normal; margin: 0; Margin-bottom: 15px;">Hi!</p>
+                        <p style="font-family: sans-serif; font-size: 14px; font-weight: normal; margin: 0; Margin-bottom: 15px;">We received a request to change the email address of your CNCF Hub account to <b>{{ .newEmail }}</b>. The change will take effect once the new address has been verified.</p>
+                        <p style="font-family: sans-serif; font-size: 14px; font-weight: normal; margin: 0; Margin-bottom: 15px;">If you didn't request this change, please <a href="{{ .link }}" target="_blank" style="display: inline-block; color: #659DBD; cursor: pointer; text-decoration: none; font-size: 14px; font-weight: bold; margin: 0;">login to CNCF Hub</a>, update your password and log out from all your sessions.</p>
+{{ end }}
+{{ define "footer" }}
+                <tr>
+                  <td class="content-block powered-by" style="font-family: sans-serif; vertical-align: top; padding-bottom: 10px; padding-top: 10px; font-size: 10px; color: #545454; text-align: center;">
+                    <p style="color: #545454; font-size: 10px; text-align: center; text-decoration: none;">This email has been sent to the current address of your account.</p>
+                  </td>
+                </tr>
+{{ end }}
+`)
@@ -3,9 +3,13 @@ package hub
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/cncf/hub/internal/email"
@@ -14,6 +18,24 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	// apiKeyLength represents the number of random bytes used to generate
+	// api keys.
+	apiKeyLength = 32
+
+	// sessionLastSeenUpdateInterval represents how often the last time a
+	// session was seen is updated while it's being used.
+	sessionLastSeenUpdateInterval = 5 * time.Minute
+
+	// maxNotifiedTrackingErrors represents the maximum number of tracking
+	// errors listed in a notification email.
+	maxNotifiedTrackingErrors = 25
+
+	// noDataFoundErrCode represents the code of the error raised by the
+	// database functions when the resource they operate on does not exist.
+	noDataFoundErrCode = "P0002"
+)
+
 // DB defines the methods the database handler must provide.
 type DB interface {
 	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
@@ -59,6 +81,18 @@ func (h *Hub) GetChartRepositoryPackagesDigest(
 	return pd, err
 }
 
+// GetOperatorProviderPackagesDigest returns the digests for all packages that
+// belong to the operator provider identified by the name provided.
+func (h *Hub) GetOperatorProviderPackagesDigest(
+	ctx context.Context,
+	operatorProviderName string,
+) (map[string]string, error) {
+	pd := make(map[string]string)
+	query := "select get_operator_provider_packages_digest($1::text)"
+	err := h.dbQueryUnmarshal(ctx, &pd, query, operatorProviderName)
+	return pd, err
+}
+
 // GetChartRepositories returns all available chart repositories.
 func (h *Hub) GetChartRepositories(ctx context.Context) ([]*ChartRepository, error) {
 	var r []*ChartRepository
@@ -73,23 +107,96 @@ func (h *Hub) GetChartRepositoriesByUserJSON(ctx context.Context) ([]byte, error
 	return h.dbQueryJSON(ctx, "select get_chart_repositories_by_user($1)", userID)
 }
 
-// AddChartRepository adds the provided chart repository to the database.
-func (h *Hub) AddChartRepository(ctx context.Context, r *ChartRepository) error {
+// GetOrganizationChartRepositoriesJSON returns all chart repositories that
+// belong to the organization provided. The user making the request must be a
+// member of the organization.
+func (h *Hub) GetOrganizationChartRepositoriesJSON(ctx context.Context, orgName string) ([]byte, error) {
+	userID := ctx.Value(UserIDKey).(string)
+	query := "select get_chart_repositories_by_org($1::uuid, $2::text)"
+	return h.dbQueryJSON(ctx, query, userID, orgName)
+}
+
+// AddChartRepository adds the provided chart repository to the database. When
+// an organization name is provided the repository will belong to it (the user
+// making the request must be an admin of it), otherwise it will belong to the
+// user making the request.
+func (h *Hub) AddChartRepository(ctx context.Context, orgName string, r *ChartRepository) error {
 	r.UserID = ctx.Value(UserIDKey).(string)
-	return h.dbExec(ctx, "select add_chart_repository($1::jsonb)", r)
+	r.OrganizationName = orgName
+	rJSON, _ := json.Marshal(r)
+	query := "select add_chart_repository($1::jsonb, $2::jsonb)"
+	_, err := h.db.Exec(ctx, query, rJSON, requestInfoJSON(ctx))
+	return checkNoDataFound(err)
 }
 
 // UpdateChartRepository updates the provided chart repository in the database.
 func (h *Hub) UpdateChartRepository(ctx context.Context, r *ChartRepository) error {
 	r.UserID = ctx.Value(UserIDKey).(string)
-	return h.dbExec(ctx, "select update_chart_repository($1::jsonb)", r)
+	rJSON, _ := json.Marshal(r)
+	query := "select update_chart_repository($1::jsonb, $2::jsonb)"
+	_, err := h.db.Exec(ctx, query, rJSON, requestInfoJSON(ctx))
+	return checkNoDataFound(err)
 }
 
 // DeleteChartRepository deletes the provided chart repository from the
 // database.
 func (h *Hub) DeleteChartRepository(ctx context.Context, r *ChartRepository) error {
 	r.UserID = ctx.Value(UserIDKey).(string)
-	return h.dbExec(ctx, "select delete_chart_repository($1::jsonb)", r)
+	rJSON, _ := json.Marshal(r)
+	query := "select delete_chart_repository($1::jsonb, $2::jsonb)"
+	_, err := h.db.Exec(ctx, query, rJSON, requestInfoJSON(ctx))
+	return checkNoDataFound(err)
+}
+
+// TransferChartRepository requests the transfer of the chart repository
+// provided to the organization given. The transfer will be completed once an
+// admin of the destination organization accepts it. A pgx.ErrNoRows error is
+// returned when the repository or the organization do not exist.
+func (h *Hub) TransferChartRepository(ctx context.Context, repoName, orgName string) error {
+	userID := ctx.Value(UserIDKey).(string)
+	var transferID string
+	query := "select transfer_chart_repository($1::uuid, $2::text, $3::text, $4::jsonb)"
+	return h.db.QueryRow(ctx, query, userID, repoName, orgName, requestInfoJSON(ctx)).Scan(&transferID)
+}
+
+// AcceptChartRepositoryTransfer completes the pending transfer of the chart
+// repository provided, moving it to the destination organization. A
+// pgx.ErrNoRows error is returned when there is no pending transfer for the
+// repository.
+func (h *Hub) AcceptChartRepositoryTransfer(ctx context.Context, repoName string) error {
+	userID := ctx.Value(UserIDKey).(string)
+	query := "select accept_chart_repository_transfer($1::uuid, $2::text, $3::jsonb)"
+	_, err := h.db.Exec(ctx, query, userID, repoName, requestInfoJSON(ctx))
+	return checkNoDataFound(err)
+}
+
+// CancelChartRepositoryTransfer cancels the pending transfer of the chart
+// repository provided. A pgx.ErrNoRows error is returned when there is no
+// pending transfer for the repository.
+func (h *Hub) CancelChartRepositoryTransfer(ctx context.Context, repoName string) error {
+	userID := ctx.Value(UserIDKey).(string)
+	query := "select cancel_chart_repository_transfer($1::uuid, $2::text, $3::jsonb)"
+	_, err := h.db.Exec(ctx, query, userID, repoName, requestInfoJSON(ctx))
+	return checkNoDataFound(err)
+}
+
+// DeclineChartRepositoryTransfer declines the pending transfer of the chart
+// repository provided. A pgx.ErrNoRows error is returned when there is no
+// pending transfer for the repository.
+func (h *Hub) DeclineChartRepositoryTransfer(ctx context.Context, repoName string) error {
+	userID := ctx.Value(UserIDKey).(string)
+	query := "select decline_chart_repository_transfer($1::uuid, $2::text, $3::jsonb)"
+	_, err := h.db.Exec(ctx, query, userID, repoName, requestInfoJSON(ctx))
+	return checkNoDataFound(err)
+}
+
+// GetOrganizationChartRepositoryTransfersJSON returns the pending transfers of
+// chart repositories to the organization provided. The json object is built
+// by the database.
+func (h *Hub) GetOrganizationChartRepositoryTransfersJSON(ctx context.Context, orgName string) ([]byte, error) {
+	userID := ctx.Value(UserIDKey).(string)
+	query := "select get_organization_chart_repository_transfers($1::uuid, $2::text)"
+	return h.dbQueryJSON(ctx, query, userID, orgName)
 }
 
 // SetChartRepositoryLastTrackingTs updates the timestamp of the last tracking
@@ -102,13 +209,479 @@ func (h *Hub) SetChartRepositoryLastTrackingTs(ctx context.Context, chartReposit
 	return err
 }
 
+// GenerateChartRepositoryVerificationToken generates a new verification token
+// for the chart repository provided, replacing the existing one if any. The
+// repository will be flagged as a verified publisher once the token is found
+// in its metadata file.
+func (h *Hub) GenerateChartRepositoryVerificationToken(ctx context.Context, repoName string) (string, error) {
+	userID := ctx.Value(UserIDKey).(string)
+	query := "select generate_chart_repository_verification_token($1::uuid, $2::text)"
+	var token string
+	err := h.db.QueryRow(ctx, query, userID, repoName).Scan(&token)
+	return token, err
+}
+
+// SetChartRepositoryVerifiedPublisher updates the verified publisher flag of
+// the provided repository in the database, resetting the number of failures
+// checking it.
+func (h *Hub) SetChartRepositoryVerifiedPublisher(
+	ctx context.Context,
+	chartRepositoryID string,
+	verified bool,
+) error {
+	query := `
+	update chart_repository set verified_publisher = $2, verification_failures = 0
+	where chart_repository_id = $1`
+	_, err := h.db.Exec(ctx, query, chartRepositoryID, verified)
+	return err
+}
+
+// RegisterChartRepositoryVerificationFailure registers a failure checking the
+// verified publisher status of the provided repository. The repository loses
+// its verified publisher flag once the maximum number of consecutive failures
+// provided is reached.
+func (h *Hub) RegisterChartRepositoryVerificationFailure(
+	ctx context.Context,
+	chartRepositoryID string,
+	maxFailures int,
+) error {
+	query := `
+	update chart_repository set
+		verification_failures = verification_failures + 1,
+		verified_publisher = verified_publisher and verification_failures + 1 < $2
+	where chart_repository_id = $1`
+	_, err := h.db.Exec(ctx, query, chartRepositoryID, maxFailures)
+	return err
+}
+
 // SetChartRepositoryLastTrackingErrors updates the errors that happened during
-// the last tracking of the provided repository in the database.
-func (h *Hub) SetChartRepositoryLastTrackingErrors(ctx context.Context, chartRepositoryID, errs string) error {
+// the last tracking of the provided repository in the database. When no errors
+// are provided, the existing ones are cleared.
+func (h *Hub) SetChartRepositoryLastTrackingErrors(
+	ctx context.Context,
+	chartRepositoryID string,
+	errs *TrackingErrors,
+) error {
+	var errsJSON []byte
+	if errs != nil {
+		var err error
+		errsJSON, err = json.Marshal(errs)
+		if err != nil {
+			return err
+		}
+	}
 	query := `
 	update chart_repository set last_tracking_errors = $2
 	where chart_repository_id = $1`
-	_, err := h.db.Exec(ctx, query, chartRepositoryID, errs)
+	_, err := h.db.Exec(ctx, query, chartRepositoryID, errsJSON)
+	return err
+}
+
+// GetChartRepositoryTrackingErrorsJSON returns the errors that happened during
+// the last tracking of the provided repository as a json object. When some
+// kinds are provided, only errors of those kinds are returned. The json object
+// is built by the database.
+func (h *Hub) GetChartRepositoryTrackingErrorsJSON(
+	ctx context.Context,
+	repoName string,
+	kinds []string,
+) ([]byte, error) {
+	userID := ctx.Value(UserIDKey).(string)
+	query := "select get_chart_repository_tracking_errors($1::uuid, $2::text, $3::text[])"
+	return h.dbQueryJSON(ctx, query, userID, repoName, kinds)
+}
+
+// GetChartRepositoryLastTrackingErrors returns the errors that happened during
+// the last tracking of the provided repository, if any.
+func (h *Hub) GetChartRepositoryLastTrackingErrors(
+	ctx context.Context,
+	chartRepositoryID string,
+) (*TrackingErrors, error) {
+	var errsJSON []byte
+	query := `
+	select last_tracking_errors from chart_repository
+	where chart_repository_id = $1`
+	if err := h.db.QueryRow(ctx, query, chartRepositoryID).Scan(&errsJSON); err != nil {
+		return nil, err
+	}
+	if len(errsJSON) == 0 {
+		return nil, nil
+	}
+	var errs *TrackingErrors
+	if err := json.Unmarshal(errsJSON, &errs); err != nil {
+		return nil, err
+	}
+	return errs, nil
+}
+
+// NotifyTrackingErrors sends an email listing the tracking errors provided to
+// the users owning the given chart repository that have not opted out of this
+// kind of notifications.
+func (h *Hub) NotifyTrackingErrors(ctx context.Context, r *ChartRepository, errs []*TrackingError) error {
+	if h.es == nil || len(errs) == 0 {
+		return nil
+	}
+
+	// Get notification recipients
+	var recipients []string
+	query := "select get_tracking_errors_notification_recipients($1::uuid)"
+	if err := h.dbQueryUnmarshal(ctx, &recipients, query, r.ChartRepositoryID); err != nil {
+		return err
+	}
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	// Prepare email body, including up to maxNotifiedTrackingErrors errors
+	templateData := map[string]interface{}{
+		"repoName": r.Name,
+		"errors":   errs,
+	}
+	if len(errs) > maxNotifiedTrackingErrors {
+		templateData["errors"] = errs[:maxNotifiedTrackingErrors]
+		templateData["more"] = len(errs) - maxNotifiedTrackingErrors
+	}
+	var emailBody bytes.Buffer
+	if err := trackingErrorsTmpl.Execute(&emailBody, templateData); err != nil {
+		return err
+	}
+
+	// Send email to each of the recipients, even if sending it to some of
+	// them fails
+	var sendErrs []string
+	for _, to := range recipients {
+		emailData := &email.Data{
+			To:      to,
+			Subject: fmt.Sprintf("New errors tracking %s", r.Name),
+			Body:    emailBody.Bytes(),
+		}
+		if err := h.es.SendEmail(emailData); err != nil {
+			sendErrs = append(sendErrs, err.Error())
+		}
+	}
+	if len(sendErrs) > 0 {
+		return fmt.Errorf("error sending %d of %d notifications: %s",
+			len(sendErrs), len(recipients), strings.Join(sendErrs, "; "))
+	}
+
+	return nil
+}
+
+// AddOrganization adds the provided organization to the database. The user
+// making the request will be added as a member of the organization.
+func (h *Hub) AddOrganization(ctx context.Context, org *Organization) error {
+	userID := ctx.Value(UserIDKey).(string)
+	orgJSON, _ := json.Marshal(org)
+	query := "select add_organization($1::uuid, $2::jsonb)"
+	_, err := h.db.Exec(ctx, query, userID, orgJSON)
+	return err
+}
+
+// UpdateOrganization updates the provided organization in the database.
+func (h *Hub) UpdateOrganization(ctx context.Context, org *Organization) error {
+	userID := ctx.Value(UserIDKey).(string)
+	orgJSON, _ := json.Marshal(org)
+	query := "select update_organization($1::uuid, $2::jsonb)"
+	_, err := h.db.Exec(ctx, query, userID, orgJSON)
+	return checkNoDataFound(err)
+}
+
+// DeleteOrganization deletes the organization identified by the name provided
+// from the database.
+func (h *Hub) DeleteOrganization(ctx context.Context, orgName string) error {
+	userID := ctx.Value(UserIDKey).(string)
+	query := "select delete_organization($1::uuid, $2::text)"
+	_, err := h.db.Exec(ctx, query, userID, orgName)
+	return checkNoDataFound(err)
+}
+
+// GetUserOrganizationsJSON returns all the organizations the user making the
+// request belongs to. The json object is built by the database.
+func (h *Hub) GetUserOrganizationsJSON(ctx context.Context) ([]byte, error) {
+	userID := ctx.Value(UserIDKey).(string)
+	return h.dbQueryJSON(ctx, "select get_user_organizations($1::uuid)", userID)
+}
+
+// GetOrganizationMembersJSON returns the members of the organization provided.
+// The json object is built by the database.
+func (h *Hub) GetOrganizationMembersJSON(ctx context.Context, orgName string) ([]byte, error) {
+	userID := ctx.Value(UserIDKey).(string)
+	query := "select get_organization_members($1::uuid, $2::text)"
+	return h.dbQueryJSON(ctx, query, userID, orgName)
+}
+
+// AddOrganizationMember adds the user identified by the alias provided as a
+// member of the organization, with the viewer role.
+func (h *Hub) AddOrganizationMember(ctx context.Context, orgName, userAlias string) error {
+	userID := ctx.Value(UserIDKey).(string)
+	query := "select add_organization_member($1::uuid, $2::text, $3::text, $4::jsonb)"
+	_, err := h.db.Exec(ctx, query, userID, orgName, userAlias, requestInfoJSON(ctx))
+	return checkNoDataFound(err)
+}
+
+// DeleteOrganizationMember removes the user identified by the alias provided
+// from the organization.
+func (h *Hub) DeleteOrganizationMember(ctx context.Context, orgName, userAlias string) error {
+	userID := ctx.Value(UserIDKey).(string)
+	query := "select delete_organization_member($1::uuid, $2::text, $3::text, $4::jsonb)"
+	_, err := h.db.Exec(ctx, query, userID, orgName, userAlias, requestInfoJSON(ctx))
+	return checkNoDataFound(err)
+}
+
+// AddOrganizationInvitation invites the email provided to join the
+// organization, with the viewer role, sending it a link to accept the
+// invitation. The email does not need to belong to a registered user. When it
+// already belongs to a member of the organization nothing is sent, but no
+// error is returned either so that the existence of an account is not
+// revealed.
+func (h *Hub) AddOrganizationInvitation(ctx context.Context, orgName, userEmail, baseURL string) error {
+	// Register invitation
+	userID := ctx.Value(UserIDKey).(string)
+	var token string
+	query := "select add_organization_invitation($1::uuid, $2::text, $3::text, $4::jsonb)"
+	err := h.db.QueryRow(ctx, query, userID, orgName, userEmail, requestInfoJSON(ctx)).Scan(&token)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return checkNoDataFound(err)
+	}
+
+	// Send invitation email
+	if h.es != nil {
+		templateData := map[string]string{
+			"orgName": orgName,
+			"link":    fmt.Sprintf("%s/acceptInvitation?token=%s", baseURL, token),
+		}
+		var emailBody bytes.Buffer
+		if err := organizationInvitationTmpl.Execute(&emailBody, templateData); err != nil {
+			return err
+		}
+		emailData := &email.Data{
+			To:      userEmail,
+			Subject: fmt.Sprintf("Invitation to join %s on CNCF Hub", orgName),
+			Body:    emailBody.Bytes(),
+		}
+		return h.es.SendEmail(emailData)
+	}
+	return nil
+}
+
+// GetUserOrganizationInvitations returns the pending invitations to join
+// organizations sent to the email of the user doing the request, including
+// the tokens needed to accept or decline them.
+func (h *Hub) GetUserOrganizationInvitations(ctx context.Context) ([]*OrganizationInvitation, error) {
+	userID := ctx.Value(UserIDKey).(string)
+	var invitations []*OrganizationInvitation
+	query := "select get_user_organization_invitations($1::uuid)"
+	err := h.dbQueryUnmarshal(ctx, &invitations, query, userID)
+	return invitations, err
+}
+
+// AcceptOrganizationInvitation accepts the organization invitation identified
+// by the token provided, which must have been sent to the email of the user
+// doing the request. It returns false when there is no valid invitation to
+// accept.
+func (h *Hub) AcceptOrganizationInvitation(ctx context.Context, token string) (bool, error) {
+	userID := ctx.Value(UserIDKey).(string)
+	var accepted bool
+	query := "select accept_organization_invitation($1::uuid, $2::uuid, $3::jsonb)"
+	err := h.db.QueryRow(ctx, query, userID, token, requestInfoJSON(ctx)).Scan(&accepted)
+	return accepted, err
+}
+
+// DeclineOrganizationInvitation declines the organization invitation
+// identified by the token provided, which must have been sent to the email of
+// the user doing the request. It returns false when there is no pending
+// invitation to decline.
+func (h *Hub) DeclineOrganizationInvitation(ctx context.Context, token string) (bool, error) {
+	userID := ctx.Value(UserIDKey).(string)
+	var declined bool
+	query := "select decline_organization_invitation($1::uuid, $2::uuid, $3::jsonb)"
+	err := h.db.QueryRow(ctx, query, userID, token, requestInfoJSON(ctx)).Scan(&declined)
+	return declined, err
+}
+
+// UpdateOrganizationMemberRole updates the role of the user identified by the
+// alias provided in the organization.
+func (h *Hub) UpdateOrganizationMemberRole(
+	ctx context.Context,
+	orgName,
+	userAlias string,
+	role OrganizationRole,
+) error {
+	userID := ctx.Value(UserIDKey).(string)
+	query := "select update_organization_member_role($1::uuid, $2::text, $3::text, $4::text, $5::jsonb)"
+	_, err := h.db.Exec(ctx, query, userID, orgName, userAlias, string(role), requestInfoJSON(ctx))
+	return checkNoDataFound(err)
+}
+
+// AddTrackingRequestJSON registers a request to track the chart repository
+// provided as soon as possible. The tracking request is returned as a json
+// object, built by the database.
+func (h *Hub) AddTrackingRequestJSON(ctx context.Context, repoName string) ([]byte, error) {
+	userID := ctx.Value(UserIDKey).(string)
+	query := "select add_tracking_request($1::uuid, $2::text)"
+	return h.dbQueryJSON(ctx, query, userID, repoName)
+}
+
+// GetTrackingRequestJSON returns the tracking request identified by the id
+// provided as a json object. The json object is built by the database.
+func (h *Hub) GetTrackingRequestJSON(ctx context.Context, repoName, trackingRequestID string) ([]byte, error) {
+	userID := ctx.Value(UserIDKey).(string)
+	query := "select get_tracking_request($1::uuid, $2::text, $3::uuid)"
+	return h.dbQueryJSON(ctx, query, userID, repoName, trackingRequestID)
+}
+
+// AddChartVersionTrackingRequestJSON registers a request to process the chart
+// version provided from the given repository as soon as possible. The
+// tracking request is returned as a json object, built by the database.
+func (h *Hub) AddChartVersionTrackingRequestJSON(
+	ctx context.Context,
+	repoName,
+	chartName,
+	chartVersion string,
+) ([]byte, error) {
+	query := "select add_chart_version_tracking_request($1::text, $2::text, $3::text)"
+	return h.dbQueryJSON(ctx, query, repoName, chartName, chartVersion)
+}
+
+// ClaimTrackingRequests returns the pending tracking requests, marking them as
+// being processed.
+func (h *Hub) ClaimTrackingRequests(ctx context.Context) ([]*TrackingRequest, error) {
+	var requests []*TrackingRequest
+	err := h.dbQueryUnmarshal(ctx, &requests, "select claim_tracking_requests()")
+	return requests, err
+}
+
+// CompleteTrackingRequest marks the tracking request provided as completed or
+// failed, depending on the status provided.
+func (h *Hub) CompleteTrackingRequest(ctx context.Context, trackingRequestID, status string) error {
+	if status != TrackingRequestCompleted && status != TrackingRequestFailed {
+		return fmt.Errorf("invalid tracking request status: %s", status)
+	}
+	query := `
+	update tracking_request set status = $2, completed_at = current_timestamp
+	where tracking_request_id = $1`
+	_, err := h.db.Exec(ctx, query, trackingRequestID, status)
+	return err
+}
+
+// RegisterTrackingRun registers the chart repository tracking run provided in
+// the database.
+func (h *Hub) RegisterTrackingRun(ctx context.Context, run *TrackingRun) error {
+	return h.dbExec(ctx, "select register_tracking_run($1::jsonb)", run)
+}
+
+// GetTrackingRunsJSON returns the tracking runs of the chart repository
+// provided as a json array, most recent first. The json array is built by the
+// database.
+func (h *Hub) GetTrackingRunsJSON(ctx context.Context, repoName string, limit, offset int) ([]byte, error) {
+	userID := ctx.Value(UserIDKey).(string)
+	query := "select get_tracking_runs($1::uuid, $2::text, $3::int, $4::int)"
+	return h.dbQueryJSON(ctx, query, userID, repoName, limit, offset)
+}
+
+// RotateChartRepositoryWebhookSecret generates a new webhook secret for the
+// chart repository provided, replacing the existing one if any. The new secret
+// is returned, as it won't be exposed again.
+func (h *Hub) RotateChartRepositoryWebhookSecret(ctx context.Context, repoName string) (string, error) {
+	userID := ctx.Value(UserIDKey).(string)
+	query := "select rotate_chart_repository_webhook_secret($1::uuid, $2::text, $3::jsonb)"
+	var secret string
+	err := h.db.QueryRow(ctx, query, userID, repoName, requestInfoJSON(ctx)).Scan(&secret)
+	return secret, err
+}
+
+// DeleteChartRepositoryWebhookSecret deletes the webhook secret of the chart
+// repository provided, disabling its webhook.
+func (h *Hub) DeleteChartRepositoryWebhookSecret(ctx context.Context, repoName string) error {
+	userID := ctx.Value(UserIDKey).(string)
+	query := "select delete_chart_repository_webhook_secret($1::uuid, $2::text, $3::jsonb)"
+	_, err := h.db.Exec(ctx, query, userID, repoName, requestInfoJSON(ctx))
+	return checkNoDataFound(err)
+}
+
+// GetChartRepositoryWebhookSecret returns the webhook secret of the chart
+// repository provided. An empty secret is returned when the repository has
+// no webhook secret.
+func (h *Hub) GetChartRepositoryWebhookSecret(ctx context.Context, repoName string) (string, error) {
+	query := "select coalesce(webhook_secret, '') from chart_repository where name = $1"
+	var secret string
+	err := h.db.QueryRow(ctx, query, repoName).Scan(&secret)
+	return secret, err
+}
+
+// AddWebhook adds the provided webhook to the chart repository given,
+// returning the id of the webhook added.
+func (h *Hub) AddWebhook(ctx context.Context, repoName string, wh *Webhook) (string, error) {
+	userID := ctx.Value(UserIDKey).(string)
+	whJSON, _ := json.Marshal(wh)
+	query := "select add_webhook($1::uuid, $2::text, $3::jsonb, $4::jsonb)"
+	var webhookID string
+	err := h.db.QueryRow(ctx, query, userID, repoName, whJSON, requestInfoJSON(ctx)).Scan(&webhookID)
+	return webhookID, err
+}
+
+// DeleteWebhook deletes the webhook provided from the chart repository given.
+func (h *Hub) DeleteWebhook(ctx context.Context, repoName, webhookID string) error {
+	userID := ctx.Value(UserIDKey).(string)
+	var deletedWebhookID string
+	query := "select delete_webhook($1::uuid, $2::text, $3::uuid, $4::jsonb)"
+	return h.db.QueryRow(ctx, query, userID, repoName, webhookID, requestInfoJSON(ctx)).Scan(&deletedWebhookID)
+}
+
+// GetChartRepositoryWebhooksJSON returns the webhooks of the chart repository
+// provided as a json array. The json object is built by the database.
+func (h *Hub) GetChartRepositoryWebhooksJSON(ctx context.Context, repoName string) ([]byte, error) {
+	userID := ctx.Value(UserIDKey).(string)
+	query := "select get_chart_repository_webhooks($1::uuid, $2::text)"
+	return h.dbQueryJSON(ctx, query, userID, repoName)
+}
+
+// GetWebhookDeliveriesJSON returns the deliveries of the webhook provided as a
+// json array, most recent first. The json object is built by the database.
+func (h *Hub) GetWebhookDeliveriesJSON(
+	ctx context.Context,
+	repoName,
+	webhookID string,
+	limit,
+	offset int,
+) ([]byte, error) {
+	userID := ctx.Value(UserIDKey).(string)
+	query := "select get_webhook_deliveries($1::uuid, $2::text, $3::uuid, $4::int, $5::int)"
+	return h.dbQueryJSON(ctx, query, userID, repoName, webhookID, limit, offset)
+}
+
+// ClaimWebhookDeliveries returns up to limit webhook deliveries pending to be
+// delivered. Deliveries claimed won't be claimed again until some time has
+// passed, and only while they have been attempted less than maxAttempts times.
+func (h *Hub) ClaimWebhookDeliveries(ctx context.Context, limit, maxAttempts int) ([]*WebhookDelivery, error) {
+	var deliveries []*WebhookDelivery
+	query := "select claim_webhook_deliveries($1::int, $2::int)"
+	err := h.dbQueryUnmarshal(ctx, &deliveries, query, limit, maxAttempts)
+	return deliveries, err
+}
+
+// RegisterWebhookDeliveryAttempt registers an attempt to deliver the webhook
+// delivery provided, including the status code received from the remote
+// endpoint or the error that prevented getting one. Deliveries are marked as
+// delivered when a 2xx status code is received.
+func (h *Hub) RegisterWebhookDeliveryAttempt(
+	ctx context.Context,
+	webhookDeliveryID string,
+	statusCode int,
+	deliveryErr error,
+) error {
+	var statusCodeArg, errArg interface{}
+	if statusCode != 0 {
+		statusCodeArg = statusCode
+	}
+	if deliveryErr != nil {
+		errArg = deliveryErr.Error()
+	}
+	query := "select register_webhook_delivery_attempt($1::uuid, $2::int, $3::text)"
+	_, err := h.db.Exec(ctx, query, webhookDeliveryID, statusCodeArg, errArg)
 	return err
 }
 
@@ -131,6 +704,12 @@ func (h *Hub) RegisterPackage(ctx context.Context, pkg *Package) error {
 	return h.dbExec(ctx, "select register_package($1::jsonb)", pkg)
 }
 
+// UnregisterPackage unregisters the package version provided from the
+// database. The package is deleted when it has no versions left.
+func (h *Hub) UnregisterPackage(ctx context.Context, pkg *Package) error {
+	return h.dbExec(ctx, "select unregister_package($1::jsonb)", pkg)
+}
+
 // GetPackageJSON returns the package identified by the input provided as a
 // json object. The json object is built by the database.
 func (h *Hub) GetPackageJSON(ctx context.Context, input *GetPackageInput) ([]byte, error) {
@@ -166,17 +745,84 @@ func (h *Hub) RegisterUser(ctx context.Context, user *User, baseURL string) erro
 	}
 
 	// Send email verification code
+	return h.sendEmailVerificationCode(user.Email, code, baseURL)
+}
+
+// sendEmailVerificationCode sends the email verification code provided to the
+// email address given, when an email sender is available.
+func (h *Hub) sendEmailVerificationCode(address, code, baseURL string) error {
+	if h.es == nil {
+		return nil
+	}
+	templateData := map[string]string{
+		"link": fmt.Sprintf("%s/verifyEmail?code=%s", baseURL, code),
+	}
+	var emailBody bytes.Buffer
+	if err := emailVerificationTmpl.Execute(&emailBody, templateData); err != nil {
+		return err
+	}
+	emailData := &email.Data{
+		To:      address,
+		Subject: "Verify your email address",
+		Body:    emailBody.Bytes(),
+	}
+	return h.es.SendEmail(emailData)
+}
+
+// VerifyEmail verifies a user's email using the email verification code
+// provided.
+func (h *Hub) VerifyEmail(ctx context.Context, code string) (bool, error) {
+	var verified bool
+	err := h.db.QueryRow(ctx, "select verify_email($1::uuid)", code).Scan(&verified)
+	return verified, err
+}
+
+// ResendEmailVerificationCode generates a new email verification code for the
+// user with the email provided and sends it to that address. Nothing is sent
+// when the email does not belong to a user pending verification or when a
+// code was sent too recently, but no error is returned either so that the
+// existence of an account is not revealed.
+func (h *Hub) ResendEmailVerificationCode(ctx context.Context, userEmail, baseURL string) error {
+	var code string
+	query := "select regenerate_email_verification_code($1::text)"
+	err := h.db.QueryRow(ctx, query, userEmail).Scan(&code)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	return h.sendEmailVerificationCode(userEmail, code, baseURL)
+}
+
+// RequestPasswordReset creates a password reset code for the user with the
+// email provided and sends it to that address. When the email does not belong
+// to a verified user nothing is sent, but no error is returned either so that
+// the existence of an account is not revealed.
+func (h *Hub) RequestPasswordReset(ctx context.Context, userEmail, baseURL string) error {
+	// Create password reset code
+	var code string
+	query := "select request_password_reset_code($1::text)"
+	err := h.db.QueryRow(ctx, query, userEmail).Scan(&code)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	// Send password reset code
 	if h.es != nil {
 		templateData := map[string]string{
-			"link": fmt.Sprintf("%s/verifyEmail?code=%s", baseURL, code),
+			"link": fmt.Sprintf("%s/resetPassword?code=%s", baseURL, code),
 		}
 		var emailBody bytes.Buffer
-		if err := emailVerificationTmpl.Execute(&emailBody, templateData); err != nil {
+		if err := passwordResetTmpl.Execute(&emailBody, templateData); err != nil {
 			return err
 		}
 		emailData := &email.Data{
-			To:      user.Email,
-			Subject: "Verify your email address",
+			To:      userEmail,
+			Subject: "Reset your password",
 			Body:    emailBody.Bytes(),
 		}
 		if err := h.es.SendEmail(emailData); err != nil {
@@ -187,20 +833,28 @@ func (h *Hub) RegisterUser(ctx context.Context, user *User, baseURL string) erro
 	return nil
 }
 
-// VerifyEmail verifies a user's email using the email verification code
-// provided.
-func (h *Hub) VerifyEmail(ctx context.Context, code string) (bool, error) {
-	var verified bool
-	err := h.db.QueryRow(ctx, "select verify_email($1::uuid)", code).Scan(&verified)
-	return verified, err
+// ResetPassword updates the password of the user the password reset code
+// provided belongs to. All the user's sessions are invalidated once the
+// password has been updated.
+func (h *Hub) ResetPassword(ctx context.Context, code, password string) (bool, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return false, err
+	}
+	var updated bool
+	query := "select reset_password($1::uuid, $2::text)"
+	err = h.db.QueryRow(ctx, query, code, string(hashedPassword)).Scan(&updated)
+	return updated, err
 }
 
-// CheckCredentials checks if the credentials provided are valid.
+// CheckCredentials checks if the credentials provided are valid. When they
+// are, the output also reports if the user's email has been verified.
 func (h *Hub) CheckCredentials(ctx context.Context, email, password string) (*CheckCredentialsOutput, error) {
 	// Get password for email provided from database
 	var userID, hashedPassword string
-	query := `select user_id, password from "user" where email = $1`
-	err := h.db.QueryRow(ctx, query, email).Scan(&userID, &hashedPassword)
+	var emailVerified bool
+	query := `select user_id, coalesce(password, ''), email_verified from "user" where email = $1`
+	err := h.db.QueryRow(ctx, query, email).Scan(&userID, &hashedPassword, &emailVerified)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return &CheckCredentialsOutput{Valid: false}, nil
@@ -215,12 +869,24 @@ func (h *Hub) CheckCredentials(ctx context.Context, email, password string) (*Ch
 	}
 
 	return &CheckCredentialsOutput{
-		Valid:  true,
-		UserID: userID,
+		Valid:         true,
+		UserID:        userID,
+		EmailVerified: emailVerified,
 	}, err
 }
 
-// RegisterSession registers a user session in the database.
+// RegisterUserIdentity returns the id of the user linked to the external
+// identity provided, creating a new user when there isn't one already. The
+// identity's email must have been verified by the identity provider.
+func (h *Hub) RegisterUserIdentity(ctx context.Context, identity *UserIdentity) (string, error) {
+	identityJSON, _ := json.Marshal(identity)
+	var userID string
+	err := h.db.QueryRow(ctx, "select register_user_identity($1::jsonb)", identityJSON).Scan(&userID)
+	return userID, err
+}
+
+// RegisterSession registers a user session in the database. The login is
+// recorded in the audit log by the database.
 func (h *Hub) RegisterSession(ctx context.Context, session *Session) ([]byte, error) {
 	sessionJSON, _ := json.Marshal(session)
 	var sessionID []byte
@@ -228,16 +894,22 @@ func (h *Hub) RegisterSession(ctx context.Context, session *Session) ([]byte, er
 	return sessionID, err
 }
 
-// CheckSession checks if the user session provided is valid.
+// CheckSession checks if the user session provided is valid. Sessions expire
+// when they haven't been used for the duration provided, so the last time the
+// session was seen is updated as it's used. Sessions of users whose email has
+// not been verified are not valid.
 func (h *Hub) CheckSession(ctx context.Context, sessionID []byte, duration time.Duration) (*CheckSessionOutput, error) {
 	// Get session details from database
 	var userID string
-	var createdAt int64
+	var lastSeenAt int64
 	query := `
-	select user_id, floor(extract(epoch from created_at))
-	from session where session_id = $1
+	select s.user_id, floor(extract(epoch from s.last_seen_at))
+	from session s
+	join "user" u using (user_id)
+	where s.session_id = $1
+	and u.email_verified = true
 	`
-	err := h.db.QueryRow(ctx, query, sessionID).Scan(&userID, &createdAt)
+	err := h.db.QueryRow(ctx, query, sessionID).Scan(&userID, &lastSeenAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return &CheckSessionOutput{Valid: false}, nil
@@ -246,22 +918,168 @@ func (h *Hub) CheckSession(ctx context.Context, sessionID []byte, duration time.
 	}
 
 	// Check if the session has expired
-	if time.Unix(createdAt, 0).Add(duration).Before(time.Now()) {
+	if time.Unix(lastSeenAt, 0).Add(duration).Before(time.Now()) {
 		return &CheckSessionOutput{Valid: false}, nil
 	}
 
+	// Update the last time the session was seen when needed
+	if time.Since(time.Unix(lastSeenAt, 0)) > sessionLastSeenUpdateInterval {
+		query := "update session set last_seen_at = current_timestamp where session_id = $1"
+		if _, err := h.db.Exec(ctx, query, sessionID); err != nil {
+			return nil, err
+		}
+	}
+
 	return &CheckSessionOutput{
 		Valid:  true,
 		UserID: userID,
 	}, nil
 }
 
-// DeleteSession deletes a user session from the database.
+// DeleteSession deletes a user session from the database, recording the
+// logout in the audit log.
 func (h *Hub) DeleteSession(ctx context.Context, sessionID []byte) error {
-	_, err := h.db.Exec(ctx, "delete from session where session_id = $1", sessionID)
+	query := "select delete_session($1::bytea, $2::jsonb)"
+	_, err := h.db.Exec(ctx, query, sessionID, requestInfoJSON(ctx))
+	return err
+}
+
+// GetUserSessionsJSON returns the sessions of the user doing the request as a
+// json array. The session provided, if any, is flagged as the current one.
+// The json object is built by the database.
+func (h *Hub) GetUserSessionsJSON(ctx context.Context, currentSessionID []byte) ([]byte, error) {
+	userID := ctx.Value(UserIDKey).(string)
+	query := "select get_user_sessions($1::uuid, $2::bytea)"
+	return h.dbQueryJSON(ctx, query, userID, currentSessionID)
+}
+
+// DeleteUserSession deletes the session provided (identified by its public
+// id) of the user doing the request.
+func (h *Hub) DeleteUserSession(ctx context.Context, sessionPublicID string) error {
+	userID := ctx.Value(UserIDKey).(string)
+	query := "select delete_user_session($1::uuid, $2::uuid, $3::jsonb)"
+	_, err := h.db.Exec(ctx, query, userID, sessionPublicID, requestInfoJSON(ctx))
 	return err
 }
 
+// DeleteUserSessions deletes all the sessions of the user doing the request,
+// logging the user out everywhere. The user's api keys are revoked as well.
+func (h *Hub) DeleteUserSessions(ctx context.Context) error {
+	userID := ctx.Value(UserIDKey).(string)
+	query := "select delete_user_sessions($1::uuid, $2::jsonb)"
+	_, err := h.db.Exec(ctx, query, userID, requestInfoJSON(ctx))
+	return err
+}
+
+// DeleteExpiredSessions deletes the sessions that haven't been used for the
+// duration provided.
+func (h *Hub) DeleteExpiredSessions(ctx context.Context, duration time.Duration) error {
+	query := "delete from session where last_seen_at < $1"
+	_, err := h.db.Exec(ctx, query, time.Now().Add(-duration))
+	return err
+}
+
+// GetAuditEventsJSON returns the audit events visible to the user doing the
+// request as a json array, most recent first. Users can see the events they
+// triggered, as well as the ones registered for the organizations they belong
+// to. The ip and user agent of the events are only included for the events
+// triggered by the user and for the organizations the user administers. The
+// json object is built by the database.
+func (h *Hub) GetAuditEventsJSON(ctx context.Context, limit, offset int) ([]byte, error) {
+	userID := ctx.Value(UserIDKey).(string)
+	query := "select get_audit_events($1::uuid, $2::int, $3::int)"
+	return h.dbQueryJSON(ctx, query, userID, limit, offset)
+}
+
+// requestInfoJSON returns the request information available in the context
+// provided as json, so that it can be registered in the audit events.
+func requestInfoJSON(ctx context.Context) []byte {
+	ri, ok := ctx.Value(RequestInfoKey).(*RequestInfo)
+	if !ok {
+		ri = &RequestInfo{}
+	}
+	data, _ := json.Marshal(ri)
+	return data
+}
+
+// AddAPIKey adds a new api key with the name provided for the user doing the
+// request. The key generated is returned, as only its hash is stored.
+func (h *Hub) AddAPIKey(ctx context.Context, name string) (*AddAPIKeyOutput, error) {
+	userID := ctx.Value(UserIDKey).(string)
+
+	// Generate api key
+	randomBytes := make([]byte, apiKeyLength)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return nil, err
+	}
+	key := hex.EncodeToString(randomBytes)
+
+	// Register api key in database
+	ak := &APIKey{
+		Name:    name,
+		KeyHash: hashAPIKey(key),
+	}
+	akJSON, _ := json.Marshal(ak)
+	var apiKeyID string
+	query := "select add_api_key($1::uuid, $2::jsonb, $3::jsonb)"
+	err := h.db.QueryRow(ctx, query, userID, akJSON, requestInfoJSON(ctx)).Scan(&apiKeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AddAPIKeyOutput{
+		APIKeyID: apiKeyID,
+		Key:      key,
+	}, nil
+}
+
+// GetUserAPIKeysJSON returns the api keys of the user doing the request as a
+// json array. The json object is built by the database.
+func (h *Hub) GetUserAPIKeysJSON(ctx context.Context) ([]byte, error) {
+	userID := ctx.Value(UserIDKey).(string)
+	return h.dbQueryJSON(ctx, "select get_user_api_keys($1::uuid)", userID)
+}
+
+// DeleteAPIKey deletes the provided api key of the user doing the request. A
+// pgx.ErrNoRows error is returned when the user does not own such a key.
+func (h *Hub) DeleteAPIKey(ctx context.Context, apiKeyID string) error {
+	userID := ctx.Value(UserIDKey).(string)
+	query := "select delete_api_key($1::uuid, $2::uuid, $3::jsonb)"
+	_, err := h.db.Exec(ctx, query, userID, apiKeyID, requestInfoJSON(ctx))
+	return checkNoDataFound(err)
+}
+
+// CheckAPIKey checks if the api key provided is valid. Api keys of users whose
+// email has not been verified are not valid.
+func (h *Hub) CheckAPIKey(ctx context.Context, key string) (*CheckAPIKeyOutput, error) {
+	var userID string
+	query := `
+	select k.user_id
+	from api_key k
+	join "user" u using (user_id)
+	where k.key_hash = $1
+	and u.email_verified = true
+	`
+	err := h.db.QueryRow(ctx, query, hashAPIKey(key)).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &CheckAPIKeyOutput{Valid: false}, nil
+		}
+		return nil, err
+	}
+	return &CheckAPIKeyOutput{
+		Valid:  true,
+		UserID: userID,
+	}, nil
+}
+
+// hashAPIKey returns the hash of the api key provided as stored in the
+// database. Api keys are long random values, so a fast hash is enough.
+func hashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
 // GetUserAlias returns the alias of the user doing the request.
 func (h *Hub) GetUserAlias(ctx context.Context) (string, error) {
 	userID := ctx.Value(UserIDKey).(string)
@@ -270,6 +1088,257 @@ func (h *Hub) GetUserAlias(ctx context.Context) (string, error) {
 	return alias, err
 }
 
+// GetUserNotificationsSettingsJSON returns the notifications settings of the
+// user doing the request as a json object.
+func (h *Hub) GetUserNotificationsSettingsJSON(ctx context.Context) ([]byte, error) {
+	userID := ctx.Value(UserIDKey).(string)
+	query := `
+	select json_build_object('tracking_errors', tracking_errors_notifications)
+	from "user" where user_id = $1`
+	return h.dbQueryJSON(ctx, query, userID)
+}
+
+// UpdateUserNotificationsSettings updates the notifications settings of the
+// user doing the request.
+func (h *Hub) UpdateUserNotificationsSettings(ctx context.Context, s *NotificationsSettings) error {
+	userID := ctx.Value(UserIDKey).(string)
+	query := `
+	update "user" set tracking_errors_notifications = $2
+	where user_id = $1`
+	_, err := h.db.Exec(ctx, query, userID, s.TrackingErrors)
+	return err
+}
+
+// GetUserProfileJSON returns the profile of the user doing the request as a
+// json object.
+func (h *Hub) GetUserProfileJSON(ctx context.Context) ([]byte, error) {
+	userID := ctx.Value(UserIDKey).(string)
+	query := `
+	select json_build_object(
+		'alias', alias,
+		'first_name', first_name,
+		'last_name', last_name,
+		'email', email
+	)
+	from "user" where user_id = $1`
+	return h.dbQueryJSON(ctx, query, userID)
+}
+
+// UpdateUserProfile updates the alias, first name and last name of the user
+// doing the request.
+func (h *Hub) UpdateUserProfile(ctx context.Context, user *User) error {
+	userID := ctx.Value(UserIDKey).(string)
+	query := `
+	update "user" set
+		alias = $2,
+		first_name = nullif($3, ''),
+		last_name = nullif($4, '')
+	where user_id = $1`
+	_, err := h.db.Exec(ctx, query, userID, user.Alias, user.FirstName, user.LastName)
+	return err
+}
+
+// UpdateUserPassword updates the password of the user doing the request,
+// returning false if the old password provided is not valid. Users who don't
+// have a password yet (i.e. registered using an oauth provider) can set one
+// without providing the old password. All the user's sessions except the
+// current one provided, if any, are deleted.
+func (h *Hub) UpdateUserPassword(
+	ctx context.Context,
+	oldPassword,
+	newPassword string,
+	currentSessionID []byte,
+) (bool, error) {
+	userID := ctx.Value(UserIDKey).(string)
+
+	// Check the old password provided is valid
+	var hashedPassword string
+	query := `select coalesce(password, '') from "user" where user_id = $1`
+	if err := h.db.QueryRow(ctx, query, userID).Scan(&hashedPassword); err != nil {
+		return false, err
+	}
+	if hashedPassword != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(oldPassword)); err != nil {
+			return false, nil
+		}
+	}
+
+	// Update password
+	newHashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return false, err
+	}
+	query = "select update_user_password($1::uuid, $2::text, $3::bytea)"
+	_, err = h.db.Exec(ctx, query, userID, string(newHashedPassword), currentSessionID)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// UpdateUserEmail requests the email of the user doing the request to be
+// changed. An email verification code is sent to the new email address, which
+// will replace the current one once verified. The current email address is
+// notified about the change as well.
+func (h *Hub) UpdateUserEmail(ctx context.Context, newEmail, baseURL string) error {
+	userID := ctx.Value(UserIDKey).(string)
+
+	// Get current email
+	var currentEmail string
+	query := `select email from "user" where user_id = $1`
+	if err := h.db.QueryRow(ctx, query, userID).Scan(&currentEmail); err != nil {
+		return err
+	}
+
+	// Register email change request and send verification code
+	var code string
+	query = "select update_user_email($1::uuid, $2::text)"
+	if err := h.db.QueryRow(ctx, query, userID, newEmail).Scan(&code); err != nil {
+		return err
+	}
+	if err := h.sendEmailVerificationCode(newEmail, code, baseURL); err != nil {
+		return err
+	}
+
+	// Notify current email address
+	if h.es == nil {
+		return nil
+	}
+	templateData := map[string]string{
+		"newEmail": newEmail,
+		"link":     baseURL,
+	}
+	var emailBody bytes.Buffer
+	if err := emailChangeTmpl.Execute(&emailBody, templateData); err != nil {
+		return err
+	}
+	emailData := &email.Data{
+		To:      currentEmail,
+		Subject: "Email change requested",
+		Body:    emailBody.Bytes(),
+	}
+	return h.es.SendEmail(emailData)
+}
+
+// DeleteUser deletes the account of the user doing the request. Users owning
+// chart repositories must delete or transfer them first, as the database will
+// refuse to delete the account otherwise.
+func (h *Hub) DeleteUser(ctx context.Context) error {
+	userID := ctx.Value(UserIDKey).(string)
+	_, err := h.db.Exec(ctx, `delete from "user" where user_id = $1`, userID)
+	return err
+}
+
+// AddSubscription adds the provided subscription to the database for the
+// user doing the request.
+func (h *Hub) AddSubscription(ctx context.Context, s *Subscription) error {
+	userID := ctx.Value(UserIDKey).(string)
+	sJSON, _ := json.Marshal(s)
+	query := "select add_subscription($1::uuid, $2::jsonb)"
+	_, err := h.db.Exec(ctx, query, userID, sJSON)
+	return err
+}
+
+// DeleteSubscription deletes the provided subscription of the user doing the
+// request from the database.
+func (h *Hub) DeleteSubscription(ctx context.Context, s *Subscription) error {
+	userID := ctx.Value(UserIDKey).(string)
+	sJSON, _ := json.Marshal(s)
+	query := "select delete_subscription($1::uuid, $2::jsonb)"
+	_, err := h.db.Exec(ctx, query, userID, sJSON)
+	return err
+}
+
+// GetUserSubscriptionsJSON returns the packages the user doing the request is
+// subscribed to. The json object is built by the database.
+func (h *Hub) GetUserSubscriptionsJSON(ctx context.Context) ([]byte, error) {
+	userID := ctx.Value(UserIDKey).(string)
+	return h.dbQueryJSON(ctx, "select get_user_subscriptions($1::uuid)", userID)
+}
+
+// GenerateNotifications generates the notifications for the users subscribed
+// to the events registered since the last time it was called.
+func (h *Hub) GenerateNotifications(ctx context.Context) error {
+	_, err := h.db.Exec(ctx, "select generate_notifications()")
+	return err
+}
+
+// ClaimNotifications returns up to limit notifications pending to be sent.
+// Notifications claimed won't be claimed again until some time has passed,
+// and only while they have been attempted less than maxAttempts times.
+func (h *Hub) ClaimNotifications(ctx context.Context, limit, maxAttempts int) ([]*Notification, error) {
+	var notifications []*Notification
+	query := "select claim_notifications($1::int, $2::int)"
+	err := h.dbQueryUnmarshal(ctx, &notifications, query, limit, maxAttempts)
+	return notifications, err
+}
+
+// SendNotificationsEmail sends an email to the address provided including the
+// notifications given. The base url provided will be used to build the links
+// to the packages.
+func (h *Hub) SendNotificationsEmail(to string, notifications []*Notification, baseURL string) error {
+	if h.es == nil {
+		return errors.New("email sender not available")
+	}
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	// Prepare email body
+	type release struct {
+		Name    string
+		Version string
+		Link    string
+	}
+	releases := make([]*release, 0, len(notifications))
+	for _, n := range notifications {
+		name := n.Package.Name
+		if n.Package.DisplayName != "" {
+			name = n.Package.DisplayName
+		}
+		releases = append(releases, &release{
+			Name:    name,
+			Version: n.Package.Version,
+			Link:    buildPackageURL(baseURL, n.Package),
+		})
+	}
+	var emailBody bytes.Buffer
+	if err := newReleasesTmpl.Execute(&emailBody, map[string]interface{}{"releases": releases}); err != nil {
+		return err
+	}
+
+	// Send email
+	subject := "New releases of packages you are subscribed to"
+	if len(releases) == 1 {
+		subject = fmt.Sprintf("%s version %s released", releases[0].Name, releases[0].Version)
+	}
+	emailData := &email.Data{
+		To:      to,
+		Subject: subject,
+		Body:    emailBody.Bytes(),
+	}
+	return h.es.SendEmail(emailData)
+}
+
+// CompleteNotifications marks the notifications provided as sent. When an
+// error is provided, the notifications are not marked as sent and the error
+// is recorded instead, so that they can be retried later.
+func (h *Hub) CompleteNotifications(ctx context.Context, notificationsIDs []string, sendErr error) error {
+	var err error
+	if sendErr == nil {
+		query := `
+		update notification set processed = true, processed_at = current_timestamp, error = null
+		where notification_id = any($1::uuid[])`
+		_, err = h.db.Exec(ctx, query, notificationsIDs)
+	} else {
+		query := `
+		update notification set error = $2
+		where notification_id = any($1::uuid[])`
+		_, err = h.db.Exec(ctx, query, notificationsIDs, sendErr.Error())
+	}
+	return err
+}
+
 // CheckAvailability checks the availability of a given value for the provided
 // resource kind.
 func (h *Hub) CheckAvailability(ctx context.Context, resourceKind, value string) (bool, error) {
@@ -283,6 +1352,8 @@ func (h *Hub) CheckAvailability(ctx context.Context, resourceKind, value string)
 		query = `select chart_repository_id from chart_repository where name = $1`
 	case "chartRepositoryURL":
 		query = `select chart_repository_id from chart_repository where url = $1`
+	case "organizationName":
+		query = `select organization_id from organization where name = $1`
 	default:
 		return false, errors.New("resource kind not supported")
 	}
@@ -292,6 +1363,30 @@ func (h *Hub) CheckAvailability(ctx context.Context, resourceKind, value string)
 	return available, err
 }
 
+// buildPackageURL returns the url of the package provided in the hub located
+// at the base url given.
+func buildPackageURL(baseURL string, p *Package) string {
+	switch {
+	case p.ChartRepository != nil:
+		return fmt.Sprintf("%s/package/chart/%s/%s/%s", baseURL, p.ChartRepository.Name, p.Name, p.Version)
+	case p.OperatorProvider != nil:
+		return fmt.Sprintf("%s/package/operator/%s/%s/%s", baseURL, p.OperatorProvider.Name, p.Name, p.Version)
+	default:
+		return baseURL
+	}
+}
+
+// checkNoDataFound returns a pgx.ErrNoRows error when the error provided was
+// raised by a database function because the resource it operates on does not
+// exist, so that it can be handled like any other missing resource.
+func checkNoDataFound(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == noDataFoundErrCode {
+		return pgx.ErrNoRows
+	}
+	return err
+}
+
 // dbQueryJSON is a helper that executes the query provided and returns a bytes
 // slice containing the json data returned from the database.
 func (h *Hub) dbQueryJSON(ctx context.Context, query string, args ...interface{}) ([]byte, error) {
@@ -2,12 +2,16 @@ package hub
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/cncf/hub/internal/email"
 	"github.com/cncf/hub/internal/tests"
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -18,6 +22,8 @@ import (
 var (
 	errFakeDatabaseFailure    = errors.New("fake database failure")
 	errFakeEmailSenderFailure = errors.New("fake email sender failure")
+	errInsufficientPrivilege  = &pgconn.PgError{Code: "42501"}
+	errNoDataFound            = &pgconn.PgError{Code: noDataFoundErrCode}
 )
 
 func TestNew(t *testing.T) {
@@ -54,6 +60,30 @@ func TestGetChartRepositoryByName(t *testing.T) {
 		db.AssertExpectations(t)
 	})
 
+	t.Run("get existing git repository by name", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "repo2").Return([]byte(`
+		{
+			"chart_repository_id": "00000000-0000-0000-0000-000000000002",
+			"name": "repo2",
+			"display_name": null,
+			"url": "https://github.com/org1/charts",
+			"kind": 1,
+			"git_branch": "main",
+			"git_path_glob": "charts/*"
+		}
+		`), nil)
+		h := New(db, nil)
+
+		r, err := h.GetChartRepositoryByName(context.Background(), "repo2")
+		require.NoError(t, err)
+		assert.Equal(t, GitChartRepository, r.Kind)
+		assert.Equal(t, "https://github.com/org1/charts", r.URL)
+		assert.Equal(t, "main", r.GitBranch)
+		assert.Equal(t, "charts/*", r.GitPathGlob)
+		db.AssertExpectations(t)
+	})
+
 	t.Run("database error calling get_chart_repository_by_name", func(t *testing.T) {
 		db := &tests.DBMock{}
 		db.On("QueryRow", dbQuery, "repo1").Return(nil, errFakeDatabaseFailure)
@@ -100,6 +130,38 @@ func TestGetChartRepositoryPackagesDigest(t *testing.T) {
 	db.AssertExpectations(t)
 }
 
+func TestGetOperatorProviderPackagesDigest(t *testing.T) {
+	dbQuery := "select get_operator_provider_packages_digest($1::text)"
+
+	t.Run("get existing operator provider packages digest", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "provider1").Return([]byte(`
+		{
+			"package1@1.0.0": "digest-package1-1.0.0",
+			"package1@0.0.9": "digest-package1-0.0.9"
+		}
+		`), nil)
+		h := New(db, nil)
+
+		pd, err := h.GetOperatorProviderPackagesDigest(context.Background(), "provider1")
+		require.NoError(t, err)
+		assert.Len(t, pd, 2)
+		assert.Equal(t, "digest-package1-1.0.0", pd["package1@1.0.0"])
+		assert.Equal(t, "digest-package1-0.0.9", pd["package1@0.0.9"])
+		db.AssertExpectations(t)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "provider1").Return(nil, errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		_, err := h.GetOperatorProviderPackagesDigest(context.Background(), "provider1")
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		db.AssertExpectations(t)
+	})
+}
+
 func TestGetChartRepositories(t *testing.T) {
 	dbQuery := "select get_chart_repositories()"
 	db := &tests.DBMock{}
@@ -175,8 +237,42 @@ func TestGetChartRepositoriesByUserJSON(t *testing.T) {
 	})
 }
 
+func TestGetOrganizationChartRepositoriesJSON(t *testing.T) {
+	dbQuery := "select get_chart_repositories_by_org($1::uuid, $2::text)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_, _ = h.GetOrganizationChartRepositoriesJSON(context.Background(), "org1")
+		})
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID", "org1").Return(nil, errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		data, err := h.GetOrganizationChartRepositoriesJSON(ctx, "org1")
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		assert.Nil(t, data)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("organization chart repositories data returned successfully", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID", "org1").Return([]byte("orgChartRepositoriesJSON"), nil)
+		h := New(db, nil)
+
+		data, err := h.GetOrganizationChartRepositoriesJSON(ctx, "org1")
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("orgChartRepositoriesJSON"), data)
+		db.AssertExpectations(t)
+	})
+}
+
 func TestAddChartRepository(t *testing.T) {
-	dbQuery := "select add_chart_repository($1::jsonb)"
+	dbQuery := "select add_chart_repository($1::jsonb, $2::jsonb)"
 	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
 
 	r := &ChartRepository{
@@ -188,33 +284,59 @@ func TestAddChartRepository(t *testing.T) {
 	t.Run("user id not found in ctx", func(t *testing.T) {
 		h := New(nil, nil)
 		assert.Panics(t, func() {
-			_ = h.AddChartRepository(context.Background(), r)
+			_ = h.AddChartRepository(context.Background(), "", r)
 		})
 	})
 
 	t.Run("database error", func(t *testing.T) {
 		db := &tests.DBMock{}
-		db.On("Exec", dbQuery, mock.Anything).Return(errFakeDatabaseFailure)
+		db.On("Exec", dbQuery, mock.Anything, []byte("{}")).Return(errFakeDatabaseFailure)
 		h := New(db, nil)
 
-		err := h.AddChartRepository(ctx, r)
+		err := h.AddChartRepository(ctx, "", r)
 		assert.Equal(t, errFakeDatabaseFailure, err)
 		db.AssertExpectations(t)
 	})
 
 	t.Run("add chart repository succeeded", func(t *testing.T) {
 		db := &tests.DBMock{}
-		db.On("Exec", dbQuery, mock.Anything).Return(nil)
+		db.On("Exec", dbQuery, mock.Anything, []byte("{}")).Return(nil)
+		h := New(db, nil)
+
+		err := h.AddChartRepository(ctx, "", r)
+		assert.NoError(t, err)
+		assert.Equal(t, "userID", r.UserID)
+		assert.Empty(t, r.OrganizationName)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("add organization chart repository succeeded", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, mock.Anything, []byte("{}")).Return(nil)
 		h := New(db, nil)
 
-		err := h.AddChartRepository(ctx, r)
+		err := h.AddChartRepository(ctx, "org1", r)
+		assert.NoError(t, err)
+		assert.Equal(t, "userID", r.UserID)
+		assert.Equal(t, "org1", r.OrganizationName)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("request information is passed to the database", func(t *testing.T) {
+		ctx := context.WithValue(ctx, RequestInfoKey, &RequestInfo{IP: "192.168.1.1", UserAgent: "ua"})
+		requestInfoJSON := []byte(`{"ip":"192.168.1.1","user_agent":"ua"}`)
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, mock.Anything, requestInfoJSON).Return(nil)
+		h := New(db, nil)
+
+		err := h.AddChartRepository(ctx, "", r)
 		assert.NoError(t, err)
 		db.AssertExpectations(t)
 	})
 }
 
 func TestUpdateChartRepository(t *testing.T) {
-	dbQuery := "select update_chart_repository($1::jsonb)"
+	dbQuery := "select update_chart_repository($1::jsonb, $2::jsonb)"
 	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
 
 	r := &ChartRepository{
@@ -230,9 +352,19 @@ func TestUpdateChartRepository(t *testing.T) {
 		})
 	})
 
+	t.Run("chart repository not found", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, mock.Anything, []byte("{}")).Return(errNoDataFound)
+		h := New(db, nil)
+
+		err := h.UpdateChartRepository(ctx, r)
+		assert.Equal(t, pgx.ErrNoRows, err)
+		db.AssertExpectations(t)
+	})
+
 	t.Run("database error", func(t *testing.T) {
 		db := &tests.DBMock{}
-		db.On("Exec", dbQuery, mock.Anything).Return(errFakeDatabaseFailure)
+		db.On("Exec", dbQuery, mock.Anything, []byte("{}")).Return(errFakeDatabaseFailure)
 		h := New(db, nil)
 
 		err := h.UpdateChartRepository(ctx, r)
@@ -242,7 +374,7 @@ func TestUpdateChartRepository(t *testing.T) {
 
 	t.Run("update chart repository succeeded", func(t *testing.T) {
 		db := &tests.DBMock{}
-		db.On("Exec", dbQuery, mock.Anything).Return(nil)
+		db.On("Exec", dbQuery, mock.Anything, []byte("{}")).Return(nil)
 		h := New(db, nil)
 
 		err := h.UpdateChartRepository(ctx, r)
@@ -252,7 +384,7 @@ func TestUpdateChartRepository(t *testing.T) {
 }
 
 func TestDeleteChartRepository(t *testing.T) {
-	dbQuery := "select delete_chart_repository($1::jsonb)"
+	dbQuery := "select delete_chart_repository($1::jsonb, $2::jsonb)"
 	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
 
 	r := &ChartRepository{
@@ -266,9 +398,19 @@ func TestDeleteChartRepository(t *testing.T) {
 		})
 	})
 
+	t.Run("chart repository not found", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, mock.Anything, []byte("{}")).Return(errNoDataFound)
+		h := New(db, nil)
+
+		err := h.DeleteChartRepository(ctx, r)
+		assert.Equal(t, pgx.ErrNoRows, err)
+		db.AssertExpectations(t)
+	})
+
 	t.Run("database error", func(t *testing.T) {
 		db := &tests.DBMock{}
-		db.On("Exec", dbQuery, mock.Anything).Return(errFakeDatabaseFailure)
+		db.On("Exec", dbQuery, mock.Anything, []byte("{}")).Return(errFakeDatabaseFailure)
 		h := New(db, nil)
 
 		err := h.DeleteChartRepository(ctx, r)
@@ -278,7 +420,7 @@ func TestDeleteChartRepository(t *testing.T) {
 
 	t.Run("delete chart repository succeeded", func(t *testing.T) {
 		db := &tests.DBMock{}
-		db.On("Exec", dbQuery, mock.Anything).Return(nil)
+		db.On("Exec", dbQuery, mock.Anything, []byte("{}")).Return(nil)
 		h := New(db, nil)
 
 		err := h.DeleteChartRepository(ctx, r)
@@ -287,512 +429,3305 @@ func TestDeleteChartRepository(t *testing.T) {
 	})
 }
 
-func TestSetChartRepositoryLastTrackingTs(t *testing.T) {
-	dbQuery := `
-	update chart_repository set last_tracking_ts = current_timestamp
-	where chart_repository_id = $1`
+func TestTransferChartRepository(t *testing.T) {
+	dbQuery := "select transfer_chart_repository($1::uuid, $2::text, $3::text, $4::jsonb)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
 
-	t.Run("database update succeeded", func(t *testing.T) {
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_ = h.TransferChartRepository(context.Background(), "repo1", "org1")
+		})
+	})
+
+	t.Run("user is not allowed to transfer the repository", func(t *testing.T) {
 		db := &tests.DBMock{}
-		db.On("Exec", dbQuery, mock.Anything).Return(nil)
+		db.On("QueryRow", dbQuery, "userID", "repo1", "org1", []byte("{}")).Return(nil, errInsufficientPrivilege)
 		h := New(db, nil)
 
-		err := h.SetChartRepositoryLastTrackingTs(context.Background(), "repoID")
+		err := h.TransferChartRepository(ctx, "repo1", "org1")
+		assert.Equal(t, errInsufficientPrivilege, err)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("destination organization not found", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID", "repo1", "org1", []byte("{}")).Return(nil, pgx.ErrNoRows)
+		h := New(db, nil)
+
+		err := h.TransferChartRepository(ctx, "repo1", "org1")
+		assert.Equal(t, pgx.ErrNoRows, err)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("transfer requested", func(t *testing.T) {
+		db := &tests.DBMock{}
+		requestInfoJSON := []byte(`{"ip":"192.168.1.1","user_agent":"ua"}`)
+		db.On("QueryRow", dbQuery, "userID", "repo1", "org1", requestInfoJSON).Return("transferID", nil)
+		h := New(db, nil)
+
+		ctx := context.WithValue(ctx, RequestInfoKey, &RequestInfo{IP: "192.168.1.1", UserAgent: "ua"})
+		err := h.TransferChartRepository(ctx, "repo1", "org1")
+		assert.NoError(t, err)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestAcceptChartRepositoryTransfer(t *testing.T) {
+	testChartRepositoryTransferAction(t,
+		"select accept_chart_repository_transfer($1::uuid, $2::text, $3::jsonb)",
+		func(h *Hub) func(context.Context, string) error { return h.AcceptChartRepositoryTransfer },
+	)
+}
+
+func TestCancelChartRepositoryTransfer(t *testing.T) {
+	testChartRepositoryTransferAction(t,
+		"select cancel_chart_repository_transfer($1::uuid, $2::text, $3::jsonb)",
+		func(h *Hub) func(context.Context, string) error { return h.CancelChartRepositoryTransfer },
+	)
+}
+
+func TestDeclineChartRepositoryTransfer(t *testing.T) {
+	testChartRepositoryTransferAction(t,
+		"select decline_chart_repository_transfer($1::uuid, $2::text, $3::jsonb)",
+		func(h *Hub) func(context.Context, string) error { return h.DeclineChartRepositoryTransfer },
+	)
+}
+
+func testChartRepositoryTransferAction(
+	t *testing.T,
+	dbQuery string,
+	action func(h *Hub) func(context.Context, string) error,
+) {
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_ = action(h)(context.Background(), "repo1")
+		})
+	})
+
+	testCases := []struct {
+		description string
+		dbResponse  interface{}
+		expectedErr error
+	}{
+		{
+			"no pending transfer",
+			errNoDataFound,
+			pgx.ErrNoRows,
+		},
+		{
+			"user is not allowed to process the transfer",
+			errInsufficientPrivilege,
+			errInsufficientPrivilege,
+		},
+		{
+			"database error",
+			errFakeDatabaseFailure,
+			errFakeDatabaseFailure,
+		},
+		{
+			"transfer processed",
+			nil,
+			nil,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			db := &tests.DBMock{}
+			db.On("Exec", dbQuery, "userID", "repo1", []byte("{}")).Return(tc.dbResponse)
+			h := New(db, nil)
+
+			err := action(h)(ctx, "repo1")
+			assert.Equal(t, tc.expectedErr, err)
+			db.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetOrganizationChartRepositoryTransfersJSON(t *testing.T) {
+	dbQuery := "select get_organization_chart_repository_transfers($1::uuid, $2::text)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_, _ = h.GetOrganizationChartRepositoryTransfersJSON(context.Background(), "org1")
+		})
+	})
+
+	t.Run("database query succeeded", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID", "org1").Return([]byte("dataJSON"), nil)
+		h := New(db, nil)
+
+		dataJSON, err := h.GetOrganizationChartRepositoryTransfersJSON(ctx, "org1")
 		assert.NoError(t, err)
+		assert.Equal(t, []byte("dataJSON"), dataJSON)
 		db.AssertExpectations(t)
 	})
 
 	t.Run("database error", func(t *testing.T) {
 		db := &tests.DBMock{}
-		db.On("Exec", dbQuery, mock.Anything).Return(errFakeDatabaseFailure)
+		db.On("QueryRow", dbQuery, "userID", "org1").Return(nil, errFakeDatabaseFailure)
 		h := New(db, nil)
 
-		err := h.SetChartRepositoryLastTrackingTs(context.Background(), "repoID")
+		dataJSON, err := h.GetOrganizationChartRepositoryTransfersJSON(ctx, "org1")
 		assert.Equal(t, errFakeDatabaseFailure, err)
+		assert.Nil(t, dataJSON)
 		db.AssertExpectations(t)
 	})
 }
 
-func TestSetChartRepositoryLastTrackingErrors(t *testing.T) {
+func TestSetChartRepositoryLastTrackingTs(t *testing.T) {
 	dbQuery := `
-	update chart_repository set last_tracking_errors = $2
+	update chart_repository set last_tracking_ts = current_timestamp
 	where chart_repository_id = $1`
 
 	t.Run("database update succeeded", func(t *testing.T) {
 		db := &tests.DBMock{}
-		db.On("Exec", dbQuery, mock.Anything, mock.Anything).Return(nil)
+		db.On("Exec", dbQuery, mock.Anything).Return(nil)
 		h := New(db, nil)
 
-		err := h.SetChartRepositoryLastTrackingErrors(context.Background(), "repoID", "errors")
+		err := h.SetChartRepositoryLastTrackingTs(context.Background(), "repoID")
 		assert.NoError(t, err)
 		db.AssertExpectations(t)
 	})
 
 	t.Run("database error", func(t *testing.T) {
 		db := &tests.DBMock{}
-		db.On("Exec", dbQuery, mock.Anything, mock.Anything).Return(errFakeDatabaseFailure)
+		db.On("Exec", dbQuery, mock.Anything).Return(errFakeDatabaseFailure)
 		h := New(db, nil)
 
-		err := h.SetChartRepositoryLastTrackingErrors(context.Background(), "repoID", "errors")
+		err := h.SetChartRepositoryLastTrackingTs(context.Background(), "repoID")
 		assert.Equal(t, errFakeDatabaseFailure, err)
 		db.AssertExpectations(t)
 	})
 }
 
-func TestGetPackagesStatsJSON(t *testing.T) {
-	dbQuery := "select get_packages_stats()"
+func TestGenerateChartRepositoryVerificationToken(t *testing.T) {
+	dbQuery := "select generate_chart_repository_verification_token($1::uuid, $2::text)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
 
-	t.Run("packages stats data returned successfully", func(t *testing.T) {
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_, _ = h.GenerateChartRepositoryVerificationToken(context.Background(), "repo1")
+		})
+	})
+
+	t.Run("user is not allowed to verify the repository", func(t *testing.T) {
 		db := &tests.DBMock{}
-		db.On("QueryRow", dbQuery).Return([]byte("packagesStatsDataJSON"), nil)
+		db.On("QueryRow", dbQuery, "userID", "repo1").Return(nil, errInsufficientPrivilege)
 		h := New(db, nil)
 
-		data, err := h.GetPackagesStatsJSON(context.Background())
-		assert.NoError(t, err)
-		assert.Equal(t, []byte("packagesStatsDataJSON"), data)
+		token, err := h.GenerateChartRepositoryVerificationToken(ctx, "repo1")
+		assert.Equal(t, errInsufficientPrivilege, err)
+		assert.Empty(t, token)
 		db.AssertExpectations(t)
 	})
 
 	t.Run("database error", func(t *testing.T) {
 		db := &tests.DBMock{}
-		db.On("QueryRow", dbQuery).Return(nil, errFakeDatabaseFailure)
+		db.On("QueryRow", dbQuery, "userID", "repo1").Return(nil, errFakeDatabaseFailure)
 		h := New(db, nil)
 
-		data, err := h.GetPackagesStatsJSON(context.Background())
+		token, err := h.GenerateChartRepositoryVerificationToken(ctx, "repo1")
 		assert.Equal(t, errFakeDatabaseFailure, err)
-		assert.Nil(t, data)
+		assert.Empty(t, token)
 		db.AssertExpectations(t)
 	})
-}
 
-func TestSearchPackagesJSON(t *testing.T) {
-	dbQuery := "select search_packages($1::jsonb)"
-	db := &tests.DBMock{}
-	db.On("QueryRow", dbQuery, mock.Anything).Return([]byte("searchResultsDataJSON"), nil)
-	h := New(db, nil)
+	t.Run("token generated successfully", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID", "repo1").Return("token", nil)
+		h := New(db, nil)
 
-	input := &SearchPackageInput{Text: "kw1"}
-	data, err := h.SearchPackagesJSON(context.Background(), input)
-	assert.NoError(t, err)
-	assert.Equal(t, []byte("searchResultsDataJSON"), data)
-	db.AssertExpectations(t)
+		token, err := h.GenerateChartRepositoryVerificationToken(ctx, "repo1")
+		assert.NoError(t, err)
+		assert.Equal(t, "token", token)
+		db.AssertExpectations(t)
+	})
 }
 
-func TestRegisterPackage(t *testing.T) {
-	dbQuery := "select register_package($1::jsonb)"
-
-	p := &Package{
-		Kind:        Chart,
-		Name:        "package1",
-		Description: "description",
-		HomeURL:     "home_url",
-		LogoImageID: "image_id",
-		Keywords:    []string{"kw1", "kw2"},
-		Readme:      "readme-version-1.0.0",
-		Links: []*Link{
-			{
-				Name: "Source",
-				URL:  "source_url",
-			},
-		},
-		Version:    "1.0.0",
-		AppVersion: "12.1.0",
-		Digest:     "digest-package1-1.0.0",
-		Maintainers: []*Maintainer{
-			{
-				Name:  "name1",
-				Email: "email1",
-			},
-			{
-				Name:  "name2",
-				Email: "email2",
-			},
-		},
-		ChartRepository: &ChartRepository{
-			ChartRepositoryID: "00000000-0000-0000-0000-000000000001",
-		},
-	}
+func TestSetChartRepositoryVerifiedPublisher(t *testing.T) {
+	dbQuery := `
+	update chart_repository set verified_publisher = $2, verification_failures = 0
+	where chart_repository_id = $1`
 
-	t.Run("successful package registration", func(t *testing.T) {
+	t.Run("database update succeeded", func(t *testing.T) {
 		db := &tests.DBMock{}
-		db.On("Exec", dbQuery, mock.Anything).Return(nil)
+		db.On("Exec", dbQuery, "repoID", true).Return(nil)
 		h := New(db, nil)
 
-		err := h.RegisterPackage(context.Background(), p)
+		err := h.SetChartRepositoryVerifiedPublisher(context.Background(), "repoID", true)
 		assert.NoError(t, err)
 		db.AssertExpectations(t)
 	})
 
 	t.Run("database error", func(t *testing.T) {
 		db := &tests.DBMock{}
-		db.On("Exec", dbQuery, mock.Anything).Return(errFakeDatabaseFailure)
+		db.On("Exec", dbQuery, "repoID", false).Return(errFakeDatabaseFailure)
 		h := New(db, nil)
 
-		err := h.RegisterPackage(context.Background(), p)
+		err := h.SetChartRepositoryVerifiedPublisher(context.Background(), "repoID", false)
 		assert.Equal(t, errFakeDatabaseFailure, err)
 		db.AssertExpectations(t)
 	})
 }
 
-func TestGetPackageJSON(t *testing.T) {
-	dbQuery := "select get_package($1::jsonb)"
-	db := &tests.DBMock{}
-	db.On("QueryRow", dbQuery, mock.Anything).Return([]byte("packageDataJSON"), nil)
-	h := New(db, nil)
+func TestRegisterChartRepositoryVerificationFailure(t *testing.T) {
+	dbQuery := `
+	update chart_repository set
+		verification_failures = verification_failures + 1,
+		verified_publisher = verified_publisher and verification_failures + 1 < $2
+	where chart_repository_id = $1`
 
-	data, err := h.GetPackageJSON(context.Background(), &GetPackageInput{})
-	assert.NoError(t, err)
-	assert.Equal(t, []byte("packageDataJSON"), data)
-	db.AssertExpectations(t)
+	t.Run("database update succeeded", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, "repoID", 3).Return(nil)
+		h := New(db, nil)
+
+		err := h.RegisterChartRepositoryVerificationFailure(context.Background(), "repoID", 3)
+		assert.NoError(t, err)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, "repoID", 3).Return(errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		err := h.RegisterChartRepositoryVerificationFailure(context.Background(), "repoID", 3)
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestSetChartRepositoryLastTrackingErrors(t *testing.T) {
+	dbQuery := `
+	update chart_repository set last_tracking_errors = $2
+	where chart_repository_id = $1`
+	errs := &TrackingErrors{
+		Errors: []*TrackingError{
+			{
+				Kind:       TrackingErrorChartDownload,
+				ChartName:  "chart1",
+				URL:        "https://repo1.com/chart1.tgz",
+				HTTPStatus: 404,
+				Message:    "unexpected status code received: 404",
+			},
+		},
+		Suppressed: 2,
+	}
+	errsJSON := []byte(`{"errors":[{"kind":"chart-download","chart_name":"chart1","url":"https://repo1.com/chart1.tgz","http_status":404,"message":"unexpected status code received: 404"}],"suppressed":2}`)
+
+	t.Run("database update succeeded", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, "repoID", errsJSON).Return(nil)
+		h := New(db, nil)
+
+		err := h.SetChartRepositoryLastTrackingErrors(context.Background(), "repoID", errs)
+		assert.NoError(t, err)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("errors cleared", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, "repoID", []byte(nil)).Return(nil)
+		h := New(db, nil)
+
+		err := h.SetChartRepositoryLastTrackingErrors(context.Background(), "repoID", nil)
+		assert.NoError(t, err)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, mock.Anything, mock.Anything).Return(errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		err := h.SetChartRepositoryLastTrackingErrors(context.Background(), "repoID", errs)
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestGetChartRepositoryTrackingErrorsJSON(t *testing.T) {
+	dbQuery := "select get_chart_repository_tracking_errors($1::uuid, $2::text, $3::text[])"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+	kinds := []string{"register"}
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_, _ = h.GetChartRepositoryTrackingErrorsJSON(context.Background(), "repo1", kinds)
+		})
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID", "repo1", kinds).Return(nil, errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		data, err := h.GetChartRepositoryTrackingErrorsJSON(ctx, "repo1", kinds)
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		assert.Nil(t, data)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("tracking errors returned successfully", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID", "repo1", kinds).Return([]byte("trackingErrorsJSON"), nil)
+		h := New(db, nil)
+
+		data, err := h.GetChartRepositoryTrackingErrorsJSON(ctx, "repo1", kinds)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("trackingErrorsJSON"), data)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestGetChartRepositoryLastTrackingErrors(t *testing.T) {
+	dbQuery := `
+	select last_tracking_errors from chart_repository
+	where chart_repository_id = $1`
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "repoID").Return(nil, errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		errs, err := h.GetChartRepositoryLastTrackingErrors(context.Background(), "repoID")
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		assert.Nil(t, errs)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("no errors available", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "repoID").Return(nil, nil)
+		h := New(db, nil)
+
+		errs, err := h.GetChartRepositoryLastTrackingErrors(context.Background(), "repoID")
+		assert.NoError(t, err)
+		assert.Nil(t, errs)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("errors returned successfully", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "repoID").Return([]byte(`
+		{
+			"errors": [{
+				"kind": "chart-parse",
+				"chart_name": "chart1",
+				"chart_version": "1.0.0",
+				"message": "error1"
+			}],
+			"suppressed": 1
+		}
+		`), nil)
+		h := New(db, nil)
+
+		errs, err := h.GetChartRepositoryLastTrackingErrors(context.Background(), "repoID")
+		assert.NoError(t, err)
+		assert.Equal(t, &TrackingErrors{
+			Errors: []*TrackingError{
+				{
+					Kind:         TrackingErrorChartParse,
+					ChartName:    "chart1",
+					ChartVersion: "1.0.0",
+					Message:      "error1",
+				},
+			},
+			Suppressed: 1,
+		}, errs)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestNotifyTrackingErrors(t *testing.T) {
+	dbQuery := "select get_tracking_errors_notification_recipients($1::uuid)"
+	r := &ChartRepository{ChartRepositoryID: "repoID", Name: "repo1"}
+	errs := []*TrackingError{
+		{Kind: TrackingErrorChartParse, ChartName: "chart1", ChartVersion: "1.0.0", Message: "error1"},
+	}
+
+	t.Run("email sender not available", func(t *testing.T) {
+		db := &tests.DBMock{}
+		h := New(db, nil)
+
+		err := h.NotifyTrackingErrors(context.Background(), r, errs)
+		assert.NoError(t, err)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("no errors to notify", func(t *testing.T) {
+		db := &tests.DBMock{}
+		es := &tests.EmailSenderMock{}
+		h := New(db, es)
+
+		err := h.NotifyTrackingErrors(context.Background(), r, nil)
+		assert.NoError(t, err)
+		db.AssertExpectations(t)
+		es.AssertExpectations(t)
+	})
+
+	t.Run("database error getting recipients", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "repoID").Return(nil, errFakeDatabaseFailure)
+		es := &tests.EmailSenderMock{}
+		h := New(db, es)
+
+		err := h.NotifyTrackingErrors(context.Background(), r, errs)
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		db.AssertExpectations(t)
+		es.AssertExpectations(t)
+	})
+
+	t.Run("no recipients available", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "repoID").Return([]byte("[]"), nil)
+		es := &tests.EmailSenderMock{}
+		h := New(db, es)
+
+		err := h.NotifyTrackingErrors(context.Background(), r, errs)
+		assert.NoError(t, err)
+		db.AssertExpectations(t)
+		es.AssertExpectations(t)
+	})
+
+	t.Run("recipients notified", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "repoID").Return([]byte(`["user1@email.com"]`), nil)
+		es := &tests.EmailSenderMock{}
+		es.On("SendEmail", mock.MatchedBy(func(data *email.Data) bool {
+			return data.To == "user1@email.com" &&
+				strings.Contains(string(data.Body), "chart-parse chart1@1.0.0: error1")
+		})).Return(nil)
+		h := New(db, es)
+
+		err := h.NotifyTrackingErrors(context.Background(), r, errs)
+		assert.NoError(t, err)
+		db.AssertExpectations(t)
+		es.AssertExpectations(t)
+	})
+
+	t.Run("error sending notification to some recipients", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "repoID").Return([]byte(`["user1@email.com", "user2@email.com"]`), nil)
+		es := &tests.EmailSenderMock{}
+		es.On("SendEmail", mock.MatchedBy(func(data *email.Data) bool {
+			return data.To == "user1@email.com"
+		})).Return(errFakeEmailSenderFailure)
+		es.On("SendEmail", mock.MatchedBy(func(data *email.Data) bool {
+			return data.To == "user2@email.com"
+		})).Return(nil)
+		h := New(db, es)
+
+		err := h.NotifyTrackingErrors(context.Background(), r, errs)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "error sending 1 of 2 notifications")
+		assert.Contains(t, err.Error(), errFakeEmailSenderFailure.Error())
+		db.AssertExpectations(t)
+		es.AssertExpectations(t)
+	})
+
+	t.Run("errors listed are limited", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "repoID").Return([]byte(`["user1@email.com"]`), nil)
+		es := &tests.EmailSenderMock{}
+		es.On("SendEmail", mock.MatchedBy(func(data *email.Data) bool {
+			return strings.Contains(string(data.Body), "and 5 more")
+		})).Return(nil)
+		h := New(db, es)
+
+		var manyErrs []*TrackingError
+		for i := 0; i < maxNotifiedTrackingErrors+5; i++ {
+			manyErrs = append(manyErrs, errs[0])
+		}
+		err := h.NotifyTrackingErrors(context.Background(), r, manyErrs)
+		assert.NoError(t, err)
+		db.AssertExpectations(t)
+		es.AssertExpectations(t)
+	})
+}
+
+func TestTrackingErrorError(t *testing.T) {
+	testCases := []struct {
+		e        *TrackingError
+		expected string
+	}{
+		{
+			&TrackingError{Kind: TrackingErrorIndexLoad, Message: "error1"},
+			"index-load: error1",
+		},
+		{
+			&TrackingError{Kind: TrackingErrorChartParse, ChartName: "chart1", Message: "error1"},
+			"chart-parse chart1: error1",
+		},
+		{
+			&TrackingError{Kind: TrackingErrorRegister, ChartName: "chart1", ChartVersion: "1.0.0", Message: "error1"},
+			"register chart1@1.0.0: error1",
+		},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, tc.e.Error())
+	}
+}
+
+func TestAddOrganization(t *testing.T) {
+	dbQuery := "select add_organization($1::uuid, $2::jsonb)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+
+	org := &Organization{
+		Name:        "org1",
+		DisplayName: "Organization 1",
+		Description: "description",
+	}
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_ = h.AddOrganization(context.Background(), org)
+		})
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, "userID", mock.Anything).Return(errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		err := h.AddOrganization(ctx, org)
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("add organization succeeded", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, "userID", mock.Anything).Return(nil)
+		h := New(db, nil)
+
+		err := h.AddOrganization(ctx, org)
+		assert.NoError(t, err)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestUpdateOrganization(t *testing.T) {
+	dbQuery := "select update_organization($1::uuid, $2::jsonb)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+
+	org := &Organization{
+		Name:        "org1",
+		DisplayName: "Organization 1 updated",
+	}
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_ = h.UpdateOrganization(context.Background(), org)
+		})
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, "userID", mock.Anything).Return(errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		err := h.UpdateOrganization(ctx, org)
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("update organization succeeded", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, "userID", mock.Anything).Return(nil)
+		h := New(db, nil)
+
+		err := h.UpdateOrganization(ctx, org)
+		assert.NoError(t, err)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestDeleteOrganization(t *testing.T) {
+	dbQuery := "select delete_organization($1::uuid, $2::text)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_ = h.DeleteOrganization(context.Background(), "org1")
+		})
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, "userID", "org1").Return(errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		err := h.DeleteOrganization(ctx, "org1")
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("delete organization succeeded", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, "userID", "org1").Return(nil)
+		h := New(db, nil)
+
+		err := h.DeleteOrganization(ctx, "org1")
+		assert.NoError(t, err)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestGetUserOrganizationsJSON(t *testing.T) {
+	dbQuery := "select get_user_organizations($1::uuid)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_, _ = h.GetUserOrganizationsJSON(context.Background())
+		})
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID").Return(nil, errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		data, err := h.GetUserOrganizationsJSON(ctx)
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		assert.Nil(t, data)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("user organizations data returned successfully", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID").Return([]byte("userOrganizationsJSON"), nil)
+		h := New(db, nil)
+
+		data, err := h.GetUserOrganizationsJSON(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("userOrganizationsJSON"), data)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestGetOrganizationMembersJSON(t *testing.T) {
+	dbQuery := "select get_organization_members($1::uuid, $2::text)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_, _ = h.GetOrganizationMembersJSON(context.Background(), "org1")
+		})
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID", "org1").Return(nil, errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		data, err := h.GetOrganizationMembersJSON(ctx, "org1")
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		assert.Nil(t, data)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("organization members data returned successfully", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID", "org1").Return([]byte("organizationMembersJSON"), nil)
+		h := New(db, nil)
+
+		data, err := h.GetOrganizationMembersJSON(ctx, "org1")
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("organizationMembersJSON"), data)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestAddOrganizationMember(t *testing.T) {
+	dbQuery := "select add_organization_member($1::uuid, $2::text, $3::text, $4::jsonb)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_ = h.AddOrganizationMember(context.Background(), "org1", "user1")
+		})
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, "userID", "org1", "user1", []byte("{}")).Return(errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		err := h.AddOrganizationMember(ctx, "org1", "user1")
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("add organization member succeeded", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, "userID", "org1", "user1", []byte("{}")).Return(nil)
+		h := New(db, nil)
+
+		err := h.AddOrganizationMember(ctx, "org1", "user1")
+		assert.NoError(t, err)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestDeleteOrganizationMember(t *testing.T) {
+	dbQuery := "select delete_organization_member($1::uuid, $2::text, $3::text, $4::jsonb)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_ = h.DeleteOrganizationMember(context.Background(), "org1", "user1")
+		})
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, "userID", "org1", "user1", []byte("{}")).Return(errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		err := h.DeleteOrganizationMember(ctx, "org1", "user1")
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("delete organization member succeeded", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, "userID", "org1", "user1", []byte("{}")).Return(nil)
+		h := New(db, nil)
+
+		err := h.DeleteOrganizationMember(ctx, "org1", "user1")
+		assert.NoError(t, err)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestUpdateOrganizationMemberRole(t *testing.T) {
+	dbQuery := "select update_organization_member_role($1::uuid, $2::text, $3::text, $4::text, $5::jsonb)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_ = h.UpdateOrganizationMemberRole(context.Background(), "org1", "user1", AdminRole)
+		})
+	})
+
+	t.Run("organization not found", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, "userID", "org1", "user1", "admin", []byte("{}")).Return(errNoDataFound)
+		h := New(db, nil)
+
+		err := h.UpdateOrganizationMemberRole(ctx, "org1", "user1", AdminRole)
+		assert.Equal(t, pgx.ErrNoRows, err)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, "userID", "org1", "user1", "admin", []byte("{}")).Return(errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		err := h.UpdateOrganizationMemberRole(ctx, "org1", "user1", AdminRole)
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("update organization member role succeeded", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, "userID", "org1", "user1", "admin", []byte("{}")).Return(nil)
+		h := New(db, nil)
+
+		err := h.UpdateOrganizationMemberRole(ctx, "org1", "user1", AdminRole)
+		assert.NoError(t, err)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestAddOrganizationInvitation(t *testing.T) {
+	dbQuery := "select add_organization_invitation($1::uuid, $2::text, $3::text, $4::jsonb)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_ = h.AddOrganizationInvitation(context.Background(), "org1", "user1@email.com", "")
+		})
+	})
+
+	t.Run("organization not found", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID", "org1", "user1@email.com", []byte("{}")).Return(nil, errNoDataFound)
+		h := New(db, nil)
+
+		err := h.AddOrganizationInvitation(ctx, "org1", "user1@email.com", "")
+		assert.Equal(t, pgx.ErrNoRows, err)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("email already belongs to a member", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID", "org1", "user1@email.com", []byte("{}")).Return(nil, pgx.ErrNoRows)
+		es := &tests.EmailSenderMock{}
+		h := New(db, es)
+
+		err := h.AddOrganizationInvitation(ctx, "org1", "user1@email.com", "")
+		assert.NoError(t, err)
+		db.AssertExpectations(t)
+		es.AssertExpectations(t)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID", "org1", "user1@email.com", []byte("{}")).Return(nil, errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		err := h.AddOrganizationInvitation(ctx, "org1", "user1@email.com", "")
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("organization invitation added", func(t *testing.T) {
+		testCases := []struct {
+			description         string
+			emailSenderResponse error
+		}{
+			{
+				"organization invitation sent successfully",
+				nil,
+			},
+			{
+				"error sending organization invitation",
+				errFakeEmailSenderFailure,
+			},
+		}
+		for _, tc := range testCases {
+			tc := tc
+			t.Run(tc.description, func(t *testing.T) {
+				db := &tests.DBMock{}
+				db.On("QueryRow", dbQuery, "userID", "org1", "user1@email.com", []byte("{}")).Return("invitationToken", nil)
+				es := &tests.EmailSenderMock{}
+				es.On("SendEmail", mock.MatchedBy(func(data *email.Data) bool {
+					return data.To == "user1@email.com" &&
+						strings.Contains(data.Subject, "org1") &&
+						strings.Contains(string(data.Body), "http://baseurl/acceptInvitation?token=invitationToken")
+				})).Return(tc.emailSenderResponse)
+				h := New(db, es)
+
+				err := h.AddOrganizationInvitation(ctx, "org1", "user1@email.com", "http://baseurl")
+				assert.Equal(t, tc.emailSenderResponse, err)
+				db.AssertExpectations(t)
+				es.AssertExpectations(t)
+			})
+		}
+	})
+}
+
+func TestGetUserOrganizationInvitations(t *testing.T) {
+	dbQuery := "select get_user_organization_invitations($1::uuid)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_, _ = h.GetUserOrganizationInvitations(context.Background())
+		})
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID").Return(nil, errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		_, err := h.GetUserOrganizationInvitations(ctx)
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("organization invitations returned", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID").Return([]byte(`
+		[{
+			"organization_name": "org1",
+			"organization_display_name": "Organization 1",
+			"organization_logo_url": "logo_url",
+			"invited_at": 1592299234,
+			"token": "invitationToken"
+		}]
+		`), nil)
+		h := New(db, nil)
+
+		invitations, err := h.GetUserOrganizationInvitations(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []*OrganizationInvitation{
+			{
+				OrganizationName:        "org1",
+				OrganizationDisplayName: "Organization 1",
+				OrganizationLogoURL:     "logo_url",
+				InvitedAt:               1592299234,
+				Token:                   "invitationToken",
+			},
+		}, invitations)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestAcceptOrganizationInvitation(t *testing.T) {
+	dbQuery := "select accept_organization_invitation($1::uuid, $2::uuid, $3::jsonb)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_, _ = h.AcceptOrganizationInvitation(context.Background(), "invitationToken")
+		})
+	})
+
+	testCases := []struct {
+		description      string
+		dbResponse       []interface{}
+		expectedAccepted bool
+		expectedErr      error
+	}{
+		{
+			"invitation accepted",
+			[]interface{}{true, nil},
+			true,
+			nil,
+		},
+		{
+			"invitation not found or expired",
+			[]interface{}{false, nil},
+			false,
+			nil,
+		},
+		{
+			"database error",
+			[]interface{}{false, errFakeDatabaseFailure},
+			false,
+			errFakeDatabaseFailure,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			db := &tests.DBMock{}
+			db.On("QueryRow", dbQuery, "userID", "invitationToken", []byte("{}")).Return(tc.dbResponse...)
+			h := New(db, nil)
+
+			accepted, err := h.AcceptOrganizationInvitation(ctx, "invitationToken")
+			assert.Equal(t, tc.expectedErr, err)
+			assert.Equal(t, tc.expectedAccepted, accepted)
+			db.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeclineOrganizationInvitation(t *testing.T) {
+	dbQuery := "select decline_organization_invitation($1::uuid, $2::uuid, $3::jsonb)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_, _ = h.DeclineOrganizationInvitation(context.Background(), "invitationToken")
+		})
+	})
+
+	testCases := []struct {
+		description      string
+		dbResponse       []interface{}
+		expectedDeclined bool
+		expectedErr      error
+	}{
+		{
+			"invitation declined",
+			[]interface{}{true, nil},
+			true,
+			nil,
+		},
+		{
+			"invitation not found",
+			[]interface{}{false, nil},
+			false,
+			nil,
+		},
+		{
+			"database error",
+			[]interface{}{false, errFakeDatabaseFailure},
+			false,
+			errFakeDatabaseFailure,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			db := &tests.DBMock{}
+			db.On("QueryRow", dbQuery, "userID", "invitationToken", []byte("{}")).Return(tc.dbResponse...)
+			h := New(db, nil)
+
+			declined, err := h.DeclineOrganizationInvitation(ctx, "invitationToken")
+			assert.Equal(t, tc.expectedErr, err)
+			assert.Equal(t, tc.expectedDeclined, declined)
+			db.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAddTrackingRequestJSON(t *testing.T) {
+	dbQuery := "select add_tracking_request($1::uuid, $2::text)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_, _ = h.AddTrackingRequestJSON(context.Background(), "repo1")
+		})
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID", "repo1").Return(nil, errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		data, err := h.AddTrackingRequestJSON(ctx, "repo1")
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		assert.Nil(t, data)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("tracking request added successfully", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID", "repo1").Return([]byte("trackingRequestJSON"), nil)
+		h := New(db, nil)
+
+		data, err := h.AddTrackingRequestJSON(ctx, "repo1")
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("trackingRequestJSON"), data)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestGetTrackingRequestJSON(t *testing.T) {
+	dbQuery := "select get_tracking_request($1::uuid, $2::text, $3::uuid)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_, _ = h.GetTrackingRequestJSON(context.Background(), "repo1", "requestID")
+		})
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID", "repo1", "requestID").Return(nil, errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		data, err := h.GetTrackingRequestJSON(ctx, "repo1", "requestID")
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		assert.Nil(t, data)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("tracking request data returned successfully", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID", "repo1", "requestID").Return([]byte("trackingRequestJSON"), nil)
+		h := New(db, nil)
+
+		data, err := h.GetTrackingRequestJSON(ctx, "repo1", "requestID")
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("trackingRequestJSON"), data)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestAddChartVersionTrackingRequestJSON(t *testing.T) {
+	dbQuery := "select add_chart_version_tracking_request($1::text, $2::text, $3::text)"
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "repo1", "chart1", "1.0.0").Return(nil, errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		data, err := h.AddChartVersionTrackingRequestJSON(context.Background(), "repo1", "chart1", "1.0.0")
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		assert.Nil(t, data)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("chart version tracking request added successfully", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "repo1", "chart1", "1.0.0").Return([]byte("trackingRequestJSON"), nil)
+		h := New(db, nil)
+
+		data, err := h.AddChartVersionTrackingRequestJSON(context.Background(), "repo1", "chart1", "1.0.0")
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("trackingRequestJSON"), data)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestClaimTrackingRequests(t *testing.T) {
+	dbQuery := "select claim_tracking_requests()"
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery).Return(nil, errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		requests, err := h.ClaimTrackingRequests(context.Background())
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		assert.Nil(t, requests)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("pending requests claimed successfully", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery).Return([]byte(`
+		[{
+			"tracking_request_id": "00000000-0000-0000-0000-000000000001",
+			"chart_repository": {
+				"chart_repository_id": "00000000-0000-0000-0000-000000000001",
+				"name": "repo1",
+				"url": "https://repo1.com"
+			},
+			"chart_name": "chart1",
+			"chart_version": "1.0.0"
+		}]
+		`), nil)
+		h := New(db, nil)
+
+		requests, err := h.ClaimTrackingRequests(context.Background())
+		require.NoError(t, err)
+		require.Len(t, requests, 1)
+		assert.Equal(t, "00000000-0000-0000-0000-000000000001", requests[0].TrackingRequestID)
+		assert.Equal(t, "repo1", requests[0].ChartRepository.Name)
+		assert.Equal(t, "chart1", requests[0].ChartName)
+		assert.Equal(t, "1.0.0", requests[0].ChartVersion)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestCompleteTrackingRequest(t *testing.T) {
+	dbQuery := `
+	update tracking_request set status = $2, completed_at = current_timestamp
+	where tracking_request_id = $1`
+
+	t.Run("invalid status", func(t *testing.T) {
+		h := New(nil, nil)
+		err := h.CompleteTrackingRequest(context.Background(), "requestID", TrackingRequestPending)
+		assert.Error(t, err)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, "requestID", TrackingRequestFailed).Return(errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		err := h.CompleteTrackingRequest(context.Background(), "requestID", TrackingRequestFailed)
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("tracking request completed successfully", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, "requestID", TrackingRequestCompleted).Return(nil)
+		h := New(db, nil)
+
+		err := h.CompleteTrackingRequest(context.Background(), "requestID", TrackingRequestCompleted)
+		assert.NoError(t, err)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestGetPackagesStatsJSON(t *testing.T) {
+	dbQuery := "select get_packages_stats()"
+
+	t.Run("packages stats data returned successfully", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery).Return([]byte("packagesStatsDataJSON"), nil)
+		h := New(db, nil)
+
+		data, err := h.GetPackagesStatsJSON(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("packagesStatsDataJSON"), data)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery).Return(nil, errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		data, err := h.GetPackagesStatsJSON(context.Background())
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		assert.Nil(t, data)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestSearchPackagesJSON(t *testing.T) {
+	dbQuery := "select search_packages($1::jsonb)"
+	db := &tests.DBMock{}
+	db.On("QueryRow", dbQuery, mock.Anything).Return([]byte("searchResultsDataJSON"), nil)
+	h := New(db, nil)
+
+	input := &SearchPackageInput{Text: "kw1"}
+	data, err := h.SearchPackagesJSON(context.Background(), input)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("searchResultsDataJSON"), data)
+	db.AssertExpectations(t)
+}
+
+func TestRegisterPackage(t *testing.T) {
+	dbQuery := "select register_package($1::jsonb)"
+
+	p := &Package{
+		Kind:        Chart,
+		Name:        "package1",
+		Description: "description",
+		HomeURL:     "home_url",
+		LogoImageID: "image_id",
+		Keywords:    []string{"kw1", "kw2"},
+		Readme:      "readme-version-1.0.0",
+		Links: []*Link{
+			{
+				Name: "Source",
+				URL:  "source_url",
+			},
+		},
+		Version:    "1.0.0",
+		AppVersion: "12.1.0",
+		Digest:     "digest-package1-1.0.0",
+		Maintainers: []*Maintainer{
+			{
+				Name:  "name1",
+				Email: "email1",
+			},
+			{
+				Name:  "name2",
+				Email: "email2",
+			},
+		},
+		ChartRepository: &ChartRepository{
+			ChartRepositoryID: "00000000-0000-0000-0000-000000000001",
+		},
+	}
+
+	t.Run("successful package registration", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, mock.Anything).Return(nil)
+		h := New(db, nil)
+
+		err := h.RegisterPackage(context.Background(), p)
+		assert.NoError(t, err)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, mock.Anything).Return(errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		err := h.RegisterPackage(context.Background(), p)
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestUnregisterPackage(t *testing.T) {
+	dbQuery := "select unregister_package($1::jsonb)"
+
+	p := &Package{
+		Kind:    Chart,
+		Name:    "package1",
+		Version: "1.0.0",
+		ChartRepository: &ChartRepository{
+			ChartRepositoryID: "00000000-0000-0000-0000-000000000001",
+		},
+	}
+
+	t.Run("successful package unregistration", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, mock.Anything).Return(nil)
+		h := New(db, nil)
+
+		err := h.UnregisterPackage(context.Background(), p)
+		assert.NoError(t, err)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, mock.Anything).Return(errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		err := h.UnregisterPackage(context.Background(), p)
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestGetPackageJSON(t *testing.T) {
+	dbQuery := "select get_package($1::jsonb)"
+	db := &tests.DBMock{}
+	db.On("QueryRow", dbQuery, mock.Anything).Return([]byte("packageDataJSON"), nil)
+	h := New(db, nil)
+
+	data, err := h.GetPackageJSON(context.Background(), &GetPackageInput{})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("packageDataJSON"), data)
+	db.AssertExpectations(t)
+}
+
+func TestGetPackagesUpdatesJSON(t *testing.T) {
+	dbQuery := "select get_packages_updates()"
+	db := &tests.DBMock{}
+	db.On("QueryRow", dbQuery).Return([]byte("packagesUpdatesDataJSON"), nil)
+	h := New(db, nil)
+
+	data, err := h.GetPackagesUpdatesJSON(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("packagesUpdatesDataJSON"), data)
+	db.AssertExpectations(t)
+}
+
+func TestRegisterUser(t *testing.T) {
+	dbQuery := "select register_user($1::jsonb)"
+
+	t.Run("successful user registration in database", func(t *testing.T) {
+		testCases := []struct {
+			description         string
+			emailSenderResponse error
+		}{
+			{
+				"email verification code sent successfully",
+				nil,
+			},
+			{
+				"error sending email verification code",
+				errFakeEmailSenderFailure,
+			},
+		}
+		for _, tc := range testCases {
+			tc := tc
+			t.Run(tc.description, func(t *testing.T) {
+				db := &tests.DBMock{}
+				db.On("QueryRow", dbQuery, mock.Anything).Return("emailVerificationCode", nil)
+				es := &tests.EmailSenderMock{}
+				es.On("SendEmail", mock.Anything).Return(tc.emailSenderResponse)
+				h := New(db, es)
+
+				u := &User{
+					Alias:     "alias",
+					FirstName: "first_name",
+					LastName:  "last_name",
+					Email:     "email@email.com",
+					Password:  "password",
+				}
+				err := h.RegisterUser(context.Background(), u, "")
+				assert.Equal(t, tc.emailSenderResponse, err)
+				assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(u.Password), []byte("password")))
+				db.AssertExpectations(t)
+				es.AssertExpectations(t)
+			})
+		}
+	})
+
+	t.Run("database error registering user", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, mock.Anything).Return("", errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		u := &User{}
+		err := h.RegisterUser(context.Background(), u, "")
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestVerifyEmail(t *testing.T) {
+	dbQuery := "select verify_email($1::uuid)"
+
+	t.Run("successful email verification", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, mock.Anything).Return(true, nil)
+		h := New(db, nil)
+
+		verified, err := h.VerifyEmail(context.Background(), "emailVerificationCode")
+		assert.NoError(t, err)
+		assert.True(t, verified)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("database error verifying email", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, mock.Anything).Return(false, errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		verified, err := h.VerifyEmail(context.Background(), "emailVerificationCode")
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		assert.False(t, verified)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestResendEmailVerificationCode(t *testing.T) {
+	dbQuery := "select regenerate_email_verification_code($1::text)"
+
+	t.Run("no code generated", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "user1@email.com").Return(nil, pgx.ErrNoRows)
+		es := &tests.EmailSenderMock{}
+		h := New(db, es)
+
+		err := h.ResendEmailVerificationCode(context.Background(), "user1@email.com", "")
+		assert.NoError(t, err)
+		db.AssertExpectations(t)
+		es.AssertExpectations(t)
+	})
+
+	t.Run("email verification code generated", func(t *testing.T) {
+		testCases := []struct {
+			description         string
+			emailSenderResponse error
+		}{
+			{
+				"email verification code sent successfully",
+				nil,
+			},
+			{
+				"error sending email verification code",
+				errFakeEmailSenderFailure,
+			},
+		}
+		for _, tc := range testCases {
+			tc := tc
+			t.Run(tc.description, func(t *testing.T) {
+				db := &tests.DBMock{}
+				db.On("QueryRow", dbQuery, "user1@email.com").Return("emailVerificationCode", nil)
+				es := &tests.EmailSenderMock{}
+				es.On("SendEmail", mock.MatchedBy(func(data *email.Data) bool {
+					return data.To == "user1@email.com" &&
+						strings.Contains(string(data.Body), "http://baseurl/verifyEmail?code=emailVerificationCode")
+				})).Return(tc.emailSenderResponse)
+				h := New(db, es)
+
+				err := h.ResendEmailVerificationCode(context.Background(), "user1@email.com", "http://baseurl")
+				assert.Equal(t, tc.emailSenderResponse, err)
+				db.AssertExpectations(t)
+				es.AssertExpectations(t)
+			})
+		}
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "user1@email.com").Return(nil, errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		err := h.ResendEmailVerificationCode(context.Background(), "user1@email.com", "")
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestRequestPasswordReset(t *testing.T) {
+	dbQuery := "select request_password_reset_code($1::text)"
+
+	t.Run("email not registered", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "user1@email.com").Return(nil, pgx.ErrNoRows)
+		es := &tests.EmailSenderMock{}
+		h := New(db, es)
+
+		err := h.RequestPasswordReset(context.Background(), "user1@email.com", "")
+		assert.NoError(t, err)
+		db.AssertExpectations(t)
+		es.AssertExpectations(t)
+	})
+
+	t.Run("password reset code created", func(t *testing.T) {
+		testCases := []struct {
+			description         string
+			emailSenderResponse error
+		}{
+			{
+				"password reset code sent successfully",
+				nil,
+			},
+			{
+				"error sending password reset code",
+				errFakeEmailSenderFailure,
+			},
+		}
+		for _, tc := range testCases {
+			tc := tc
+			t.Run(tc.description, func(t *testing.T) {
+				db := &tests.DBMock{}
+				db.On("QueryRow", dbQuery, "user1@email.com").Return("passwordResetCode", nil)
+				es := &tests.EmailSenderMock{}
+				es.On("SendEmail", mock.MatchedBy(func(data *email.Data) bool {
+					return data.To == "user1@email.com" &&
+						strings.Contains(string(data.Body), "http://baseurl/resetPassword?code=passwordResetCode")
+				})).Return(tc.emailSenderResponse)
+				h := New(db, es)
+
+				err := h.RequestPasswordReset(context.Background(), "user1@email.com", "http://baseurl")
+				assert.Equal(t, tc.emailSenderResponse, err)
+				db.AssertExpectations(t)
+				es.AssertExpectations(t)
+			})
+		}
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "user1@email.com").Return(nil, errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		err := h.RequestPasswordReset(context.Background(), "user1@email.com", "")
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestResetPassword(t *testing.T) {
+	dbQuery := "select reset_password($1::uuid, $2::text)"
+
+	t.Run("successful password reset", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "passwordResetCode", mock.MatchedBy(func(hashedPassword string) bool {
+			return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte("password")) == nil
+		})).Return(true, nil)
+		h := New(db, nil)
+
+		updated, err := h.ResetPassword(context.Background(), "passwordResetCode", "password")
+		assert.NoError(t, err)
+		assert.True(t, updated)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("database error resetting password", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "passwordResetCode", mock.Anything).Return(false, errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		updated, err := h.ResetPassword(context.Background(), "passwordResetCode", "password")
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		assert.False(t, updated)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestCheckCredentials(t *testing.T) {
+	dbQuery := `select user_id, coalesce(password, ''), email_verified from "user" where email = $1`
+
+	t.Run("credentials provided not found in database", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, mock.Anything).Return(nil, pgx.ErrNoRows)
+		h := New(db, nil)
+
+		output, err := h.CheckCredentials(context.Background(), "user", "pass")
+		assert.NoError(t, err)
+		assert.False(t, output.Valid)
+		assert.Empty(t, output.UserID)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("error getting credentials from database", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, mock.Anything).Return(nil, errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		output, err := h.CheckCredentials(context.Background(), "user", "pass")
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		assert.Nil(t, output)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("invalid credentials provided", func(t *testing.T) {
+		pw, _ := bcrypt.GenerateFromPassword([]byte("pass"), bcrypt.DefaultCost)
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, mock.Anything).Return([]interface{}{"userID", string(pw), true}, nil)
+		h := New(db, nil)
+
+		output, err := h.CheckCredentials(context.Background(), "email", "pass2")
+		assert.NoError(t, err)
+		assert.False(t, output.Valid)
+		assert.Empty(t, output.UserID)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("valid credentials provided", func(t *testing.T) {
+		pw, _ := bcrypt.GenerateFromPassword([]byte("pass"), bcrypt.DefaultCost)
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, mock.Anything).Return([]interface{}{"userID", string(pw), true}, nil)
+		h := New(db, nil)
+
+		output, err := h.CheckCredentials(context.Background(), "email", "pass")
+		assert.NoError(t, err)
+		assert.True(t, output.Valid)
+		assert.Equal(t, "userID", output.UserID)
+		assert.True(t, output.EmailVerified)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("valid credentials provided but email not verified", func(t *testing.T) {
+		pw, _ := bcrypt.GenerateFromPassword([]byte("pass"), bcrypt.DefaultCost)
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, mock.Anything).Return([]interface{}{"userID", string(pw), false}, nil)
+		h := New(db, nil)
+
+		output, err := h.CheckCredentials(context.Background(), "email", "pass")
+		assert.NoError(t, err)
+		assert.True(t, output.Valid)
+		assert.Equal(t, "userID", output.UserID)
+		assert.False(t, output.EmailVerified)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestRegisterUserIdentity(t *testing.T) {
+	dbQuery := "select register_user_identity($1::jsonb)"
+	identity := &UserIdentity{
+		Provider:       "github",
+		ProviderUserID: "1234",
+		Alias:          "user1",
+		Email:          "user1@email.com",
+	}
+	identityJSON := []byte(`{"provider":"github","provider_user_id":"1234","alias":"user1","first_name":"","last_name":"","email":"user1@email.com"}`)
+
+	t.Run("identity registered successfully", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, identityJSON).Return("userID", nil)
+		h := New(db, nil)
+
+		userID, err := h.RegisterUserIdentity(context.Background(), identity)
+		assert.NoError(t, err)
+		assert.Equal(t, "userID", userID)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, identityJSON).Return(nil, errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		userID, err := h.RegisterUserIdentity(context.Background(), identity)
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		assert.Empty(t, userID)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestRegisterSession(t *testing.T) {
+	dbQuery := "select register_session($1::jsonb)"
+
+	s := &Session{
+		UserID:    "00000000-0000-0000-0000-000000000001",
+		IP:        "192.168.1.100",
+		UserAgent: "Safari 13.0.5",
+	}
+
+	t.Run("successful session registration", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, mock.Anything).Return([]byte("sessionID"), nil)
+		h := New(db, nil)
+
+		sessionID, err := h.RegisterSession(context.Background(), s)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("sessionID"), sessionID)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, mock.Anything).Return(nil, errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		sessionID, err := h.RegisterSession(context.Background(), s)
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		assert.Nil(t, sessionID)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestCheckSession(t *testing.T) {
+	dbQuery := `
+	select s.user_id, floor(extract(epoch from s.last_seen_at))
+	from session s
+	join "user" u using (user_id)
+	where s.session_id = $1
+	and u.email_verified = true
+	`
+	dbQueryUpdate := "update session set last_seen_at = current_timestamp where session_id = $1"
+
+	t.Run("session not found in database", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, mock.Anything).Return(nil, pgx.ErrNoRows)
+		h := New(db, nil)
+
+		output, err := h.CheckSession(context.Background(), []byte("sessionID"), 1*time.Hour)
+		assert.NoError(t, err)
+		assert.False(t, output.Valid)
+		assert.Empty(t, output.UserID)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("error getting session from database", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, mock.Anything).Return(nil, errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		output, err := h.CheckSession(context.Background(), []byte("sessionID"), 1*time.Hour)
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		assert.Nil(t, output)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("session has expired", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, mock.Anything).Return([]interface{}{"userID", int64(1)}, nil)
+		h := New(db, nil)
+
+		output, err := h.CheckSession(context.Background(), []byte("sessionID"), 1*time.Hour)
+		assert.NoError(t, err)
+		assert.False(t, output.Valid)
+		assert.Empty(t, output.UserID)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("valid session", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, mock.Anything).Return([]interface{}{
+			"userID",
+			time.Now().Unix(),
+		}, nil)
+		h := New(db, nil)
+
+		output, err := h.CheckSession(context.Background(), []byte("sessionID"), 1*time.Hour)
+		assert.NoError(t, err)
+		assert.True(t, output.Valid)
+		assert.Equal(t, "userID", output.UserID)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("valid session, last seen updated", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, mock.Anything).Return([]interface{}{
+			"userID",
+			time.Now().Add(-10 * time.Minute).Unix(),
+		}, nil)
+		db.On("Exec", dbQueryUpdate, []byte("sessionID")).Return(nil)
+		h := New(db, nil)
+
+		output, err := h.CheckSession(context.Background(), []byte("sessionID"), 1*time.Hour)
+		assert.NoError(t, err)
+		assert.True(t, output.Valid)
+		assert.Equal(t, "userID", output.UserID)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("error updating session last seen", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, mock.Anything).Return([]interface{}{
+			"userID",
+			time.Now().Add(-10 * time.Minute).Unix(),
+		}, nil)
+		db.On("Exec", dbQueryUpdate, []byte("sessionID")).Return(errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		output, err := h.CheckSession(context.Background(), []byte("sessionID"), 1*time.Hour)
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		assert.Nil(t, output)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestDeleteSession(t *testing.T) {
+	dbQuery := "select delete_session($1::bytea, $2::jsonb)"
+
+	t.Run("delete session", func(t *testing.T) {
+		testCases := []struct {
+			description string
+			dbResponse  interface{}
+		}{
+			{
+				"session deleted successfully",
+				nil,
+			},
+			{
+				"error deleting session from database",
+				errFakeDatabaseFailure,
+			},
+		}
+		for _, tc := range testCases {
+			tc := tc
+			t.Run(tc.description, func(t *testing.T) {
+				db := &tests.DBMock{}
+				db.On("Exec", dbQuery, []byte("sessionID"), []byte("{}")).Return(tc.dbResponse)
+				h := New(db, nil)
+
+				err := h.DeleteSession(context.Background(), []byte("sessionID"))
+				assert.Equal(t, tc.dbResponse, err)
+				db.AssertExpectations(t)
+			})
+		}
+	})
+}
+
+func TestGetUserSessionsJSON(t *testing.T) {
+	dbQuery := "select get_user_sessions($1::uuid, $2::bytea)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_, _ = h.GetUserSessionsJSON(context.Background(), nil)
+		})
+	})
+
+	t.Run("database query succeeded", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID", []byte("sessionID")).Return([]byte("dataJSON"), nil)
+		h := New(db, nil)
+
+		dataJSON, err := h.GetUserSessionsJSON(ctx, []byte("sessionID"))
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("dataJSON"), dataJSON)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID", []byte("sessionID")).Return(nil, errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		dataJSON, err := h.GetUserSessionsJSON(ctx, []byte("sessionID"))
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		assert.Nil(t, dataJSON)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestDeleteUserSession(t *testing.T) {
+	dbQuery := "select delete_user_session($1::uuid, $2::uuid, $3::jsonb)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_ = h.DeleteUserSession(context.Background(), "sessionID")
+		})
+	})
+
+	t.Run("delete user session", func(t *testing.T) {
+		testCases := []struct {
+			description string
+			dbResponse  interface{}
+		}{
+			{
+				"session deleted successfully",
+				nil,
+			},
+			{
+				"error deleting session from database",
+				errFakeDatabaseFailure,
+			},
+		}
+		for _, tc := range testCases {
+			tc := tc
+			t.Run(tc.description, func(t *testing.T) {
+				db := &tests.DBMock{}
+				db.On("Exec", dbQuery, "userID", "sessionID", []byte("{}")).Return(tc.dbResponse)
+				h := New(db, nil)
+
+				err := h.DeleteUserSession(ctx, "sessionID")
+				assert.Equal(t, tc.dbResponse, err)
+				db.AssertExpectations(t)
+			})
+		}
+	})
+}
+
+func TestDeleteUserSessions(t *testing.T) {
+	dbQuery := "select delete_user_sessions($1::uuid, $2::jsonb)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_ = h.DeleteUserSessions(context.Background())
+		})
+	})
+
+	t.Run("delete user sessions", func(t *testing.T) {
+		testCases := []struct {
+			description string
+			dbResponse  interface{}
+		}{
+			{
+				"sessions deleted successfully",
+				nil,
+			},
+			{
+				"error deleting sessions from database",
+				errFakeDatabaseFailure,
+			},
+		}
+		for _, tc := range testCases {
+			tc := tc
+			t.Run(tc.description, func(t *testing.T) {
+				db := &tests.DBMock{}
+				db.On("Exec", dbQuery, "userID", []byte("{}")).Return(tc.dbResponse)
+				h := New(db, nil)
+
+				err := h.DeleteUserSessions(ctx)
+				assert.Equal(t, tc.dbResponse, err)
+				db.AssertExpectations(t)
+			})
+		}
+	})
+}
+
+func TestGetAuditEventsJSON(t *testing.T) {
+	dbQuery := "select get_audit_events($1::uuid, $2::int, $3::int)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_, _ = h.GetAuditEventsJSON(context.Background(), 10, 0)
+		})
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID", 10, 0).Return(nil, errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		data, err := h.GetAuditEventsJSON(ctx, 10, 0)
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		assert.Nil(t, data)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("audit events data returned successfully", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID", 10, 0).Return([]byte("dataJSON"), nil)
+		h := New(db, nil)
+
+		data, err := h.GetAuditEventsJSON(ctx, 10, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("dataJSON"), data)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestDeleteExpiredSessions(t *testing.T) {
+	dbQuery := "delete from session where last_seen_at < $1"
+
+	testCases := []struct {
+		description string
+		dbResponse  interface{}
+	}{
+		{
+			"expired sessions deleted successfully",
+			nil,
+		},
+		{
+			"error deleting expired sessions from database",
+			errFakeDatabaseFailure,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.description, func(t *testing.T) {
+			db := &tests.DBMock{}
+			db.On("Exec", dbQuery, mock.Anything).Return(tc.dbResponse)
+			h := New(db, nil)
+
+			err := h.DeleteExpiredSessions(context.Background(), 1*time.Hour)
+			assert.Equal(t, tc.dbResponse, err)
+			cutoff := db.Calls[0].Arguments.Get(1).(time.Time)
+			assert.WithinDuration(t, time.Now().Add(-1*time.Hour), cutoff, 1*time.Minute)
+			db.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAddAPIKey(t *testing.T) {
+	dbQuery := "select add_api_key($1::uuid, $2::jsonb, $3::jsonb)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_, _ = h.AddAPIKey(context.Background(), "key1")
+		})
+	})
+
+	t.Run("api key added successfully", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID", mock.Anything, []byte("{}")).Return("apiKeyID", nil)
+		h := New(db, nil)
+
+		output, err := h.AddAPIKey(ctx, "key1")
+		assert.NoError(t, err)
+		assert.Equal(t, "apiKeyID", output.APIKeyID)
+		assert.Len(t, output.Key, 2*apiKeyLength)
+
+		// Only the hash of the key is stored
+		akJSON := db.Calls[0].Arguments.Get(2).([]byte)
+		ak := &APIKey{}
+		require.NoError(t, json.Unmarshal(akJSON, ak))
+		assert.Equal(t, "key1", ak.Name)
+		assert.Equal(t, hashAPIKey(output.Key), ak.KeyHash)
+		assert.NotEqual(t, output.Key, ak.KeyHash)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID", mock.Anything, []byte("{}")).Return(nil, errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		output, err := h.AddAPIKey(ctx, "key1")
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		assert.Nil(t, output)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestGetUserAPIKeysJSON(t *testing.T) {
+	dbQuery := "select get_user_api_keys($1::uuid)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_, _ = h.GetUserAPIKeysJSON(context.Background())
+		})
+	})
+
+	t.Run("database query succeeded", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID").Return([]byte("dataJSON"), nil)
+		h := New(db, nil)
+
+		dataJSON, err := h.GetUserAPIKeysJSON(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("dataJSON"), dataJSON)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID").Return(nil, errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		dataJSON, err := h.GetUserAPIKeysJSON(ctx)
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		assert.Nil(t, dataJSON)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestDeleteAPIKey(t *testing.T) {
+	dbQuery := "select delete_api_key($1::uuid, $2::uuid, $3::jsonb)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_ = h.DeleteAPIKey(context.Background(), "apiKeyID")
+		})
+	})
+
+	t.Run("delete api key", func(t *testing.T) {
+		testCases := []struct {
+			description   string
+			dbResponse    error
+			expectedError error
+		}{
+			{
+				"api key deleted successfully",
+				nil,
+				nil,
+			},
+			{
+				"error deleting api key from database",
+				errFakeDatabaseFailure,
+				errFakeDatabaseFailure,
+			},
+			{
+				"api key not found",
+				errNoDataFound,
+				pgx.ErrNoRows,
+			},
+		}
+		for _, tc := range testCases {
+			tc := tc
+			t.Run(tc.description, func(t *testing.T) {
+				db := &tests.DBMock{}
+				db.On("Exec", dbQuery, "userID", "apiKeyID", []byte("{}")).Return(tc.dbResponse)
+				h := New(db, nil)
+
+				err := h.DeleteAPIKey(ctx, "apiKeyID")
+				assert.Equal(t, tc.expectedError, err)
+				db.AssertExpectations(t)
+			})
+		}
+	})
+}
+
+func TestCheckAPIKey(t *testing.T) {
+	dbQuery := `
+	select k.user_id
+	from api_key k
+	join "user" u using (user_id)
+	where k.key_hash = $1
+	and u.email_verified = true
+	`
+	keyHash := hashAPIKey("key")
+
+	t.Run("api key not found in database", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, keyHash).Return(nil, pgx.ErrNoRows)
+		h := New(db, nil)
+
+		output, err := h.CheckAPIKey(context.Background(), "key")
+		assert.NoError(t, err)
+		assert.False(t, output.Valid)
+		assert.Empty(t, output.UserID)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("error getting api key from database", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, keyHash).Return(nil, errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		output, err := h.CheckAPIKey(context.Background(), "key")
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		assert.Nil(t, output)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("valid api key", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, keyHash).Return("userID", nil)
+		h := New(db, nil)
+
+		output, err := h.CheckAPIKey(context.Background(), "key")
+		assert.NoError(t, err)
+		assert.True(t, output.Valid)
+		assert.Equal(t, "userID", output.UserID)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestGetUserAlias(t *testing.T) {
+	dbQuery := `select alias from "user" where user_id = $1`
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+
+	t.Run("database query succeeded", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, mock.Anything).Return("alias", nil)
+		h := New(db, nil)
+
+		alias, err := h.GetUserAlias(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, "alias", alias)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, mock.Anything).Return("", errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		alias, err := h.GetUserAlias(ctx)
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		assert.Empty(t, alias)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestGetUserProfileJSON(t *testing.T) {
+	dbQuery := `
+	select json_build_object(
+		'alias', alias,
+		'first_name', first_name,
+		'last_name', last_name,
+		'email', email
+	)
+	from "user" where user_id = $1`
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_, _ = h.GetUserProfileJSON(context.Background())
+		})
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID").Return(nil, errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		data, err := h.GetUserProfileJSON(ctx)
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		assert.Nil(t, data)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("user profile returned successfully", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID").Return([]byte("profileJSON"), nil)
+		h := New(db, nil)
+
+		data, err := h.GetUserProfileJSON(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("profileJSON"), data)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestUpdateUserProfile(t *testing.T) {
+	dbQuery := `
+	update "user" set
+		alias = $2,
+		first_name = nullif($3, ''),
+		last_name = nullif($4, '')
+	where user_id = $1`
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+	u := &User{Alias: "alias", FirstName: "first_name", LastName: "last_name"}
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_ = h.UpdateUserProfile(context.Background(), u)
+		})
+	})
+
+	t.Run("database update succeeded", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, "userID", "alias", "first_name", "last_name").Return(nil)
+		h := New(db, nil)
+
+		err := h.UpdateUserProfile(ctx, u)
+		assert.NoError(t, err)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, "userID", "alias", "first_name", "last_name").Return(errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		err := h.UpdateUserProfile(ctx, u)
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestUpdateUserPassword(t *testing.T) {
+	dbQueryGet := `select coalesce(password, '') from "user" where user_id = $1`
+	dbQueryUpdate := "select update_user_password($1::uuid, $2::text, $3::bytea)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("old"), bcrypt.DefaultCost)
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_, _ = h.UpdateUserPassword(context.Background(), "old", "new", nil)
+		})
+	})
+
+	t.Run("database error getting current password", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQueryGet, "userID").Return(nil, errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		updated, err := h.UpdateUserPassword(ctx, "old", "new", []byte("sessionID"))
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		assert.False(t, updated)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("invalid old password provided", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQueryGet, "userID").Return(string(hashedPassword), nil)
+		h := New(db, nil)
+
+		updated, err := h.UpdateUserPassword(ctx, "invalid", "new", []byte("sessionID"))
+		assert.NoError(t, err)
+		assert.False(t, updated)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("database error updating password", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQueryGet, "userID").Return(string(hashedPassword), nil)
+		db.On("Exec", dbQueryUpdate, "userID", mock.Anything, []byte("sessionID")).
+			Return(errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		updated, err := h.UpdateUserPassword(ctx, "old", "new", []byte("sessionID"))
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		assert.False(t, updated)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("password updated successfully", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQueryGet, "userID").Return(string(hashedPassword), nil)
+		db.On("Exec", dbQueryUpdate, "userID", mock.MatchedBy(func(newHashedPassword string) bool {
+			return bcrypt.CompareHashAndPassword([]byte(newHashedPassword), []byte("new")) == nil
+		}), []byte("sessionID")).Return(nil)
+		h := New(db, nil)
+
+		updated, err := h.UpdateUserPassword(ctx, "old", "new", []byte("sessionID"))
+		assert.NoError(t, err)
+		assert.True(t, updated)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("password set by user without password", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQueryGet, "userID").Return("", nil)
+		db.On("Exec", dbQueryUpdate, "userID", mock.MatchedBy(func(newHashedPassword string) bool {
+			return bcrypt.CompareHashAndPassword([]byte(newHashedPassword), []byte("new")) == nil
+		}), []byte("sessionID")).Return(nil)
+		h := New(db, nil)
+
+		updated, err := h.UpdateUserPassword(ctx, "", "new", []byte("sessionID"))
+		assert.NoError(t, err)
+		assert.True(t, updated)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestUpdateUserEmail(t *testing.T) {
+	dbQueryGet := `select email from "user" where user_id = $1`
+	dbQuery := "select update_user_email($1::uuid, $2::text)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_ = h.UpdateUserEmail(context.Background(), "new@email.com", "")
+		})
+	})
+
+	t.Run("database error getting current email", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQueryGet, "userID").Return(nil, errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		err := h.UpdateUserEmail(ctx, "new@email.com", "")
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQueryGet, "userID").Return("old@email.com", nil)
+		db.On("QueryRow", dbQuery, "userID", "new@email.com").Return(nil, errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		err := h.UpdateUserEmail(ctx, "new@email.com", "")
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("email verification code sent to the new email", func(t *testing.T) {
+		testCases := []struct {
+			description                string
+			verificationSenderResponse error
+			notificationSenderResponse error
+		}{
+			{
+				"emails sent successfully",
+				nil,
+				nil,
+			},
+			{
+				"error sending email verification code",
+				errFakeEmailSenderFailure,
+				nil,
+			},
+			{
+				"error notifying current email",
+				nil,
+				errFakeEmailSenderFailure,
+			},
+		}
+		for _, tc := range testCases {
+			tc := tc
+			t.Run(tc.description, func(t *testing.T) {
+				db := &tests.DBMock{}
+				db.On("QueryRow", dbQueryGet, "userID").Return("old@email.com", nil)
+				db.On("QueryRow", dbQuery, "userID", "new@email.com").Return("emailVerificationCode", nil)
+				es := &tests.EmailSenderMock{}
+				es.On("SendEmail", mock.MatchedBy(func(data *email.Data) bool {
+					return data.To == "new@email.com" &&
+						strings.Contains(string(data.Body), "http://baseurl/verifyEmail?code=emailVerificationCode")
+				})).Return(tc.verificationSenderResponse)
+				if tc.verificationSenderResponse == nil {
+					es.On("SendEmail", mock.MatchedBy(func(data *email.Data) bool {
+						return data.To == "old@email.com" && strings.Contains(string(data.Body), "new@email.com")
+					})).Return(tc.notificationSenderResponse)
+				}
+				h := New(db, es)
+
+				err := h.UpdateUserEmail(ctx, "new@email.com", "http://baseurl")
+				if tc.verificationSenderResponse != nil {
+					assert.Equal(t, tc.verificationSenderResponse, err)
+				} else {
+					assert.Equal(t, tc.notificationSenderResponse, err)
+				}
+				db.AssertExpectations(t)
+				es.AssertExpectations(t)
+			})
+		}
+	})
+}
+
+func TestDeleteUser(t *testing.T) {
+	dbQuery := `delete from "user" where user_id = $1`
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_ = h.DeleteUser(context.Background())
+		})
+	})
+
+	t.Run("database delete succeeded", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, "userID").Return(nil)
+		h := New(db, nil)
+
+		err := h.DeleteUser(ctx)
+		assert.NoError(t, err)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, "userID").Return(errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		err := h.DeleteUser(ctx)
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestGetUserNotificationsSettingsJSON(t *testing.T) {
+	dbQuery := `
+	select json_build_object('tracking_errors', tracking_errors_notifications)
+	from "user" where user_id = $1`
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_, _ = h.GetUserNotificationsSettingsJSON(context.Background())
+		})
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID").Return(nil, errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		data, err := h.GetUserNotificationsSettingsJSON(ctx)
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		assert.Nil(t, data)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("notifications settings returned successfully", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID").Return([]byte("settingsJSON"), nil)
+		h := New(db, nil)
+
+		data, err := h.GetUserNotificationsSettingsJSON(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("settingsJSON"), data)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestUpdateUserNotificationsSettings(t *testing.T) {
+	dbQuery := `
+	update "user" set tracking_errors_notifications = $2
+	where user_id = $1`
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+	s := &NotificationsSettings{TrackingErrors: false}
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_ = h.UpdateUserNotificationsSettings(context.Background(), s)
+		})
+	})
+
+	t.Run("database update succeeded", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, "userID", false).Return(nil)
+		h := New(db, nil)
+
+		err := h.UpdateUserNotificationsSettings(ctx, s)
+		assert.NoError(t, err)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, "userID", false).Return(errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		err := h.UpdateUserNotificationsSettings(ctx, s)
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestAddSubscription(t *testing.T) {
+	dbQuery := "select add_subscription($1::uuid, $2::jsonb)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+	s := &Subscription{PackageID: "packageID", EventKind: NewRelease}
+	sJSON := []byte(`{"package_id":"packageID","event_kind":0}`)
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_ = h.AddSubscription(context.Background(), s)
+		})
+	})
+
+	t.Run("database update succeeded", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, "userID", sJSON).Return(nil)
+		h := New(db, nil)
+
+		err := h.AddSubscription(ctx, s)
+		assert.NoError(t, err)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, "userID", sJSON).Return(errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		err := h.AddSubscription(ctx, s)
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		db.AssertExpectations(t)
+	})
 }
 
-func TestGetPackagesUpdatesJSON(t *testing.T) {
-	dbQuery := "select get_packages_updates()"
-	db := &tests.DBMock{}
-	db.On("QueryRow", dbQuery).Return([]byte("packagesUpdatesDataJSON"), nil)
-	h := New(db, nil)
+func TestDeleteSubscription(t *testing.T) {
+	dbQuery := "select delete_subscription($1::uuid, $2::jsonb)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+	s := &Subscription{PackageID: "packageID", EventKind: NewRelease}
+	sJSON := []byte(`{"package_id":"packageID","event_kind":0}`)
 
-	data, err := h.GetPackagesUpdatesJSON(context.Background())
-	assert.NoError(t, err)
-	assert.Equal(t, []byte("packagesUpdatesDataJSON"), data)
-	db.AssertExpectations(t)
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_ = h.DeleteSubscription(context.Background(), s)
+		})
+	})
+
+	t.Run("database update succeeded", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, "userID", sJSON).Return(nil)
+		h := New(db, nil)
+
+		err := h.DeleteSubscription(ctx, s)
+		assert.NoError(t, err)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, "userID", sJSON).Return(errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		err := h.DeleteSubscription(ctx, s)
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		db.AssertExpectations(t)
+	})
 }
 
-func TestRegisterUser(t *testing.T) {
-	dbQuery := "select register_user($1::jsonb)"
+func TestGetUserSubscriptionsJSON(t *testing.T) {
+	dbQuery := "select get_user_subscriptions($1::uuid)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
 
-	t.Run("successful user registration in database", func(t *testing.T) {
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_, _ = h.GetUserSubscriptionsJSON(context.Background())
+		})
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID").Return(nil, errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		data, err := h.GetUserSubscriptionsJSON(ctx)
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		assert.Nil(t, data)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("user subscriptions returned successfully", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID").Return([]byte("subscriptionsJSON"), nil)
+		h := New(db, nil)
+
+		data, err := h.GetUserSubscriptionsJSON(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("subscriptionsJSON"), data)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestGenerateNotifications(t *testing.T) {
+	dbQuery := "select generate_notifications()"
+
+	t.Run("database update succeeded", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery).Return(nil)
+		h := New(db, nil)
+
+		err := h.GenerateNotifications(context.Background())
+		assert.NoError(t, err)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery).Return(errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		err := h.GenerateNotifications(context.Background())
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestClaimNotifications(t *testing.T) {
+	dbQuery := "select claim_notifications($1::int, $2::int)"
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, 10, 5).Return(nil, errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		notifications, err := h.ClaimNotifications(context.Background(), 10, 5)
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		assert.Nil(t, notifications)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("notifications claimed successfully", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, 10, 5).Return([]byte(`
+		[{
+			"notification_id": "00000000-0000-0000-0000-000000000001",
+			"email": "user1@email.com",
+			"event_kind": 0,
+			"package": {
+				"package_id": "00000000-0000-0000-0000-000000000001",
+				"kind": 0,
+				"name": "package1",
+				"display_name": "Package 1",
+				"version": "1.0.0",
+				"chart_repository": {
+					"name": "repo1",
+					"display_name": "Repo 1"
+				},
+				"operator_provider": null
+			}
+		}]
+		`), nil)
+		h := New(db, nil)
+
+		notifications, err := h.ClaimNotifications(context.Background(), 10, 5)
+		require.NoError(t, err)
+		assert.Equal(t, []*Notification{
+			{
+				NotificationID: "00000000-0000-0000-0000-000000000001",
+				Email:          "user1@email.com",
+				EventKind:      NewRelease,
+				Package: &Package{
+					PackageID:   "00000000-0000-0000-0000-000000000001",
+					Kind:        Chart,
+					Name:        "package1",
+					DisplayName: "Package 1",
+					Version:     "1.0.0",
+					ChartRepository: &ChartRepository{
+						Name:        "repo1",
+						DisplayName: "Repo 1",
+					},
+				},
+			},
+		}, notifications)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestSendNotificationsEmail(t *testing.T) {
+	n1 := &Notification{
+		NotificationID: "notification1",
+		Email:          "user1@email.com",
+		EventKind:      NewRelease,
+		Package: &Package{
+			Name:            "package1",
+			DisplayName:     "Package 1",
+			Version:         "1.0.0",
+			ChartRepository: &ChartRepository{Name: "repo1"},
+		},
+	}
+	n2 := &Notification{
+		NotificationID: "notification2",
+		Email:          "user1@email.com",
+		EventKind:      NewRelease,
+		Package: &Package{
+			Name:             "package2",
+			Version:          "2.0.0",
+			OperatorProvider: &OperatorProvider{Name: "provider1"},
+		},
+	}
+
+	t.Run("email sender not available", func(t *testing.T) {
+		h := New(nil, nil)
+
+		err := h.SendNotificationsEmail("user1@email.com", []*Notification{n1}, "http://hub")
+		assert.Error(t, err)
+	})
+
+	t.Run("single release notified", func(t *testing.T) {
+		es := &tests.EmailSenderMock{}
+		es.On("SendEmail", mock.MatchedBy(func(data *email.Data) bool {
+			return data.To == "user1@email.com" &&
+				data.Subject == "Package 1 version 1.0.0 released" &&
+				strings.Contains(string(data.Body), "http://hub/package/chart/repo1/package1/1.0.0")
+		})).Return(nil)
+		h := New(nil, es)
+
+		err := h.SendNotificationsEmail("user1@email.com", []*Notification{n1}, "http://hub")
+		assert.NoError(t, err)
+		es.AssertExpectations(t)
+	})
+
+	t.Run("several releases notified", func(t *testing.T) {
+		es := &tests.EmailSenderMock{}
+		es.On("SendEmail", mock.MatchedBy(func(data *email.Data) bool {
+			body := string(data.Body)
+			return data.Subject == "New releases of packages you are subscribed to" &&
+				strings.Contains(body, "http://hub/package/chart/repo1/package1/1.0.0") &&
+				strings.Contains(body, "http://hub/package/operator/provider1/package2/2.0.0")
+		})).Return(errFakeEmailSenderFailure)
+		h := New(nil, es)
+
+		err := h.SendNotificationsEmail("user1@email.com", []*Notification{n1, n2}, "http://hub")
+		assert.Equal(t, errFakeEmailSenderFailure, err)
+		es.AssertExpectations(t)
+	})
+}
+
+func TestCompleteNotifications(t *testing.T) {
+	ids := []string{"notification1", "notification2"}
+
+	t.Run("notifications sent", func(t *testing.T) {
+		dbQuery := `
+		update notification set processed = true, processed_at = current_timestamp, error = null
+		where notification_id = any($1::uuid[])`
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, ids).Return(nil)
+		h := New(db, nil)
+
+		err := h.CompleteNotifications(context.Background(), ids, nil)
+		assert.NoError(t, err)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("notifications not sent", func(t *testing.T) {
+		dbQuery := `
+		update notification set error = $2
+		where notification_id = any($1::uuid[])`
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, ids, errFakeEmailSenderFailure.Error()).Return(errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		err := h.CompleteNotifications(context.Background(), ids, errFakeEmailSenderFailure)
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestCheckAvailability(t *testing.T) {
+	t.Run("resource kind not supported", func(t *testing.T) {
+		h := New(nil, nil)
+		_, err := h.CheckAvailability(context.Background(), "invalidKind", "value")
+		assert.Error(t, err)
+	})
+
+	t.Run("database query succeeded", func(t *testing.T) {
 		testCases := []struct {
-			description         string
-			emailSenderResponse error
+			resourceKind string
+			dbQuery      string
+			available    bool
 		}{
 			{
-				"email verification code sent successfully",
-				nil,
+				"userAlias",
+				`select user_id from "user" where alias = $1`,
+				true,
 			},
 			{
-				"error sending email verification code",
-				errFakeEmailSenderFailure,
+				"chartRepositoryName",
+				`select chart_repository_id from chart_repository where name = $1`,
+				true,
+			},
+			{
+				"chartRepositoryURL",
+				`select chart_repository_id from chart_repository where url = $1`,
+				false,
+			},
+			{
+				"organizationName",
+				`select organization_id from organization where name = $1`,
+				true,
 			},
 		}
 		for _, tc := range testCases {
 			tc := tc
-			t.Run(tc.description, func(t *testing.T) {
+			t.Run(fmt.Sprintf("resource kind: %s", tc.resourceKind), func(t *testing.T) {
+				tc.dbQuery = fmt.Sprintf("select not exists (%s)", tc.dbQuery)
 				db := &tests.DBMock{}
-				db.On("QueryRow", dbQuery, mock.Anything).Return("emailVerificationCode", nil)
-				es := &tests.EmailSenderMock{}
-				es.On("SendEmail", mock.Anything).Return(tc.emailSenderResponse)
-				h := New(db, es)
+				db.On("QueryRow", tc.dbQuery, mock.Anything).Return(tc.available, nil)
+				h := New(db, nil)
 
-				u := &User{
-					Alias:     "alias",
-					FirstName: "first_name",
-					LastName:  "last_name",
-					Email:     "email@email.com",
-					Password:  "password",
-				}
-				err := h.RegisterUser(context.Background(), u, "")
-				assert.Equal(t, tc.emailSenderResponse, err)
-				assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(u.Password), []byte("password")))
+				available, err := h.CheckAvailability(context.Background(), tc.resourceKind, "value")
+				assert.NoError(t, err)
+				assert.Equal(t, tc.available, available)
 				db.AssertExpectations(t)
-				es.AssertExpectations(t)
 			})
 		}
 	})
 
-	t.Run("database error registering user", func(t *testing.T) {
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		dbQuery := `select not exists (select user_id from "user" where alias = $1)`
+		db.On("QueryRow", dbQuery, mock.Anything).Return(false, errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		available, err := h.CheckAvailability(context.Background(), "userAlias", "value")
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		assert.False(t, available)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestRegisterTrackingRun(t *testing.T) {
+	dbQuery := "select register_tracking_run($1::jsonb)"
+	run := &TrackingRun{
+		ChartRepositoryID: "00000000-0000-0000-0000-000000000001",
+		StartedAt:         1,
+		FinishedAt:        2,
+		VersionsProcessed: 1,
+		VersionsFailed:    1,
+		Errors: []*TrackingError{
+			{Kind: TrackingErrorRegister, Message: "error1"},
+		},
+	}
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, mock.Anything).Return(errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		err := h.RegisterTrackingRun(context.Background(), run)
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("tracking run registered successfully", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("Exec", dbQuery, mock.Anything).Return(nil)
+		h := New(db, nil)
+
+		err := h.RegisterTrackingRun(context.Background(), run)
+		assert.NoError(t, err)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestGetTrackingRunsJSON(t *testing.T) {
+	dbQuery := "select get_tracking_runs($1::uuid, $2::text, $3::int, $4::int)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_, _ = h.GetTrackingRunsJSON(context.Background(), "repo1", 10, 0)
+		})
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID", "repo1", 10, 0).Return(nil, errFakeDatabaseFailure)
+		h := New(db, nil)
+
+		data, err := h.GetTrackingRunsJSON(ctx, "repo1", 10, 0)
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		assert.Nil(t, data)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("tracking runs returned successfully", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID", "repo1", 10, 0).Return([]byte("trackingRunsJSON"), nil)
+		h := New(db, nil)
+
+		data, err := h.GetTrackingRunsJSON(ctx, "repo1", 10, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("trackingRunsJSON"), data)
+		db.AssertExpectations(t)
+	})
+}
+
+func TestRotateChartRepositoryWebhookSecret(t *testing.T) {
+	dbQuery := "select rotate_chart_repository_webhook_secret($1::uuid, $2::text, $3::jsonb)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_, _ = h.RotateChartRepositoryWebhookSecret(context.Background(), "repo1")
+		})
+	})
+
+	t.Run("database error", func(t *testing.T) {
 		db := &tests.DBMock{}
-		db.On("QueryRow", dbQuery, mock.Anything).Return("", errFakeDatabaseFailure)
+		db.On("QueryRow", dbQuery, "userID", "repo1", []byte("{}")).Return(nil, errFakeDatabaseFailure)
 		h := New(db, nil)
 
-		u := &User{}
-		err := h.RegisterUser(context.Background(), u, "")
+		secret, err := h.RotateChartRepositoryWebhookSecret(ctx, "repo1")
 		assert.Equal(t, errFakeDatabaseFailure, err)
+		assert.Empty(t, secret)
 		db.AssertExpectations(t)
 	})
-}
-
-func TestVerifyEmail(t *testing.T) {
-	dbQuery := "select verify_email($1::uuid)"
 
-	t.Run("successful email verification", func(t *testing.T) {
+	t.Run("secret rotated successfully", func(t *testing.T) {
 		db := &tests.DBMock{}
-		db.On("QueryRow", dbQuery, mock.Anything).Return(true, nil)
+		db.On("QueryRow", dbQuery, "userID", "repo1", []byte("{}")).Return("secret", nil)
 		h := New(db, nil)
 
-		verified, err := h.VerifyEmail(context.Background(), "emailVerificationCode")
+		secret, err := h.RotateChartRepositoryWebhookSecret(ctx, "repo1")
 		assert.NoError(t, err)
-		assert.True(t, verified)
+		assert.Equal(t, "secret", secret)
 		db.AssertExpectations(t)
 	})
+}
 
-	t.Run("database error verifying email", func(t *testing.T) {
+func TestDeleteChartRepositoryWebhookSecret(t *testing.T) {
+	dbQuery := "select delete_chart_repository_webhook_secret($1::uuid, $2::text, $3::jsonb)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_ = h.DeleteChartRepositoryWebhookSecret(context.Background(), "repo1")
+		})
+	})
+
+	t.Run("database error", func(t *testing.T) {
 		db := &tests.DBMock{}
-		db.On("QueryRow", dbQuery, mock.Anything).Return(false, errFakeDatabaseFailure)
+		db.On("Exec", dbQuery, "userID", "repo1", []byte("{}")).Return(errFakeDatabaseFailure)
 		h := New(db, nil)
 
-		verified, err := h.VerifyEmail(context.Background(), "emailVerificationCode")
+		err := h.DeleteChartRepositoryWebhookSecret(ctx, "repo1")
 		assert.Equal(t, errFakeDatabaseFailure, err)
-		assert.False(t, verified)
 		db.AssertExpectations(t)
 	})
-}
 
-func TestCheckCredentials(t *testing.T) {
-	dbQuery := `select user_id, password from "user" where email = $1`
-
-	t.Run("credentials provided not found in database", func(t *testing.T) {
+	t.Run("secret deleted successfully", func(t *testing.T) {
 		db := &tests.DBMock{}
-		db.On("QueryRow", dbQuery, mock.Anything).Return(nil, pgx.ErrNoRows)
+		db.On("Exec", dbQuery, "userID", "repo1", []byte("{}")).Return(nil)
 		h := New(db, nil)
 
-		output, err := h.CheckCredentials(context.Background(), "user", "pass")
+		err := h.DeleteChartRepositoryWebhookSecret(ctx, "repo1")
 		assert.NoError(t, err)
-		assert.False(t, output.Valid)
-		assert.Empty(t, output.UserID)
 		db.AssertExpectations(t)
 	})
+}
 
-	t.Run("error getting credentials from database", func(t *testing.T) {
+func TestGetChartRepositoryWebhookSecret(t *testing.T) {
+	dbQuery := "select coalesce(webhook_secret, '') from chart_repository where name = $1"
+
+	t.Run("database error", func(t *testing.T) {
 		db := &tests.DBMock{}
-		db.On("QueryRow", dbQuery, mock.Anything).Return(nil, errFakeDatabaseFailure)
+		db.On("QueryRow", dbQuery, "repo1").Return(nil, errFakeDatabaseFailure)
 		h := New(db, nil)
 
-		output, err := h.CheckCredentials(context.Background(), "user", "pass")
+		secret, err := h.GetChartRepositoryWebhookSecret(context.Background(), "repo1")
 		assert.Equal(t, errFakeDatabaseFailure, err)
-		assert.Nil(t, output)
+		assert.Empty(t, secret)
 		db.AssertExpectations(t)
 	})
 
-	t.Run("invalid credentials provided", func(t *testing.T) {
-		pw, _ := bcrypt.GenerateFromPassword([]byte("pass"), bcrypt.DefaultCost)
+	t.Run("secret returned successfully", func(t *testing.T) {
 		db := &tests.DBMock{}
-		db.On("QueryRow", dbQuery, mock.Anything).Return([]interface{}{"userID", string(pw)}, nil)
+		db.On("QueryRow", dbQuery, "repo1").Return("secret", nil)
 		h := New(db, nil)
 
-		output, err := h.CheckCredentials(context.Background(), "email", "pass2")
+		secret, err := h.GetChartRepositoryWebhookSecret(context.Background(), "repo1")
 		assert.NoError(t, err)
-		assert.False(t, output.Valid)
-		assert.Empty(t, output.UserID)
+		assert.Equal(t, "secret", secret)
 		db.AssertExpectations(t)
 	})
+}
 
-	t.Run("valid credentials provided", func(t *testing.T) {
-		pw, _ := bcrypt.GenerateFromPassword([]byte("pass"), bcrypt.DefaultCost)
+func TestAddWebhook(t *testing.T) {
+	dbQuery := "select add_webhook($1::uuid, $2::text, $3::jsonb, $4::jsonb)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+	wh := &Webhook{URL: "https://url.test", Secret: "secret", EventKinds: []EventKind{NewRelease}}
+	whJSON := []byte(`{"webhook_id":"","url":"https://url.test","secret":"secret","event_kinds":[0]}`)
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_, _ = h.AddWebhook(context.Background(), "repo1", wh)
+		})
+	})
+
+	t.Run("database error", func(t *testing.T) {
 		db := &tests.DBMock{}
-		db.On("QueryRow", dbQuery, mock.Anything).Return([]interface{}{"userID", string(pw)}, nil)
+		db.On("QueryRow", dbQuery, "userID", "repo1", whJSON, []byte("{}")).Return(nil, errFakeDatabaseFailure)
 		h := New(db, nil)
 
-		output, err := h.CheckCredentials(context.Background(), "email", "pass")
+		webhookID, err := h.AddWebhook(ctx, "repo1", wh)
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		assert.Empty(t, webhookID)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("webhook added successfully", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID", "repo1", whJSON, []byte("{}")).Return("webhookID", nil)
+		h := New(db, nil)
+
+		webhookID, err := h.AddWebhook(ctx, "repo1", wh)
 		assert.NoError(t, err)
-		assert.True(t, output.Valid)
-		assert.Equal(t, "userID", output.UserID)
+		assert.Equal(t, "webhookID", webhookID)
 		db.AssertExpectations(t)
 	})
 }
 
-func TestRegisterSession(t *testing.T) {
-	dbQuery := "select register_session($1::jsonb)"
+func TestDeleteWebhook(t *testing.T) {
+	dbQuery := "select delete_webhook($1::uuid, $2::text, $3::uuid, $4::jsonb)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
 
-	s := &Session{
-		UserID:    "00000000-0000-0000-0000-000000000001",
-		IP:        "192.168.1.100",
-		UserAgent: "Safari 13.0.5",
-	}
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_ = h.DeleteWebhook(context.Background(), "repo1", "webhookID")
+		})
+	})
 
-	t.Run("successful session registration", func(t *testing.T) {
+	t.Run("database error", func(t *testing.T) {
 		db := &tests.DBMock{}
-		db.On("QueryRow", dbQuery, mock.Anything).Return([]byte("sessionID"), nil)
+		db.On("QueryRow", dbQuery, "userID", "repo1", "webhookID", []byte("{}")).Return(nil, errFakeDatabaseFailure)
 		h := New(db, nil)
 
-		sessionID, err := h.RegisterSession(context.Background(), s)
+		err := h.DeleteWebhook(ctx, "repo1", "webhookID")
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		db.AssertExpectations(t)
+	})
+
+	t.Run("webhook deleted successfully", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, "userID", "repo1", "webhookID", []byte("{}")).Return("webhookID", nil)
+		h := New(db, nil)
+
+		err := h.DeleteWebhook(ctx, "repo1", "webhookID")
 		assert.NoError(t, err)
-		assert.Equal(t, []byte("sessionID"), sessionID)
 		db.AssertExpectations(t)
 	})
+}
+
+func TestGetChartRepositoryWebhooksJSON(t *testing.T) {
+	dbQuery := "select get_chart_repository_webhooks($1::uuid, $2::text)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_, _ = h.GetChartRepositoryWebhooksJSON(context.Background(), "repo1")
+		})
+	})
 
 	t.Run("database error", func(t *testing.T) {
 		db := &tests.DBMock{}
-		db.On("QueryRow", dbQuery, mock.Anything).Return(nil, errFakeDatabaseFailure)
+		db.On("QueryRow", dbQuery, "userID", "repo1").Return(nil, errFakeDatabaseFailure)
 		h := New(db, nil)
 
-		sessionID, err := h.RegisterSession(context.Background(), s)
+		data, err := h.GetChartRepositoryWebhooksJSON(ctx, "repo1")
 		assert.Equal(t, errFakeDatabaseFailure, err)
-		assert.Nil(t, sessionID)
+		assert.Nil(t, data)
 		db.AssertExpectations(t)
 	})
-}
-
-func TestCheckSession(t *testing.T) {
-	dbQuery := `
-	select user_id, floor(extract(epoch from created_at))
-	from session where session_id = $1
-	`
 
-	t.Run("session not found in database", func(t *testing.T) {
+	t.Run("webhooks returned successfully", func(t *testing.T) {
 		db := &tests.DBMock{}
-		db.On("QueryRow", dbQuery, mock.Anything).Return(nil, pgx.ErrNoRows)
+		db.On("QueryRow", dbQuery, "userID", "repo1").Return([]byte("webhooksJSON"), nil)
 		h := New(db, nil)
 
-		output, err := h.CheckSession(context.Background(), []byte("sessionID"), 1*time.Hour)
+		data, err := h.GetChartRepositoryWebhooksJSON(ctx, "repo1")
 		assert.NoError(t, err)
-		assert.False(t, output.Valid)
-		assert.Empty(t, output.UserID)
+		assert.Equal(t, []byte("webhooksJSON"), data)
 		db.AssertExpectations(t)
 	})
+}
 
-	t.Run("error getting session from database", func(t *testing.T) {
+func TestGetWebhookDeliveriesJSON(t *testing.T) {
+	dbQuery := "select get_webhook_deliveries($1::uuid, $2::text, $3::uuid, $4::int, $5::int)"
+	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+
+	t.Run("user id not found in ctx", func(t *testing.T) {
+		h := New(nil, nil)
+		assert.Panics(t, func() {
+			_, _ = h.GetWebhookDeliveriesJSON(context.Background(), "repo1", "webhookID", 10, 0)
+		})
+	})
+
+	t.Run("database error", func(t *testing.T) {
 		db := &tests.DBMock{}
-		db.On("QueryRow", dbQuery, mock.Anything).Return(nil, errFakeDatabaseFailure)
+		db.On("QueryRow", dbQuery, "userID", "repo1", "webhookID", 10, 0).Return(nil, errFakeDatabaseFailure)
 		h := New(db, nil)
 
-		output, err := h.CheckSession(context.Background(), []byte("sessionID"), 1*time.Hour)
+		data, err := h.GetWebhookDeliveriesJSON(ctx, "repo1", "webhookID", 10, 0)
 		assert.Equal(t, errFakeDatabaseFailure, err)
-		assert.Nil(t, output)
+		assert.Nil(t, data)
 		db.AssertExpectations(t)
 	})
 
-	t.Run("session has expired", func(t *testing.T) {
+	t.Run("webhook deliveries returned successfully", func(t *testing.T) {
 		db := &tests.DBMock{}
-		db.On("QueryRow", dbQuery, mock.Anything).Return([]interface{}{"userID", int64(1)}, nil)
+		db.On("QueryRow", dbQuery, "userID", "repo1", "webhookID", 10, 0).Return([]byte("deliveriesJSON"), nil)
 		h := New(db, nil)
 
-		output, err := h.CheckSession(context.Background(), []byte("sessionID"), 1*time.Hour)
+		data, err := h.GetWebhookDeliveriesJSON(ctx, "repo1", "webhookID", 10, 0)
 		assert.NoError(t, err)
-		assert.False(t, output.Valid)
-		assert.Empty(t, output.UserID)
+		assert.Equal(t, []byte("deliveriesJSON"), data)
 		db.AssertExpectations(t)
 	})
+}
 
-	t.Run("valid session", func(t *testing.T) {
+func TestClaimWebhookDeliveries(t *testing.T) {
+	dbQuery := "select claim_webhook_deliveries($1::int, $2::int)"
+
+	t.Run("database error", func(t *testing.T) {
 		db := &tests.DBMock{}
-		db.On("QueryRow", dbQuery, mock.Anything).Return([]interface{}{
-			"userID",
-			time.Now().Unix(),
-		}, nil)
+		db.On("QueryRow", dbQuery, 10, 5).Return(nil, errFakeDatabaseFailure)
 		h := New(db, nil)
 
-		output, err := h.CheckSession(context.Background(), []byte("sessionID"), 1*time.Hour)
-		assert.NoError(t, err)
-		assert.True(t, output.Valid)
-		assert.Equal(t, "userID", output.UserID)
+		deliveries, err := h.ClaimWebhookDeliveries(context.Background(), 10, 5)
+		assert.Equal(t, errFakeDatabaseFailure, err)
+		assert.Nil(t, deliveries)
 		db.AssertExpectations(t)
 	})
-}
 
-func TestDeleteSession(t *testing.T) {
-	dbQuery := "delete from session where session_id = $1"
+	t.Run("webhook deliveries claimed successfully", func(t *testing.T) {
+		db := &tests.DBMock{}
+		db.On("QueryRow", dbQuery, 10, 5).Return([]byte(`
+		[{
+			"webhook_delivery_id": "00000000-0000-0000-0000-000000000001",
+			"url": "https://url.test",
+			"secret": "secret",
+			"event_kind": 1,
+			"payload": {"event_kind":"version-removed"}
+		}]
+		`), nil)
+		h := New(db, nil)
 
-	t.Run("delete session", func(t *testing.T) {
-		testCases := []struct {
-			description string
-			dbResponse  interface{}
-		}{
-			{
-				"session deleted successfully",
-				nil,
-			},
+		deliveries, err := h.ClaimWebhookDeliveries(context.Background(), 10, 5)
+		require.NoError(t, err)
+		assert.Equal(t, []*WebhookDelivery{
 			{
-				"error deleting session from database",
-				errFakeDatabaseFailure,
+				WebhookDeliveryID: "00000000-0000-0000-0000-000000000001",
+				URL:               "https://url.test",
+				Secret:            "secret",
+				EventKind:         VersionRemoved,
+				Payload:           json.RawMessage(`{"event_kind":"version-removed"}`),
 			},
-		}
-		for _, tc := range testCases {
-			tc := tc
-			t.Run(tc.description, func(t *testing.T) {
-				db := &tests.DBMock{}
-				db.On("Exec", dbQuery, mock.Anything).Return(tc.dbResponse)
-				h := New(db, nil)
-
-				err := h.DeleteSession(context.Background(), []byte("sessionID"))
-				assert.Equal(t, tc.dbResponse, err)
-				db.AssertExpectations(t)
-			})
-		}
+		}, deliveries)
+		db.AssertExpectations(t)
 	})
 }
 
-func TestGetUserAlias(t *testing.T) {
-	dbQuery := `select alias from "user" where user_id = $1`
-	ctx := context.WithValue(context.Background(), UserIDKey, "userID")
+func TestRegisterWebhookDeliveryAttempt(t *testing.T) {
+	dbQuery := "select register_webhook_delivery_attempt($1::uuid, $2::int, $3::text)"
 
-	t.Run("database query succeeded", func(t *testing.T) {
+	t.Run("successful attempt", func(t *testing.T) {
 		db := &tests.DBMock{}
-		db.On("QueryRow", dbQuery, mock.Anything).Return("alias", nil)
+		db.On("Exec", dbQuery, "deliveryID", 200, nil).Return(nil)
 		h := New(db, nil)
 
-		alias, err := h.GetUserAlias(ctx)
+		err := h.RegisterWebhookDeliveryAttempt(context.Background(), "deliveryID", 200, nil)
 		assert.NoError(t, err)
-		assert.Equal(t, "alias", alias)
 		db.AssertExpectations(t)
 	})
 
-	t.Run("database error", func(t *testing.T) {
+	t.Run("failed attempt without status code", func(t *testing.T) {
 		db := &tests.DBMock{}
-		db.On("QueryRow", dbQuery, mock.Anything).Return("", errFakeDatabaseFailure)
+		db.On("Exec", dbQuery, "deliveryID", nil, "connection refused").Return(nil)
 		h := New(db, nil)
 
-		alias, err := h.GetUserAlias(ctx)
-		assert.Equal(t, errFakeDatabaseFailure, err)
-		assert.Empty(t, alias)
+		err := h.RegisterWebhookDeliveryAttempt(
+			context.Background(), "deliveryID", 0, errors.New("connection refused"))
+		assert.NoError(t, err)
 		db.AssertExpectations(t)
 	})
-}
-
-func TestCheckAvailability(t *testing.T) {
-	t.Run("resource kind not supported", func(t *testing.T) {
-		h := New(nil, nil)
-		_, err := h.CheckAvailability(context.Background(), "invalidKind", "value")
-		assert.Error(t, err)
-	})
-
-	t.Run("database query succeeded", func(t *testing.T) {
-		testCases := []struct {
-			resourceKind string
-			dbQuery      string
-			available    bool
-		}{
-			{
-				"userAlias",
-				`select user_id from "user" where alias = $1`,
-				true,
-			},
-			{
-				"chartRepositoryName",
-				`select chart_repository_id from chart_repository where name = $1`,
-				true,
-			},
-			{
-				"chartRepositoryURL",
-				`select chart_repository_id from chart_repository where url = $1`,
-				false,
-			},
-		}
-		for _, tc := range testCases {
-			tc := tc
-			t.Run(fmt.Sprintf("resource kind: %s", tc.resourceKind), func(t *testing.T) {
-				tc.dbQuery = fmt.Sprintf("select not exists (%s)", tc.dbQuery)
-				db := &tests.DBMock{}
-				db.On("QueryRow", tc.dbQuery, mock.Anything).Return(tc.available, nil)
-				h := New(db, nil)
-
-				available, err := h.CheckAvailability(context.Background(), tc.resourceKind, "value")
-				assert.NoError(t, err)
-				assert.Equal(t, tc.available, available)
-				db.AssertExpectations(t)
-			})
-		}
-	})
 
 	t.Run("database error", func(t *testing.T) {
 		db := &tests.DBMock{}
-		dbQuery := `select not exists (select user_id from "user" where alias = $1)`
-		db.On("QueryRow", dbQuery, mock.Anything).Return(false, errFakeDatabaseFailure)
+		db.On("Exec", dbQuery, "deliveryID", 500, "unexpected status code").Return(errFakeDatabaseFailure)
 		h := New(db, nil)
 
-		available, err := h.CheckAvailability(context.Background(), "userAlias", "value")
+		err := h.RegisterWebhookDeliveryAttempt(
+			context.Background(), "deliveryID", 500, errors.New("unexpected status code"))
 		assert.Equal(t, errFakeDatabaseFailure, err)
-		assert.False(t, available)
 		db.AssertExpectations(t)
 	})
 }
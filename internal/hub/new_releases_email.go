@@ -0,0 +1,22 @@
+package hub
+
+var newReleasesTmpl = newEmailTemplate(`
+{{ define "title" }}New releases{{ end }}
+{{ define "preheader" }}New releases of packages you are subscribed to{{ end }}
+{{ define "content" }}
+                        <p style="font-family: sans-serif; font-size: 14px; font-weight: normal; margin: 0; Margin-bottom: 15px;">Hi!</p>
+                        <p style="font-family: sans-serif; font-size: 14px; font-weight: normal; margin: 0; Margin-bottom: 15px;">New versions of some packages you are subscribed to have been released:</p>
+                        <ul style="font-family: sans-serif; font-size: 14px; font-weight: normal; margin: 0; Margin-bottom: 15px; padding-left: 20px;">
+                          {{ range .releases }}<li style="Margin-bottom: 5px;"><a href="{{ .Link }}" target="_blank" style="color: #659DBD; text-decoration: none; font-weight: bold;">{{ .Name }}</a> {{ .Version }}</li>
+                          {{ end }}
+                        </ul>
+                        <p style="font-family: sans-serif; font-size: 14px; font-weight: normal; margin: 0; Margin-bottom: 15px;">Thanks for using <a href="https://hub.cncf.io" target="_blank" style="display: inline-block; color: #659DBD; cursor: pointer; text-decoration: none; font-size: 14px; font-weight: bold; margin: 0;">CNCF Hub</a>.</p>
+{{ end }}
+{{ define "footer" }}
+                <tr>
+                  <td class="content-block powered-by" style="font-family: sans-serif; vertical-align: top; padding-bottom: 10px; padding-top: 10px; font-size: 10px; color: #545454; text-align: center;">
+                    <p style="color: #545454; font-size: 10px; text-align: center; text-decoration: none;">You are receiving this email because you are subscribed to these packages in CNCF Hub.<br>You can unsubscribe from them from your account settings.</p>
+                  </td>
+                </tr>
+{{ end }}
+`)
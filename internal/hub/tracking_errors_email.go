@@ -0,0 +1,22 @@
+package hub
+
+var trackingErrorsTmpl = newEmailTemplate(`
+{{ define "title" }}Tracking errors{{ end }}
+{{ define "preheader" }}New errors found tracking {{ .repoName }}{{ end }}
+{{ define "content" }}
+                        <p style="font-family: sans-serif; font-size: 14px; font-weight: normal; margin: 0; Margin-bottom: 15px;">Hi!</p>
+                        <p style="font-family: sans-serif; font-size: 14px; font-weight: normal; margin: 0; Margin-bottom: 15px;">Some new errors were found while tracking the chart repository <b>{{ .repoName }}</b>, so some chart versions may not be available in CNCF Hub:</p>
+                        <ul style="font-family: monospace; font-size: 12px; font-weight: normal; margin: 0; Margin-bottom: 15px; padding-left: 20px;">
+                          {{ range .errors }}<li style="Margin-bottom: 5px;">{{ .Error }}</li>
+                          {{ end }}{{ if .more }}<li style="Margin-bottom: 5px;">and {{ .more }} more</li>{{ end }}
+                        </ul>
+                        <p style="font-family: sans-serif; font-size: 14px; font-weight: normal; margin: 0; Margin-bottom: 15px;">The full list of errors of the last tracking is available from the repository's control panel.</p>
+{{ end }}
+{{ define "footer" }}
+                <tr>
+                  <td class="content-block powered-by" style="font-family: sans-serif; vertical-align: top; padding-bottom: 10px; padding-top: 10px; font-size: 10px; color: #545454; text-align: center;">
+                    <p style="color: #545454; font-size: 10px; text-align: center; text-decoration: none;">You are receiving this email because you own this chart repository in CNCF Hub.<br>You can disable these notifications from your account settings.</p>
+                  </td>
+                </tr>
+{{ end }}
+`)
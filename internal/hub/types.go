@@ -1,17 +1,166 @@
 package hub
 
+import (
+	"encoding/json"
+	"strings"
+)
+
 type userIDKey struct{}
 
 // UserIDKey represents the key used for the userID value inside a context.
 var UserIDKey = userIDKey{}
 
+type requestInfoKey struct{}
+
+// RequestInfoKey represents the key used for the request info value inside a
+// context.
+var RequestInfoKey = requestInfoKey{}
+
+// RequestInfo represents some information about the http request that
+// triggered an operation, used when registering audit events.
+type RequestInfo struct {
+	IP        string `json:"ip,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+}
+
+// ChartRepositoryKind represents the kind of a given chart repository.
+type ChartRepositoryKind int64
+
+const (
+	// HelmChartRepository represents a chart repository that provides an
+	// index file (or an OCI registry when its url uses the oci scheme).
+	HelmChartRepository ChartRepositoryKind = 0
+
+	// GitChartRepository represents a git repository containing charts in
+	// source form (directories with a Chart.yaml file).
+	GitChartRepository ChartRepositoryKind = 1
+)
+
 // ChartRepository represents a Helm chart repository.
 type ChartRepository struct {
-	ChartRepositoryID string `json:"chart_repository_id"`
-	Name              string `json:"name"`
-	DisplayName       string `json:"display_name"`
-	URL               string `json:"url"`
-	UserID            string `json:"user_id"`
+	ChartRepositoryID    string              `json:"chart_repository_id"`
+	Name                 string              `json:"name"`
+	DisplayName          string              `json:"display_name"`
+	URL                  string              `json:"url"`
+	Kind                 ChartRepositoryKind `json:"kind"`
+	GitBranch            string              `json:"git_branch"`
+	GitPathGlob          string              `json:"git_path_glob"`
+	LastTrackingTs       int64               `json:"last_tracking_ts"`
+	VerificationToken    string              `json:"verification_token"`
+	VerifiedPublisher    bool                `json:"verified_publisher"`
+	VerificationFailures int                 `json:"verification_failures"`
+	UserID               string              `json:"user_id"`
+	OrganizationName     string              `json:"organization_name"`
+}
+
+// Organization represents an entity with one or more users associated that
+// can own repositories.
+type Organization struct {
+	OrganizationID string `json:"organization_id"`
+	Name           string `json:"name"`
+	DisplayName    string `json:"display_name"`
+	Description    string `json:"description"`
+	HomeURL        string `json:"home_url"`
+	LogoURL        string `json:"logo_url"`
+}
+
+// OrganizationInvitation represents a pending invitation a user has received
+// to join an organization. The token is used to accept or decline it.
+type OrganizationInvitation struct {
+	OrganizationName        string `json:"organization_name"`
+	OrganizationDisplayName string `json:"organization_display_name"`
+	OrganizationLogoURL     string `json:"organization_logo_url"`
+	InvitedAt               int64  `json:"invited_at"`
+	Token                   string `json:"token"`
+}
+
+// Tracking requests statuses.
+const (
+	TrackingRequestPending    = "pending"
+	TrackingRequestProcessing = "processing"
+	TrackingRequestCompleted  = "completed"
+	TrackingRequestFailed     = "failed"
+)
+
+// TrackingRequest represents a request to track a chart repository as soon as
+// possible, without waiting for its next scheduled tracking. When a chart name
+// and version are provided, only that chart version is processed.
+type TrackingRequest struct {
+	TrackingRequestID string           `json:"tracking_request_id"`
+	ChartRepository   *ChartRepository `json:"chart_repository"`
+	ChartName         string           `json:"chart_name"`
+	ChartVersion      string           `json:"chart_version"`
+	Status            string           `json:"status"`
+}
+
+// TrackingRun represents a chart repository tracking run, including some
+// stats about the chart versions processed and the errors that happened.
+type TrackingRun struct {
+	ChartRepositoryID  string           `json:"chart_repository_id"`
+	StartedAt          int64            `json:"started_at"`
+	FinishedAt         int64            `json:"finished_at"`
+	VersionsProcessed  int              `json:"versions_processed"`
+	VersionsRegistered int              `json:"versions_registered"`
+	VersionsFailed     int              `json:"versions_failed"`
+	Errors             []*TrackingError `json:"errors"`
+	SuppressedErrors   int              `json:"suppressed_errors"`
+}
+
+// TrackingErrorKind represents the kind of an error that happened while
+// tracking a chart repository.
+type TrackingErrorKind string
+
+const (
+	// TrackingErrorIndexLoad represents an error loading the repository index
+	TrackingErrorIndexLoad TrackingErrorKind = "index-load"
+
+	// TrackingErrorChartDownload represents an error downloading a chart
+	TrackingErrorChartDownload TrackingErrorKind = "chart-download"
+
+	// TrackingErrorChartParse represents an error parsing a chart
+	TrackingErrorChartParse TrackingErrorKind = "chart-parse"
+
+	// TrackingErrorLogoDownload represents an error downloading a chart logo
+	TrackingErrorLogoDownload TrackingErrorKind = "logo-download"
+
+	// TrackingErrorLogoDecode represents an error decoding a chart logo
+	TrackingErrorLogoDecode TrackingErrorKind = "logo-decode"
+
+	// TrackingErrorRegister represents an error registering a chart version
+	TrackingErrorRegister TrackingErrorKind = "register"
+)
+
+// TrackingError represents an error that happened while tracking a chart
+// repository, including some details about the chart version involved.
+type TrackingError struct {
+	Kind         TrackingErrorKind `json:"kind"`
+	ChartName    string            `json:"chart_name,omitempty"`
+	ChartVersion string            `json:"chart_version,omitempty"`
+	URL          string            `json:"url,omitempty"`
+	HTTPStatus   int               `json:"http_status,omitempty"`
+	Message      string            `json:"message"`
+}
+
+// Error implements the error interface.
+func (e *TrackingError) Error() string {
+	var sb strings.Builder
+	sb.WriteString(string(e.Kind))
+	if e.ChartName != "" {
+		sb.WriteString(" " + e.ChartName)
+		if e.ChartVersion != "" {
+			sb.WriteString("@" + e.ChartVersion)
+		}
+	}
+	sb.WriteString(": " + e.Message)
+	return sb.String()
+}
+
+// TrackingErrors represents the errors that happened while tracking a chart
+// repository. Only a limited number of errors are kept, the number of errors
+// suppressed is provided as well.
+type TrackingErrors struct {
+	Errors     []*TrackingError `json:"errors"`
+	Suppressed int              `json:"suppressed"`
 }
 
 // OperatorProvider represents an entity that provides operators that can be
@@ -86,10 +235,11 @@ type SearchPackageInput struct {
 
 // GetPackageInput represents the input used to get a specific package.
 type GetPackageInput struct {
-	Kind                PackageKind `json:"kind"`
-	ChartRepositoryName string      `json:"chart_repository_name"`
-	PackageName         string      `json:"package_name"`
-	Version             string      `json:"version"`
+	Kind                 PackageKind `json:"kind"`
+	ChartRepositoryName  string      `json:"chart_repository_name"`
+	OperatorProviderName string      `json:"operator_provider_name"`
+	PackageName          string      `json:"package_name"`
+	Version              string      `json:"version"`
 }
 
 // User represents a Hub user.
@@ -103,11 +253,94 @@ type User struct {
 	Password      string `json:"password"`
 }
 
+// UserIdentity represents a user identity provided by an external identity
+// provider (i.e. GitHub) used to log in.
+type UserIdentity struct {
+	Provider       string `json:"provider"`
+	ProviderUserID string `json:"provider_user_id"`
+	Alias          string `json:"alias"`
+	FirstName      string `json:"first_name"`
+	LastName       string `json:"last_name"`
+	Email          string `json:"email"`
+
+	// UserID is the id of the user logged in when the identity is registered,
+	// who the identity will be linked to.
+	UserID string `json:"user_id,omitempty"`
+}
+
+// APIKey represents a personal api key used by a user to access the hub
+// programmatically. Only the hash of the key is stored in the database.
+type APIKey struct {
+	APIKeyID string `json:"api_key_id"`
+	Name     string `json:"name"`
+	KeyHash  string `json:"key_hash"`
+}
+
+// AddAPIKeyOutput represents the output returned by the AddAPIKey method. The
+// key is only available at this point, as it is not stored in plain text.
+type AddAPIKeyOutput struct {
+	APIKeyID string `json:"api_key_id"`
+	Key      string `json:"key"`
+}
+
+// NotificationsSettings represents the notifications a user has chosen to
+// receive.
+type NotificationsSettings struct {
+	TrackingErrors bool `json:"tracking_errors"`
+}
+
+// EventKind represents the kind of an event.
+type EventKind int64
+
+const (
+	// NewRelease represents an event for a new package release.
+	NewRelease EventKind = 0
+
+	// VersionRemoved represents an event for a package version removal.
+	VersionRemoved EventKind = 1
+)
+
+// Subscription represents a user's subscription to receive notifications
+// about a given package's events.
+type Subscription struct {
+	PackageID string    `json:"package_id"`
+	EventKind EventKind `json:"event_kind"`
+}
+
+// Notification represents a notification pending to be delivered to a user
+// about a package event. The package version is the one the event refers to.
+type Notification struct {
+	NotificationID string    `json:"notification_id"`
+	Email          string    `json:"email"`
+	EventKind      EventKind `json:"event_kind"`
+	Package        *Package  `json:"package"`
+}
+
+// Webhook represents an outgoing webhook registered by the owner of a chart
+// repository to be notified about the events of the kinds provided that
+// happen in it. Payloads delivered are signed using the webhook secret.
+type Webhook struct {
+	WebhookID  string      `json:"webhook_id"`
+	URL        string      `json:"url"`
+	Secret     string      `json:"secret"`
+	EventKinds []EventKind `json:"event_kinds"`
+}
+
+// WebhookDelivery represents a payload pending to be delivered to a webhook.
+type WebhookDelivery struct {
+	WebhookDeliveryID string          `json:"webhook_delivery_id"`
+	URL               string          `json:"url"`
+	Secret            string          `json:"secret"`
+	EventKind         EventKind       `json:"event_kind"`
+	Payload           json.RawMessage `json:"payload"`
+}
+
 // CheckCredentialsOutput represents the output returned by the
 // CheckCredentials method.
 type CheckCredentialsOutput struct {
-	Valid  bool   `json:"valid"`
-	UserID string `json:"user_id"`
+	Valid         bool   `json:"valid"`
+	UserID        string `json:"user_id"`
+	EmailVerified bool   `json:"email_verified"`
 }
 
 // Session represents some information about a user session.
@@ -123,3 +356,9 @@ type CheckSessionOutput struct {
 	Valid  bool   `json:"valid"`
 	UserID string `json:"user_id"`
 }
+
+// CheckAPIKeyOutput represents the output returned by the CheckAPIKey method.
+type CheckAPIKeyOutput struct {
+	Valid  bool   `json:"valid"`
+	UserID string `json:"user_id"`
+}
@@ -0,0 +1,23 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+)
+
+// CloneGitRepository clones the branch provided of the git repository located
+// at the url provided into the destination directory. When no branch is
+// provided, the repository's default branch will be used.
+func CloneGitRepository(ctx context.Context, url, branch, dst string) error {
+	args := []string{"clone", "--depth", "1"}
+	if branch != "" {
+		args = append(args, "--branch", branch)
+	}
+	args = append(args, "--", url, dst)
+	cmd := exec.CommandContext(ctx, "git", args...) // #nosec
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, output)
+	}
+	return nil
+}